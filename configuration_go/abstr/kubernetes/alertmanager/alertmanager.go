@@ -1,10 +1,10 @@
 package alertmanager
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/observatorium/observatorium/configuration_go/kubegen/cmdopt"
@@ -129,11 +129,34 @@ func NewAlertManager(opts *AlertManagerOptions, namespace, imageTag string) *Ale
 
 }
 
+// Objects returns the manifests for the alertmanager.
+// It panics if the configuration is invalid, see ObjectsE.
 func (a *AlertManagerStatefulSet) Objects() []runtime.Object {
-	container := a.makeContainer()
-	ret := a.StatefulSetWorkload.Objects(container)
+	ret, err := a.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
 
-	service := kghelpers.GetObject[*corev1.Service](ret, a.Name)
+	return ret
+}
+
+// ObjectsE is like Objects but returns an error instead of panicking when the configuration is invalid,
+// such as probes not targeting the web port, an unparsable cluster listen address or a missing storage path.
+func (a *AlertManagerStatefulSet) ObjectsE() ([]runtime.Object, error) {
+	container, err := a.makeContainer()
+	if err != nil {
+		return nil, fmt.Errorf("invalid alertmanager %s: %w", a.Name, err)
+	}
+
+	ret, err := a.StatefulSetWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alertmanager %s: %w", a.Name, err)
+	}
+
+	service, err := kghelpers.GetObjectE[*corev1.Service](ret, a.Name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alertmanager %s: %w", a.Name, err)
+	}
 	// remove cluster port
 	service.Spec.Ports = service.Spec.Ports[:1]
 
@@ -147,30 +170,36 @@ func (a *AlertManagerStatefulSet) Objects() []runtime.Object {
 		headlessService.MetaConfig.Name = fmt.Sprintf("%s-cluster", headlessService.MetaConfig.Name)
 		ret = append(ret, headlessService.Object())
 
-		ss := kghelpers.GetObject[*appsv1.StatefulSet](ret, a.Name)
+		ss, err := kghelpers.GetObjectE[*appsv1.StatefulSet](ret, a.Name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid alertmanager %s: %w", a.Name, err)
+		}
 		ss.Spec.ServiceName = headlessService.MetaConfig.Name
 	}
 
-	return ret
+	return ret, nil
 }
 
-func (a *AlertManagerStatefulSet) makeContainer() *workload.Container {
+func (a *AlertManagerStatefulSet) makeContainer() (*workload.Container, error) {
 	webPort := kghelpers.GetPortOrDefault(defaultWebPort, a.options.WebListenAddress)
+	errs := []error{
+		kghelpers.CheckProbePortE(webPort, a.LivenessProbe),
+		kghelpers.CheckProbePortE(webPort, a.ReadinessProbe),
+	}
 
 	clusterPort := defaultClusterPort
 	if a.options.ClusterListenAddress != "" {
-		var err error
-		clusterPort, err = strconv.Atoi(strings.Split(a.options.ClusterListenAddress, ":")[1])
+		_, port, err := net.SplitHostPort(a.options.ClusterListenAddress)
+		if err == nil {
+			clusterPort, err = strconv.Atoi(port)
+		}
 		if err != nil {
-			panic(fmt.Sprintf(`failed to parse cluster listen address %s`, a.options.ClusterListenAddress))
+			errs = append(errs, fmt.Errorf("failed to parse cluster listen address %s", a.options.ClusterListenAddress))
 		}
 	}
 
-	kghelpers.CheckProbePort(webPort, a.LivenessProbe)
-	kghelpers.CheckProbePort(webPort, a.ReadinessProbe)
-
 	if a.options.StoragePath == "" {
-		panic(`data directory is not specified for the statefulset.`)
+		errs = append(errs, errors.New("data directory is not specified for the statefulset"))
 	}
 
 	ret := a.ToContainer()
@@ -213,8 +242,12 @@ func (a *AlertManagerStatefulSet) makeContainer() *workload.Container {
 	}
 
 	if a.options.ConfigFile != nil {
-		a.options.ConfigFile.Update(ret)
+		errs = append(errs, containeropts.UpdateContainerE(a.options.ConfigFile, ret))
 	}
 
-	return ret
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
 }
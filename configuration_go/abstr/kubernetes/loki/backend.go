@@ -0,0 +1,162 @@
+package loki
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Compactor represents the Loki compactor, compacting the index and applying retention.
+// It is deployed as a StatefulSet and must run as a singleton.
+type Compactor struct {
+	baseLoki
+	workload.StatefulSetWorkload
+}
+
+// NewCompactor returns a new Compactor with default configuration.
+func NewCompactor(opts *LokiOptions, namespace, imageTag string) *Compactor {
+	if opts == nil {
+		opts = NewDefaultOptions()
+	}
+
+	baseLoki, podConfig := newBaseLoki(opts, namespace, imageTag, TargetCompactor, true)
+
+	return &Compactor{
+		baseLoki: *baseLoki,
+		StatefulSetWorkload: workload.StatefulSetWorkload{
+			Replicas:   1,
+			VolumeSize: "10Gi",
+			PodConfig:  podConfig,
+		},
+	}
+}
+
+// Objects returns the manifests for the Compactor.
+// It panics if the configuration is invalid, see ObjectsE.
+func (c *Compactor) Objects() []runtime.Object {
+	ret, err := c.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// ObjectsE is like Objects but returns an error instead of panicking when the config file is missing or invalid,
+// a probe does not target the HTTP port, or the disruption budget or network policy of the compactor is invalid.
+func (c *Compactor) ObjectsE() ([]runtime.Object, error) {
+	container, err := c.makeContainer(&c.PodConfig, withPersistentData(c.VolumeType, c.VolumeSize))
+	if err != nil {
+		return nil, fmt.Errorf("invalid loki compactor %s: %w", c.Name, err)
+	}
+
+	ret, err := c.StatefulSetWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid loki compactor %s: %w", c.Name, err)
+	}
+
+	return ret, nil
+}
+
+// Ruler represents the Loki ruler, evaluating recording and alerting rules.
+// It is deployed as a StatefulSet.
+type Ruler struct {
+	baseLoki
+	workload.StatefulSetWorkload
+
+	// Rules maps the tenant IDs to their rule files, by file name. The files of each tenant are rendered in
+	// a ConfigMap mounted in the directory of the tenant, in the local rule storage of the default config.
+	Rules map[string]map[string]string
+}
+
+// NewRuler returns a new Ruler with default configuration.
+func NewRuler(opts *LokiOptions, namespace, imageTag string) *Ruler {
+	if opts == nil {
+		opts = NewDefaultOptions()
+	}
+
+	baseLoki, podConfig := newBaseLoki(opts, namespace, imageTag, TargetRuler, true)
+	podConfig.ContainerResources = kghelpers.NewResourcesRequirements("100m", "500m", "200Mi", "1Gi")
+
+	return &Ruler{
+		baseLoki: *baseLoki,
+		StatefulSetWorkload: workload.StatefulSetWorkload{
+			Replicas:   1,
+			VolumeSize: "10Gi",
+			PodConfig:  podConfig,
+		},
+	}
+}
+
+// Objects returns the manifests for the Ruler.
+// It panics if the configuration is invalid, see ObjectsE.
+func (r *Ruler) Objects() []runtime.Object {
+	ret, err := r.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// ObjectsE is like Objects but returns an error instead of panicking when the config file is missing,
+// a probe does not target the HTTP port, a tenant ID can't name the volume of its rules,
+// or the disruption budget or network policy of the ruler is invalid.
+func (r *Ruler) ObjectsE() ([]runtime.Object, error) {
+	var errs []error
+	for tenant := range r.Rules {
+		for _, msg := range validation.IsDNS1123Label(rulesVolumeName + "-" + tenant) {
+			errs = append(errs, fmt.Errorf("invalid rules of tenant %q: %s", tenant, msg))
+		}
+	}
+
+	container, err := r.makeContainer(&r.PodConfig, withPersistentData(r.VolumeType, r.VolumeSize), withRules(r.Name, r.Rules))
+	if err := errors.Join(append(errs, err)...); err != nil {
+		return nil, fmt.Errorf("invalid loki ruler %s: %w", r.Name, err)
+	}
+
+	ret, err := r.StatefulSetWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid loki ruler %s: %w", r.Name, err)
+	}
+
+	return ret, nil
+}
+
+// withRules mounts the rule files of each tenant in its directory of the local rule storage.
+// The rule storage is backed by an emptyDir volume, so that it exists when no tenant has rules.
+func withRules(name string, rules map[string]map[string]string) ContainerOption {
+	return func(container *workload.Container) {
+		container.ConfigMaps = maps.Clone(container.ConfigMaps)
+		container.Volumes = append(container.Volumes, corev1.Volume{
+			Name: rulesVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      rulesVolumeName,
+			MountPath: rulesDir,
+		})
+
+		for _, tenant := range slices.Sorted(maps.Keys(rules)) {
+			volumeName := rulesVolumeName + "-" + tenant
+			configMapName := fmt.Sprintf("%s-rules-%s", name, tenant)
+			container.ConfigMaps[configMapName] = rules[tenant]
+			container.Volumes = append(container.Volumes, kghelpers.NewPodVolumeFromConfigMap(volumeName, configMapName))
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      volumeName,
+				MountPath: path.Join(rulesDir, tenant),
+				ReadOnly:  true,
+			})
+		}
+	}
+}
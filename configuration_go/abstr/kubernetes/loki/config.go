@@ -0,0 +1,141 @@
+package loki
+
+import (
+	"fmt"
+	"time"
+
+	lokischema "github.com/observatorium/observatorium/configuration_go/schemas/loki"
+	prommodel "github.com/prometheus/common/model"
+)
+
+// NewDefaultConfig returns a Loki config wiring together the components generated by this package.
+// Cross-component addresses use the short Service names, resolved in the namespace of the pods.
+// Object storage settings are expanded from the environment variables populated from ObjectStoreSecretName.
+// The ruler reads the rules from its local storage, where the Ruler mounts its rule files.
+func NewDefaultConfig() *lokischema.Config {
+	ringConfig := &lokischema.RingConfig{
+		KVStore: &lokischema.KVStoreConfig{
+			Store: lokischema.KVStoreMemberlist,
+		},
+	}
+
+	return &lokischema.Config{
+		AuthEnabled: true,
+		Server: &lokischema.ServerConfig{
+			HTTPListenPort:           defaultHTTPPort,
+			GRPCListenPort:           defaultGRPCPort,
+			GRPCServerMaxRecvMsgSize: 104857600,
+			GRPCServerMaxSendMsgSize: 104857600,
+		},
+		Common: &lokischema.CommonConfig{
+			PathPrefix:           dataDir,
+			ReplicationFactor:    1,
+			CompactorGRPCAddress: fmt.Sprintf("%s:%d", ServiceName(TargetCompactor), defaultGRPCPort),
+			Ring:                 ringConfig,
+			Storage: &lokischema.CommonStorageConfig{
+				S3: &lokischema.S3Config{
+					S3:               "${S3_URL}",
+					S3ForcePathStyle: true,
+					BucketNames:      "${S3_BUCKETS}",
+					Region:           "${S3_REGION}",
+					AccessKeyID:      "${AWS_ACCESS_KEY_ID}",
+					SecretAccessKey:  "${AWS_SECRET_ACCESS_KEY}",
+				},
+			},
+		},
+		Memberlist: &lokischema.MemberlistConfig{
+			JoinMembers:      []string{fmt.Sprintf("%s:%d", GossipRingName, defaultGossipPort)},
+			AbortIfJoinFails: false,
+		},
+		Ingester: &lokischema.IngesterConfig{
+			ChunkEncoding:     lokischema.ChunkEncodingSnappy,
+			ChunkIdlePeriod:   prommodel.Duration(time.Hour),
+			ChunkRetainPeriod: prommodel.Duration(5 * time.Minute),
+			ChunkTargetSize:   2097152,
+			MaxChunkAge:       prommodel.Duration(2 * time.Hour),
+			WAL: &lokischema.WALConfig{
+				Enabled:         true,
+				Dir:             dataDir + "/wal",
+				FlushOnShutdown: true,
+			},
+		},
+		Querier: &lokischema.QuerierConfig{
+			MaxConcurrent: 4,
+		},
+		QueryRange: &lokischema.QueryRangeConfig{
+			AlignQueriesWithStep:        true,
+			MaxRetries:                  5,
+			CacheResults:                true,
+			ParallelizeShardableQueries: true,
+			ResultsCache: &lokischema.ResultsCacheConfig{
+				Cache: &lokischema.CacheConfig{
+					EmbeddedCache: &lokischema.EmbeddedCacheConfig{
+						Enabled:   true,
+						MaxSizeMB: 500,
+					},
+				},
+			},
+		},
+		Frontend: &lokischema.FrontendConfig{
+			CompressResponses:       true,
+			MaxOutstandingPerTenant: 256,
+			TailProxyURL:            fmt.Sprintf("http://%s:%d", ServiceName(TargetQuerier), defaultHTTPPort),
+		},
+		FrontendWorker: &lokischema.FrontendWorkerConfig{
+			FrontendAddress: fmt.Sprintf("%s:%d", ServiceName(TargetQueryFrontend), defaultGRPCPort),
+		},
+		Compactor: &lokischema.CompactorConfig{
+			WorkingDirectory:   dataDir + "/compactor",
+			SharedStore:        lokischema.ObjectStoreS3,
+			CompactionInterval: prommodel.Duration(2 * time.Hour),
+		},
+		Ruler: &lokischema.RulerConfig{
+			EnableAPI:      true,
+			EnableSharding: true,
+			RulePath:       dataDir + "/rules-temp",
+			WAL: &lokischema.RulerWALConfig{
+				Dir: dataDir + "/ruler-wal",
+			},
+			Storage: &lokischema.RulerStorageConfig{
+				Type: "local",
+				Local: &lokischema.RulerLocalConfig{
+					Directory: rulesDir,
+				},
+			},
+		},
+		SchemaConfig: &lokischema.SchemaConfig{
+			Configs: []lokischema.PeriodConfig{
+				{
+					From:        "2023-01-01",
+					Store:       lokischema.IndexStoreTSDB,
+					ObjectStore: lokischema.ObjectStoreS3,
+					Schema:      "v12",
+					Index: &lokischema.IndexConfig{
+						Prefix: "index_",
+						Period: prommodel.Duration(24 * time.Hour),
+					},
+				},
+			},
+		},
+		StorageConfig: &lokischema.StorageConfig{
+			TSDBShipper: &lokischema.ShipperConfig{
+				ActiveIndexDirectory: dataDir + "/tsdb-index",
+				CacheLocation:        dataDir + "/tsdb-cache",
+				SharedStore:          lokischema.ObjectStoreS3,
+			},
+		},
+		LimitsConfig: &lokischema.LimitsConfig{
+			IngestionRateStrategy:   "global",
+			IngestionRateMB:         10,
+			IngestionBurstSizeMB:    20,
+			MaxGlobalStreamsPerUser: 10000,
+			MaxQueryParallelism:     32,
+			MaxEntriesLimitPerQuery: 5000,
+			RejectOldSamples:        true,
+			RejectOldSamplesMaxAge:  prommodel.Duration(7 * 24 * time.Hour),
+		},
+		Analytics: &lokischema.AnalyticsConfig{
+			ReportingEnabled: false,
+		},
+	}
+}
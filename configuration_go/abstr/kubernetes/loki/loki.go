@@ -0,0 +1,329 @@
+package loki
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/observatorium/observatorium/configuration_go/kubegen/cmdopt"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/containeropts"
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	"github.com/observatorium/observatorium/configuration_go/schemas/log"
+	lokischema "github.com/observatorium/observatorium/configuration_go/schemas/loki"
+	monv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	defaultHTTPPort   int    = 3100
+	defaultGRPCPort   int    = 9095
+	defaultGossipPort int    = 7946
+	dataVolumeName    string = "data"
+	dataDir           string = "/data/loki"
+	rulesVolumeName   string = "rules"
+	rulesDir          string = "/etc/loki/rules"
+
+	// GossipMemberLabel is set on the pods joining the memberlist gossip ring.
+	GossipMemberLabel string = "loki.grafana.com/gossip"
+	// GossipRingName is the name of the headless service used by the ring members to discover each other.
+	GossipRingName string = "observatorium-loki-gossip-ring"
+	// ObjectStoreSecretName is the name of the secret holding the object storage credentials.
+	// Its keys are exposed as environment variables expanded in the default Loki config.
+	ObjectStoreSecretName string = "loki-objectstore-secret"
+)
+
+// Target is the Loki microservice run by a container.
+type Target string
+
+const (
+	TargetDistributor   Target = "distributor"
+	TargetIngester      Target = "ingester"
+	TargetQuerier       Target = "querier"
+	TargetQueryFrontend Target = "query-frontend"
+	TargetCompactor     Target = "compactor"
+	TargetRuler         Target = "ruler"
+)
+
+// ServiceName returns the name of the Service generated for the given target.
+// It can be used to wire other components (e.g. the Observatorium API) to Loki.
+func ServiceName(target Target) string {
+	return fmt.Sprintf("observatorium-loki-%s", target)
+}
+
+// NewConfigFile returns a new Loki config file option.
+func NewConfigFile(value *lokischema.Config) *containeropts.ConfigResourceAsFile {
+	ret := containeropts.NewConfigResourceAsFile("/etc/loki/config", "config.yaml", "config", "observatorium-loki-config")
+	if value != nil {
+		ret.WithValue(value.String())
+	}
+	return ret
+}
+
+// NewOverridesConfigFile returns a new per-tenant limits overrides file option.
+// The value must be the YAML content of the runtime configuration, see https://grafana.com/docs/loki/latest/configure/#runtime-configuration-file.
+func NewOverridesConfigFile(value *string) *containeropts.ConfigResourceAsFile {
+	ret := containeropts.NewConfigResourceAsFile("/etc/loki/overrides", "overrides.yaml", "overrides", "observatorium-loki-overrides")
+	if value != nil {
+		ret.WithValue(*value)
+	}
+	return ret
+}
+
+// LokiOptions represents the options/flags shared by all Loki microservices.
+// The -target flag is set by each component.
+// See https://grafana.com/docs/loki/latest/configure/ for details.
+type LokiOptions struct {
+	ConfigExpandEnv             bool                           `opt:"config.expand-env,single-hyphen"`
+	ConfigFile                  containeropts.ContainerUpdater `opt:"config.file,single-hyphen"`
+	LimitsPerUserOverrideConfig containeropts.ContainerUpdater `opt:"limits.per-user-override-config,single-hyphen"`
+	LogFormat                   log.Format                     `opt:"log.format,single-hyphen"`
+	LogLevel                    log.Level                      `opt:"log.level,single-hyphen"`
+	MemberlistBindPort          int                            `opt:"memberlist.bind-port,single-hyphen"`
+	ServerGRPCListenPort        int                            `opt:"server.grpc-listen-port,single-hyphen"`
+	ServerHTTPListenPort        int                            `opt:"server.http-listen-port,single-hyphen"`
+
+	// Extra options not officially supported.
+	cmdopt.ExtraOpts
+}
+
+// NewDefaultOptions returns the default options, rendering the default Loki config in a ConfigMap.
+func NewDefaultOptions() *LokiOptions {
+	return &LokiOptions{
+		ConfigExpandEnv: true,
+		ConfigFile:      NewConfigFile(NewDefaultConfig()),
+		LogLevel:        log.LevelWarn,
+		LogFormat:       log.FormatLogfmt,
+	}
+}
+
+// baseLoki is the base struct for all Loki components.
+// It contains their common configuration.
+type baseLoki struct {
+	options  *LokiOptions
+	target   Target
+	joinRing bool
+}
+
+func newBaseLoki(opts *LokiOptions, namespace, imageTag string, target Target, joinRing bool) (*baseLoki, workload.PodConfig) {
+	commonLabels := map[string]string{
+		workload.NameLabel:      "loki",
+		workload.InstanceLabel:  "observatorium",
+		workload.PartOfLabel:    "observatorium",
+		workload.ComponentLabel: string(target),
+		workload.VersionLabel:   imageTag,
+	}
+
+	if joinRing {
+		commonLabels[GossipMemberLabel] = "true"
+	}
+
+	labelSelectors := map[string]string{
+		workload.NameLabel:      commonLabels[workload.NameLabel],
+		workload.InstanceLabel:  commonLabels[workload.InstanceLabel],
+		workload.ComponentLabel: commonLabels[workload.ComponentLabel],
+	}
+
+	probePort := getPortOrDefault(defaultHTTPPort, opts.ServerHTTPListenPort)
+
+	podCfg := workload.PodConfig{
+		Image:                "docker.io/grafana/loki",
+		ImageTag:             imageTag,
+		ImagePullPolicy:      corev1.PullIfNotPresent,
+		Name:                 ServiceName(target),
+		Namespace:            namespace,
+		CommonLabels:         commonLabels,
+		ContainerResources:   kghelpers.NewResourcesRequirements("100m", "200m", "100Mi", "200Mi"),
+		Affinity:             kghelpers.NewAntiAffinity(nil, labelSelectors),
+		EnableServiceMonitor: true,
+		ReadinessProbe: kghelpers.NewProbe("/ready", probePort, kghelpers.ProbeConfig{
+			FailureThreshold:    3,
+			InitialDelaySeconds: 15,
+			PeriodSeconds:       10,
+			TimeoutSeconds:      1,
+		}),
+		TerminationGracePeriodSeconds: 120,
+		Env: []corev1.EnvVar{
+			kghelpers.NewEnvFromSecret("S3_URL", ObjectStoreSecretName, "endpoint"),
+			kghelpers.NewEnvFromSecret("S3_BUCKETS", ObjectStoreSecretName, "buckets"),
+			kghelpers.NewEnvFromSecret("S3_REGION", ObjectStoreSecretName, "region"),
+			kghelpers.NewEnvFromSecret("AWS_ACCESS_KEY_ID", ObjectStoreSecretName, "aws_access_key_id"),
+			kghelpers.NewEnvFromSecret("AWS_SECRET_ACCESS_KEY", ObjectStoreSecretName, "aws_secret_access_key"),
+		},
+		ConfigMaps: make(map[string]map[string]string),
+		Secrets:    make(map[string]map[string][]byte),
+	}
+
+	return &baseLoki{
+		options:  opts,
+		target:   target,
+		joinRing: joinRing,
+	}, podCfg
+}
+
+// ContainerOption allows each component to customize the generic Loki container.
+type ContainerOption func(*workload.Container)
+
+// withPersistentData mounts a volume claim at the data directory.
+func withPersistentData(volumeType, volumeSize string) ContainerOption {
+	return func(container *workload.Container) {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      dataVolumeName,
+			MountPath: dataDir,
+		})
+		container.VolumeClaims = append(container.VolumeClaims, workload.PersistentVolumeClaim{
+			Name:  dataVolumeName,
+			Size:  volumeSize,
+			Class: volumeType,
+		})
+	}
+}
+
+// withEphemeralData mounts an emptyDir volume at the data directory.
+func withEphemeralData() ContainerOption {
+	return func(container *workload.Container) {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      dataVolumeName,
+			MountPath: dataDir,
+		})
+		container.Volumes = append(container.Volumes, corev1.Volume{
+			Name: dataVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+	}
+}
+
+func (bl *baseLoki) makeContainer(podConfig *workload.PodConfig, opts ...ContainerOption) (*workload.Container, error) {
+	httpPort := getPortOrDefault(defaultHTTPPort, bl.options.ServerHTTPListenPort)
+	grpcPort := getPortOrDefault(defaultGRPCPort, bl.options.ServerGRPCListenPort)
+
+	errs := []error{
+		kghelpers.CheckProbePortE(httpPort, podConfig.LivenessProbe),
+		kghelpers.CheckProbePortE(httpPort, podConfig.ReadinessProbe),
+	}
+
+	if bl.options.ConfigFile == nil {
+		errs = append(errs, errors.New("config file is not specified for loki"))
+	}
+
+	// The config file is updating the container below, so stop before rendering it.
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	ret := podConfig.ToContainer()
+	ret.Name = "loki"
+	ret.Args = append([]string{fmt.Sprintf("-target=%s", bl.target)}, cmdopt.GetOpts(bl.options)...)
+	ret.Ports = []corev1.ContainerPort{
+		{
+			Name:          "http",
+			ContainerPort: int32(httpPort),
+			Protocol:      corev1.ProtocolTCP,
+		},
+		{
+			Name:          "grpc",
+			ContainerPort: int32(grpcPort),
+			Protocol:      corev1.ProtocolTCP,
+		},
+	}
+	ret.ServicePorts = []corev1.ServicePort{
+		kghelpers.NewServicePort("http", httpPort, httpPort),
+		kghelpers.NewServicePort("grpc", grpcPort, grpcPort),
+	}
+	ret.MonitorPorts = []monv1.Endpoint{
+		{
+			Port:           "http",
+			RelabelConfigs: kghelpers.GetDefaultServiceMonitorRelabelConfig(),
+		},
+	}
+
+	if bl.joinRing {
+		gossipPort := getPortOrDefault(defaultGossipPort, bl.options.MemberlistBindPort)
+		ret.Ports = append(ret.Ports, corev1.ContainerPort{
+			Name:          "gossip",
+			ContainerPort: int32(gossipPort),
+			Protocol:      corev1.ProtocolTCP,
+		})
+	}
+
+	errs = []error{containeropts.UpdateContainerE(bl.options.ConfigFile, ret)}
+
+	if bl.options.LimitsPerUserOverrideConfig != nil {
+		errs = append(errs, containeropts.UpdateContainerE(bl.options.LimitsPerUserOverrideConfig, ret))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	for _, opt := range opts {
+		opt(ret)
+	}
+
+	return ret, nil
+}
+
+// GossipRing is the headless service used by Loki components to join the memberlist ring.
+type GossipRing struct {
+	Name      string
+	Namespace string
+	Port      int
+}
+
+// NewGossipRing returns the gossip ring service shared by all ring members.
+func NewGossipRing(namespace string) *GossipRing {
+	return &GossipRing{
+		Name:      GossipRingName,
+		Namespace: namespace,
+		Port:      defaultGossipPort,
+	}
+}
+
+// ObjectsE is like Objects, for callers handling all the components through ObjectsE.
+// The gossip ring service has no configuration that can be invalid, so it never returns an error.
+func (g *GossipRing) ObjectsE() ([]runtime.Object, error) {
+	return g.Objects(), nil
+}
+
+// Objects returns the headless service selecting all the gossip ring members.
+func (g *GossipRing) Objects() []runtime.Object {
+	labels := map[string]string{
+		workload.NameLabel:     "loki",
+		workload.InstanceLabel: "observatorium",
+		workload.PartOfLabel:   "observatorium",
+	}
+
+	selector := maps.Clone(labels)
+	delete(selector, workload.PartOfLabel)
+	selector[GossipMemberLabel] = "true"
+
+	port := kghelpers.NewServicePort("gossip", g.Port, g.Port)
+
+	return []runtime.Object{
+		&corev1.Service{
+			TypeMeta: workload.ServiceMeta,
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      g.Name,
+				Namespace: g.Namespace,
+				Labels:    labels,
+			},
+			Spec: corev1.ServiceSpec{
+				ClusterIP:                corev1.ClusterIPNone,
+				PublishNotReadyAddresses: true,
+				Ports:                    []corev1.ServicePort{port},
+				Selector:                 selector,
+			},
+		},
+	}
+}
+
+func getPortOrDefault(defaultValue, port int) int {
+	if port != 0 {
+		return port
+	}
+	return defaultValue
+}
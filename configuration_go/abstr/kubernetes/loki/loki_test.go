@@ -0,0 +1,198 @@
+package loki_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/loki"
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	lokischema "github.com/observatorium/observatorium/configuration_go/schemas/loki"
+	"gopkg.in/yaml.v2"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// objectsE is implemented by all the Loki components.
+type objectsE interface {
+	ObjectsE() ([]runtime.Object, error)
+}
+
+func TestComponents(t *testing.T) {
+	testCases := map[string]struct {
+		component   objectsE
+		statefulSet bool
+		headless    bool
+		gossip      bool
+		noData      bool
+	}{
+		"distributor":    {component: loki.NewDistributor(nil, "ns", "2.9.0"), gossip: true},
+		"ingester":       {component: loki.NewIngester(nil, "ns", "2.9.0"), statefulSet: true, gossip: true},
+		"querier":        {component: loki.NewQuerier(nil, "ns", "2.9.0"), gossip: true},
+		"query-frontend": {component: loki.NewQueryFrontend(nil, "ns", "2.9.0"), headless: true, noData: true},
+		"compactor":      {component: loki.NewCompactor(nil, "ns", "2.9.0"), statefulSet: true, gossip: true},
+		"ruler":          {component: loki.NewRuler(nil, "ns", "2.9.0"), statefulSet: true, gossip: true},
+	}
+
+	for target, tc := range testCases {
+		t.Run(target, func(t *testing.T) {
+			objs, err := tc.component.ObjectsE()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			name := loki.ServiceName(loki.Target(target))
+			var pod corev1.PodSpec
+			if tc.statefulSet {
+				sts := kghelpers.GetObject[*appsv1.StatefulSet](objs, name)
+				pod = sts.Spec.Template.Spec
+				if len(sts.Spec.VolumeClaimTemplates) != 1 || sts.Spec.VolumeClaimTemplates[0].Name != "data" {
+					t.Errorf("expected a data volume claim, got %+v", sts.Spec.VolumeClaimTemplates)
+				}
+			} else {
+				pod = kghelpers.GetObject[*appsv1.Deployment](objs, name).Spec.Template.Spec
+			}
+
+			container := pod.Containers[0]
+			expectedArgs := []string{
+				"-target=" + target,
+				"-config.expand-env=true",
+				"-config.file=/etc/loki/config/config.yaml",
+				"-log.format=logfmt",
+				"-log.level=warn",
+			}
+			if !slices.Equal(container.Args, expectedArgs) {
+				t.Errorf("expected args %v, got %v", expectedArgs, container.Args)
+			}
+
+			ports := []string{}
+			for _, p := range container.Ports {
+				ports = append(ports, fmt.Sprintf("%s:%d", p.Name, p.ContainerPort))
+			}
+			expectedPorts := []string{"http:3100", "grpc:9095"}
+			if tc.gossip {
+				expectedPorts = append(expectedPorts, "gossip:7946")
+			}
+			if !slices.Equal(ports, expectedPorts) {
+				t.Errorf("expected container ports %v, got %v", expectedPorts, ports)
+			}
+
+			if ref := container.Env[0].ValueFrom.SecretKeyRef; ref == nil || ref.Name != loki.ObjectStoreSecretName {
+				t.Errorf("expected the object storage env to come from %s, got %+v", loki.ObjectStoreSecretName, container.Env[0])
+			}
+			if mounted := slices.ContainsFunc(container.VolumeMounts, func(m corev1.VolumeMount) bool { return m.MountPath == "/data/loki" }); mounted == tc.noData {
+				t.Errorf("expected the data directory to be mounted: %t, got %+v", !tc.noData, container.VolumeMounts)
+			}
+
+			svc := kghelpers.GetObject[*corev1.Service](objs, name)
+			servicePorts := []string{}
+			for _, p := range svc.Spec.Ports {
+				servicePorts = append(servicePorts, fmt.Sprintf("%s:%d", p.Name, p.Port))
+			}
+			if !slices.Equal(servicePorts, []string{"http:3100", "grpc:9095"}) {
+				t.Errorf("expected service ports http:3100 and grpc:9095, got %v", servicePorts)
+			}
+			if headless := svc.Spec.ClusterIP == corev1.ClusterIPNone; headless != tc.headless {
+				t.Errorf("expected service to be headless: %t, got cluster IP %q", tc.headless, svc.Spec.ClusterIP)
+			}
+			if member := svc.Spec.Selector[loki.GossipMemberLabel] == "true"; member != tc.gossip {
+				t.Errorf("expected the pods to join the gossip ring: %t, got selector %v", tc.gossip, svc.Spec.Selector)
+			}
+		})
+	}
+}
+
+func TestDefaultConfig(t *testing.T) {
+	objs := loki.NewQuerier(nil, "ns", "2.9.0").Objects()
+	cm := kghelpers.GetObject[*corev1.ConfigMap](objs, "observatorium-loki-config")
+
+	config := lokischema.Config{}
+	if err := yaml.UnmarshalStrict([]byte(cm.Data["config.yaml"]), &config); err != nil {
+		t.Fatalf("failed to parse the rendered config: %v", err)
+	}
+
+	if !config.AuthEnabled {
+		t.Error("expected auth to be enabled")
+	}
+	if got := config.Server.HTTPListenPort; got != 3100 {
+		t.Errorf("expected HTTP port 3100, got %d", got)
+	}
+	if got := config.Common.CompactorGRPCAddress; got != "observatorium-loki-compactor:9095" {
+		t.Errorf("unexpected compactor address %s", got)
+	}
+	if got := config.Common.Storage.S3.BucketNames; got != "${S3_BUCKETS}" {
+		t.Errorf("expected the buckets to be expanded from the env, got %s", got)
+	}
+	if got := config.Memberlist.JoinMembers; !slices.Equal(got, []string{loki.GossipRingName + ":7946"}) {
+		t.Errorf("unexpected memberlist join members %v", got)
+	}
+	if got := config.Frontend.TailProxyURL; got != "http://observatorium-loki-querier:3100" {
+		t.Errorf("unexpected tail proxy URL %s", got)
+	}
+	if got := config.FrontendWorker.FrontendAddress; got != "observatorium-loki-query-frontend:9095" {
+		t.Errorf("unexpected frontend address %s", got)
+	}
+	if got := config.Ruler.Storage.Local.Directory; got != "/etc/loki/rules" {
+		t.Errorf("unexpected rules directory %s", got)
+	}
+}
+
+func TestRulerRules(t *testing.T) {
+	ruler := loki.NewRuler(nil, "ns", "2.9.0")
+	ruler.Rules = map[string]map[string]string{
+		"tenant-b": {"alerts.yaml": "groups: []"},
+		"tenant-a": {"alerts.yaml": "groups: []", "records.yaml": "groups: []"},
+	}
+
+	objs, err := ruler.ObjectsE()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cm := kghelpers.GetObject[*corev1.ConfigMap](objs, "observatorium-loki-ruler-rules-tenant-a")
+	if len(cm.Data) != 2 {
+		t.Errorf("expected the rule files of tenant-a, got %v", cm.Data)
+	}
+
+	pod := kghelpers.GetObject[*appsv1.StatefulSet](objs, ruler.Name).Spec.Template.Spec
+	mounts := []string{}
+	for _, m := range pod.Containers[0].VolumeMounts {
+		if strings.HasPrefix(m.MountPath, "/etc/loki/rules") {
+			mounts = append(mounts, fmt.Sprintf("%s:%s", m.Name, m.MountPath))
+		}
+	}
+	expected := []string{"rules:/etc/loki/rules", "rules-tenant-a:/etc/loki/rules/tenant-a", "rules-tenant-b:/etc/loki/rules/tenant-b"}
+	if !slices.Equal(mounts, expected) {
+		t.Errorf("expected rule mounts %v, got %v", expected, mounts)
+	}
+
+	for _, v := range pod.Volumes {
+		if v.Name == "rules-tenant-b" && v.ConfigMap.Name != "observatorium-loki-ruler-rules-tenant-b" {
+			t.Errorf("expected the rules of tenant-b to come from their ConfigMap, got %+v", v)
+		}
+	}
+
+	// The rule storage exists even without rules.
+	objs = loki.NewRuler(nil, "ns", "2.9.0").Objects()
+	pod = kghelpers.GetObject[*appsv1.StatefulSet](objs, ruler.Name).Spec.Template.Spec
+	if !slices.ContainsFunc(pod.Containers[0].VolumeMounts, func(m corev1.VolumeMount) bool { return m.MountPath == "/etc/loki/rules" }) {
+		t.Errorf("expected the rule storage to be mounted, got %+v", pod.Containers[0].VolumeMounts)
+	}
+
+	ruler.Rules = map[string]map[string]string{"Tenant_A": {"alerts.yaml": "groups: []"}}
+	if _, err := ruler.ObjectsE(); err == nil || !strings.Contains(err.Error(), `invalid rules of tenant "Tenant_A"`) {
+		t.Errorf("expected an invalid tenant error, got %v", err)
+	}
+}
+
+func TestGossipRing(t *testing.T) {
+	svc := kghelpers.GetObject[*corev1.Service](loki.NewGossipRing("ns").Objects(), loki.GossipRingName)
+	if svc.Spec.ClusterIP != corev1.ClusterIPNone || !svc.Spec.PublishNotReadyAddresses {
+		t.Errorf("expected a headless service publishing not ready addresses, got %+v", svc.Spec)
+	}
+	if svc.Spec.Selector[loki.GossipMemberLabel] != "true" {
+		t.Errorf("expected the service to select the gossip members, got %v", svc.Spec.Selector)
+	}
+}
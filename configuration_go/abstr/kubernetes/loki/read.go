@@ -0,0 +1,125 @@
+package loki
+
+import (
+	"fmt"
+
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Querier represents the Loki querier, answering queries from ingesters and long-term storage.
+// It is deployed as a Deployment.
+type Querier struct {
+	baseLoki
+	workload.DeploymentWorkload
+}
+
+// NewQuerier returns a new Querier with default configuration.
+func NewQuerier(opts *LokiOptions, namespace, imageTag string) *Querier {
+	if opts == nil {
+		opts = NewDefaultOptions()
+	}
+
+	baseLoki, podConfig := newBaseLoki(opts, namespace, imageTag, TargetQuerier, true)
+	podConfig.ContainerResources = kghelpers.NewResourcesRequirements("500m", "1", "500Mi", "1Gi")
+
+	return &Querier{
+		baseLoki: *baseLoki,
+		DeploymentWorkload: workload.DeploymentWorkload{
+			Replicas:  1,
+			PodConfig: podConfig,
+		},
+	}
+}
+
+// Objects returns the manifests for the Querier.
+// It panics if the configuration is invalid, see ObjectsE.
+func (q *Querier) Objects() []runtime.Object {
+	ret, err := q.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// ObjectsE is like Objects but returns an error instead of panicking when the config file is missing or invalid,
+// a probe does not target the HTTP port, or the autoscaling, disruption budget or network policy of the queriers is invalid.
+func (q *Querier) ObjectsE() ([]runtime.Object, error) {
+	container, err := q.makeContainer(&q.PodConfig, withEphemeralData())
+	if err != nil {
+		return nil, fmt.Errorf("invalid loki querier %s: %w", q.Name, err)
+	}
+
+	ret, err := q.DeploymentWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid loki querier %s: %w", q.Name, err)
+	}
+
+	return ret, nil
+}
+
+// QueryFrontend represents the Loki query frontend, splitting and caching queries.
+// It is deployed as a Deployment.
+type QueryFrontend struct {
+	baseLoki
+	workload.DeploymentWorkload
+}
+
+// NewQueryFrontend returns a new QueryFrontend with default configuration.
+func NewQueryFrontend(opts *LokiOptions, namespace, imageTag string) *QueryFrontend {
+	if opts == nil {
+		opts = NewDefaultOptions()
+	}
+
+	baseLoki, podConfig := newBaseLoki(opts, namespace, imageTag, TargetQueryFrontend, false)
+	podConfig.LivenessProbe = kghelpers.NewProbe("/ready", getPortOrDefault(defaultHTTPPort, opts.ServerHTTPListenPort), kghelpers.ProbeConfig{
+		FailureThreshold: 10,
+		PeriodSeconds:    30,
+		TimeoutSeconds:   1,
+	})
+
+	return &QueryFrontend{
+		baseLoki: *baseLoki,
+		DeploymentWorkload: workload.DeploymentWorkload{
+			Replicas:  1,
+			PodConfig: podConfig,
+		},
+	}
+}
+
+// Objects returns the manifests for the QueryFrontend.
+// It panics if the configuration is invalid, see ObjectsE.
+func (q *QueryFrontend) Objects() []runtime.Object {
+	ret, err := q.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// ObjectsE is like Objects but returns an error instead of panicking when the config file is missing or invalid,
+// a probe does not target the HTTP port, or the workload settings are invalid. The Service of the query frontend
+// must be headless for the querier workers to connect to every replica, it is an error if none is generated.
+func (q *QueryFrontend) ObjectsE() ([]runtime.Object, error) {
+	container, err := q.makeContainer(&q.PodConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid loki query frontend %s: %w", q.Name, err)
+	}
+
+	ret, err := q.DeploymentWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid loki query frontend %s: %w", q.Name, err)
+	}
+
+	service, err := kghelpers.GetObjectE[*corev1.Service](ret, q.Name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid loki query frontend %s: %w", q.Name, err)
+	}
+	service.Spec.ClusterIP = corev1.ClusterIPNone
+
+	return ret, nil
+}
@@ -0,0 +1,120 @@
+package loki
+
+import (
+	"fmt"
+
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Distributor represents the Loki distributor, receiving and validating incoming streams.
+// It is deployed as a Deployment.
+type Distributor struct {
+	baseLoki
+	workload.DeploymentWorkload
+}
+
+// NewDistributor returns a new Distributor with default configuration.
+func NewDistributor(opts *LokiOptions, namespace, imageTag string) *Distributor {
+	if opts == nil {
+		opts = NewDefaultOptions()
+	}
+
+	baseLoki, podConfig := newBaseLoki(opts, namespace, imageTag, TargetDistributor, true)
+	podConfig.LivenessProbe = kghelpers.NewProbe("/ready", getPortOrDefault(defaultHTTPPort, opts.ServerHTTPListenPort), kghelpers.ProbeConfig{
+		FailureThreshold: 10,
+		PeriodSeconds:    30,
+		TimeoutSeconds:   1,
+	})
+
+	return &Distributor{
+		baseLoki: *baseLoki,
+		DeploymentWorkload: workload.DeploymentWorkload{
+			Replicas:  1,
+			PodConfig: podConfig,
+		},
+	}
+}
+
+// Objects returns the manifests for the Distributor.
+// It panics if the configuration is invalid, see ObjectsE.
+func (d *Distributor) Objects() []runtime.Object {
+	ret, err := d.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// ObjectsE is like Objects but returns an error instead of panicking when the config file is missing or invalid,
+// a probe does not target the HTTP port, or the autoscaling, disruption budget or network policy of the distributors is invalid.
+func (d *Distributor) ObjectsE() ([]runtime.Object, error) {
+	container, err := d.makeContainer(&d.PodConfig, withEphemeralData())
+	if err != nil {
+		return nil, fmt.Errorf("invalid loki distributor %s: %w", d.Name, err)
+	}
+
+	ret, err := d.DeploymentWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid loki distributor %s: %w", d.Name, err)
+	}
+
+	return ret, nil
+}
+
+// Ingester represents the Loki ingester, writing streams to long-term storage.
+// It is deployed as a StatefulSet.
+type Ingester struct {
+	baseLoki
+	workload.StatefulSetWorkload
+}
+
+// NewIngester returns a new Ingester with default configuration.
+func NewIngester(opts *LokiOptions, namespace, imageTag string) *Ingester {
+	if opts == nil {
+		opts = NewDefaultOptions()
+	}
+
+	baseLoki, podConfig := newBaseLoki(opts, namespace, imageTag, TargetIngester, true)
+	podConfig.ContainerResources = kghelpers.NewResourcesRequirements("500m", "1", "1Gi", "2Gi")
+	// Ingesters flush their chunks on shutdown, give them time to do so.
+	podConfig.TerminationGracePeriodSeconds = 4800
+
+	return &Ingester{
+		baseLoki: *baseLoki,
+		StatefulSetWorkload: workload.StatefulSetWorkload{
+			Replicas:   1,
+			VolumeSize: "10Gi",
+			PodConfig:  podConfig,
+		},
+	}
+}
+
+// Objects returns the manifests for the Ingester.
+// It panics if the configuration is invalid, see ObjectsE.
+func (i *Ingester) Objects() []runtime.Object {
+	ret, err := i.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// ObjectsE is like Objects but returns an error instead of panicking when the config or limits file can't be mounted,
+// a probe does not target the HTTP port, or the disruption budget or network policy of the ingesters is invalid.
+func (i *Ingester) ObjectsE() ([]runtime.Object, error) {
+	container, err := i.makeContainer(&i.PodConfig, withPersistentData(i.VolumeType, i.VolumeSize))
+	if err != nil {
+		return nil, fmt.Errorf("invalid loki ingester %s: %w", i.Name, err)
+	}
+
+	ret, err := i.StatefulSetWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid loki ingester %s: %w", i.Name, err)
+	}
+
+	return ret, nil
+}
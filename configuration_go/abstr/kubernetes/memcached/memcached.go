@@ -2,6 +2,7 @@ package memcached
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/observatorium/observatorium/configuration_go/kubegen/cmdopt"
 	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
@@ -62,7 +63,7 @@ func NewMemcached() *MemcachedDeployment {
 		PodConfig: workload.PodConfig{
 			Name:                          "memcached",
 			Image:                         "docker.io/memcached",
-			ImageTag:                      "latest",
+			ImageTag:                      "1.6.3-alpine",
 			ImagePullPolicy:               corev1.PullIfNotPresent,
 			CommonLabels:                  commonLabels,
 			Env:                           []corev1.EnvVar{},
@@ -79,24 +80,45 @@ func NewMemcached() *MemcachedDeployment {
 		Options:            &options,
 		DeploymentWorkload: depWorkload,
 		ExporterImage:      "quay.io/prometheus/memcached-exporter",
-		ExporterImageTag:   "latest",
+		ExporterImageTag:   "v0.6.0",
 	}
 }
 
 // Manifests returns the manifests for the memcached deployment.
+// It panics if the configuration is invalid, see ObjectsE.
 func (m *MemcachedDeployment) Objects() []runtime.Object {
-	if m.EnableServiceMonitor {
-		m.Sidecars = append(m.Sidecars, m.makeExporterContainer())
+	ret, err := m.ObjectsE()
+	if err != nil {
+		panic(err.Error())
 	}
 
+	return ret
+}
+
+// ObjectsE is like Objects but returns an error instead of panicking when the workload configuration is invalid,
+// such as an autoscaler or a pod disruption budget set on the deployment with inconsistent bounds.
+func (m *MemcachedDeployment) ObjectsE() ([]runtime.Object, error) {
 	container := m.makeContainer()
-	ret := m.DeploymentWorkload.Objects(container)
+
+	// Add the exporter to a copy of the workload, so that it is not added again on the next call.
+	dep := m.DeploymentWorkload
+	if m.EnableServiceMonitor {
+		dep.Sidecars = append(slices.Clone(m.Sidecars), m.makeExporterContainer())
+	}
+
+	ret, err := dep.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid memcached %s: %w", m.Name, err)
+	}
 
 	// Set headless service to get stable network ID.
-	service := kghelpers.GetObject[*corev1.Service](ret, "")
+	service, err := kghelpers.GetObjectE[*corev1.Service](ret, "")
+	if err != nil {
+		return nil, fmt.Errorf("invalid memcached %s: %w", m.Name, err)
+	}
 	service.Spec.ClusterIP = corev1.ClusterIPNone
 
-	return ret
+	return ret, nil
 }
 
 func (m *MemcachedDeployment) makeContainer() *workload.Container {
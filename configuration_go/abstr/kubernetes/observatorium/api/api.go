@@ -1,6 +1,8 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"net"
 	"time"
 
@@ -146,6 +148,7 @@ func NewObservatoriumAPI(opts *ObservatoriumAPIOptions, namespace, imageTag stri
 			ContainerResources:   kghelpers.NewResourcesRequirements("100m", "1", "1Gi", "4Gi"),
 			Affinity:             kghelpers.NewAntiAffinity(nil, labelSelectors),
 			EnableServiceMonitor: true,
+			PodDisruptionBudget:  workload.NewMaxUnavailableBudget(1),
 
 			LivenessProbe: kghelpers.NewProbe("/live", httpInternalPort, kghelpers.ProbeConfig{
 				FailureThreshold: 8,
@@ -169,17 +172,38 @@ func NewObservatoriumAPI(opts *ObservatoriumAPIOptions, namespace, imageTag stri
 }
 
 func (o *ObservatoriumAPIDeployment) Objects() []runtime.Object {
-	container := o.makeContainer()
-	return o.DeploymentWorkload.Objects(container)
+	ret, err := o.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
 }
 
-func (o *ObservatoriumAPIDeployment) makeContainer() *workload.Container {
+// ObjectsE is like Objects but returns an error instead of panicking when the probes do not target the internal HTTP port.
+func (o *ObservatoriumAPIDeployment) ObjectsE() ([]runtime.Object, error) {
+	container, err := o.makeContainer()
+	if err != nil {
+		return nil, fmt.Errorf("invalid observatorium api %s: %w", o.Name, err)
+	}
+
+	ret, err := o.DeploymentWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid observatorium api %s: %w", o.Name, err)
+	}
+
+	return ret, nil
+}
+
+func (o *ObservatoriumAPIDeployment) makeContainer() (*workload.Container, error) {
 	httpPublicPort := kghelpers.GetPortOrDefault(defaultHTTPPublicPort, o.options.WebListen)
 	httpInternalPort := kghelpers.GetPortOrDefault(defaultHTTPInternalPort, o.options.WebInternalListen)
 	grpcPort := kghelpers.GetPortOrDefault(defaultGRPCPort, o.options.GrpcListen)
 
-	kghelpers.CheckProbePort(httpInternalPort, o.LivenessProbe)
-	kghelpers.CheckProbePort(httpInternalPort, o.ReadinessProbe)
+	errs := []error{
+		kghelpers.CheckProbePortE(httpInternalPort, o.LivenessProbe),
+		kghelpers.CheckProbePortE(httpInternalPort, o.ReadinessProbe),
+	}
 
 	ret := o.ToContainer()
 	ret.Name = "observatorium-api"
@@ -214,12 +238,16 @@ func (o *ObservatoriumAPIDeployment) makeContainer() *workload.Container {
 	}
 
 	if o.options.RbacConfig != nil {
-		o.options.RbacConfig.Update(ret)
+		errs = append(errs, containeropts.UpdateContainerE(o.options.RbacConfig, ret))
 	}
 
 	if o.options.TenantsConfig != nil {
-		o.options.TenantsConfig.Update(ret)
+		errs = append(errs, containeropts.UpdateContainerE(o.options.TenantsConfig, ret))
 	}
 
-	return ret
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
 }
@@ -1,27 +1,94 @@
 package api
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"strings"
+	"time"
 
+	"github.com/observatorium/observatorium/configuration_go/kubegen/cmdopt"
 	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
 	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
 	monv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+)
 
-	rbacv1 "k8s.io/api/rbac/v1"
+const (
+	gubernatorHttpPort       = 8080
+	gubernatorGrpcPort       = 8081
+	gubernatorMemberListPort = 7946
 )
 
+// PeerDiscovery is the way Gubernator instances find their peers.
+type PeerDiscovery string
+
 const (
-	gubernatorHttpPort = 8080
-	gubernatorGrpcPort = 8081
+	// PeerDiscoveryKubernetes watches the endpoints of the Gubernator pods through the Kubernetes API.
+	// The RBAC objects allowing it are generated.
+	PeerDiscoveryKubernetes PeerDiscovery = "k8s"
+	// PeerDiscoveryDNS resolves the peers from the DNS records of the headless Service.
+	PeerDiscoveryDNS PeerDiscovery = "dns"
+	// PeerDiscoveryMemberList finds the peers by gossip, starting from a static list of known nodes.
+	PeerDiscoveryMemberList PeerDiscovery = "member-list"
 )
 
+// GubernatorOptions holds the configuration of Gubernator, rendered as its environment variables.
+// Addresses and peer discovery settings left empty are derived from the deployment.
+type GubernatorOptions struct {
+	HTTPAddress *net.TCPAddr `env:"GUBER_HTTP_ADDRESS"`
+	GRPCAddress *net.TCPAddr `env:"GUBER_GRPC_ADDRESS"`
+	// AdvertiseAddress is the address of the instance given to its peers. It defaults to the pod IP and gRPC port.
+	AdvertiseAddress string `env:"GUBER_ADVERTISE_ADDRESS"`
+	LogLevel         string `env:"GUBER_LOG_LEVEL"`
+	// CacheSize is the number of rate limits kept in memory by each instance.
+	CacheSize int `env:"GUBER_CACHE_SIZE"`
+
+	PeerDiscoveryType PeerDiscovery `env:"GUBER_PEER_DISCOVERY_TYPE"`
+	// K8sEndpointsSelector defaults to the selector labels of the pods.
+	K8sEndpointsSelector string `env:"GUBER_K8S_ENDPOINTS_SELECTOR"`
+	// DNSFQDN defaults to the name of the headless Service.
+	DNSFQDN           string       `env:"GUBER_DNS_FQDN"`
+	MemberListAddress *net.TCPAddr `env:"GUBER_MEMBERLIST_ADDRESS"`
+	// MemberListKnownNodes defaults to the headless Service, which resolves to all the pods.
+	MemberListKnownNodes []string `env:"GUBER_MEMBERLIST_KNOWN_NODES"`
+
+	// ForceGlobal selects the global behaviour for all the rate limits: instances answer checks from their
+	// own counters and sync them asynchronously with the owner of the limit. Otherwise, the local behaviour
+	// applies unless requested otherwise by the client: checks are forwarded to the owner of the limit,
+	// which is exact but adds a network hop.
+	ForceGlobal      bool          `env:"GUBER_FORCE_GLOBAL"`
+	GlobalSyncWait   time.Duration `env:"GUBER_GLOBAL_SYNC_WAIT"`
+	GlobalTimeout    time.Duration `env:"GUBER_GLOBAL_TIMEOUT"`
+	GlobalBatchLimit int           `env:"GUBER_GLOBAL_BATCH_LIMIT"`
+	BatchWait        time.Duration `env:"GUBER_BATCH_WAIT"`
+	BatchTimeout     time.Duration `env:"GUBER_BATCH_TIMEOUT"`
+	BatchLimit       int           `env:"GUBER_BATCH_LIMIT"`
+}
+
+// NewDefaultGubernatorOptions returns the options of Gubernator discovering its peers through the Kubernetes API.
+func NewDefaultGubernatorOptions() *GubernatorOptions {
+	return &GubernatorOptions{
+		HTTPAddress:       &net.TCPAddr{Port: gubernatorHttpPort, IP: net.ParseIP("0.0.0.0")},
+		GRPCAddress:       &net.TCPAddr{Port: gubernatorGrpcPort, IP: net.ParseIP("0.0.0.0")},
+		LogLevel:          "info",
+		PeerDiscoveryType: PeerDiscoveryKubernetes,
+	}
+}
+
 type GubernatorDeployment struct {
 	workload.DeploymentWorkload
+
+	options *GubernatorOptions
 }
 
-func NewGubernatorDeployment(namespace, imageTag string) *GubernatorDeployment {
+func NewGubernatorDeployment(opts *GubernatorOptions, namespace, imageTag string) *GubernatorDeployment {
+	if opts == nil {
+		opts = NewDefaultGubernatorOptions()
+	}
+
 	commonLabels := map[string]string{
 		workload.NameLabel:      "gubernator",
 		workload.InstanceLabel:  "observatorium",
@@ -59,32 +126,6 @@ func NewGubernatorDeployment(namespace, imageTag string) *GubernatorDeployment {
 			}),
 			TerminationGracePeriodSeconds: 120,
 			Env: []corev1.EnvVar{
-				kghelpers.NewEnvFromField("GUBER_K8S_NAMESPACE", "metadata.namespace"),
-				kghelpers.NewEnvFromField("GUBER_K8S_POD_IP", "status.podIP"),
-				{
-					Name:  "GUBER_HTTP_ADDRESS",
-					Value: fmt.Sprintf("0.0.0.0:%d", gubernatorHttpPort),
-				},
-				{
-					Name:  "GUBER_GRPC_ADDRESS",
-					Value: fmt.Sprintf("0.0.0.0:%d", gubernatorGrpcPort),
-				},
-				{
-					Name:  "GUBER_K8S_POD_PORT",
-					Value: fmt.Sprintf("%d", gubernatorGrpcPort),
-				},
-				{
-					Name:  "GUBER_K8S_ENDPOINTS_SELECTOR",
-					Value: "app.kubernetes.io/name=gubernator",
-				},
-				{
-					Name:  "GUBER_PEER_DISCOVERY_TYPE",
-					Value: "k8s",
-				},
-				{
-					Name:  "GUBER_LOG_LEVEL",
-					Value: "info",
-				},
 				{
 					Name:  "OTEL_TRACES_EXPORTER",
 					Value: "none",
@@ -97,69 +138,125 @@ func NewGubernatorDeployment(namespace, imageTag string) *GubernatorDeployment {
 
 	return &GubernatorDeployment{
 		DeploymentWorkload: depWorkload,
+		options:            opts,
 	}
 }
 
 func (g *GubernatorDeployment) Objects() []runtime.Object {
-	container := g.makeContainer()
-	ret := g.DeploymentWorkload.Objects(container)
-
-	kghelpers.GetObject[*corev1.Service](ret, g.Name).Spec.ClusterIP = corev1.ClusterIPNone
-
-	rbacRole := &rbacv1.Role{
-		TypeMeta:   workload.RoleMeta,
-		ObjectMeta: g.ObjectMeta().MakeMeta(),
-		Rules: []rbacv1.PolicyRule{
-			{
-				APIGroups: []string{""},
-				Resources: []string{"endpoints"},
-				Verbs:     []string{"list", "watch", "get"},
-			},
-		},
+	ret, err := g.ObjectsE()
+	if err != nil {
+		panic(err.Error())
 	}
 
-	sa := kghelpers.GetObject[*corev1.ServiceAccount](ret, g.Name)
-	roleBinding := &rbacv1.RoleBinding{
-		TypeMeta:   workload.RoleBindingMeta,
-		ObjectMeta: g.ObjectMeta().MakeMeta(),
-		Subjects: []rbacv1.Subject{
-			{
-				Kind:      sa.GetObjectKind().GroupVersionKind().Kind,
-				Name:      sa.GetName(),
-				Namespace: sa.GetNamespace(),
-			},
-		},
-		RoleRef: rbacv1.RoleRef{
-			Kind:     rbacRole.GetObjectKind().GroupVersionKind().Kind,
-			APIGroup: rbacRole.GetObjectKind().GroupVersionKind().Group,
-			Name:     rbacRole.GetName(),
-		},
+	return ret
+}
+
+// ObjectsE is like Objects but returns an error instead of panicking when the peer discovery type is unknown,
+// the cache size is negative or the probes do not target the HTTP port.
+// The Service is headless, so that it resolves to all the instances for DNS and member list discovery.
+func (g *GubernatorDeployment) ObjectsE() ([]runtime.Object, error) {
+	container, err := g.makeContainer()
+	if err != nil {
+		return nil, fmt.Errorf("invalid gubernator %s: %w", g.Name, err)
 	}
 
-	ret = append(ret, rbacRole, roleBinding)
+	ret, err := g.DeploymentWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gubernator %s: %w", g.Name, err)
+	}
 
-	return ret
+	service, err := kghelpers.GetObjectE[*corev1.Service](ret, g.Name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gubernator %s: %w", g.Name, err)
+	}
+	service.Spec.ClusterIP = corev1.ClusterIPNone
+
+	return ret, nil
 }
 
-func (s *GubernatorDeployment) makeContainer() *workload.Container {
-	ret := s.ToContainer()
+func (g *GubernatorDeployment) makeContainer() (*workload.Container, error) {
+	httpPort := kghelpers.GetPortOrDefault(gubernatorHttpPort, g.options.HTTPAddress)
+	grpcPort := kghelpers.GetPortOrDefault(gubernatorGrpcPort, g.options.GRPCAddress)
+	memberListPort := kghelpers.GetPortOrDefault(gubernatorMemberListPort, g.options.MemberListAddress)
+	serviceFQDN := fmt.Sprintf("%s.%s.svc.cluster.local", g.Name, g.Namespace)
+
+	errs := []error{
+		kghelpers.CheckProbePortE(httpPort, g.LivenessProbe),
+		kghelpers.CheckProbePortE(httpPort, g.ReadinessProbe),
+	}
+
+	if g.options.CacheSize < 0 {
+		errs = append(errs, fmt.Errorf("cache size must not be negative, got %d", g.options.CacheSize))
+	}
+
+	// Options are copied so that derived values don't leak into the user options.
+	opts := *g.options
+	env := []corev1.EnvVar{kghelpers.NewEnvFromField("POD_IP", "status.podIP")}
+	if opts.AdvertiseAddress == "" {
+		opts.AdvertiseAddress = fmt.Sprintf("$(POD_IP):%d", grpcPort)
+	}
+
+	servicePorts := []corev1.ServicePort{
+		kghelpers.NewServicePort("http", httpPort, httpPort),
+		kghelpers.NewServicePort("grpc", grpcPort, grpcPort),
+	}
+
+	ret := g.ToContainer()
 	ret.Name = "gubernator"
 	ret.Ports = []corev1.ContainerPort{
 		{
 			Name:          "http",
-			ContainerPort: int32(gubernatorHttpPort),
+			ContainerPort: int32(httpPort),
 			Protocol:      corev1.ProtocolTCP,
 		},
 		{
 			Name:          "grpc",
-			ContainerPort: int32(gubernatorGrpcPort),
+			ContainerPort: int32(grpcPort),
 			Protocol:      corev1.ProtocolTCP,
 		},
 	}
-	ret.ServicePorts = []corev1.ServicePort{
-		kghelpers.NewServicePort("http", gubernatorHttpPort, gubernatorHttpPort),
-		kghelpers.NewServicePort("grpc", gubernatorGrpcPort, gubernatorGrpcPort),
+
+	switch opts.PeerDiscoveryType {
+	case PeerDiscoveryKubernetes:
+		if opts.K8sEndpointsSelector == "" {
+			opts.K8sEndpointsSelector = fmt.Sprintf("%s=%s,%s=%s",
+				workload.NameLabel, g.CommonLabels[workload.NameLabel],
+				workload.InstanceLabel, g.CommonLabels[workload.InstanceLabel])
+		}
+		env = append(env,
+			kghelpers.NewEnvFromField("GUBER_K8S_NAMESPACE", "metadata.namespace"),
+			kghelpers.NewEnvFromField("GUBER_K8S_POD_IP", "status.podIP"),
+			corev1.EnvVar{Name: "GUBER_K8S_POD_PORT", Value: fmt.Sprintf("%d", grpcPort)},
+		)
+		ret.Rules = []rbacv1.PolicyRule{
+			workload.NewPolicyRule("", []string{"endpoints"}, "list", "watch", "get"),
+		}
+	case PeerDiscoveryDNS:
+		if opts.DNSFQDN == "" {
+			opts.DNSFQDN = serviceFQDN
+		}
+	case PeerDiscoveryMemberList:
+		if len(opts.MemberListKnownNodes) == 0 {
+			opts.MemberListKnownNodes = []string{fmt.Sprintf("%s:%d", serviceFQDN, memberListPort)}
+		}
+		ret.Ports = append(ret.Ports,
+			corev1.ContainerPort{Name: "memberlist", ContainerPort: int32(memberListPort), Protocol: corev1.ProtocolTCP},
+			corev1.ContainerPort{Name: "memberlist-udp", ContainerPort: int32(memberListPort), Protocol: corev1.ProtocolUDP},
+		)
+		memberListUDP := kghelpers.NewServicePort("memberlist-udp", memberListPort, memberListPort)
+		memberListUDP.Protocol = corev1.ProtocolUDP
+		servicePorts = append(servicePorts, kghelpers.NewServicePort("memberlist", memberListPort, memberListPort), memberListUDP)
+	default:
+		errs = append(errs, fmt.Errorf("unsupported peer discovery type %q, expected one of %s", opts.PeerDiscoveryType,
+			strings.Join([]string{string(PeerDiscoveryKubernetes), string(PeerDiscoveryDNS), string(PeerDiscoveryMemberList)}, ", ")))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
 	}
+
+	ret.Env = append(append(env, cmdopt.GetEnv(&opts)...), ret.Env...)
+	ret.ServicePorts = servicePorts
 	ret.MonitorPorts = []monv1.Endpoint{
 		{
 			Port:           "http",
@@ -167,5 +264,5 @@ func (s *GubernatorDeployment) makeContainer() *workload.Container {
 		},
 	}
 
-	return ret
+	return ret, nil
 }
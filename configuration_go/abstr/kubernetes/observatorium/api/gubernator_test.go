@@ -0,0 +1,140 @@
+package api_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/api"
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestGubernatorPeerDiscovery(t *testing.T) {
+	testCases := map[string]struct {
+		discovery    api.PeerDiscovery
+		env          map[string]string
+		absentEnv    []string
+		servicePorts []string
+		rbac         bool
+	}{
+		"kubernetes": {
+			discovery: api.PeerDiscoveryKubernetes,
+			env: map[string]string{
+				"GUBER_PEER_DISCOVERY_TYPE":    "k8s",
+				"GUBER_K8S_ENDPOINTS_SELECTOR": "app.kubernetes.io/name=gubernator,app.kubernetes.io/instance=observatorium",
+				"GUBER_K8S_POD_PORT":           "8081",
+				"GUBER_ADVERTISE_ADDRESS":      "$(POD_IP):8081",
+			},
+			absentEnv:    []string{"GUBER_DNS_FQDN", "GUBER_MEMBERLIST_KNOWN_NODES"},
+			servicePorts: []string{"http/TCP:8080", "grpc/TCP:8081"},
+			rbac:         true,
+		},
+		"dns": {
+			discovery: api.PeerDiscoveryDNS,
+			env: map[string]string{
+				"GUBER_PEER_DISCOVERY_TYPE": "dns",
+				"GUBER_DNS_FQDN":            "observatorium-gubernator.ns.svc.cluster.local",
+			},
+			absentEnv:    []string{"GUBER_K8S_ENDPOINTS_SELECTOR", "GUBER_K8S_NAMESPACE", "GUBER_MEMBERLIST_KNOWN_NODES"},
+			servicePorts: []string{"http/TCP:8080", "grpc/TCP:8081"},
+		},
+		"member list": {
+			discovery: api.PeerDiscoveryMemberList,
+			env: map[string]string{
+				"GUBER_PEER_DISCOVERY_TYPE":    "member-list",
+				"GUBER_MEMBERLIST_KNOWN_NODES": "observatorium-gubernator.ns.svc.cluster.local:7946",
+			},
+			absentEnv:    []string{"GUBER_K8S_ENDPOINTS_SELECTOR", "GUBER_K8S_NAMESPACE", "GUBER_DNS_FQDN"},
+			servicePorts: []string{"http/TCP:8080", "grpc/TCP:8081", "memberlist/TCP:7946", "memberlist-udp/UDP:7946"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			opts := api.NewDefaultGubernatorOptions()
+			opts.PeerDiscoveryType = tc.discovery
+			objects := api.NewGubernatorDeployment(opts, "ns", "v2.2.1").Objects()
+
+			container := kghelpers.GetObject[*appsv1.Deployment](objects, "observatorium-gubernator").Spec.Template.Spec.Containers[0]
+			env := map[string]string{}
+			for _, e := range container.Env {
+				env[e.Name] = e.Value
+			}
+			for k, v := range tc.env {
+				if env[k] != v {
+					t.Errorf("expected env %s=%q, got %q", k, v, env[k])
+				}
+			}
+			for _, k := range tc.absentEnv {
+				if _, ok := env[k]; ok {
+					t.Errorf("expected no env %s, got %q", k, env[k])
+				}
+			}
+
+			svc := kghelpers.GetObject[*corev1.Service](objects, "observatorium-gubernator")
+			if svc.Spec.ClusterIP != corev1.ClusterIPNone {
+				t.Errorf("expected a headless service, got cluster IP %q", svc.Spec.ClusterIP)
+			}
+			ports := []string{}
+			for _, p := range svc.Spec.Ports {
+				ports = append(ports, fmt.Sprintf("%s/%s:%d", p.Name, p.Protocol, p.Port))
+			}
+			if strings.Join(ports, ",") != strings.Join(tc.servicePorts, ",") {
+				t.Errorf("expected service ports %v, got %v", tc.servicePorts, ports)
+			}
+			if len(container.Ports) != len(tc.servicePorts) {
+				t.Errorf("expected %d container ports, got %+v", len(tc.servicePorts), container.Ports)
+			}
+
+			hasRole, hasRoleBinding := false, false
+			for _, obj := range objects {
+				switch obj.(type) {
+				case *rbacv1.Role:
+					hasRole = true
+				case *rbacv1.RoleBinding:
+					hasRoleBinding = true
+				}
+			}
+			if hasRole != tc.rbac || hasRoleBinding != tc.rbac {
+				t.Errorf("expected RBAC objects to be generated: %t, got role %t and role binding %t", tc.rbac, hasRole, hasRoleBinding)
+			}
+			if tc.rbac {
+				role := kghelpers.GetObject[*rbacv1.Role](objects, "")
+				if len(role.Rules) != 1 || strings.Join(role.Rules[0].Resources, ",") != "endpoints" {
+					t.Errorf("expected the role to grant access to the endpoints, got %+v", role.Rules)
+				}
+			}
+		})
+	}
+}
+
+func TestGubernatorOptionsErrors(t *testing.T) {
+	testCases := map[string]struct {
+		mutate   func(opts *api.GubernatorOptions)
+		expected string
+	}{
+		"unknown peer discovery": {
+			mutate:   func(opts *api.GubernatorOptions) { opts.PeerDiscoveryType = "etcd" },
+			expected: `unsupported peer discovery type "etcd", expected one of k8s, dns, member-list`,
+		},
+		"negative cache size": {
+			mutate:   func(opts *api.GubernatorOptions) { opts.CacheSize = -1 },
+			expected: "cache size must not be negative, got -1",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			opts := api.NewDefaultGubernatorOptions()
+			tc.mutate(opts)
+
+			_, err := api.NewGubernatorDeployment(opts, "ns", "v2.2.1").ObjectsE()
+			if err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("expected error to contain %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	obsrbac "github.com/observatorium/api/rbac"
+)
+
+// Resources of the API that roles grant permissions on.
+const (
+	ResourceMetrics string = "metrics"
+	ResourceLogs    string = "logs"
+	ResourceTraces  string = "traces"
+)
+
+// AllResources lists the resources of the API.
+var AllResources = []string{ResourceMetrics, ResourceLogs, ResourceTraces}
+
+// RBACBuilder builds the RBAC config of the API. Its helpers name roles and role bindings after the granted
+// access, e.g. <tenant>-read-only, and bind more subjects to them when called several times.
+type RBACBuilder struct {
+	tenants *Tenants
+	rbac    RBAC
+}
+
+// NewRBACBuilder returns a builder of the RBAC config for the given tenants.
+func NewRBACBuilder(tenants *Tenants) *RBACBuilder {
+	return &RBACBuilder{
+		tenants: tenants,
+		rbac: RBAC{
+			Roles:        []obsrbac.Role{},
+			RoleBindings: []obsrbac.RoleBinding{},
+		},
+	}
+}
+
+// WithRole adds a role.
+func (b *RBACBuilder) WithRole(role obsrbac.Role) *RBACBuilder {
+	b.rbac.Roles = append(b.rbac.Roles, role)
+	return b
+}
+
+// WithRoleBinding adds a role binding.
+func (b *RBACBuilder) WithRoleBinding(binding obsrbac.RoleBinding) *RBACBuilder {
+	b.rbac.RoleBindings = append(b.rbac.RoleBindings, binding)
+	return b
+}
+
+// WithReadOnly grants the subjects read access to all the resources of the tenant.
+func (b *RBACBuilder) WithReadOnly(tenant string, subjects ...obsrbac.Subject) *RBACBuilder {
+	return b.withAccess(tenant+"-read-only", []string{tenant}, []obsrbac.Permission{obsrbac.Read}, subjects)
+}
+
+// WithWriteOnly grants the subjects write access to all the resources of the tenant, e.g. for collectors.
+func (b *RBACBuilder) WithWriteOnly(tenant string, subjects ...obsrbac.Subject) *RBACBuilder {
+	return b.withAccess(tenant+"-write-only", []string{tenant}, []obsrbac.Permission{obsrbac.Write}, subjects)
+}
+
+// WithAdmin grants the subjects read and write access to all the resources of all the tenants.
+func (b *RBACBuilder) WithAdmin(subjects ...obsrbac.Subject) *RBACBuilder {
+	tenants := []string{}
+	if b.tenants != nil {
+		for _, t := range b.tenants.Tenants {
+			tenants = append(tenants, t.Name)
+		}
+	}
+
+	return b.withAccess("admin", tenants, []obsrbac.Permission{obsrbac.Read, obsrbac.Write}, subjects)
+}
+
+// withAccess adds the role and its binding if missing, and binds the subjects to it.
+func (b *RBACBuilder) withAccess(name string, tenants []string, permissions []obsrbac.Permission, subjects []obsrbac.Subject) *RBACBuilder {
+	if !slices.ContainsFunc(b.rbac.Roles, func(r obsrbac.Role) bool { return r.Name == name }) {
+		b.rbac.Roles = append(b.rbac.Roles, obsrbac.Role{
+			Name:        name,
+			Resources:   slices.Clone(AllResources),
+			Tenants:     tenants,
+			Permissions: permissions,
+		})
+	}
+
+	i := slices.IndexFunc(b.rbac.RoleBindings, func(rb obsrbac.RoleBinding) bool { return rb.Name == name })
+	if i < 0 {
+		b.rbac.RoleBindings = append(b.rbac.RoleBindings, obsrbac.RoleBinding{Name: name, Roles: []string{name}})
+		i = len(b.rbac.RoleBindings) - 1
+	}
+	b.rbac.RoleBindings[i].Subjects = append(b.rbac.RoleBindings[i].Subjects, subjects...)
+
+	return b
+}
+
+// Build returns the RBAC config, or an error if it is inconsistent, see RBAC.Validate.
+func (b *RBACBuilder) Build() (*RBAC, error) {
+	ret := RBAC{
+		Roles:        slices.Clone(b.rbac.Roles),
+		RoleBindings: slices.Clone(b.rbac.RoleBindings),
+	}
+	if err := ret.Validate(b.tenants); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+// Validate checks the referential integrity of the RBAC config: role and role binding names are unique,
+// bindings reference existing roles and valid subjects, and roles reference existing tenants, known
+// resources and permissions.
+func (r RBAC) Validate(tenants *Tenants) error {
+	var errs []error
+
+	tenantNames := map[string]bool{}
+	if tenants != nil {
+		for _, t := range tenants.Tenants {
+			tenantNames[t.Name] = true
+		}
+	}
+
+	roles := map[string]bool{}
+	for _, role := range r.Roles {
+		if role.Name == "" {
+			errs = append(errs, errors.New("role must have a name"))
+		} else if roles[role.Name] {
+			errs = append(errs, fmt.Errorf("role %s is defined several times", role.Name))
+		}
+		roles[role.Name] = true
+
+		for _, tenant := range role.Tenants {
+			if !tenantNames[tenant] {
+				errs = append(errs, fmt.Errorf("role %s references unknown tenant %s", role.Name, tenant))
+			}
+		}
+
+		for _, resource := range role.Resources {
+			if !slices.Contains(AllResources, resource) {
+				errs = append(errs, fmt.Errorf("role %s references unknown resource %s, expected one of %s", role.Name, resource, strings.Join(AllResources, ", ")))
+			}
+		}
+
+		for _, permission := range role.Permissions {
+			if permission != obsrbac.Read && permission != obsrbac.Write {
+				errs = append(errs, fmt.Errorf("role %s references unknown permission %s", role.Name, permission))
+			}
+		}
+	}
+
+	bindings := map[string]bool{}
+	for _, binding := range r.RoleBindings {
+		if binding.Name == "" {
+			errs = append(errs, errors.New("role binding must have a name"))
+		} else if bindings[binding.Name] {
+			errs = append(errs, fmt.Errorf("role binding %s is defined several times", binding.Name))
+		}
+		bindings[binding.Name] = true
+
+		for _, role := range binding.Roles {
+			if !roles[role] {
+				errs = append(errs, fmt.Errorf("role binding %s references unknown role %s", binding.Name, role))
+			}
+		}
+
+		if len(binding.Subjects) == 0 {
+			errs = append(errs, fmt.Errorf("role binding %s has no subject", binding.Name))
+		}
+
+		for _, subject := range binding.Subjects {
+			if subject.Name == "" || (subject.Kind != obsrbac.User && subject.Kind != obsrbac.Group) {
+				errs = append(errs, fmt.Errorf("role binding %s has invalid subject %+v", binding.Name, subject))
+			}
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid RBAC: %w", err)
+	}
+
+	return nil
+}
+
+// Grant is a role granting a permission, bound to a subject by a role binding.
+type Grant struct {
+	RoleBinding string
+	Role        string
+	Subject     obsrbac.Subject
+}
+
+// Explanation tells whether a subject has a permission, and which grants give it.
+type Explanation struct {
+	Allowed bool
+	Grants  []Grant
+}
+
+func (e Explanation) String() string {
+	if !e.Allowed {
+		return "denied: no role binding grants the permission"
+	}
+
+	grants := make([]string, 0, len(e.Grants))
+	for _, g := range e.Grants {
+		grants = append(grants, fmt.Sprintf("role %s bound to %s %s by %s", g.Role, g.Subject.Kind, g.Subject.Name, g.RoleBinding))
+	}
+
+	return "allowed: " + strings.Join(grants, "; ")
+}
+
+// Explain answers whether the subject, member of the given groups, has the permission on the resource
+// of the tenant, and lists the grants giving it. It is meant to review RBAC configs.
+func (r RBAC) Explain(subject string, groups []string, permission obsrbac.Permission, resource, tenant string) Explanation {
+	roles := map[string]obsrbac.Role{}
+	for _, role := range r.Roles {
+		roles[role.Name] = role
+	}
+
+	ret := Explanation{}
+	for _, binding := range r.RoleBindings {
+		for _, s := range binding.Subjects {
+			if !(s.Kind == obsrbac.User && s.Name == subject) && !(s.Kind == obsrbac.Group && slices.Contains(groups, s.Name)) {
+				continue
+			}
+
+			for _, name := range binding.Roles {
+				role, ok := roles[name]
+				if !ok || !slices.Contains(role.Permissions, permission) || !slices.Contains(role.Resources, resource) || !slices.Contains(role.Tenants, tenant) {
+					continue
+				}
+
+				ret.Allowed = true
+				ret.Grants = append(ret.Grants, Grant{RoleBinding: binding.Name, Role: name, Subject: s})
+			}
+		}
+	}
+
+	return ret
+}
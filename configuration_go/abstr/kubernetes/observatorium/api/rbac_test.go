@@ -0,0 +1,273 @@
+package api_test
+
+import (
+	"strings"
+	"testing"
+
+	obsrbac "github.com/observatorium/api/rbac"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/api"
+)
+
+func newTenants(names ...string) *api.Tenants {
+	ret := &api.Tenants{}
+	for _, name := range names {
+		ret.Tenants = append(ret.Tenants, api.Tenant{Name: name, ID: name + "-id"})
+	}
+	return ret
+}
+
+func TestRBACValidate(t *testing.T) {
+	user := obsrbac.Subject{Name: "alice", Kind: obsrbac.User}
+	role := obsrbac.Role{
+		Name:        "reader",
+		Resources:   []string{api.ResourceMetrics},
+		Tenants:     []string{"a"},
+		Permissions: []obsrbac.Permission{obsrbac.Read},
+	}
+	binding := obsrbac.RoleBinding{Name: "reader", Roles: []string{"reader"}, Subjects: []obsrbac.Subject{user}}
+
+	testCases := map[string]struct {
+		rbac     api.RBAC
+		expected []string
+	}{
+		"empty": {},
+		"valid": {
+			rbac: api.RBAC{Roles: []obsrbac.Role{role}, RoleBindings: []obsrbac.RoleBinding{binding}},
+		},
+		"unnamed role": {
+			rbac:     api.RBAC{Roles: []obsrbac.Role{{}}},
+			expected: []string{"role must have a name"},
+		},
+		"duplicate role": {
+			rbac:     api.RBAC{Roles: []obsrbac.Role{role, role}},
+			expected: []string{"role reader is defined several times"},
+		},
+		"unknown tenant": {
+			rbac: api.RBAC{Roles: []obsrbac.Role{{
+				Name:    "reader",
+				Tenants: []string{"a", "unknown"},
+			}}},
+			expected: []string{"role reader references unknown tenant unknown"},
+		},
+		"unknown resource": {
+			rbac: api.RBAC{Roles: []obsrbac.Role{{
+				Name:      "reader",
+				Resources: []string{"profiles"},
+			}}},
+			expected: []string{"role reader references unknown resource profiles, expected one of metrics, logs, traces"},
+		},
+		"unknown permission": {
+			rbac: api.RBAC{Roles: []obsrbac.Role{{
+				Name:        "reader",
+				Permissions: []obsrbac.Permission{"delete"},
+			}}},
+			expected: []string{"role reader references unknown permission delete"},
+		},
+		"unnamed role binding": {
+			rbac: api.RBAC{
+				Roles:        []obsrbac.Role{role},
+				RoleBindings: []obsrbac.RoleBinding{{Roles: []string{"reader"}, Subjects: []obsrbac.Subject{user}}},
+			},
+			expected: []string{"role binding must have a name"},
+		},
+		"duplicate role binding": {
+			rbac:     api.RBAC{Roles: []obsrbac.Role{role}, RoleBindings: []obsrbac.RoleBinding{binding, binding}},
+			expected: []string{"role binding reader is defined several times"},
+		},
+		"unknown role": {
+			rbac:     api.RBAC{RoleBindings: []obsrbac.RoleBinding{binding}},
+			expected: []string{"role binding reader references unknown role reader"},
+		},
+		"no subject": {
+			rbac: api.RBAC{
+				Roles:        []obsrbac.Role{role},
+				RoleBindings: []obsrbac.RoleBinding{{Name: "reader", Roles: []string{"reader"}}},
+			},
+			expected: []string{"role binding reader has no subject"},
+		},
+		"invalid subjects": {
+			rbac: api.RBAC{
+				Roles: []obsrbac.Role{role},
+				RoleBindings: []obsrbac.RoleBinding{{
+					Name:  "reader",
+					Roles: []string{"reader"},
+					Subjects: []obsrbac.Subject{
+						{Kind: obsrbac.User},
+						{Name: "bob", Kind: "serviceaccount"},
+					},
+				}},
+			},
+			expected: []string{
+				"role binding reader has invalid subject {Name: Kind:user}",
+				"role binding reader has invalid subject {Name:bob Kind:serviceaccount}",
+			},
+		},
+		"several errors": {
+			rbac: api.RBAC{
+				Roles:        []obsrbac.Role{{Name: "reader", Tenants: []string{"b"}}},
+				RoleBindings: []obsrbac.RoleBinding{{Name: "writer", Roles: []string{"writer"}}},
+			},
+			expected: []string{
+				"role reader references unknown tenant b",
+				"role binding writer references unknown role writer",
+				"role binding writer has no subject",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.rbac.Validate(newTenants("a"))
+			if len(tc.expected) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.HasPrefix(err.Error(), "invalid RBAC: ") {
+				t.Errorf("expected error to start with %q, got %v", "invalid RBAC: ", err)
+			}
+			for _, expected := range tc.expected {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected error to contain %q, got %v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRBACBuilder(t *testing.T) {
+	alice := obsrbac.Subject{Name: "alice", Kind: obsrbac.User}
+	bob := obsrbac.Subject{Name: "bob", Kind: obsrbac.User}
+	admins := obsrbac.Subject{Name: "admins", Kind: obsrbac.Group}
+
+	rbac, err := api.NewRBACBuilder(newTenants("a", "b")).
+		WithReadOnly("a", alice).
+		WithReadOnly("a", bob).
+		WithWriteOnly("b", bob).
+		WithAdmin(admins).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	roles := []string{}
+	for _, role := range rbac.Roles {
+		roles = append(roles, role.Name)
+	}
+	if strings.Join(roles, ",") != "a-read-only,b-write-only,admin" {
+		t.Errorf("expected roles a-read-only,b-write-only,admin, got %v", roles)
+	}
+	if len(rbac.RoleBindings) != 3 || len(rbac.RoleBindings[0].Subjects) != 2 {
+		t.Errorf("expected the read-only binding to gather both subjects, got %+v", rbac.RoleBindings)
+	}
+	if admin := rbac.Roles[2]; strings.Join(admin.Tenants, ",") != "a,b" || len(admin.Permissions) != 2 {
+		t.Errorf("expected the admin role to grant read and write on all the tenants, got %+v", admin)
+	}
+
+	_, err = api.NewRBACBuilder(newTenants("a")).WithReadOnly("unknown", alice).Build()
+	if err == nil || !strings.Contains(err.Error(), "role unknown-read-only references unknown tenant unknown") {
+		t.Errorf("expected an unknown tenant error, got %v", err)
+	}
+
+	_, err = api.NewRBACBuilder(newTenants("a")).WithReadOnly("a").Build()
+	if err == nil || !strings.Contains(err.Error(), "role binding a-read-only has no subject") {
+		t.Errorf("expected a missing subject error, got %v", err)
+	}
+}
+
+func TestRBACExplain(t *testing.T) {
+	rbac, err := api.NewRBACBuilder(newTenants("a", "b")).
+		WithReadOnly("a", obsrbac.Subject{Name: "alice", Kind: obsrbac.User}).
+		WithWriteOnly("a", obsrbac.Subject{Name: "collectors", Kind: obsrbac.Group}).
+		WithAdmin(obsrbac.Subject{Name: "admins", Kind: obsrbac.Group}).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := map[string]struct {
+		subject    string
+		groups     []string
+		permission obsrbac.Permission
+		resource   string
+		tenant     string
+		expected   string
+	}{
+		"user allowed": {
+			subject:    "alice",
+			permission: obsrbac.Read,
+			resource:   api.ResourceMetrics,
+			tenant:     "a",
+			expected:   "allowed: role a-read-only bound to user alice by a-read-only",
+		},
+		"group allowed": {
+			subject:    "otel",
+			groups:     []string{"collectors"},
+			permission: obsrbac.Write,
+			resource:   api.ResourceLogs,
+			tenant:     "a",
+			expected:   "allowed: role a-write-only bound to group collectors by a-write-only",
+		},
+		"allowed by several grants": {
+			subject:    "alice",
+			groups:     []string{"admins"},
+			permission: obsrbac.Read,
+			resource:   api.ResourceTraces,
+			tenant:     "a",
+			expected:   "allowed: role a-read-only bound to user alice by a-read-only; role admin bound to group admins by admin",
+		},
+		"wrong permission": {
+			subject:    "alice",
+			permission: obsrbac.Write,
+			resource:   api.ResourceMetrics,
+			tenant:     "a",
+			expected:   "denied: no role binding grants the permission",
+		},
+		"wrong tenant": {
+			subject:    "alice",
+			permission: obsrbac.Read,
+			resource:   api.ResourceMetrics,
+			tenant:     "b",
+			expected:   "denied: no role binding grants the permission",
+		},
+		"wrong resource": {
+			subject:    "alice",
+			permission: obsrbac.Read,
+			resource:   "profiles",
+			tenant:     "a",
+			expected:   "denied: no role binding grants the permission",
+		},
+		"user named like a group": {
+			subject:    "collectors",
+			permission: obsrbac.Write,
+			resource:   api.ResourceMetrics,
+			tenant:     "a",
+			expected:   "denied: no role binding grants the permission",
+		},
+		"unknown subject": {
+			subject:    "mallory",
+			groups:     []string{"users"},
+			permission: obsrbac.Read,
+			resource:   api.ResourceMetrics,
+			tenant:     "a",
+			expected:   "denied: no role binding grants the permission",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			explanation := rbac.Explain(tc.subject, tc.groups, tc.permission, tc.resource, tc.tenant)
+			if explanation.Allowed != strings.HasPrefix(tc.expected, "allowed") {
+				t.Errorf("expected allowed to be %t, got %+v", !explanation.Allowed, explanation)
+			}
+			if got := explanation.String(); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
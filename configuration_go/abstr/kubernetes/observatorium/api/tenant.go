@@ -15,11 +15,20 @@ type Tenants struct {
 }
 
 func (t Tenants) String() string {
-	res, err := yaml.Marshal(t)
+	ret, err := t.StringE()
 	if err != nil {
-		panic(fmt.Sprintf("failed to marshal tenants file: %v", err))
+		panic(err.Error())
 	}
-	return string(res)
+	return ret
+}
+
+// StringE is like String but returns an error instead of panicking when the Tenants cannot be marshalled.
+func (t Tenants) StringE() (string, error) {
+	ret, err := yaml.Marshal(t)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling Tenants to yaml: %w", err)
+	}
+	return string(ret), nil
 }
 
 type Tenant struct {
@@ -95,9 +104,18 @@ type RBAC struct {
 }
 
 func (r RBAC) String() string {
-	res, err := yaml.Marshal(r)
+	ret, err := r.StringE()
+	if err != nil {
+		panic(err.Error())
+	}
+	return ret
+}
+
+// StringE is like String but returns an error instead of panicking when the RBAC cannot be marshalled.
+func (r RBAC) StringE() (string, error) {
+	ret, err := yaml.Marshal(r)
 	if err != nil {
-		panic(fmt.Sprintf("failed to marshal rbac file: %v", err))
+		return "", fmt.Errorf("error marshalling RBAC to yaml: %w", err)
 	}
-	return string(res)
+	return string(ret), nil
 }
@@ -0,0 +1,262 @@
+package observatorium
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/api"
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// NetworkPolicySpec declares the peers of the stack. Traffic between the components is allowed
+// following the endpoints they are wired to, while traffic with other peers must be declared here.
+type NetworkPolicySpec struct {
+	// APIConsumers are allowed to reach the API, e.g. the pods of an ingress controller.
+	APIConsumers []workload.NetworkPeer
+	// APIDependencies are reached by the API to authenticate and authorize the tenants, e.g. their OIDC issuers,
+	// OPA servers or authenticators. They are required by the tenants relying on such services.
+	APIDependencies []workload.NetworkDependency
+	// Monitoring peers are allowed to reach all the components, e.g. Prometheus scraping their metrics.
+	Monitoring []workload.NetworkPeer
+	// ObjectStorage is reached by the components accessing the bucket.
+	// Use an IP block for object storages running outside of the cluster.
+	ObjectStorage []workload.NetworkDependency
+	// KubernetesAPI is reached by the components watching the Kubernetes API, e.g. Gubernator discovering its peers,
+	// and by the API when tenants authenticate with OpenShift.
+	KubernetesAPI []workload.NetworkDependency
+}
+
+// validateTenants checks that the peers reached by the API for the given tenants are declared.
+func (n NetworkPolicySpec) validateTenants(tenants *api.Tenants) error {
+	var errs []error
+	for _, t := range tenants.Tenants {
+		var services []string
+		if t.OIDC != nil {
+			services = append(services, "an OIDC issuer")
+		}
+		if t.OPA != nil && t.OPA.URL != "" {
+			services = append(services, "an OPA server")
+		}
+		if t.Authenticator != nil {
+			services = append(services, fmt.Sprintf("the %s authenticator", t.Authenticator.Type))
+		}
+
+		if len(services) > 0 && len(n.APIDependencies) == 0 {
+			errs = append(errs, fmt.Errorf("tenant %s relies on %s, which must be declared in the API dependencies of the network policies", t.Name, strings.Join(services, " and ")))
+		}
+
+		if t.OpenShift != nil && len(n.KubernetesAPI) == 0 {
+			errs = append(errs, fmt.Errorf("tenant %s authenticates with OpenShift, the Kubernetes API must be declared in the network policies", t.Name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateNetworkPolicies checks that the peers reached by the components, as configured after NewStack, are declared.
+func (s *Stack) validateNetworkPolicies() error {
+	if s.Gubernator != nil && s.GubernatorOptions.PeerDiscoveryType == api.PeerDiscoveryKubernetes && len(s.Spec.NetworkPolicies.KubernetesAPI) == 0 {
+		return errors.New("gubernator discovers its peers through the Kubernetes API, which must be declared in the network policies")
+	}
+
+	return nil
+}
+
+// networkNode is a component of the stack in the graph of the network policies.
+type networkNode struct {
+	pod *workload.PodConfig
+	// endpoints are the addresses the component is wired to, from which its dependencies on the other components are derived.
+	endpoints []string
+	// dependencies and consumers are the peers of the component outside of the stack.
+	dependencies []workload.NetworkDependency
+	consumers    []workload.NetworkPeer
+}
+
+// networkNodes returns the components of the stack with the endpoints they are wired to.
+func (s *Stack) networkNodes(w wiring) []*networkNode {
+	spec := s.Spec.NetworkPolicies
+
+	var ingestorEndpoints []string
+	for _, hashring := range w.hashrings {
+		for _, endpoint := range hashring.Endpoints {
+			ingestorEndpoints = append(ingestorEndpoints, endpoint.Address)
+		}
+	}
+
+	apiDeps := slices.Clone(spec.APIDependencies)
+	if s.Spec.Tenants != nil && slices.ContainsFunc(s.Spec.Tenants.Tenants, func(t api.Tenant) bool { return t.OpenShift != nil }) {
+		apiDeps = append(apiDeps, spec.KubernetesAPI...)
+	}
+
+	q := w.query
+	ret := []*networkNode{
+		{
+			pod: &s.API.PodConfig,
+			endpoints: []string{
+				w.api.MetricsReadEndpoint,
+				w.api.MetricsWriteEndpoint,
+				w.api.MetricsRulesEndpoint,
+				w.api.MetricsAlertmanagerEndpoint,
+				w.api.MiddlewareRateLimiterGrpcAddress,
+			},
+			dependencies: apiDeps,
+			consumers:    spec.APIConsumers,
+		},
+		{pod: &s.QueryFrontend.PodConfig, endpoints: []string{w.queryFrontend.QueryFrontendDownstreamURL}},
+		{pod: &s.Query.PodConfig, endpoints: slices.Concat(q.Endpoint, q.EndpointStrict, q.EndpointGroup, q.EndpointGroupStrict)},
+		{pod: &s.Router.PodConfig, endpoints: ingestorEndpoints},
+		{pod: &s.Ingestor.PodConfig},
+		{pod: &s.Store.PodConfig},
+		{pod: &s.Compactor.PodConfig},
+		{pod: &s.Ruler.PodConfig, endpoints: w.ruler.Query},
+	}
+
+	if s.Gubernator != nil {
+		// Gubernator instances reach each other to forward and sync rate limits, and to discover each other.
+		gubernator := &s.Gubernator.PodConfig
+		node := &networkNode{
+			pod:          gubernator,
+			dependencies: []workload.NetworkDependency{{NetworkPeer: gubernator.NetworkPeer()}},
+			consumers:    []workload.NetworkPeer{gubernator.NetworkPeer()},
+		}
+		if s.GubernatorOptions.PeerDiscoveryType == api.PeerDiscoveryKubernetes {
+			node.dependencies = append(node.dependencies, spec.KubernetesAPI...)
+		}
+		ret = append(ret, node)
+	}
+
+	for _, node := range ret {
+		if slices.ContainsFunc(node.pod.Env, func(e corev1.EnvVar) bool { return e.Name == objstoreEnvName }) {
+			node.dependencies = append(slices.Clone(spec.ObjectStorage), node.dependencies...)
+		}
+	}
+
+	return ret
+}
+
+// networkPolicies returns the NetworkPolicies of the components that don't have one, once they are wired.
+// A component is allowed to reach the ports of the Services targeted by its endpoints, and to be reached by
+// the components targeting its Service. Endpoints outside of the stack are ignored: these peers must be
+// declared in the NetworkPolicySpec.
+func (s *Stack) networkPolicies(objects []runtime.Object, w wiring) ([]runtime.Object, error) {
+	nodes := s.networkNodes(w)
+
+	services := make(map[*networkNode]*corev1.Service, len(nodes))
+	for _, node := range nodes {
+		svc, err := kghelpers.GetObjectE[*corev1.Service](objects, node.pod.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the service of %s: %w", node.pod.Name, err)
+		}
+		services[node] = svc
+	}
+
+	consumers := map[*networkNode][]workload.NetworkPeer{}
+	dependencies := map[*networkNode][]workload.NetworkDependency{}
+	for _, node := range nodes {
+		seenDeps, seenTargets := map[string]bool{}, map[*networkNode]bool{}
+		for _, endpoint := range node.endpoints {
+			for _, target := range nodes {
+				port, ok := targetPort(services[target], endpoint)
+				if !ok {
+					continue
+				}
+
+				key := fmt.Sprintf("%s/%s", target.pod.Name, port.String())
+				if seenDeps[key] {
+					continue
+				}
+				seenDeps[key] = true
+
+				dependencies[node] = append(dependencies[node], workload.NetworkDependency{
+					NetworkPeer: target.pod.NetworkPeer(),
+					Ports:       []intstr.IntOrString{port},
+				})
+				if !seenTargets[target] {
+					seenTargets[target] = true
+					consumers[target] = append(consumers[target], node.pod.NetworkPeer())
+				}
+			}
+		}
+	}
+
+	ret := []runtime.Object{}
+	var errs []error
+	for _, node := range nodes {
+		if node.pod.NetworkPolicy != nil {
+			// The component was given its own policy, which it already generated.
+			continue
+		}
+
+		svc := services[node]
+		np := &workload.NetworkPolicy{
+			MetaConfig: *node.pod.ObjectMeta(),
+			NetworkPolicyConfig: workload.NetworkPolicyConfig{
+				Consumers:    slices.Concat(s.Spec.NetworkPolicies.Monitoring, consumers[node], node.consumers),
+				Dependencies: slices.Concat(node.dependencies, dependencies[node]),
+			},
+			ServicePorts: workload.ServiceProviderFunc(func() []corev1.ServicePort { return svc.Spec.Ports }),
+		}
+		obj, err := np.ObjectE()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ret = append(ret, obj)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// targetPort returns the container port reached by an endpoint, if it targets the given Service or one of its pods.
+// Endpoints are DNS SRV names, URLs or host and port pairs, whose ports are Service port names or numbers.
+func targetPort(svc *corev1.Service, endpoint string) (intstr.IntOrString, bool) {
+	host, port := splitEndpoint(endpoint)
+	svcHost := fmt.Sprintf("%s.%s.svc.cluster.local", svc.Name, svc.Namespace)
+	if host != svcHost && !strings.HasSuffix(host, "."+svcHost) {
+		return intstr.IntOrString{}, false
+	}
+
+	for _, p := range svc.Spec.Ports {
+		if p.Name != port && strconv.Itoa(int(p.Port)) != port && p.TargetPort.String() != port {
+			continue
+		}
+
+		if p.TargetPort.Type == intstr.Int && p.TargetPort.IntVal == 0 {
+			return intstr.FromInt32(p.Port), true
+		}
+		return p.TargetPort, true
+	}
+
+	return intstr.IntOrString{}, false
+}
+
+// splitEndpoint returns the host and port of an endpoint.
+func splitEndpoint(endpoint string) (string, string) {
+	if rest, ok := strings.CutPrefix(endpoint, "dnssrv+_"); ok {
+		port, host, _ := strings.Cut(rest, "._tcp.")
+		return host, port
+	}
+
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		return u.Hostname(), u.Port()
+	}
+
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return endpoint, ""
+	}
+	return host, port
+}
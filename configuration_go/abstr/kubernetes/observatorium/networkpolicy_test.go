@@ -0,0 +1,280 @@
+package observatorium_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/api"
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestStackNetworkPolicies(t *testing.T) {
+	ipPeer := func(cidr string) workload.NetworkDependency {
+		return workload.NetworkDependency{NetworkPeer: workload.NetworkPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}}}
+	}
+
+	stack := observatorium.NewStack(observatorium.StackSpec{
+		Name:        "obs",
+		Namespace:   "ns",
+		Versions:    observatorium.Versions{Thanos: "v0.34.1", API: "main", Gubernator: "v2.2.1"},
+		RateLimiter: true,
+		Tenants: &api.Tenants{Tenants: []api.Tenant{
+			{Name: "oidc", ID: "1", OIDC: &api.TenantOIDC{IssuerURL: "https://sso.example.com"}},
+			{Name: "openshift", ID: "2", OpenShift: &api.TenantOpenShift{ServiceAccount: "api"}},
+		}},
+		NetworkPolicies: &observatorium.NetworkPolicySpec{
+			APIConsumers:    []workload.NetworkPeer{{Namespace: "ingress"}},
+			APIDependencies: []workload.NetworkDependency{ipPeer("10.0.0.2/32")},
+			Monitoring:      []workload.NetworkPeer{{Namespace: "monitoring"}},
+			ObjectStorage:   []workload.NetworkDependency{ipPeer("10.0.0.3/32")},
+			KubernetesAPI:   []workload.NetworkDependency{ipPeer("10.0.0.1/32")},
+		},
+	})
+	// A policy set on a component is kept as is.
+	stack.Compactor.NetworkPolicy = &workload.NetworkPolicyConfig{}
+
+	objects, err := stack.ObjectsE()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := map[string]struct {
+		consumers    []string
+		dependencies []string
+	}{
+		"obs-api": {
+			consumers:    []string{"ns/monitoring", "ns/ingress"},
+			dependencies: []string{"10.0.0.2/32", "10.0.0.1/32", "thanos-query-frontend:10902", "thanos-receive-router:19291", "gubernator:8081"},
+		},
+		"obs-thanos-query-frontend": {
+			consumers:    []string{"ns/monitoring", "observatorium-api"},
+			dependencies: []string{"thanos-query:10902"},
+		},
+		"obs-thanos-query": {
+			consumers:    []string{"ns/monitoring", "thanos-query-frontend", "thanos-rule"},
+			dependencies: []string{"thanos-receive-ingestor:10901", "thanos-store:10901", "thanos-rule:10901"},
+		},
+		"obs-thanos-receive-router": {
+			consumers:    []string{"ns/monitoring", "observatorium-api"},
+			dependencies: []string{"thanos-receive-ingestor:10901"},
+		},
+		"obs-thanos-receive-ingestor": {
+			consumers:    []string{"ns/monitoring", "thanos-query", "thanos-receive-router"},
+			dependencies: []string{"10.0.0.3/32"},
+		},
+		"obs-thanos-store": {
+			consumers:    []string{"ns/monitoring", "thanos-query"},
+			dependencies: []string{"10.0.0.3/32"},
+		},
+		"obs-thanos-compact": {},
+		"obs-thanos-ruler": {
+			consumers:    []string{"ns/monitoring", "thanos-query"},
+			dependencies: []string{"10.0.0.3/32", "thanos-query:10902"},
+		},
+		"obs-gubernator": {
+			consumers:    []string{"ns/monitoring", "observatorium-api", "gubernator"},
+			dependencies: []string{"gubernator:*", "10.0.0.1/32"},
+		},
+	}
+
+	if got := len(networkPolicies(objects)); got != len(testCases) {
+		t.Errorf("expected %d network policies, got %d", len(testCases), got)
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			np := kghelpers.GetObject[*networkingv1.NetworkPolicy](objects, name)
+
+			consumers := []string{}
+			for _, rule := range np.Spec.Ingress {
+				for _, peer := range rule.From {
+					consumers = append(consumers, peerName(peer))
+				}
+			}
+
+			dependencies := []string{}
+			for _, rule := range np.Spec.Egress[1:] { // The first rule allows DNS.
+				ports := []string{}
+				for _, port := range rule.Ports {
+					ports = append(ports, port.Port.String())
+				}
+				if len(ports) == 0 {
+					ports = append(ports, "*")
+				}
+				for _, peer := range rule.To {
+					if peer.IPBlock != nil {
+						dependencies = append(dependencies, peer.IPBlock.CIDR)
+						continue
+					}
+					dependencies = append(dependencies, fmt.Sprintf("%s:%s", peerName(peer), strings.Join(ports, ",")))
+				}
+			}
+
+			if !slices.Equal(consumers, tc.consumers) {
+				t.Errorf("expected consumers %v, got %v", tc.consumers, consumers)
+			}
+			if !slices.Equal(dependencies, tc.dependencies) {
+				t.Errorf("expected dependencies %v, got %v", tc.dependencies, dependencies)
+			}
+		})
+	}
+}
+
+func TestStackNetworkPolicyTenants(t *testing.T) {
+	dependency := []workload.NetworkDependency{{NetworkPeer: workload.NetworkPeer{Namespace: "sso"}}}
+
+	testCases := map[string]struct {
+		tenants  []api.Tenant
+		spec     observatorium.NetworkPolicySpec
+		expected []string
+	}{
+		"tenants without external services": {
+			tenants: []api.Tenant{{Name: "mtls", ID: "1", MTLS: &api.TenantMTLS{CAPath: "/ca.pem"}}},
+		},
+		"undeclared OIDC issuer and OPA server": {
+			tenants: []api.Tenant{{
+				Name: "oidc",
+				ID:   "1",
+				OIDC: &api.TenantOIDC{IssuerURL: "https://sso.example.com"},
+				OPA:  &api.TenantOPA{URL: "http://opa:8181/v1/data/observatorium/allow"},
+			}},
+			expected: []string{"tenant oidc relies on an OIDC issuer and an OPA server, which must be declared in the API dependencies"},
+		},
+		"undeclared authenticator": {
+			tenants:  []api.Tenant{{Name: "auth", ID: "1", Authenticator: &api.TenantAuthenticator{Type: "oidc"}}},
+			expected: []string{"tenant auth relies on the oidc authenticator"},
+		},
+		"embedded OPA policies": {
+			tenants: []api.Tenant{{Name: "opa", ID: "1", OPA: &api.TenantOPA{Query: "data.observatorium.allow", Paths: []string{"/policies"}}}},
+		},
+		"declared API dependencies": {
+			tenants: []api.Tenant{{Name: "oidc", ID: "1", OIDC: &api.TenantOIDC{IssuerURL: "https://sso.example.com"}}},
+			spec:    observatorium.NetworkPolicySpec{APIDependencies: dependency},
+		},
+		"undeclared Kubernetes API": {
+			tenants:  []api.Tenant{{Name: "openshift", ID: "1", OpenShift: &api.TenantOpenShift{ServiceAccount: "api"}}},
+			spec:     observatorium.NetworkPolicySpec{APIDependencies: dependency},
+			expected: []string{"tenant openshift authenticates with OpenShift, the Kubernetes API must be declared"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			spec := tc.spec
+			_, err := observatorium.NewStackE(observatorium.StackSpec{
+				Name:            "obs",
+				Namespace:       "ns",
+				Tenants:         &api.Tenants{Tenants: tc.tenants},
+				NetworkPolicies: &spec,
+			})
+			if len(tc.expected) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, expected := range tc.expected {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected error to contain %q, got %v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+func TestStackNetworkPolicyGubernator(t *testing.T) {
+	kubernetesAPI := []workload.NetworkDependency{{NetworkPeer: workload.NetworkPeer{Namespace: "default"}}}
+
+	testCases := map[string]struct {
+		peerDiscovery api.PeerDiscovery
+		kubernetesAPI []workload.NetworkDependency
+		expected      api.PeerDiscovery
+		expectedErr   string
+	}{
+		"kubernetes discovery by default": {
+			kubernetesAPI: kubernetesAPI,
+			expected:      api.PeerDiscoveryKubernetes,
+		},
+		"undeclared kubernetes API": {
+			expectedErr: "gubernator discovers its peers through the Kubernetes API, which must be declared in the network policies",
+		},
+		"undeclared kubernetes API with kubernetes discovery": {
+			peerDiscovery: api.PeerDiscoveryKubernetes,
+			expectedErr:   "gubernator discovers its peers through the Kubernetes API, which must be declared in the network policies",
+		},
+		"dns discovery": {
+			peerDiscovery: api.PeerDiscoveryDNS,
+			expected:      api.PeerDiscoveryDNS,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			stack, err := observatorium.NewStackE(observatorium.StackSpec{
+				Name:                     "obs",
+				Namespace:                "ns",
+				RateLimiter:              true,
+				RateLimiterPeerDiscovery: tc.peerDiscovery,
+				NetworkPolicies:          &observatorium.NetworkPolicySpec{KubernetesAPI: tc.kubernetesAPI},
+			})
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Errorf("expected error to contain %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := stack.GubernatorOptions.PeerDiscoveryType; got != tc.expected {
+				t.Errorf("expected %s peer discovery, got %s", tc.expected, got)
+			}
+			if _, err := stack.ObjectsE(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+
+	// The discovery can still be changed after NewStack, the objects are then checked against the network policies.
+	stack := observatorium.NewStack(observatorium.StackSpec{
+		Name:                     "obs",
+		Namespace:                "ns",
+		RateLimiter:              true,
+		RateLimiterPeerDiscovery: api.PeerDiscoveryDNS,
+		NetworkPolicies:          &observatorium.NetworkPolicySpec{},
+	})
+	stack.GubernatorOptions.PeerDiscoveryType = api.PeerDiscoveryKubernetes
+	_, err := stack.ObjectsE()
+	if expected := "gubernator discovers its peers through the Kubernetes API, which must be declared in the network policies"; err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("expected error to contain %q, got %v", expected, err)
+	}
+}
+
+func networkPolicies(objects []runtime.Object) []*networkingv1.NetworkPolicy {
+	ret := []*networkingv1.NetworkPolicy{}
+	for _, obj := range objects {
+		if np, ok := obj.(*networkingv1.NetworkPolicy); ok {
+			ret = append(ret, np)
+		}
+	}
+	return ret
+}
+
+// peerName returns the name label of the pods selected by a peer, or the namespace of the peer if it selects all its pods.
+func peerName(peer networkingv1.NetworkPolicyPeer) string {
+	if name, ok := peer.PodSelector.MatchLabels[workload.NameLabel]; ok {
+		return name
+	}
+	return "ns/" + peer.NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"]
+}
@@ -0,0 +1,512 @@
+package observatorium
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/api"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/compactor"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/query"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/queryfrontend"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/receive"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/ruler"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/store"
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	objstoreEnvName   string = "OBJSTORE_CONFIG"
+	objstoreSecretKey string = "thanos.yaml"
+)
+
+// Versions holds the image tags of the stack components.
+type Versions struct {
+	Thanos     string
+	API        string
+	Gubernator string
+}
+
+// ComponentSizing overrides the size of a component.
+// Zero values keep the defaults of the component.
+type ComponentSizing struct {
+	Replicas  int32
+	Resources *corev1.ResourceRequirements
+	// VolumeSize is only used by components deployed as StatefulSets.
+	VolumeSize string
+}
+
+// Sizing holds the sizing of every stack component.
+type Sizing struct {
+	API           ComponentSizing
+	Query         ComponentSizing
+	QueryFrontend ComponentSizing
+	Router        ComponentSizing
+	Ingestor      ComponentSizing
+	Store         ComponentSizing
+	Compactor     ComponentSizing
+	Ruler         ComponentSizing
+	Gubernator    ComponentSizing
+}
+
+// StackSpec is the single specification from which the whole stack is derived.
+type StackSpec struct {
+	// Name is the prefix of all the generated resources, e.g. "observatorium-xyz".
+	Name      string
+	Namespace string
+	Versions  Versions
+	Sizing    Sizing
+
+	Tenants *api.Tenants
+	// RBAC is checked against the Tenants, if both are set.
+	RBAC *api.RBAC
+
+	// ObjectStorage is rendered in a secret shared by all the components accessing the bucket.
+	// If nil, the secret named ObjectStorageSecretName() must already exist.
+	ObjectStorage *objstore.BucketConfig
+
+	// NetworkPolicies enables the NetworkPolicies of the components, if not nil.
+	NetworkPolicies *NetworkPolicySpec
+
+	// RateLimiter deploys Gubernator and wires the rate limiter middleware of the API to it.
+	RateLimiter bool
+	// RateLimiterPeerDiscovery is the way Gubernator discovers its peers, api.PeerDiscoveryKubernetes if empty.
+	// The Kubernetes API must then be declared in the network policies, if enabled.
+	RateLimiterPeerDiscovery api.PeerDiscovery
+}
+
+// ObjectStorageSecretName returns the name of the secret holding the object storage config.
+func (s StackSpec) ObjectStorageSecretName() string {
+	return s.Name + "-objectstore"
+}
+
+// Stack is a whole Observatorium deployment: the API in front of a Thanos metrics backend.
+// The components are exposed so that they can be customized before calling Objects(),
+// but the endpoints between them are derived from the generated Services and must not be set.
+type Stack struct {
+	Spec StackSpec
+
+	APIOptions *api.ObservatoriumAPIOptions
+	API        *api.ObservatoriumAPIDeployment
+
+	QueryOptions *query.QueryOptions
+	Query        *query.QueryDeployment
+
+	QueryFrontendOptions *queryfrontend.QueryFrontendOptions
+	QueryFrontend        *queryfrontend.QueryFrontendDeployment
+
+	RouterOptions *receive.ReceiveOptions
+	Router        *receive.Router
+
+	IngestorOptions *receive.ReceiveOptions
+	Ingestor        *receive.Ingestor
+
+	StoreOptions *store.StoreOptions
+	Store        *store.StoreStatefulSet
+
+	CompactorOptions *compactor.CompactorOptions
+	Compactor        *compactor.CompactorStatefulSet
+
+	RulerOptions *ruler.RulerOptions
+	Ruler        *ruler.RulerStatefulSet
+
+	// Gubernator is nil unless the spec enables the rate limiter.
+	GubernatorOptions *api.GubernatorOptions
+	Gubernator        *api.GubernatorDeployment
+}
+
+// NewStack returns a new Stack with default options, named and sized after the given spec.
+// It panics if the spec is invalid, see NewStackE for a non panicking version.
+func NewStack(spec StackSpec) *Stack {
+	ret, err := NewStackE(spec)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// NewStackE is like NewStack but returns an error reporting all the problems of the spec at once.
+func NewStackE(spec StackSpec) (*Stack, error) {
+	var errs []error
+
+	if spec.Name == "" {
+		errs = append(errs, errors.New("stack name must be specified"))
+	}
+
+	if spec.Namespace == "" {
+		errs = append(errs, errors.New("stack namespace must be specified"))
+	}
+
+	if spec.ObjectStorage != nil {
+		if err := spec.ObjectStorage.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("invalid object storage config: %w", err))
+		}
+	}
+
+	if spec.RBAC != nil && spec.Tenants != nil {
+		if err := spec.RBAC.Validate(spec.Tenants); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if spec.NetworkPolicies != nil && spec.Tenants != nil {
+		if err := spec.NetworkPolicies.validateTenants(spec.Tenants); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	peerDiscovery := spec.RateLimiterPeerDiscovery
+	if peerDiscovery == "" {
+		peerDiscovery = api.PeerDiscoveryKubernetes
+	}
+
+	if spec.RateLimiter && spec.NetworkPolicies != nil && peerDiscovery == api.PeerDiscoveryKubernetes && len(spec.NetworkPolicies.KubernetesAPI) == 0 {
+		errs = append(errs, fmt.Errorf("gubernator discovers its peers through the Kubernetes API, which must be declared in the network policies, or another rate limiter peer discovery must be set, e.g. %s", api.PeerDiscoveryDNS))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid stack %s: %w", spec.Name, err)
+	}
+
+	ret := &Stack{
+		Spec:                 spec,
+		APIOptions:           &api.ObservatoriumAPIOptions{},
+		QueryOptions:         query.NewDefaultOptions(),
+		QueryFrontendOptions: queryfrontend.NewDefaultOptions(),
+		RouterOptions:        receive.NewDefaultRouterOptions(),
+		IngestorOptions:      receive.NewDefaultIngestorOptions(),
+		StoreOptions:         store.NewDefaultOptions(),
+		CompactorOptions:     compactor.NewDefaultOptions(),
+		RulerOptions:         ruler.NewDefaultOptions(),
+	}
+
+	ns := spec.Namespace
+	thanosTag := spec.Versions.Thanos
+
+	ret.API = api.NewObservatoriumAPI(ret.APIOptions, ns, spec.Versions.API)
+	ret.API.Name = spec.Name + "-api"
+	spec.Sizing.API.applyToDeployment(&ret.API.DeploymentWorkload)
+
+	ret.Query = query.NewQuery(ret.QueryOptions, ns, thanosTag)
+	ret.Query.Name = spec.Name + "-thanos-query"
+	spec.Sizing.Query.applyToDeployment(&ret.Query.DeploymentWorkload)
+
+	ret.QueryFrontend = queryfrontend.NewQueryFrontend(ret.QueryFrontendOptions, ns, thanosTag)
+	ret.QueryFrontend.Name = spec.Name + "-thanos-query-frontend"
+	spec.Sizing.QueryFrontend.applyToDeployment(&ret.QueryFrontend.DeploymentWorkload)
+
+	ret.Router = receive.NewRouter(ret.RouterOptions, ns, thanosTag)
+	ret.Router.Name = spec.Name + "-thanos-receive-router"
+	spec.Sizing.Router.applyToDeployment(&ret.Router.DeploymentWorkload)
+
+	ret.Ingestor = receive.NewIngestor(ret.IngestorOptions, ns, thanosTag)
+	ret.Ingestor.Name = spec.Name + "-thanos-receive-ingestor"
+	ret.Ingestor.Env = ret.withObjstoreEnv(ret.Ingestor.Env)
+	spec.Sizing.Ingestor.applyToStatefulSet(&ret.Ingestor.StatefulSetWorkload)
+
+	ret.Store = store.NewStore(ret.StoreOptions, ns, thanosTag)
+	ret.Store.Name = spec.Name + "-thanos-store"
+	ret.Store.Env = ret.withObjstoreEnv(ret.Store.Env)
+	spec.Sizing.Store.applyToStatefulSet(&ret.Store.StatefulSetWorkload)
+
+	ret.Compactor = compactor.NewCompactor(ret.CompactorOptions, ns, thanosTag)
+	ret.Compactor.Name = spec.Name + "-thanos-compact"
+	ret.Compactor.Env = ret.withObjstoreEnv(ret.Compactor.Env)
+	spec.Sizing.Compactor.applyToStatefulSet(&ret.Compactor.StatefulSetWorkload)
+
+	ret.Ruler = ruler.NewRuler(ret.RulerOptions, ns, thanosTag)
+	ret.Ruler.Name = spec.Name + "-thanos-ruler"
+	ret.Ruler.Env = ret.withObjstoreEnv(ret.Ruler.Env)
+	spec.Sizing.Ruler.applyToStatefulSet(&ret.Ruler.StatefulSetWorkload)
+
+	if spec.RateLimiter {
+		ret.GubernatorOptions = api.NewDefaultGubernatorOptions()
+		ret.GubernatorOptions.PeerDiscoveryType = peerDiscovery
+		ret.Gubernator = api.NewGubernatorDeployment(ret.GubernatorOptions, ns, spec.Versions.Gubernator)
+		ret.Gubernator.Name = spec.Name + "-gubernator"
+		spec.Sizing.Gubernator.applyToDeployment(&ret.Gubernator.DeploymentWorkload)
+	}
+
+	return ret, nil
+}
+
+// Objects returns the manifests of the whole stack.
+// Components are generated in dependency order, each one being wired to the Services generated before it.
+// It panics if a component is invalid, see ObjectsE for a non panicking version.
+func (s *Stack) Objects() []runtime.Object {
+	ret, err := s.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// ObjectsE is like Objects but returns an error instead of panicking.
+// The errors of all the components are reported at once. Components depending on
+// an invalid component are not generated, as they can't be wired to it.
+// The options of the stack are left untouched, so that ObjectsE can be called several times.
+func (s *Stack) ObjectsE() ([]runtime.Object, error) {
+	ret := []runtime.Object{}
+	var errs []error
+
+	// collect keeps the objects of a component, or its error.
+	collect := func(objs []runtime.Object, err error) []runtime.Object {
+		if err != nil {
+			errs = append(errs, err)
+			return nil
+		}
+		ret = append(ret, objs...)
+		return objs
+	}
+
+	if s.Spec.NetworkPolicies != nil {
+		if err := s.validateNetworkPolicies(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if s.Spec.ObjectStorage != nil {
+		ret = append(ret, s.objstoreSecret())
+	}
+
+	// The components are wired through copies of their options, leaving the options set by the caller untouched.
+	w := wiring{
+		router:        *s.RouterOptions,
+		ruler:         *s.RulerOptions,
+		query:         *s.QueryOptions,
+		queryFrontend: *s.QueryFrontendOptions,
+		api:           *s.APIOptions,
+	}
+
+	// Storage layer.
+	ingestorObjs := collect(s.Ingestor.ObjectsE())
+	storeObjs := collect(s.Store.ObjectsE())
+	collect(s.Compactor.ObjectsE())
+
+	// Write path.
+	var routerObjs []runtime.Object
+	if ingestorObjs != nil {
+		var err error
+		w.hashrings, err = receive.NewStaticHashRingsConfig(receive.HashringAssignment{
+			Hashring:  "default",
+			Ingestors: []*receive.Ingestor{s.Ingestor},
+		})
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			w.router.ReceiveHashringsFile = receive.NewReceiveHashringConfigFile(&w.hashrings).
+				WithResourceName(s.Spec.Name + "-thanos-receive-hashring")
+			if w.router.ReceiveReplicationFactor == 0 {
+				w.router.ReceiveReplicationFactor = replicationFactor(s.Ingestor.Replicas)
+			}
+			routerObjs = collect(withOptions(s.RouterOptions, &w.router, s.Router.ObjectsE))
+		}
+	}
+
+	// Read path. The ruler is queried by the querier and queries it back. As the querier Service
+	// is generated after the ruler, it is resolved by name through DNS SRV records.
+	w.ruler.Query = []string{fmt.Sprintf("dnssrv+_http._tcp.%s.%s.svc.cluster.local", s.Query.Name, s.Spec.Namespace)}
+	rulerObjs := collect(withOptions(s.RulerOptions, &w.ruler, s.Ruler.ObjectsE))
+
+	var queryObjs []runtime.Object
+	if ingestorObjs != nil && storeObjs != nil && rulerObjs != nil {
+		ingestorEndpoint, ingestorErr := grpcSRVEndpoint(s.Spec.Namespace, ingestorObjs, s.Ingestor.Name)
+		storeEndpoint, storeErr := grpcSRVEndpoint(s.Spec.Namespace, storeObjs, s.Store.Name)
+		rulerEndpoint, rulerErr := grpcSRVEndpoint(s.Spec.Namespace, rulerObjs, s.Ruler.Name)
+		if err := errors.Join(ingestorErr, storeErr, rulerErr); err != nil {
+			errs = append(errs, err)
+		} else {
+			w.query.Endpoint = []string{ingestorEndpoint, storeEndpoint, rulerEndpoint}
+			queryObjs = collect(withOptions(s.QueryOptions, &w.query, s.Query.ObjectsE))
+		}
+	}
+
+	var qfObjs []runtime.Object
+	if queryObjs != nil {
+		downstreamURL, err := serviceURL(s.Spec.Namespace, queryObjs, s.Query.Name, "http")
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			w.queryFrontend.QueryFrontendDownstreamURL = downstreamURL
+			qfObjs = collect(withOptions(s.QueryFrontendOptions, &w.queryFrontend, s.QueryFrontend.ObjectsE))
+		}
+	}
+
+	// API.
+	var gubernatorObjs []runtime.Object
+	if s.Gubernator != nil {
+		gubernatorObjs = collect(s.Gubernator.ObjectsE())
+	}
+
+	if qfObjs != nil && routerObjs != nil && (s.Gubernator == nil || gubernatorObjs != nil) {
+		readEndpoint, readErr := serviceURL(s.Spec.Namespace, qfObjs, s.QueryFrontend.Name, "http")
+		writeEndpoint, writeErr := serviceURL(s.Spec.Namespace, routerObjs, s.Router.Name, "remote-write")
+		var rateLimiterAddress string
+		var rateLimiterErr error
+		if gubernatorObjs != nil {
+			rateLimiterAddress, rateLimiterErr = grpcAddress(s.Spec.Namespace, gubernatorObjs, s.Gubernator.Name)
+		}
+
+		if err := errors.Join(readErr, writeErr, rateLimiterErr); err != nil {
+			errs = append(errs, err)
+		} else {
+			w.api.MetricsReadEndpoint = readEndpoint
+			w.api.MetricsWriteEndpoint = writeEndpoint
+			if s.Spec.RBAC != nil {
+				w.api.RbacConfig = api.NewRbacConfig(s.Spec.RBAC).WithResourceName(s.Spec.Name + "-rbac")
+			}
+			if s.Spec.Tenants != nil {
+				w.api.TenantsConfig = api.NewTenantsConfig(s.Spec.Tenants).WithResourceName(s.Spec.Name + "-tenants")
+			}
+			if gubernatorObjs != nil {
+				w.api.MiddlewareRateLimiterGrpcAddress = rateLimiterAddress
+			}
+			collect(withOptions(s.APIOptions, &w.api, s.API.ObjectsE))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid stack %s: %w", s.Spec.Name, err)
+	}
+
+	if s.Spec.NetworkPolicies != nil {
+		nps, err := s.networkPolicies(ret, w)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stack %s: %w", s.Spec.Name, err)
+		}
+		ret = append(ret, nps...)
+	}
+
+	return ret, nil
+}
+
+// wiring holds the options of the components once wired to the Services they depend on.
+type wiring struct {
+	hashrings     receive.HashRingsConfig
+	router        receive.ReceiveOptions
+	ruler         ruler.RulerOptions
+	query         query.QueryOptions
+	queryFrontend queryfrontend.QueryFrontendOptions
+	api           api.ObservatoriumAPIOptions
+}
+
+// withOptions generates the objects of a component with its wired options. The component reads its options
+// through the pointer it was created with, whose value is swapped for the wired copy and restored afterwards.
+func withOptions[T any](opts *T, wired *T, objectsE func() ([]runtime.Object, error)) ([]runtime.Object, error) {
+	saved := *opts
+	defer func() { *opts = saved }()
+
+	*opts = *wired
+	return objectsE()
+}
+
+func (s *Stack) objstoreSecret() *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: workload.SecretMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.Spec.ObjectStorageSecretName(),
+			Namespace: s.Spec.Namespace,
+			Labels: map[string]string{
+				workload.InstanceLabel: "observatorium",
+				workload.PartOfLabel:   "observatorium",
+			},
+		},
+		StringData: map[string]string{
+			objstoreSecretKey: s.Spec.ObjectStorage.String(),
+		},
+	}
+}
+
+// withObjstoreEnv points the object storage env var of a component to the stack secret.
+func (s *Stack) withObjstoreEnv(env []corev1.EnvVar) []corev1.EnvVar {
+	ret := slices.DeleteFunc(slices.Clone(env), func(e corev1.EnvVar) bool {
+		return e.Name == objstoreEnvName
+	})
+	return append(ret, kghelpers.NewEnvFromSecret(objstoreEnvName, s.Spec.ObjectStorageSecretName(), objstoreSecretKey))
+}
+
+// replicationFactor returns the highest odd replication factor, up to 3, supported by the number of ingestors.
+func replicationFactor(ingestors int32) int {
+	if ingestors >= 3 {
+		return 3
+	}
+	return 1
+}
+
+func (c ComponentSizing) applyToDeployment(w *workload.DeploymentWorkload) {
+	if c.Replicas != 0 {
+		w.Replicas = c.Replicas
+	}
+
+	if c.Resources != nil {
+		w.ContainerResources = *c.Resources
+	}
+}
+
+func (c ComponentSizing) applyToStatefulSet(w *workload.StatefulSetWorkload) {
+	if c.Replicas != 0 {
+		w.Replicas = c.Replicas
+	}
+
+	if c.Resources != nil {
+		w.ContainerResources = *c.Resources
+	}
+
+	if c.VolumeSize != "" {
+		w.VolumeSize = c.VolumeSize
+	}
+}
+
+// serviceURL returns the HTTP URL of the named port of a generated Service.
+func serviceURL(namespace string, objects []runtime.Object, name, portName string) (string, error) {
+	svc, port, err := servicePort(objects, name, portName)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("http://%s.%s.svc.cluster.local:%d", svc.Name, namespace, port), nil
+}
+
+// grpcAddress returns the host and port of the gRPC server behind a generated Service.
+func grpcAddress(namespace string, objects []runtime.Object, name string) (string, error) {
+	svc, port, err := servicePort(objects, name, "grpc")
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s.%s.svc.cluster.local:%d", svc.Name, namespace, port), nil
+}
+
+// grpcSRVEndpoint returns the DNS SRV endpoint resolving to all the gRPC servers behind a generated Service.
+func grpcSRVEndpoint(namespace string, objects []runtime.Object, name string) (string, error) {
+	// The port is not part of the endpoint, but the Service must expose gRPC.
+	svc, _, err := servicePort(objects, name, "grpc")
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("dnssrv+_grpc._tcp.%s.%s.svc.cluster.local", svc.Name, namespace), nil
+}
+
+// servicePort returns the generated Service of a component and the number of its named port.
+func servicePort(objects []runtime.Object, name, portName string) (*corev1.Service, int32, error) {
+	svc, err := kghelpers.GetObjectE[*corev1.Service](objects, name)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, port := range svc.Spec.Ports {
+		if port.Name == portName {
+			return svc, port.Port, nil
+		}
+	}
+
+	return nil, 0, fmt.Errorf("service %s has no port named %s", svc.Name, portName)
+}
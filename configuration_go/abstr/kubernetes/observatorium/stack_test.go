@@ -0,0 +1,135 @@
+package observatorium_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/receive"
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestStackWiring(t *testing.T) {
+	stack := observatorium.NewStack(observatorium.StackSpec{
+		Name:        "obs",
+		Namespace:   "ns",
+		Versions:    observatorium.Versions{Thanos: "v0.34.1", API: "main", Gubernator: "v2.2.1"},
+		Sizing:      observatorium.Sizing{Ingestor: observatorium.ComponentSizing{Replicas: 3}},
+		RateLimiter: true,
+	})
+
+	objects, err := stack.ObjectsE()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := map[string]struct {
+		workload string
+		args     []string
+	}{
+		"query": {
+			workload: "obs-thanos-query",
+			args: []string{
+				"--endpoint=dnssrv+_grpc._tcp.obs-thanos-receive-ingestor.ns.svc.cluster.local",
+				"--endpoint=dnssrv+_grpc._tcp.obs-thanos-store.ns.svc.cluster.local",
+				"--endpoint=dnssrv+_grpc._tcp.obs-thanos-ruler.ns.svc.cluster.local",
+			},
+		},
+		"query frontend": {
+			workload: "obs-thanos-query-frontend",
+			args:     []string{"--query-frontend.downstream-url=http://obs-thanos-query.ns.svc.cluster.local:10902"},
+		},
+		"ruler": {
+			workload: "obs-thanos-ruler",
+			args:     []string{"--query=dnssrv+_http._tcp.obs-thanos-query.ns.svc.cluster.local"},
+		},
+		"router": {
+			workload: "obs-thanos-receive-router",
+			args:     []string{"--receive.hashrings-file=/etc/thanos/hashring/hashrings.json", "--receive.replication-factor=3"},
+		},
+		"api": {
+			workload: "obs-api",
+			args: []string{
+				"--metrics.read.endpoint=http://obs-thanos-query-frontend.ns.svc.cluster.local:10902",
+				"--metrics.write.endpoint=http://obs-thanos-receive-router.ns.svc.cluster.local:19291",
+				"--middleware.rate-limiter.grpc-address=obs-gubernator.ns.svc.cluster.local:8081",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			args := containerArgs(t, objects, tc.workload)
+			for _, arg := range tc.args {
+				if !slices.Contains(args, arg) {
+					t.Errorf("expected arg %s, got %v", arg, args)
+				}
+			}
+		})
+	}
+
+	cm := kghelpers.GetObject[*corev1.ConfigMap](objects, "obs-thanos-receive-hashring")
+	hashrings := receive.HashRingsConfig{}
+	if err := json.Unmarshal([]byte(cm.Data["hashrings.json"]), &hashrings); err != nil {
+		t.Fatalf("failed to parse the hashrings: %v", err)
+	}
+	if len(hashrings) != 1 || hashrings[0].Hashring != "default" {
+		t.Fatalf("expected a single default hashring, got %+v", hashrings)
+	}
+	endpoints := []string{}
+	for _, endpoint := range hashrings[0].Endpoints {
+		endpoints = append(endpoints, endpoint.Address)
+	}
+	expected := []string{}
+	for i := range 3 {
+		expected = append(expected, fmt.Sprintf("obs-thanos-receive-ingestor-%d.obs-thanos-receive-ingestor.ns.svc.cluster.local:10901", i))
+	}
+	if !slices.Equal(endpoints, expected) {
+		t.Errorf("expected hashring endpoints %v, got %v", expected, endpoints)
+	}
+}
+
+func TestStackObjectsIdempotent(t *testing.T) {
+	stack := observatorium.NewStack(observatorium.StackSpec{
+		Name:      "obs",
+		Namespace: "ns",
+		Versions:  observatorium.Versions{Thanos: "v0.34.1", API: "main"},
+	})
+	stack.QueryOptions.Endpoint = []string{"dnssrv+_grpc._tcp.sidecar.ns.svc.cluster.local"}
+	query, queryFrontend, router, ruler, api := *stack.QueryOptions, *stack.QueryFrontendOptions, *stack.RouterOptions, *stack.RulerOptions, *stack.APIOptions
+
+	first, err := stack.ObjectsE()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := stack.ObjectsE()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(first, second) {
+		t.Error("expected the objects to be identical when generated twice")
+	}
+
+	if !reflect.DeepEqual(*stack.QueryOptions, query) || !reflect.DeepEqual(*stack.QueryFrontendOptions, queryFrontend) ||
+		!reflect.DeepEqual(*stack.RouterOptions, router) || !reflect.DeepEqual(*stack.RulerOptions, ruler) ||
+		!reflect.DeepEqual(*stack.APIOptions, api) {
+		t.Error("expected the options of the caller to be left untouched")
+	}
+}
+
+func containerArgs(t *testing.T, objects []runtime.Object, name string) []string {
+	t.Helper()
+
+	if sts, err := kghelpers.GetObjectE[*appsv1.StatefulSet](objects, name); err == nil {
+		return sts.Spec.Template.Spec.Containers[0].Args
+	}
+
+	return kghelpers.GetObject[*appsv1.Deployment](objects, name).Spec.Template.Spec.Containers[0].Args
+}
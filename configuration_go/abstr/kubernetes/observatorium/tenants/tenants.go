@@ -0,0 +1,268 @@
+// Package tenants holds the single description of the tenants of an Observatorium stack.
+// The registry projects it into the configs of the components: the API tenants and RBAC files,
+// the receive hashrings and limits, the rules syncer tenants and the up probes.
+package tenants
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	obsrbac "github.com/observatorium/api/rbac"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/api"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/up"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/receive"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/ruler"
+)
+
+// DefaultHashring is the hashring of the tenants that don't specify one.
+const DefaultHashring string = "default"
+
+// Tenant describes a tenant and its settings in all the components.
+type Tenant struct {
+	// Name identifies the tenant in the API, e.g. in its URLs and RBAC roles.
+	Name string
+	// ID identifies the tenant in the backends, e.g. in the tenant header of the receive.
+	ID string
+
+	// Authentication and rate limiting settings of the API.
+	OIDC          *api.TenantOIDC
+	OpenShift     *api.TenantOpenShift
+	Authenticator *api.TenantAuthenticator
+	MTLS          *api.TenantMTLS
+	OPA           *api.TenantOPA
+	RateLimits    []api.TenantRateLimits
+
+	// Access grants permissions on the tenant to subjects, in the API RBAC.
+	Access []Access
+
+	// Hashring is the receive hashring the tenant is written to, DefaultHashring if empty.
+	Hashring string
+	// LimitsClass is the class of the receive limits of the tenant. The default limits apply if empty.
+	LimitsClass receive.TenantClass
+
+	// SyncRules enables the sync of the rules of the tenant to the ruler.
+	SyncRules bool
+	// Probe enables an up probe of the tenant.
+	Probe bool
+}
+
+// Access grants permissions on resources of a tenant to subjects.
+type Access struct {
+	// Name suffixes the name of the role and role binding of the access, e.g. "read-write".
+	Name        string
+	Permissions []obsrbac.Permission
+	// Resources are among metrics, logs and traces.
+	Resources []string
+	Subjects  []obsrbac.Subject
+}
+
+// Registry holds the tenants of a stack.
+type Registry struct {
+	tenants []Tenant
+}
+
+// NewRegistry returns a registry holding the given tenants.
+// It returns an error if a tenant is invalid, see Add.
+func NewRegistry(tenants ...Tenant) (*Registry, error) {
+	ret := &Registry{}
+	if err := ret.Add(tenants...); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// Add adds tenants to the registry. Names and IDs must be set and unique, and limits classes known.
+// No tenant is added if one is invalid.
+func (r *Registry) Add(tenants ...Tenant) error {
+	var errs []error
+	names := map[string]bool{}
+	ids := map[string]bool{}
+	for _, t := range r.tenants {
+		names[t.Name] = true
+		ids[t.ID] = true
+	}
+
+	for _, t := range tenants {
+		if t.Name == "" || t.ID == "" {
+			errs = append(errs, fmt.Errorf("name and ID must be specified for tenant %q", t.Name))
+		}
+
+		if names[t.Name] {
+			errs = append(errs, fmt.Errorf("tenant name %s is already registered", t.Name))
+		}
+		names[t.Name] = true
+
+		if ids[t.ID] {
+			errs = append(errs, fmt.Errorf("tenant ID %s is already registered", t.ID))
+		}
+		ids[t.ID] = true
+
+		if _, ok := receive.TenantClassLimits[t.LimitsClass]; t.LimitsClass != "" && !ok {
+			errs = append(errs, fmt.Errorf("unknown limits class %q for tenant %s", t.LimitsClass, t.Name))
+		}
+
+		for _, a := range t.Access {
+			if a.Name == "" {
+				errs = append(errs, fmt.Errorf("access of tenant %s must have a name", t.Name))
+			}
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid tenants: %w", err)
+	}
+
+	r.tenants = append(r.tenants, tenants...)
+	return nil
+}
+
+// Tenants returns the registered tenants, in registration order.
+func (r *Registry) Tenants() []Tenant {
+	return slices.Clone(r.tenants)
+}
+
+// APITenants returns the tenants config of the API.
+func (r *Registry) APITenants() *api.Tenants {
+	ret := &api.Tenants{Tenants: []api.Tenant{}}
+	for _, t := range r.tenants {
+		ret.Tenants = append(ret.Tenants, api.Tenant{
+			Name:          t.Name,
+			ID:            t.ID,
+			OIDC:          t.OIDC,
+			OpenShift:     t.OpenShift,
+			Authenticator: t.Authenticator,
+			MTLS:          t.MTLS,
+			OPA:           t.OPA,
+			RateLimits:    t.RateLimits,
+		})
+	}
+
+	return ret
+}
+
+// RBAC returns the RBAC config of the API, with a role and a role binding named <tenant>-<access> per access.
+// It returns an error if the config is inconsistent, see api.RBAC.Validate.
+func (r *Registry) RBAC() (*api.RBAC, error) {
+	b := api.NewRBACBuilder(r.APITenants())
+	for _, t := range r.tenants {
+		for _, a := range t.Access {
+			name := fmt.Sprintf("%s-%s", t.Name, a.Name)
+			b.WithRole(obsrbac.Role{
+				Name:        name,
+				Resources:   a.Resources,
+				Tenants:     []string{t.Name},
+				Permissions: a.Permissions,
+			}).WithRoleBinding(obsrbac.RoleBinding{
+				Name:     name,
+				Subjects: a.Subjects,
+				Roles:    []string{name},
+			})
+		}
+	}
+
+	return b.Build()
+}
+
+// HashRingsConfig returns the receive hashrings, made of the given ingestors keyed by hashring name.
+// Tenants are listed by ID in their hashring, except for the ones of DefaultHashring: it has no tenant
+// list and is the last one, so that it receives the data of all the tenants not listed in another hashring.
+// Other hashrings are sorted by name. It returns an error if a hashring has no ingestor or no tenant.
+func (r *Registry) HashRingsConfig(ingestors map[string][]*receive.Ingestor) (receive.HashRingsConfig, error) {
+	var errs []error
+	tenants := map[string][]string{}
+	for _, t := range r.tenants {
+		hashring := t.Hashring
+		if hashring == "" {
+			hashring = DefaultHashring
+		}
+
+		if _, ok := ingestors[hashring]; !ok {
+			errs = append(errs, fmt.Errorf("tenant %s is assigned to hashring %s which has no ingestor", t.Name, hashring))
+		}
+
+		tenants[hashring] = append(tenants[hashring], t.ID)
+	}
+
+	names := make([]string, 0, len(ingestors))
+	for name := range ingestors {
+		if name == DefaultHashring {
+			continue
+		}
+
+		if len(tenants[name]) == 0 {
+			errs = append(errs, fmt.Errorf("hashring %s has no tenant", name))
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid tenants hashrings: %w", err)
+	}
+
+	assignments := make([]receive.HashringAssignment, 0, len(ingestors))
+	for _, name := range names {
+		assignments = append(assignments, receive.HashringAssignment{
+			Hashring:  name,
+			Tenants:   tenants[name],
+			Ingestors: ingestors[name],
+		})
+	}
+
+	if defaultIngestors, ok := ingestors[DefaultHashring]; ok {
+		assignments = append(assignments, receive.HashringAssignment{
+			Hashring:  DefaultHashring,
+			Ingestors: defaultIngestors,
+		})
+	}
+
+	return receive.NewStaticHashRingsConfig(assignments...)
+}
+
+// ReceiveLimits returns the given receive limits, with the limits of the tenants set to the preset of their class.
+func (r *Registry) ReceiveLimits(limits receive.ReceiveLimitsConfig) (receive.ReceiveLimitsConfig, error) {
+	ids := make(map[string]string, len(r.tenants))
+	classes := map[string]receive.TenantClass{}
+	for _, t := range r.tenants {
+		ids[t.Name] = t.ID
+		if t.LimitsClass != "" {
+			classes[t.Name] = t.LimitsClass
+		}
+	}
+
+	return limits.WithTenants(ids, classes)
+}
+
+// RulesSyncerTenants returns the tenants config of the rules syncer, listing the tenants syncing their rules.
+func (r *Registry) RulesSyncerTenants() *ruler.TenantsConfig {
+	ret := &ruler.TenantsConfig{Tenants: []ruler.TenantConfig{}}
+	for _, t := range r.tenants {
+		if t.SyncRules {
+			ret.Tenants = append(ret.Tenants, ruler.TenantConfig{ID: t.ID})
+		}
+	}
+
+	return ret
+}
+
+// UpOptions returns the options of the up probes of the tenants, keyed by tenant name.
+// Each one is a copy of the given options, probing the tenant: its Tenant is set to the tenant ID.
+func (r *Registry) UpOptions(opts up.UpOptions) map[string]*up.UpOptions {
+	ret := map[string]*up.UpOptions{}
+	for _, t := range r.tenants {
+		if !t.Probe {
+			continue
+		}
+
+		probe := opts
+		probe.Labels = slices.Clone(opts.Labels)
+		probe.Logs = slices.Clone(opts.Logs)
+		probe.Tenant = t.ID
+		ret[t.Name] = &probe
+	}
+
+	return ret
+}
@@ -0,0 +1,199 @@
+package tenants_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/tenants"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/up"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/receive"
+)
+
+func TestRegistryAdd(t *testing.T) {
+	registry, err := tenants.NewRegistry(tenants.Tenant{Name: "a", ID: "1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := map[string]struct {
+		tenants  []tenants.Tenant
+		expected []string
+	}{
+		"valid": {
+			tenants: []tenants.Tenant{{Name: "b", ID: "2", LimitsClass: receive.TenantClassSmall}},
+		},
+		"missing name and ID": {
+			tenants:  []tenants.Tenant{{Name: "b"}, {ID: "3"}},
+			expected: []string{`name and ID must be specified for tenant "b"`, `name and ID must be specified for tenant ""`},
+		},
+		"registered name and ID": {
+			tenants:  []tenants.Tenant{{Name: "a", ID: "2"}, {Name: "b", ID: "1"}},
+			expected: []string{"tenant name a is already registered", "tenant ID 1 is already registered"},
+		},
+		"duplicates among the added tenants": {
+			tenants:  []tenants.Tenant{{Name: "b", ID: "2"}, {Name: "b", ID: "2"}},
+			expected: []string{"tenant name b is already registered", "tenant ID 2 is already registered"},
+		},
+		"unknown limits class": {
+			tenants:  []tenants.Tenant{{Name: "b", ID: "2", LimitsClass: "huge"}},
+			expected: []string{`unknown limits class "huge" for tenant b`},
+		},
+		"unnamed access": {
+			tenants:  []tenants.Tenant{{Name: "b", ID: "2", Access: []tenants.Access{{Resources: []string{"metrics"}}}}},
+			expected: []string{"access of tenant b must have a name"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r, err := tenants.NewRegistry(registry.Tenants()...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = r.Add(tc.tenants...)
+			if len(tc.expected) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(r.Tenants()) != 1+len(tc.tenants) {
+					t.Errorf("expected the tenants to be added, got %v", r.Tenants())
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, expected := range tc.expected {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected error to contain %q, got %v", expected, err)
+				}
+			}
+
+			if len(r.Tenants()) != 1 {
+				t.Errorf("expected no tenant to be added, got %v", r.Tenants())
+			}
+		})
+	}
+}
+
+func TestRegistryHashRingsConfig(t *testing.T) {
+	newIngestor := func(name string) *receive.Ingestor {
+		ret := receive.NewIngestor(nil, "ns", "v0.34.1")
+		ret.Name = name
+		return ret
+	}
+
+	registry, err := tenants.NewRegistry(
+		tenants.Tenant{Name: "a", ID: "1"},
+		tenants.Tenant{Name: "b", ID: "2", Hashring: "zeta"},
+		tenants.Tenant{Name: "c", ID: "3", Hashring: "alpha"},
+		tenants.Tenant{Name: "d", ID: "4", Hashring: "zeta"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		hashrings, err := registry.HashRingsConfig(map[string][]*receive.Ingestor{
+			tenants.DefaultHashring: {newIngestor("default")},
+			"zeta":                  {newIngestor("zeta")},
+			"alpha":                 {newIngestor("alpha")},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := []struct {
+			name    string
+			tenants []string
+		}{
+			{"alpha", []string{"3"}},
+			{"zeta", []string{"2", "4"}},
+			{tenants.DefaultHashring, nil},
+		}
+		if len(hashrings) != len(expected) {
+			t.Fatalf("expected %d hashrings, got %v", len(expected), hashrings)
+		}
+		for i, e := range expected {
+			if hashrings[i].Hashring != e.name || !slices.Equal(hashrings[i].Tenants, e.tenants) {
+				t.Errorf("expected hashring %d to be %s with tenants %v, got %s with %v", i, e.name, e.tenants, hashrings[i].Hashring, hashrings[i].Tenants)
+			}
+		}
+	})
+
+	t.Run("missing ingestors and tenants", func(t *testing.T) {
+		_, err := registry.HashRingsConfig(map[string][]*receive.Ingestor{
+			"zeta":  {newIngestor("zeta")},
+			"alpha": {newIngestor("alpha")},
+			"empty": {newIngestor("empty")},
+		})
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+
+		for _, expected := range []string{
+			"tenant a is assigned to hashring default which has no ingestor",
+			"hashring empty has no tenant",
+		} {
+			if !strings.Contains(err.Error(), expected) {
+				t.Errorf("expected error to contain %q, got %v", expected, err)
+			}
+		}
+	})
+}
+
+func TestRegistryProjections(t *testing.T) {
+	registry, err := tenants.NewRegistry(
+		tenants.Tenant{Name: "a", ID: "1", SyncRules: true, Probe: true, LimitsClass: receive.TenantClassMedium},
+		tenants.Tenant{Name: "b", ID: "2"},
+		tenants.Tenant{Name: "c", ID: "3", SyncRules: true, Probe: true},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("rules syncer tenants", func(t *testing.T) {
+		syncer := registry.RulesSyncerTenants()
+		ids := []string{}
+		for _, tenant := range syncer.Tenants {
+			ids = append(ids, tenant.ID)
+		}
+		if !slices.Equal(ids, []string{"1", "3"}) {
+			t.Errorf("expected the tenants syncing their rules, got %v", ids)
+		}
+	})
+
+	t.Run("receive limits", func(t *testing.T) {
+		limits, err := registry.ReceiveLimits(receive.ReceiveLimitsConfig{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(limits.WriteLimits.TenantsLimits) != 1 || limits.WriteLimits.TenantsLimits["1"] != receive.TenantClassLimits[receive.TenantClassMedium] {
+			t.Errorf("expected the limits of the medium class keyed by tenant ID, got %v", limits.WriteLimits.TenantsLimits)
+		}
+	})
+
+	t.Run("up options", func(t *testing.T) {
+		opts := up.UpOptions{Labels: []string{"probe=up"}, Logs: []string{"log"}, Tenant: "base"}
+		probes := registry.UpOptions(opts)
+		if len(probes) != 2 || probes["a"] == nil || probes["c"] == nil {
+			t.Fatalf("expected the probes of tenants a and c, got %v", probes)
+		}
+
+		if probes["a"].Tenant != "1" || probes["c"].Tenant != "3" {
+			t.Errorf("expected the probes to target the tenant IDs, got %s and %s", probes["a"].Tenant, probes["c"].Tenant)
+		}
+
+		probes["a"].Labels[0] = "changed"
+		probes["a"].Logs[0] = "changed"
+		if opts.Labels[0] != "probe=up" || opts.Logs[0] != "log" || probes["c"].Labels[0] != "probe=up" {
+			t.Errorf("expected the probes not to share their labels and logs")
+		}
+		if opts.Tenant != "base" {
+			t.Errorf("expected the given options to be unchanged, got tenant %s", opts.Tenant)
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package up
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"time"
@@ -31,11 +32,20 @@ type QueriesFile struct {
 }
 
 func (q QueriesFile) String() string {
-	res, err := yaml.Marshal(q)
+	ret, err := q.StringE()
 	if err != nil {
-		panic(fmt.Sprintf("failed to marshal queries file: %v", err))
+		panic(err.Error())
 	}
-	return string(res)
+	return ret
+}
+
+// StringE is like String but returns an error instead of panicking when the QueriesFile cannot be marshalled.
+func (q QueriesFile) StringE() (string, error) {
+	ret, err := yaml.Marshal(q)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling QueriesFile to yaml: %w", err)
+	}
+	return string(ret), nil
 }
 
 // NewQueriesFileOption creates a new queries file option with the given value.
@@ -125,15 +135,50 @@ func NewUp(opts *UpOptions, namespace, imageTag string) *UpDeployment {
 	}
 }
 
+// Objects returns the manifests for up.
+// It panics if the configuration is invalid, see ObjectsE.
 func (u *UpDeployment) Objects() []runtime.Object {
-	container := u.makeContainer()
-	return u.DeploymentWorkload.Objects(container)
+	ret, err := u.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
 }
 
-func (u *UpDeployment) makeContainer() *workload.Container {
+// ObjectsE is like Objects but returns an error instead of panicking when neither a read nor a write endpoint
+// is probed, or when a probe does not target the listen port.
+func (u *UpDeployment) ObjectsE() ([]runtime.Object, error) {
+	container, err := u.makeContainer()
+	if err != nil {
+		return nil, fmt.Errorf("invalid up %s: %w", u.Name, err)
+	}
+
+	ret, err := u.DeploymentWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid up %s: %w", u.Name, err)
+	}
+
+	return ret, nil
+}
+
+func (u *UpDeployment) makeContainer() (*workload.Container, error) {
+	serverPort := kghelpers.GetPortOrDefault(8080, u.options.Listen)
+	errs := []error{
+		kghelpers.CheckProbePortE(serverPort, u.LivenessProbe),
+		kghelpers.CheckProbePortE(serverPort, u.ReadinessProbe),
+	}
+
+	if u.options.EndpointRead == "" && u.options.EndpointWrite == "" {
+		errs = append(errs, errors.New("neither read nor write endpoint is specified"))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
 	ret := u.ToContainer()
 	ret.Name = "observatorium-up"
-	serverPort := kghelpers.GetPortOrDefault(8080, u.options.Listen)
 	ret.Ports = []corev1.ContainerPort{
 		{
 			Name:          "http",
@@ -150,12 +195,15 @@ func (u *UpDeployment) makeContainer() *workload.Container {
 		},
 	}
 	if u.options.QueriesFile != nil {
-		u.options.QueriesFile.Update(ret)
+		errs = append(errs, containeropts.UpdateContainerE(u.options.QueriesFile, ret))
 	}
 	if u.options.TokenFile != nil {
-		u.options.TokenFile.Update(ret)
+		errs = append(errs, containeropts.UpdateContainerE(u.options.TokenFile, ret))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
 	}
 
 	ret.Args = cmdopt.GetOpts(u.options)
-	return ret
+	return ret, nil
 }
@@ -1,6 +1,8 @@
 package avalanche
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/observatorium/observatorium/configuration_go/kubegen/cmdopt"
@@ -39,6 +41,10 @@ type AvalancheDeployment struct {
 }
 
 func NewAvalanche(opts *AvalancheOptions, namespace, imageTag string) *AvalancheDeployment {
+	if opts == nil {
+		opts = &AvalancheOptions{}
+	}
+
 	commonLabels := map[string]string{
 		workload.NameLabel:      "avalanche",
 		workload.InstanceLabel:  "observatorium",
@@ -71,19 +77,48 @@ func NewAvalanche(opts *AvalancheOptions, namespace, imageTag string) *Avalanche
 	}
 }
 
+// Objects returns the manifests for avalanche.
+// It panics if the configuration is invalid, see ObjectsE.
 func (a *AvalancheDeployment) Objects() []runtime.Object {
-	container := a.makeContainer()
-	return a.DeploymentWorkload.Objects(container)
+	ret, err := a.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
 }
 
-func (a *AvalancheDeployment) makeContainer() *workload.Container {
-	ret := a.ToContainer()
-	ret.Name = "avalanche"
+// ObjectsE is like Objects but returns an error instead of panicking when a probe does not target the metrics port.
+func (a *AvalancheDeployment) ObjectsE() ([]runtime.Object, error) {
+	container, err := a.makeContainer()
+	if err != nil {
+		return nil, fmt.Errorf("invalid avalanche %s: %w", a.Name, err)
+	}
+
+	ret, err := a.DeploymentWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid avalanche %s: %w", a.Name, err)
+	}
+
+	return ret, nil
+}
+
+func (a *AvalancheDeployment) makeContainer() (*workload.Container, error) {
 	serverPort := 9001
 	if a.options.Port != 0 {
 		serverPort = a.options.Port
 	}
 
+	if err := errors.Join(
+		kghelpers.CheckProbePortE(serverPort, a.LivenessProbe),
+		kghelpers.CheckProbePortE(serverPort, a.ReadinessProbe),
+	); err != nil {
+		return nil, err
+	}
+
+	ret := a.ToContainer()
+	ret.Name = "avalanche"
+
 	ret.Ports = []corev1.ContainerPort{
 		{
 			Name:          "http",
@@ -101,5 +136,5 @@ func (a *AvalancheDeployment) makeContainer() *workload.Container {
 	}
 
 	ret.Args = cmdopt.GetOpts(a.options)
-	return ret
+	return ret, nil
 }
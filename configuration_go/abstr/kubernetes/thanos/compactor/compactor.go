@@ -1,6 +1,8 @@
 package compactor
 
 import (
+	"errors"
+	"fmt"
 	"net"
 	"time"
 
@@ -22,20 +24,21 @@ const (
 // CompactorOptions represents the options/flags for the compactor.
 // See https://thanos.io/tip/components/compact.md/#flags for details.
 type CompactorOptions struct {
-	BlockFilesConcurrency              int                             `opt:"block-files-concurrency"`
+	BlockFilesConcurrency              int                             `opt:"block-files-concurrency" since:"v0.28.0"`
 	BlockMetaFetchConcurrency          int                             `opt:"block-meta-fetch-concurrency"`
 	BlockViewerGlobalSyncBlockInterval time.Duration                   `opt:"block-viewer.global.sync-block-interval"`
 	BlockViewerGlobalSyncBlockTimeout  time.Duration                   `opt:"block-viewer.global.sync-block-timeout"`
 	BucketWebLabel                     string                          `opt:"bucket-web-label"`
-	CompactBlocksFetchConcurrency      int                             `opt:"compact.blocks-fetch-concurrency"`
+	CompactBlocksFetchConcurrency      int                             `opt:"compact.blocks-fetch-concurrency" since:"v0.28.0"`
 	CompactCleanupInterval             time.Duration                   `opt:"compact.cleanup-interval"`
 	CompactConcurrency                 int                             `opt:"compact.concurrency"`
 	CompactProgressInterval            time.Duration                   `opt:"compact.progress-interval"`
 	ConsistencyDelay                   time.Duration                   `opt:"consistency-delay"`
 	DataDir                            string                          `opt:"data-dir"`
 	DeduplicationFunc                  string                          `opt:"deduplication.func"`
-	DeduplicationReplicaLabel          string                          `opt:"deduplication.replica-label"`
+	DeduplicationReplicaLabel          []string                        `opt:"deduplication.replica-label"`
 	DeleteDelay                        time.Duration                   `opt:"delete-delay"`
+	DisableAdminOperations             bool                            `opt:"disable-admin-operations,noval" since:"v0.33.0"`
 	DownsampleConcurrency              int                             `opt:"downsample.concurrency"`
 	DownsamplingDisable                bool                            `opt:"downsampling.disable"`
 	HashFunc                           string                          `opt:"hash-func"`
@@ -58,7 +61,7 @@ type CompactorOptions struct {
 	Version                            bool                            `opt:"version,noval"`
 	Wait                               bool                            `opt:"wait,noval"`
 	WaitInterval                       time.Duration                   `opt:"wait-interval"`
-	WebDisable                         bool                            `opt:"web.disable"`
+	WebDisable                         bool                            `opt:"web.disable" since:"v0.29.0"`
 	WebDisableCors                     bool                            `opt:"web.disable-cors"`
 	WebExternalPrefix                  string                          `opt:"web.external-prefix"`
 	WebPrefixHeader                    string                          `opt:"web.prefix-header"`
@@ -84,7 +87,7 @@ func NewDefaultOptions() *CompactorOptions {
 		DeleteDelay:               time.Hour * 24 * 2,
 		CompactConcurrency:        1,
 		DownsampleConcurrency:     1,
-		DeduplicationReplicaLabel: "replica",
+		DeduplicationReplicaLabel: []string{"replica"},
 	}
 }
 
@@ -150,18 +153,46 @@ func NewCompactor(opts *CompactorOptions, namespace, imageTag string) *Compactor
 // Manifests returns the manifests for the compactor.
 // It includes the statefulset, the service, the service monitor, the service account and the config maps required by the containers.
 func (c *CompactorStatefulSet) Objects() []runtime.Object {
-	container := c.makeContainer()
-	return c.StatefulSetWorkload.Objects(container)
+	ret, err := c.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
 }
 
-func (c *CompactorStatefulSet) makeContainer() *workload.Container {
+// ObjectsE is like Objects but returns an error instead of panicking when the data directory is missing,
+// the probes do not target the HTTP port or the flags are not supported by the image tag.
+func (c *CompactorStatefulSet) ObjectsE() ([]runtime.Object, error) {
+	container, err := c.makeContainer()
+	if err != nil {
+		return nil, fmt.Errorf("invalid compactor %s: %w", c.Name, err)
+	}
+
+	ret, err := c.StatefulSetWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid compactor %s: %w", c.Name, err)
+	}
+
+	return ret, nil
+}
+
+// Validate checks that the options are supported by the image tag of the compactor.
+func (c *CompactorStatefulSet) Validate() error {
+	return cmdopt.CheckVersion(c.options, c.ImageTag)
+}
+
+func (c *CompactorStatefulSet) makeContainer() (*workload.Container, error) {
 	httpPort := kghelpers.GetPortOrDefault(defaultHTTPPort, c.options.HttpAddress)
-	kghelpers.CheckProbePort(httpPort, c.LivenessProbe)
-	kghelpers.CheckProbePort(httpPort, c.ReadinessProbe)
+	errs := []error{
+		kghelpers.CheckProbePortE(httpPort, c.LivenessProbe),
+		kghelpers.CheckProbePortE(httpPort, c.ReadinessProbe),
+		c.Validate(),
+	}
 
 	// Print warning if data directory is not specified.
 	if c.options.DataDir == "" {
-		panic("data directory is not specified for the statefulset.")
+		errs = append(errs, errors.New("data directory is not specified for the statefulset"))
 	}
 
 	ret := c.ToContainer()
@@ -195,5 +226,9 @@ func (c *CompactorStatefulSet) makeContainer() *workload.Container {
 		},
 	}
 
-	return ret
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
 }
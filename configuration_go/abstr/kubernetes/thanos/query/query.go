@@ -1,6 +1,8 @@
 package query
 
 import (
+	"errors"
+	"fmt"
 	"net"
 	"time"
 
@@ -46,11 +48,11 @@ func NewRequestLoggingConfigFile(value *reqlogging.RequestConfig) *containeropts
 
 type QueryOptions struct {
 	AlertQueryURL                                 string                         `opt:"alert.query-url"`
-	EnableFeature                                 string                         `opt:"enable-feature"`
+	EnableFeature                                 []string                       `opt:"enable-feature"`
 	Endpoint                                      []string                       `opt:"endpoint"`
-	EndpointGroup                                 []string                       `opt:"endpoint-group"`
+	EndpointGroup                                 []string                       `opt:"endpoint-group" since:"v0.31.0"`
 	EndpointStrict                                []string                       `opt:"endpoint-strict"`
-	EndpointGroupStrict                           []string                       `opt:"endpoint-group-strict"`
+	EndpointGroupStrict                           []string                       `opt:"endpoint-group-strict" since:"v0.31.0"`
 	GrpcAddress                                   *net.TCPAddr                   `opt:"grpc-address"`
 	GrpcClientsServerName                         string                         `opt:"grpc-client-server-name"`
 	GrpcClientsTLSCA                              string                         `opt:"grpc-client-tls-ca"`
@@ -58,7 +60,7 @@ type QueryOptions struct {
 	GrpcClientsTLSKey                             string                         `opt:"grpc-client-tls-key"`
 	GrpcClientsTLSSecure                          bool                           `opt:"grpc-client-tls-secure,noval"`
 	GrpcClientsTLSSkipVerify                      bool                           `opt:"grpc-client-tls-skip-verify,noval"`
-	GrpcClientsCompression                        GrpcCompressionType            `opt:"grpc-compression"`
+	GrpcClientsCompression                        GrpcCompressionType            `opt:"grpc-compression" since:"v0.29.0"`
 	GrpcGracePeriod                               time.Duration                  `opt:"grpc-grace-period"`
 	GrpcMMaxConnectionAge                         time.Duration                  `opt:"grpc-server-max-connection-age"`
 	GrpcServerTLSCert                             string                         `opt:"grpc-server-tls-cert"`
@@ -66,33 +68,38 @@ type QueryOptions struct {
 	GrpcServerTLSKey                              string                         `opt:"grpc-server-tls-key"`
 	HttpAddress                                   *net.TCPAddr                   `opt:"http-address"`
 	HttpGracePeriod                               time.Duration                  `opt:"http-grace-period"`
-	HttpConfig                                    string                         `opt:"http-config"`
+	HttpConfig                                    string                         `opt:"http.config"`
 	LogFormat                                     log.Format                     `opt:"log.format"`
 	LogLevel                                      log.Level                      `opt:"log.level"`
-	QueryActiveQueryPath                          string                         `opt:"query.active-query-path"`
+	QueryActiveQueryPath                          string                         `opt:"query.active-query-path" since:"v0.28.0"`
 	QueryAutoDownsampling                         bool                           `opt:"query.auto-downsampling,noval"`
-	QueryConnMetricLabel                          []string                       `opt:"query.conn-metric.label"`
+	QueryConnMetricLabel                          []string                       `opt:"query.conn-metric.label" since:"v0.30.0"`
 	QueryDefaultEvaluationInterval                time.Duration                  `opt:"query.default-evaluation-interval"`
 	QueryDefaultStep                              time.Duration                  `opt:"query.default-step"`
-	QueryDefaultTenantID                          string                         `opt:"query.default-tenant-id"`
+	QueryDefaultTenantID                          string                         `opt:"query.default-tenant-id" since:"v0.33.0"`
+	QueryEnableXFunctions                         bool                           `opt:"query.enable-x-functions,noval" since:"v0.34.0"`
+	QueryEnforceTenancy                           bool                           `opt:"query.enforce-tenancy,noval" since:"v0.34.0"`
 	QueryLookbackDelta                            time.Duration                  `opt:"query.lookback-delta"`
 	QueryMaxConcurrent                            int                            `opt:"query.max-concurrent"`
 	QueryMaxConcurrentSelect                      int                            `opt:"query.max-concurrent-select"`
 	QueryMetadataDefaultTimeRange                 time.Duration                  `opt:"query.metadata.default-time-range"`
 	QueryPartialResponse                          bool                           `opt:"query.partial-response,noval"`
-	QueryPromQLEngine                             string                         `opt:"query.promql-engine"`
+	QueryPromQLEngine                             string                         `opt:"query.promql-engine" since:"v0.30.0"`
 	QueryReplicaLabel                             []string                       `opt:"query.replica-label"`
-	QueryTelemetryRequestDurationSecondsQuantiles []float64                      `opt:"query.telemetry.request-duration-seconds-quantiles"`
-	QueryTelemetryRequestSamplesQuantiles         []float64                      `opt:"query.telemetry.request-samples-quantiles"`
-	QueryTelemetryRequestSeriesSecondsQuantiles   []float64                      `opt:"query.telemetry.request-series-seconds-quantiles"`
-	QueryTenantCertificateField                   string                         `opt:"query.tenant-certificate-field"`
-	QueryTenantHeader                             string                         `opt:"query.tenant-header"`
+	QueryTelemetryRequestDurationSecondsQuantiles []float64                      `opt:"query.telemetry.request-duration-seconds-quantiles" since:"v0.29.0"`
+	QueryTelemetryRequestSamplesQuantiles         []float64                      `opt:"query.telemetry.request-samples-quantiles" since:"v0.29.0"`
+	QueryTelemetryRequestSeriesSecondsQuantiles   []float64                      `opt:"query.telemetry.request-series-seconds-quantiles" since:"v0.29.0"`
+	QueryTenantCertificateField                   string                         `opt:"query.tenant-certificate-field" since:"v0.33.0"`
+	QueryTenantHeader                             string                         `opt:"query.tenant-header" since:"v0.33.0"`
+	QueryTenantLabelName                          string                         `opt:"query.tenant-label-name" since:"v0.34.0"`
 	QueryTimeout                                  time.Duration                  `opt:"query.timeout"`
 	RequestLoggingConfig                          *reqlogging.RequestConfig      `opt:"request.logging-config"`
 	RequestLoggingConfigFile                      containeropts.ContainerUpdater `opt:"request.logging-config-file"`
 	SelectorLabel                                 []string                       `opt:"selector-label"`
-	StoreLimitsRequestSamples                     int                            `opt:"store.limits.request-samples"`
-	StoreLimitsRequestSeries                      int                            `opt:"store.limits.request-series"`
+	Store                                         []string                       `opt:"store"`        // Deprecated by Thanos in favor of Endpoint.
+	StoreStrict                                   []string                       `opt:"store-strict"` // Deprecated by Thanos in favor of EndpointStrict.
+	StoreLimitsRequestSamples                     int                            `opt:"store.limits.request-samples" since:"v0.31.0"`
+	StoreLimitsRequestSeries                      int                            `opt:"store.limits.request-series" since:"v0.31.0"`
 	StoreResponseTimeout                          time.Duration                  `opt:"store.response-timeout"`
 	StoreSDDNSInterval                            time.Duration                  `opt:"store.sd-dns-interval"`
 	StoreSDFiles                                  []string                       `opt:"store.sd-files"`
@@ -153,6 +160,7 @@ func NewQuery(opts *QueryOptions, namespace, imageTag string) *QueryDeployment {
 			ContainerResources:   kghelpers.NewResourcesRequirements("500m", "2", "1Gi", "8Gi"),
 			Affinity:             kghelpers.NewAntiAffinity(nil, labelSelectors),
 			EnableServiceMonitor: true,
+			PodDisruptionBudget:  workload.NewMaxUnavailableBudget(1),
 
 			LivenessProbe: kghelpers.NewProbe("/-/healthy", probePort, kghelpers.ProbeConfig{
 				FailureThreshold: 8,
@@ -179,14 +187,42 @@ func NewQuery(opts *QueryOptions, namespace, imageTag string) *QueryDeployment {
 }
 
 func (q *QueryDeployment) Objects() []runtime.Object {
-	container := q.makeContainer()
-	return q.DeploymentWorkload.Objects(container)
+	ret, err := q.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
 }
 
-func (q *QueryDeployment) makeContainer() *workload.Container {
+// ObjectsE is like Objects but returns an error instead of panicking when the probes do not target the HTTP port
+// or the options are not supported by the image tag, both being reported together.
+func (q *QueryDeployment) ObjectsE() ([]runtime.Object, error) {
+	container, err := q.makeContainer()
+	if err != nil {
+		return nil, fmt.Errorf("invalid query %s: %w", q.Name, err)
+	}
+
+	ret, err := q.DeploymentWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query %s: %w", q.Name, err)
+	}
+
+	return ret, nil
+}
+
+// Validate checks that the options are supported by the image tag of the query.
+func (q *QueryDeployment) Validate() error {
+	return cmdopt.CheckVersion(q.options, q.ImageTag)
+}
+
+func (q *QueryDeployment) makeContainer() (*workload.Container, error) {
 	httpPort := kghelpers.GetPortOrDefault(defaultHTTPPort, q.options.HttpAddress)
-	kghelpers.CheckProbePort(httpPort, q.LivenessProbe)
-	kghelpers.CheckProbePort(httpPort, q.ReadinessProbe)
+	errs := []error{
+		kghelpers.CheckProbePortE(httpPort, q.LivenessProbe),
+		kghelpers.CheckProbePortE(httpPort, q.ReadinessProbe),
+		q.Validate(),
+	}
 
 	grpcPort := kghelpers.GetPortOrDefault(defaultGRPCPort, q.options.GrpcAddress)
 
@@ -217,12 +253,16 @@ func (q *QueryDeployment) makeContainer() *workload.Container {
 	}
 
 	if q.options.RequestLoggingConfig != nil {
-		q.options.RequestLoggingConfigFile.Update(ret)
+		errs = append(errs, containeropts.UpdateContainerE(q.options.RequestLoggingConfigFile, ret))
 	}
 
 	if q.options.TracingConfigFile != nil {
-		q.options.TracingConfigFile.Update(ret)
+		errs = append(errs, containeropts.UpdateContainerE(q.options.TracingConfigFile, ret))
 	}
 
-	return ret
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
 }
@@ -20,9 +20,18 @@ type DownstreamTripperConfig struct {
 // String returns a string representation of the IndexCacheConfig as YAML.
 // We use "gopkg.in/yaml.v2" instead of "github.com/ghodss/yaml" for correct formatting of this config.
 func (c DownstreamTripperConfig) String() string {
+	ret, err := c.StringE()
+	if err != nil {
+		panic(err.Error())
+	}
+	return ret
+}
+
+// StringE is like String but returns an error instead of panicking when the DownstreamTripperConfig cannot be marshalled.
+func (c DownstreamTripperConfig) StringE() (string, error) {
 	ret, err := yaml.Marshal(c)
 	if err != nil {
-		panic(fmt.Sprintf("error mashalling DownstreamTripperConfig to yaml: %v", err))
+		return "", fmt.Errorf("error marshalling DownstreamTripperConfig to yaml: %w", err)
 	}
-	return string(ret)
+	return string(ret), nil
 }
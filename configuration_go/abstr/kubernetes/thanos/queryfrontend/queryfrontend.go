@@ -1,6 +1,8 @@
 package queryfrontend
 
 import (
+	"errors"
+	"fmt"
 	"net"
 	"time"
 
@@ -45,6 +47,15 @@ func NewRequestLoggingConfigFile(value *reqlogging.RequestConfig) *containeropts
 	return ret
 }
 
+// NewDownstreamTripperConfigFile returns a new downstream tripper config file option.
+func NewDownstreamTripperConfigFile(value *DownstreamTripperConfig) *containeropts.ConfigResourceAsFile {
+	ret := containeropts.NewConfigResourceAsFile("/etc/thanos/downstream-tripper", "config.yaml", "downstream-tripper", "observatorium-thanos-query-frontend-downstream-tripper")
+	if value != nil {
+		ret.WithValue(value.String())
+	}
+	return ret
+}
+
 // NewLabelsResponseCacheConfigFile returns a new labels response cache config file option.
 func NewLabelsResponseCacheConfigFile(value *cache.ResponseCacheConfig) *containeropts.ConfigResourceAsFile {
 	ret := containeropts.NewConfigResourceAsFile("/etc/thanos/labels-response-cache", "config.yaml", "labels-response-cache", "observatorium-thanos-query-labels-response-cache")
@@ -64,44 +75,47 @@ func NewQueryRangeResponseCacheConfigFile(value *cache.ResponseCacheConfig) *con
 }
 
 type QueryFrontendOptions struct {
-	CacheCompressionType                 CacheCompressionType           `opt:"cache-compression-type"`
-	HttpAddress                          *net.TCPAddr                   `opt:"http-address"`
-	HttpGracePeriod                      time.Duration                  `opt:"http-grace-period"`
-	HttpConfig                           string                         `opt:"http.config"`
-	LabelsDefaultTimeRange               time.Duration                  `opt:"labels.default-time-range"`
-	LabelsMaxQueryParallelism            int                            `opt:"labels.max-query-parallelism"`
-	LabelsMaxRetriesPerRequest           *int                           `opt:"labels.max-retries-per-request"`
-	LabelsPartialResponse                bool                           `opt:"labels.partial-response,noval"`
-	LabelsResponseCacheConfig            *cache.ResponseCacheConfig     `opt:"labels.response-cache-config"`
-	LabelsResponseCacheConfigFile        containeropts.ContainerUpdater `opt:"labels.response-cache-config-file"`
-	LabelsResponseMaxFreshness           string                         `opt:"labels.response-cache-max-freshness"`
-	LabelsSplitInterval                  time.Duration                  `opt:"labels.split-interval"`
-	LogFormat                            log.Format                     `opt:"log.format"`
-	LogLevel                             log.Level                      `opt:"log.level"`
-	QueryFrontendCompressResponses       bool                           `opt:"query-frontend.compress-responses,noval"`
-	QueryFrontendDownstreamTripperConfig *DownstreamTripperConfig       `opt:"query-frontend.downstream-tripper-config"`
-	QueryFrontendDownstreamURL           string                         `opt:"query-frontend.downstream-url"`
-	QueryFrontendForwardHeader           []string                       `opt:"query-frontend.forward-header"`
-	QueryFrontendLogQueriesLongerThan    time.Duration                  `opt:"query-frontend.log-queries-longer-than"`
-	QueryFrontendVerticalShards          int                            `opt:"query-frontend.vertical-shards"`
-	QueryRangeAlignRangeWithStep         bool                           `opt:"query-range.align-range-with-step,noval"`
-	QueryRangeHorizontalShards           int                            `opt:"query-range.horizontal-shards"`
-	QueryRangeMaxQueryLength             time.Duration                  `opt:"query-range.max-query-length"`
-	QueryRangeMaxQueryParallelism        int                            `opt:"query-range.max-query-parallelism"`
-	QueryRangeMaxRetriesPerRequest       *int                           `opt:"query-range.max-retries-per-request"`
-	QueryRangeMaxSplitInterval           time.Duration                  `opt:"query-range.max-split-interval"`
-	QueryRangeMinSplitInterval           time.Duration                  `opt:"query-range.min-split-interval"`
-	QueryRangePartialResponse            bool                           `opt:"query-range.partial-response,noval"`
-	QueryRangeRequestDownsampled         bool                           `opt:"query-range.request-downsampled,noval"`
-	QueryRangeResponseCacheConfig        *cache.ResponseCacheConfig     `opt:"query-range.response-cache-config"`
-	QueryRangeResponseCacheConfigFile    containeropts.ContainerUpdater `opt:"query-range.response-cache-config-file"`
-	QueryRangeResponseCacheMaxFreshness  time.Duration                  `opt:"query-range.response-cache-max-freshness"`
-	QueryRangeSplitInterval              time.Duration                  `opt:"query-range.split-interval"`
-	RequestLoggingConfig                 *reqlogging.RequestConfig      `opt:"request.logging-config"`
-	RequestLoggingConfigFile             containeropts.ContainerUpdater `opt:"request.logging-config-file"`
-	TracingConfig                        *trclient.TracingConfig        `opt:"tracing.config"`
-	TracingConfigFile                    containeropts.ContainerUpdater `opt:"tracing.config-file"`
-	WebDisableCORS                       bool                           `opt:"web.disable-cors,noval"`
+	CacheCompressionType                     CacheCompressionType           `opt:"cache-compression-type"`
+	HttpAddress                              *net.TCPAddr                   `opt:"http-address"`
+	HttpGracePeriod                          time.Duration                  `opt:"http-grace-period"`
+	HttpConfig                               string                         `opt:"http.config"`
+	LabelsDefaultTimeRange                   time.Duration                  `opt:"labels.default-time-range"`
+	LabelsMaxQueryParallelism                int                            `opt:"labels.max-query-parallelism"`
+	LabelsMaxRetriesPerRequest               *int                           `opt:"labels.max-retries-per-request"`
+	LabelsPartialResponse                    bool                           `opt:"labels.partial-response,noval"`
+	LabelsResponseCacheConfig                *cache.ResponseCacheConfig     `opt:"labels.response-cache-config"`
+	LabelsResponseCacheConfigFile            containeropts.ContainerUpdater `opt:"labels.response-cache-config-file"`
+	LabelsResponseMaxFreshness               string                         `opt:"labels.response-cache-max-freshness"`
+	LabelsSplitInterval                      time.Duration                  `opt:"labels.split-interval"`
+	LogFormat                                log.Format                     `opt:"log.format"`
+	LogLevel                                 log.Level                      `opt:"log.level"`
+	QueryFrontendCompressResponses           bool                           `opt:"query-frontend.compress-responses,noval"`
+	QueryFrontendDownstreamTripperConfig     *DownstreamTripperConfig       `opt:"query-frontend.downstream-tripper-config"`
+	QueryFrontendDownstreamTripperConfigFile containeropts.ContainerUpdater `opt:"query-frontend.downstream-tripper-config-file"`
+	QueryFrontendDownstreamURL               string                         `opt:"query-frontend.downstream-url"`
+	QueryFrontendForwardHeader               []string                       `opt:"query-frontend.forward-header"`
+	QueryFrontendEnableXFunctions            bool                           `opt:"query-frontend.enable-x-functions,noval" since:"v0.34.0"`
+	QueryFrontendLogQueriesLongerThan        time.Duration                  `opt:"query-frontend.log-queries-longer-than"`
+	QueryFrontendOrgIdHeader                 []string                       `opt:"query-frontend.org-id-header"` // Deprecated by Thanos.
+	QueryFrontendVerticalShards              int                            `opt:"query-frontend.vertical-shards" since:"v0.28.0"`
+	QueryRangeAlignRangeWithStep             bool                           `opt:"query-range.align-range-with-step,noval"`
+	QueryRangeHorizontalShards               int                            `opt:"query-range.horizontal-shards" since:"v0.29.0"`
+	QueryRangeMaxQueryLength                 time.Duration                  `opt:"query-range.max-query-length"`
+	QueryRangeMaxQueryParallelism            int                            `opt:"query-range.max-query-parallelism"`
+	QueryRangeMaxRetriesPerRequest           *int                           `opt:"query-range.max-retries-per-request"`
+	QueryRangeMaxSplitInterval               time.Duration                  `opt:"query-range.max-split-interval" since:"v0.29.0"`
+	QueryRangeMinSplitInterval               time.Duration                  `opt:"query-range.min-split-interval" since:"v0.29.0"`
+	QueryRangePartialResponse                bool                           `opt:"query-range.partial-response,noval"`
+	QueryRangeRequestDownsampled             bool                           `opt:"query-range.request-downsampled,noval"`
+	QueryRangeResponseCacheConfig            *cache.ResponseCacheConfig     `opt:"query-range.response-cache-config"`
+	QueryRangeResponseCacheConfigFile        containeropts.ContainerUpdater `opt:"query-range.response-cache-config-file"`
+	QueryRangeResponseCacheMaxFreshness      time.Duration                  `opt:"query-range.response-cache-max-freshness"`
+	QueryRangeSplitInterval                  time.Duration                  `opt:"query-range.split-interval"`
+	RequestLoggingConfig                     *reqlogging.RequestConfig      `opt:"request.logging-config"`
+	RequestLoggingConfigFile                 containeropts.ContainerUpdater `opt:"request.logging-config-file"`
+	TracingConfig                            *trclient.TracingConfig        `opt:"tracing.config"`
+	TracingConfigFile                        containeropts.ContainerUpdater `opt:"tracing.config-file"`
+	WebDisableCORS                           bool                           `opt:"web.disable-cors,noval"`
 
 	// Extra options not officially supported.
 	cmdopt.ExtraOpts
@@ -151,6 +165,7 @@ func NewQueryFrontend(opts *QueryFrontendOptions, namespace, imageTag string) *Q
 			ContainerResources:   kghelpers.NewResourcesRequirements("500m", "2", "1Gi", "2Gi"),
 			Affinity:             kghelpers.NewAntiAffinity(nil, labelSelectors),
 			EnableServiceMonitor: true,
+			PodDisruptionBudget:  workload.NewMaxUnavailableBudget(1),
 
 			LivenessProbe: kghelpers.NewProbe("/-/healthy", probePort, kghelpers.ProbeConfig{
 				FailureThreshold: 8,
@@ -177,14 +192,42 @@ func NewQueryFrontend(opts *QueryFrontendOptions, namespace, imageTag string) *Q
 }
 
 func (q *QueryFrontendDeployment) Objects() []runtime.Object {
-	container := q.makeContainer()
-	return q.DeploymentWorkload.Objects(container)
+	ret, err := q.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
 }
 
-func (q *QueryFrontendDeployment) makeContainer() *workload.Container {
+// ObjectsE is like Objects but returns an error instead of panicking when the probes do not target the HTTP port
+// or the options are not supported by the image tag.
+func (q *QueryFrontendDeployment) ObjectsE() ([]runtime.Object, error) {
+	container, err := q.makeContainer()
+	if err != nil {
+		return nil, fmt.Errorf("invalid query frontend %s: %w", q.Name, err)
+	}
+
+	ret, err := q.DeploymentWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query frontend %s: %w", q.Name, err)
+	}
+
+	return ret, nil
+}
+
+// Validate checks that the options are supported by the image tag of the query frontend.
+func (q *QueryFrontendDeployment) Validate() error {
+	return cmdopt.CheckVersion(q.options, q.ImageTag)
+}
+
+func (q *QueryFrontendDeployment) makeContainer() (*workload.Container, error) {
 	httpPort := kghelpers.GetPortOrDefault(defaultHTTPPort, q.options.HttpAddress)
-	kghelpers.CheckProbePort(httpPort, q.LivenessProbe)
-	kghelpers.CheckProbePort(httpPort, q.ReadinessProbe)
+	errs := []error{
+		kghelpers.CheckProbePortE(httpPort, q.LivenessProbe),
+		kghelpers.CheckProbePortE(httpPort, q.ReadinessProbe),
+		q.Validate(),
+	}
 
 	ret := q.ToContainer()
 	ret.Name = "thanos"
@@ -206,21 +249,29 @@ func (q *QueryFrontendDeployment) makeContainer() *workload.Container {
 		},
 	}
 
+	if q.options.QueryFrontendDownstreamTripperConfigFile != nil {
+		errs = append(errs, containeropts.UpdateContainerE(q.options.QueryFrontendDownstreamTripperConfigFile, ret))
+	}
+
 	if q.options.RequestLoggingConfigFile != nil {
-		q.options.RequestLoggingConfigFile.Update(ret)
+		errs = append(errs, containeropts.UpdateContainerE(q.options.RequestLoggingConfigFile, ret))
 	}
 
 	if q.options.TracingConfigFile != nil {
-		q.options.TracingConfigFile.Update(ret)
+		errs = append(errs, containeropts.UpdateContainerE(q.options.TracingConfigFile, ret))
 	}
 
 	if q.options.LabelsResponseCacheConfigFile != nil {
-		q.options.LabelsResponseCacheConfigFile.Update(ret)
+		errs = append(errs, containeropts.UpdateContainerE(q.options.LabelsResponseCacheConfigFile, ret))
 	}
 
 	if q.options.QueryRangeResponseCacheConfigFile != nil {
-		q.options.QueryRangeResponseCacheConfigFile.Update(ret)
+		errs = append(errs, containeropts.UpdateContainerE(q.options.QueryRangeResponseCacheConfigFile, ret))
 	}
 
-	return ret
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
 }
@@ -1,15 +1,14 @@
 package receive
 
 import (
+	"errors"
 	"fmt"
-	"strings"
 
 	"github.com/observatorium/observatorium/configuration_go/kubegen/cmdopt"
 	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
 	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -75,70 +74,54 @@ func NewController(opts *ControllerOptions, namespace, imageTag string) *Control
 	}
 }
 
+// Objects returns the manifests for the receive controller.
+// It panics if the configuration is invalid, see ObjectsE.
 func (c *Controller) Objects() []runtime.Object {
-	container := c.makeContainer()
+	ret, err := c.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
 
-	ret := c.DeploymentWorkload.Objects(container)
+	return ret
+}
 
-	// create role
-	ret = append(ret, &rbacv1.Role{
-		TypeMeta: workload.RoleMeta,
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      c.Name,
-			Labels:    c.CommonLabels,
-			Namespace: c.Namespace,
-		},
-		Rules: []rbacv1.PolicyRule{
-			{
-				APIGroups: []string{""},
-				Resources: []string{"configmaps"},
-				Verbs:     []string{"list", "watch", "get", "create", "update", "delete"},
-			},
-			{
-				APIGroups: []string{""},
-				Resources: []string{"pods"},
+// ObjectsE is like Objects but returns an error instead of panicking when the configmaps
+// the controller reads the hashrings from and writes them to are not specified.
+func (c *Controller) ObjectsE() ([]runtime.Object, error) {
+	container, err := c.makeContainer()
+	if err != nil {
+		return nil, fmt.Errorf("invalid receive controller %s: %w", c.Name, err)
+	}
 
-				Verbs: []string{"get", "update"},
-			},
-			{
-				APIGroups: []string{"apps"},
-				Resources: []string{"statefulsets"},
-				Verbs:     []string{"list", "watch", "get"},
-			},
-		},
-	})
-
-	// create role binding
-	apiGroup := strings.Split(workload.RoleMeta.APIVersion, "/")[0]
-	ret = append(ret, &rbacv1.RoleBinding{
-		TypeMeta: workload.RoleBindingMeta,
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      c.Name,
-			Labels:    c.CommonLabels,
-			Namespace: c.Namespace,
-		},
-		Subjects: []rbacv1.Subject{
-			{
-				Kind:      "ServiceAccount",
-				Name:      c.Name,
-				Namespace: c.Namespace,
-			},
-		},
-		RoleRef: rbacv1.RoleRef{
-			Kind:     workload.RoleMeta.Kind,
-			Name:     c.Name,
-			APIGroup: apiGroup,
-		},
-	})
+	ret, err := c.DeploymentWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid receive controller %s: %w", c.Name, err)
+	}
 
-	return ret
+	return ret, nil
 }
 
-func (c *Controller) makeContainer() *workload.Container {
+func (c *Controller) makeContainer() (*workload.Container, error) {
+	var errs []error
+	if c.options.ConfigMapName == "" {
+		errs = append(errs, errors.New("configmap name is not specified"))
+	}
+	if c.options.ConfigMapGeneratedName == "" {
+		errs = append(errs, errors.New("generated configmap name is not specified"))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
 	container := c.ToContainer()
 	container.Env = append(container.Env, kghelpers.NewEnvFromField("NAMESPACE", "metadata.namespace"))
 
 	container.Args = cmdopt.GetOpts(c.options)
+	container.Rules = []rbacv1.PolicyRule{
+		workload.NewPolicyRule("", []string{"configmaps"}, "list", "watch", "get", "create", "update", "delete"),
+		workload.NewPolicyRule("", []string{"pods"}, "get", "update"),
+		workload.NewPolicyRule("apps", []string{"statefulsets"}, "list", "watch", "get"),
+	}
 
-	return container
+	return container, nil
 }
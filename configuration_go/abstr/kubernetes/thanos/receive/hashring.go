@@ -0,0 +1,90 @@
+package receive
+
+import (
+	"errors"
+	"fmt"
+)
+
+// HashringAssignment assigns tenants to the ingestors forming a hashring.
+type HashringAssignment struct {
+	Hashring string
+	// Tenants are the tenants written to the hashring. A hashring without tenants receives the data of all
+	// the tenants not assigned to another hashring, it must then be the last one.
+	Tenants   []string
+	Ingestors []*Ingestor
+	// Algorithm defaults to the hashrings algorithm of the routers if empty.
+	Algorithm      HashRingAlgorithm
+	ExternalLabels map[string]string
+}
+
+// NewStaticHashRingsConfig returns the hashrings config of the given assignments, computed from the names,
+// replicas, namespaces and gRPC ports of the ingestors. It doesn't require the receive controller, the config
+// being fully determined by the ingestors: it can be rendered with NewReceiveHashringConfigFile.
+// Endpoints are ordered as the ingestors, then by pod ordinal, and tagged with the zone of their ingestor.
+// It returns an error if the assignments are inconsistent.
+func NewStaticHashRingsConfig(assignments ...HashringAssignment) (HashRingsConfig, error) {
+	var errs []error
+	hashrings := map[string]bool{}
+	tenants := map[string]string{}
+	ingestors := map[string]string{}
+
+	ret := make(HashRingsConfig, 0, len(assignments))
+	for i, a := range assignments {
+		if a.Hashring == "" {
+			errs = append(errs, fmt.Errorf("name is not specified for hashring %d", i))
+		} else if hashrings[a.Hashring] {
+			errs = append(errs, fmt.Errorf("hashring %s is specified several times", a.Hashring))
+		}
+		hashrings[a.Hashring] = true
+
+		if len(a.Tenants) == 0 && i != len(assignments)-1 {
+			errs = append(errs, fmt.Errorf("hashring %s has no tenant and must be the last one", a.Hashring))
+		}
+
+		for _, tenant := range a.Tenants {
+			if other, ok := tenants[tenant]; ok {
+				errs = append(errs, fmt.Errorf("tenant %s is assigned to hashrings %s and %s", tenant, other, a.Hashring))
+			}
+			tenants[tenant] = a.Hashring
+		}
+
+		switch a.Algorithm {
+		case "", HashRingAlgorithmHashmod, HashRingAlgorithmKetama:
+		default:
+			errs = append(errs, fmt.Errorf("unsupported algorithm %s for hashring %s", a.Algorithm, a.Hashring))
+		}
+
+		hashring := HashringConfig{
+			Hashring:       a.Hashring,
+			Tenants:        a.Tenants,
+			Algorithm:      a.Algorithm,
+			ExternalLabels: a.ExternalLabels,
+		}
+
+		for _, ingestor := range a.Ingestors {
+			key := fmt.Sprintf("%s/%s", ingestor.Namespace, ingestor.Name)
+			if other, ok := ingestors[key]; ok {
+				errs = append(errs, fmt.Errorf("ingestor %s is part of hashrings %s and %s", ingestor.Name, other, a.Hashring))
+			}
+			ingestors[key] = a.Hashring
+
+			endpoints, err := ingestor.EndpointsE()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("hashring %s: %w", a.Hashring, err))
+			}
+			hashring.Endpoints = append(hashring.Endpoints, endpoints...)
+		}
+
+		if len(a.Ingestors) == 0 {
+			errs = append(errs, fmt.Errorf("hashring %s has no ingestor", a.Hashring))
+		}
+
+		ret = append(ret, hashring)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid hashrings: %w", err)
+	}
+
+	return ret, nil
+}
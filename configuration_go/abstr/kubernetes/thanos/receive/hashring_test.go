@@ -0,0 +1,128 @@
+package receive_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/receive"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/openshift"
+)
+
+func TestNewStaticHashRingsConfig(t *testing.T) {
+	newIngestor := func(name string, replicas int32) *receive.Ingestor {
+		ret := receive.NewIngestor(nil, "ns", "v0.34.1")
+		ret.Name = name
+		ret.Replicas = replicas
+		return ret
+	}
+
+	notHeadless := newIngestor("a", 1)
+	notHeadless.Headless = false
+
+	parameterized := newIngestor("param", 3)
+	if err := openshift.NewParameters().Parameterize(parameterized, "INGESTOR"); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		assignments []receive.HashringAssignment
+		expected    map[string][]string
+		expectedErr []string
+	}{
+		"endpoints ordered by ingestor and ordinal": {
+			assignments: []receive.HashringAssignment{
+				{Hashring: "tenant-a", Tenants: []string{"a"}, Ingestors: []*receive.Ingestor{newIngestor("b", 2), newIngestor("a", 1)}},
+				{Hashring: "default", Ingestors: []*receive.Ingestor{newIngestor("c", 1)}},
+			},
+			expected: map[string][]string{
+				"tenant-a": {"b-0.b.ns.svc.cluster.local:10901", "b-1.b.ns.svc.cluster.local:10901", "a-0.a.ns.svc.cluster.local:10901"},
+				"default":  {"c-0.c.ns.svc.cluster.local:10901"},
+			},
+		},
+		"tenant-less hashring not last": {
+			assignments: []receive.HashringAssignment{
+				{Hashring: "default", Ingestors: []*receive.Ingestor{newIngestor("a", 1)}},
+				{Hashring: "tenant-b", Tenants: []string{"b"}, Ingestors: []*receive.Ingestor{newIngestor("b", 1)}},
+			},
+			expectedErr: []string{"hashring default has no tenant and must be the last one"},
+		},
+		"duplicates": {
+			assignments: []receive.HashringAssignment{
+				{Hashring: "h", Tenants: []string{"a"}, Ingestors: []*receive.Ingestor{newIngestor("a", 1)}},
+				{Hashring: "h", Tenants: []string{"a"}, Ingestors: []*receive.Ingestor{newIngestor("a", 1)}},
+			},
+			expectedErr: []string{
+				"hashring h is specified several times",
+				"tenant a is assigned to hashrings h and h",
+				"ingestor a is part of hashrings h and h",
+			},
+		},
+		"missing name, ingestors and unknown algorithm": {
+			assignments: []receive.HashringAssignment{
+				{Tenants: []string{"a"}, Ingestors: []*receive.Ingestor{newIngestor("a", 1)}},
+				{Hashring: "empty", Algorithm: "random"},
+			},
+			expectedErr: []string{
+				"name is not specified for hashring 0",
+				"hashring empty has no ingestor",
+				"unsupported algorithm random for hashring empty",
+			},
+		},
+		"ingestor without replica": {
+			assignments: []receive.HashringAssignment{
+				{Hashring: "default", Ingestors: []*receive.Ingestor{newIngestor("a", 0)}},
+			},
+			expectedErr: []string{"hashring default: ingestor a must have at least one replica to list its endpoints, got 0"},
+		},
+		"ingestor without headless service": {
+			assignments: []receive.HashringAssignment{
+				{Hashring: "default", Ingestors: []*receive.Ingestor{notHeadless}},
+			},
+			expectedErr: []string{"service of the ingestor a must be headless to list its endpoints"},
+		},
+		"parameterized replicas": {
+			assignments: []receive.HashringAssignment{
+				{Hashring: "default", Ingestors: []*receive.Ingestor{parameterized}},
+			},
+			expectedErr: []string{"replicas of the ingestor param are a template parameter, its endpoints can't be listed"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			hashrings, err := receive.NewStaticHashRingsConfig(tc.assignments...)
+			if len(tc.expectedErr) > 0 {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				for _, expected := range tc.expectedErr {
+					if !strings.Contains(err.Error(), expected) {
+						t.Errorf("expected error to contain %q, got %v", expected, err)
+					}
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(hashrings) != len(tc.assignments) {
+				t.Fatalf("expected %d hashrings, got %d", len(tc.assignments), len(hashrings))
+			}
+			for i, hashring := range hashrings {
+				if hashring.Hashring != tc.assignments[i].Hashring {
+					t.Errorf("expected hashring %d to be %s, got %s", i, tc.assignments[i].Hashring, hashring.Hashring)
+				}
+
+				addresses := []string{}
+				for _, endpoint := range hashring.Endpoints {
+					addresses = append(addresses, endpoint.Address)
+				}
+				if strings.Join(addresses, ",") != strings.Join(tc.expected[hashring.Hashring], ",") {
+					t.Errorf("expected endpoints %v for hashring %s, got %v", tc.expected[hashring.Hashring], hashring.Hashring, addresses)
+				}
+			}
+		})
+	}
+}
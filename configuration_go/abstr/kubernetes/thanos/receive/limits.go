@@ -1,6 +1,14 @@
 package receive
 
-import "gopkg.in/yaml.v2"
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+
+	"gopkg.in/yaml.v2"
+)
 
 // Taken from https://github.com/thanos-io/thanos/blob/release-0.32/pkg/receive/limiter_config.go
 
@@ -13,13 +21,21 @@ type ReceiveLimitsConfig struct {
 // String returns a string representation of the RootLimitsConfig as JSON.
 // It implements the Stringer interface that is used by the cmdopt package.
 func (r ReceiveLimitsConfig) String() string {
+	ret, err := r.StringE()
+	if err != nil {
+		panic(err.Error())
+	}
+	return ret
+}
+
+// StringE is like String but returns an error instead of panicking when the ReceiveLimitsConfig cannot be marshalled.
+func (r ReceiveLimitsConfig) StringE() (string, error) {
 	// We use "gopkg.in/yaml.v2" instead of "github.com/ghodss/yaml" for correct formatting of this config.
 	ret, err := yaml.Marshal(r)
 	if err != nil {
-		panic(err)
+		return "", fmt.Errorf("error marshalling ReceiveLimitsConfig to yaml: %w", err)
 	}
-
-	return string(ret)
+	return string(ret), nil
 }
 
 func (r ReceiveLimitsConfig) WithGlobalLimits(concurrency int, monUrl, query string) ReceiveLimitsConfig {
@@ -29,7 +45,7 @@ func (r ReceiveLimitsConfig) WithGlobalLimits(concurrency int, monUrl, query str
 	return r
 }
 
-func (r ReceiveLimitsConfig) WithDefaultLimits(sizeBytes, series, samples int, headSeries int) ReceiveLimitsConfig {
+func (r ReceiveLimitsConfig) WithDefaultLimits(sizeBytes, series, samples int, headSeries uint64) ReceiveLimitsConfig {
 	r.WriteLimits.DefaultLimits.RequestLimits.SizeBytesLimit = sizeBytes
 	r.WriteLimits.DefaultLimits.RequestLimits.SeriesLimit = series
 	r.WriteLimits.DefaultLimits.RequestLimits.SamplesLimit = samples
@@ -38,17 +54,155 @@ func (r ReceiveLimitsConfig) WithDefaultLimits(sizeBytes, series, samples int, h
 }
 
 func (r ReceiveLimitsConfig) WithTenantLimits(tenant string, sizeBytes, series, samples int, headSeries uint64) ReceiveLimitsConfig {
-	r.WriteLimits.TenantsLimits[tenant] = WriteLimitConfig{
+	return r.withTenantLimits(tenant, WriteLimitConfig{
 		RequestLimits: RequestLimitsConfig{
 			SizeBytesLimit: sizeBytes,
 			SeriesLimit:    series,
 			SamplesLimit:   samples,
 		},
 		HeadSeriesLimit: headSeries,
+	})
+}
+
+// WithTenantClass sets the limits of the tenant to the preset of the given class.
+// It returns an error if the class is unknown.
+func (r ReceiveLimitsConfig) WithTenantClass(tenant string, class TenantClass) (ReceiveLimitsConfig, error) {
+	limits, ok := TenantClassLimits[class]
+	if !ok {
+		return r, fmt.Errorf("unknown class %q for tenant %s", class, tenant)
 	}
+
+	return r.withTenantLimits(tenant, limits), nil
+}
+
+// WithTenants sets the limits of the tenants to the preset of their class, classes being keyed by tenant name.
+// Limits are keyed by tenant ID, as the API forwards the ID in the tenant header of the receive: ids maps the
+// names of the tenants to their IDs, e.g. the ones of the API tenants config. Tenants without class get the
+// default limits.
+// It returns an error if a class is unknown or given for a tenant missing from ids.
+func (r ReceiveLimitsConfig) WithTenants(ids map[string]string, classes map[string]TenantClass) (ReceiveLimitsConfig, error) {
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(classes)) {
+		id, ok := ids[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("class is given for unknown tenant %s", name))
+			continue
+		}
+
+		ret, err := r.WithTenantClass(id, classes[name])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		r = ret
+	}
+
+	return r, errors.Join(errs...)
+}
+
+// withTenantLimits copies the tenants limits before updating them, so that configs derived from
+// the same config don't share them.
+func (r ReceiveLimitsConfig) withTenantLimits(tenant string, limits WriteLimitConfig) ReceiveLimitsConfig {
+	tenantsLimits := maps.Clone(r.WriteLimits.TenantsLimits)
+	if tenantsLimits == nil {
+		tenantsLimits = TenantsWriteLimitsConfig{}
+	}
+
+	tenantsLimits[tenant] = limits
+	r.WriteLimits.TenantsLimits = tenantsLimits
 	return r
 }
 
+// Validate checks that the limits are consistent. Head series limiting requires a meta-monitoring
+// URL and query, see NewMetaMonitoringLimitQuery.
+func (r ReceiveLimitsConfig) Validate() error {
+	var errs []error
+	headSeriesLimited := r.WriteLimits.DefaultLimits.HeadSeriesLimit > 0
+
+	if r.WriteLimits.GlobalLimits.MaxConcurrency < 0 {
+		errs = append(errs, fmt.Errorf("max concurrency must not be negative, got %d", r.WriteLimits.GlobalLimits.MaxConcurrency))
+	}
+
+	if err := r.WriteLimits.DefaultLimits.RequestLimits.validate(); err != nil {
+		errs = append(errs, fmt.Errorf("invalid default limits: %w", err))
+	}
+
+	for _, tenant := range slices.Sorted(maps.Keys(r.WriteLimits.TenantsLimits)) {
+		limits := r.WriteLimits.TenantsLimits[tenant]
+		if tenant == "" {
+			errs = append(errs, errors.New("tenant limits must have a tenant"))
+		}
+
+		if err := limits.RequestLimits.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("invalid limits of tenant %s: %w", tenant, err))
+		}
+
+		headSeriesLimited = headSeriesLimited || limits.HeadSeriesLimit > 0
+	}
+
+	global := r.WriteLimits.GlobalLimits
+	if global.MetaMonitoringURL != "" {
+		if u, err := url.Parse(global.MetaMonitoringURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("meta-monitoring URL %q must be an absolute HTTP URL", global.MetaMonitoringURL))
+		}
+	}
+
+	if headSeriesLimited && (global.MetaMonitoringURL == "" || global.MetaMonitoringLimitQuery == "") {
+		errs = append(errs, errors.New("head series limits require a meta-monitoring URL and query"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// NewMetaMonitoringLimitQuery returns the query counting the head series of each tenant, as required by head
+// series limiting. The job is the one of the ingestors in the meta-monitoring Prometheus, their Service name by default.
+// Series being stored by as many ingestors as the replication factor, their count is divided by it.
+func NewMetaMonitoringLimitQuery(job string, replicationFactor int) string {
+	ret := fmt.Sprintf("sum(prometheus_tsdb_head_series{job=%q}) by (tenant)", job)
+	if replicationFactor > 1 {
+		ret = fmt.Sprintf("%s / %d", ret, replicationFactor)
+	}
+
+	return ret
+}
+
+// TenantClass is a class of tenants of similar size, sharing the same preset of limits.
+type TenantClass string
+
+const (
+	TenantClassSmall  TenantClass = "small"
+	TenantClassMedium TenantClass = "medium"
+	TenantClassLarge  TenantClass = "large"
+)
+
+// TenantClassLimits holds the preset of limits of each class of tenants.
+var TenantClassLimits = map[TenantClass]WriteLimitConfig{
+	TenantClassSmall: {
+		RequestLimits: RequestLimitsConfig{
+			SizeBytesLimit: 1 << 20, // 1MiB
+			SeriesLimit:    1000,
+			SamplesLimit:   10000,
+		},
+		HeadSeriesLimit: 100_000,
+	},
+	TenantClassMedium: {
+		RequestLimits: RequestLimitsConfig{
+			SizeBytesLimit: 5 << 20, // 5MiB
+			SeriesLimit:    5000,
+			SamplesLimit:   50000,
+		},
+		HeadSeriesLimit: 1_000_000,
+	},
+	TenantClassLarge: {
+		RequestLimits: RequestLimitsConfig{
+			SizeBytesLimit: 10 << 20, // 10MiB
+			SeriesLimit:    10000,
+			SamplesLimit:   100000,
+		},
+		HeadSeriesLimit: 10_000_000,
+	},
+}
+
 type WriteLimitsConfig struct {
 	// GlobalLimits are limits that are shared across all tenants.
 	GlobalLimits GlobalLimitsConfig `yaml:"global,omitempty"`
@@ -70,7 +224,7 @@ type DefaultLimitsConfig struct {
 	// RequestLimits holds the difficult per-request limits.
 	RequestLimits RequestLimitsConfig `yaml:"request,omitempty"`
 	// HeadSeriesLimit specifies the maximum number of head series allowed for any tenant.
-	HeadSeriesLimit int `yaml:"head_series_limit,omitempty"`
+	HeadSeriesLimit uint64 `yaml:"head_series_limit,omitempty"`
 }
 
 // TenantsWriteLimitsConfig is a map of tenant IDs to their WriteLimitConfig.
@@ -90,3 +244,26 @@ type RequestLimitsConfig struct {
 	SeriesLimit    int `yaml:"series_limit,omitempty"`
 	SamplesLimit   int `yaml:"samples_limit,omitempty"`
 }
+
+func (r RequestLimitsConfig) validate() error {
+	var errs []error
+	for _, l := range []struct {
+		name  string
+		limit int
+	}{
+		{"size bytes", r.SizeBytesLimit},
+		{"series", r.SeriesLimit},
+		{"samples", r.SamplesLimit},
+	} {
+		if l.limit < 0 {
+			errs = append(errs, fmt.Errorf("%s limit must not be negative, got %d", l.name, l.limit))
+		}
+	}
+
+	// Each series of a request has at least one sample.
+	if r.SeriesLimit > 0 && r.SamplesLimit > 0 && r.SamplesLimit < r.SeriesLimit {
+		errs = append(errs, fmt.Errorf("samples limit %d must not be lower than the series limit %d", r.SamplesLimit, r.SeriesLimit))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,180 @@
+package receive_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/receive"
+)
+
+func TestReceiveLimitsConfigWithTenantLimits(t *testing.T) {
+	// The tenants limits of a zero config are a nil map, which must not be written to.
+	var base receive.ReceiveLimitsConfig
+	small, err := base.WithTenantClass("a", receive.TenantClassSmall)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	custom := small.WithTenantLimits("b", 100, 10, 20, 1000)
+
+	if base.WriteLimits.TenantsLimits != nil {
+		t.Errorf("expected the base config to be unchanged, got %v", base.WriteLimits.TenantsLimits)
+	}
+	if len(small.WriteLimits.TenantsLimits) != 1 {
+		t.Errorf("expected derived configs not to share their tenants limits, got %v", small.WriteLimits.TenantsLimits)
+	}
+	if small.WriteLimits.TenantsLimits["a"] != receive.TenantClassLimits[receive.TenantClassSmall] {
+		t.Errorf("expected the limits of the small class, got %v", small.WriteLimits.TenantsLimits["a"])
+	}
+
+	limits := custom.WriteLimits.TenantsLimits["b"]
+	if limits.RequestLimits != (receive.RequestLimitsConfig{SizeBytesLimit: 100, SeriesLimit: 10, SamplesLimit: 20}) || limits.HeadSeriesLimit != 1000 {
+		t.Errorf("unexpected limits of tenant b: %+v", limits)
+	}
+	if len(custom.WriteLimits.TenantsLimits) != 2 {
+		t.Errorf("expected the limits of both tenants, got %v", custom.WriteLimits.TenantsLimits)
+	}
+}
+
+func TestReceiveLimitsConfigWithTenantClass(t *testing.T) {
+	if _, err := (receive.ReceiveLimitsConfig{}).WithTenantClass("a", "huge"); err == nil || !strings.Contains(err.Error(), `unknown class "huge" for tenant a`) {
+		t.Errorf("expected an error on the unknown class, got %v", err)
+	}
+}
+
+func TestReceiveLimitsConfigWithTenants(t *testing.T) {
+	ids := map[string]string{"a": "1", "b": "2", "c": "3"}
+
+	testCases := map[string]struct {
+		classes  map[string]receive.TenantClass
+		expected map[string]receive.TenantClass
+		err      []string
+	}{
+		"no class": {},
+		"classes keyed by ID": {
+			classes:  map[string]receive.TenantClass{"a": receive.TenantClassSmall, "c": receive.TenantClassLarge},
+			expected: map[string]receive.TenantClass{"1": receive.TenantClassSmall, "3": receive.TenantClassLarge},
+		},
+		"unknown class and tenant": {
+			classes: map[string]receive.TenantClass{"a": "huge", "d": receive.TenantClassSmall},
+			err:     []string{`unknown class "huge" for tenant 1`, "class is given for unknown tenant d"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			limits, err := receive.ReceiveLimitsConfig{}.WithTenants(ids, tc.classes)
+			if len(tc.err) > 0 {
+				for _, expected := range tc.err {
+					if err == nil || !strings.Contains(err.Error(), expected) {
+						t.Errorf("expected error to contain %q, got %v", expected, err)
+					}
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(limits.WriteLimits.TenantsLimits) != len(tc.expected) {
+				t.Fatalf("expected the limits of %d tenants, got %v", len(tc.expected), limits.WriteLimits.TenantsLimits)
+			}
+			for id, class := range tc.expected {
+				if limits.WriteLimits.TenantsLimits[id] != receive.TenantClassLimits[class] {
+					t.Errorf("expected tenant %s to get the limits of the %s class, got %+v", id, class, limits.WriteLimits.TenantsLimits[id])
+				}
+			}
+		})
+	}
+}
+
+func TestReceiveLimitsConfigValidate(t *testing.T) {
+	query := receive.NewMetaMonitoringLimitQuery("ingestor", 1)
+
+	testCases := map[string]struct {
+		limits   receive.ReceiveLimitsConfig
+		expected []string
+	}{
+		"empty": {},
+		"request limits": {
+			limits: receive.ReceiveLimitsConfig{}.WithGlobalLimits(10, "", "").WithDefaultLimits(1<<20, 100, 1000, 0).
+				WithTenantLimits("a", 1<<20, 10, 10, 0),
+		},
+		"head series limits": {
+			limits: receive.ReceiveLimitsConfig{}.WithGlobalLimits(0, "http://prometheus:9090", query).
+				WithTenantLimits("a", 0, 0, 0, 1000),
+		},
+		"negative limits": {
+			limits: receive.ReceiveLimitsConfig{}.WithGlobalLimits(-1, "", "").WithDefaultLimits(-1, 0, 0, 0).
+				WithTenantLimits("a", 0, -2, 0, 0),
+			expected: []string{
+				"max concurrency must not be negative, got -1",
+				"invalid default limits: size bytes limit must not be negative, got -1",
+				"invalid limits of tenant a: series limit must not be negative, got -2",
+			},
+		},
+		"fewer samples than series": {
+			limits:   receive.ReceiveLimitsConfig{}.WithDefaultLimits(0, 100, 10, 0),
+			expected: []string{"samples limit 10 must not be lower than the series limit 100"},
+		},
+		"tenant without name": {
+			limits:   receive.ReceiveLimitsConfig{}.WithTenantLimits("", 1, 1, 1, 0),
+			expected: []string{"tenant limits must have a tenant"},
+		},
+		"head series limits without meta-monitoring": {
+			limits:   receive.ReceiveLimitsConfig{}.WithTenantLimits("a", 0, 0, 0, 1000),
+			expected: []string{"head series limits require a meta-monitoring URL and query"},
+		},
+		"relative meta-monitoring URL": {
+			limits:   receive.ReceiveLimitsConfig{}.WithGlobalLimits(0, "prometheus:9090", query).WithDefaultLimits(0, 0, 0, 1000),
+			expected: []string{`meta-monitoring URL "prometheus:9090" must be an absolute HTTP URL`},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.limits.Validate()
+			if len(tc.expected) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, expected := range tc.expected {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected error to contain %q, got %v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+func TestNewMetaMonitoringLimitQuery(t *testing.T) {
+	testCases := map[string]struct {
+		replicationFactor int
+		expected          string
+	}{
+		"no replication": {
+			replicationFactor: 1,
+			expected:          `sum(prometheus_tsdb_head_series{job="ingestor"}) by (tenant)`,
+		},
+		"unset replication": {
+			expected: `sum(prometheus_tsdb_head_series{job="ingestor"}) by (tenant)`,
+		},
+		"replication": {
+			replicationFactor: 3,
+			expected:          `sum(prometheus_tsdb_head_series{job="ingestor"}) by (tenant) / 3`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if got := receive.NewMetaMonitoringLimitQuery("ingestor", tc.replicationFactor); got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
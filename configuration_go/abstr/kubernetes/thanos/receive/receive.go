@@ -2,17 +2,21 @@ package receive
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
+	"strings"
 	"time"
 
 	"github.com/observatorium/observatorium/configuration_go/kubegen/cmdopt"
 	"github.com/observatorium/observatorium/configuration_go/kubegen/containeropts"
 	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/openshift"
 	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
 	"github.com/observatorium/observatorium/configuration_go/schemas/log"
 	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/reqlogging"
 	trclient "github.com/observatorium/observatorium/configuration_go/schemas/thanos/tracing/client"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/units"
 	monv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/prometheus/prometheus/model/relabel"
 	corev1 "k8s.io/api/core/v1"
@@ -46,6 +50,19 @@ func (l Label) String() string {
 	return l.Key + "=" + l.Value
 }
 
+// UnmarshalText parses a label from its string representation.
+// It implements the encoding.TextUnmarshaler interface that is used by the cmdopt package.
+func (l *Label) UnmarshalText(text []byte) error {
+	key, value, ok := strings.Cut(string(text), "=")
+	if !ok || key == "" {
+		return fmt.Errorf("invalid label %q, expected key=value", text)
+	}
+
+	l.Key = key
+	l.Value = value
+	return nil
+}
+
 // HashringConfig represents a single hashring configuration.
 type HashringConfig struct {
 	Hashring       string            `json:"hashring,omitempty"`
@@ -67,21 +84,42 @@ type HashRingsConfig []HashringConfig
 // String returns a string representation of the HashRingsConfig as JSON.
 // It implements the Stringer interface that is used by the cmdopt package.
 func (h HashRingsConfig) String() string {
-	ret, err := json.Marshal(h)
+	ret, err := h.StringE()
 	if err != nil {
-		panic(err)
+		panic(err.Error())
 	}
+	return ret
+}
 
-	return string(ret)
+// StringE is like String but returns an error instead of panicking when the HashRingsConfig cannot be marshalled.
+func (h HashRingsConfig) StringE() (string, error) {
+	ret, err := json.Marshal(h)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling HashRingsConfig to json: %w", err)
+	}
+	return string(ret), nil
 }
 
 // NewReceiveLimitsConfigFile returns a new receive limits config file option.
+// It panics if the limits are invalid, see NewReceiveLimitsConfigFileE for a non panicking version.
 func NewReceiveLimitsConfigFile(value *ReceiveLimitsConfig) *containeropts.ConfigResourceAsFile {
+	ret, err := NewReceiveLimitsConfigFileE(value)
+	if err != nil {
+		panic(err.Error())
+	}
+	return ret
+}
+
+// NewReceiveLimitsConfigFileE is like NewReceiveLimitsConfigFile but returns an error if the limits are invalid.
+func NewReceiveLimitsConfigFileE(value *ReceiveLimitsConfig) (*containeropts.ConfigResourceAsFile, error) {
 	ret := containeropts.NewConfigResourceAsFile("/etc/thanos/receive-limits", "limits.yaml", "receive-limits", "observatorium-thanos-receive-limits")
 	if value != nil {
+		if err := value.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid receive limits: %w", err)
+		}
 		ret.WithValue(value.String())
 	}
-	return ret
+	return ret, nil
 }
 
 // NewReceiveHashringConfigFile returns a new receive hashring config file option.
@@ -112,19 +150,19 @@ type ReceiveOptions struct {
 	ObjstoreConfig                      string                         `opt:"objstore.config"`
 	ObjstoreConfigFile                  string                         `opt:"objstore.config-file"`
 	ReceiveDefaultTenantID              string                         `opt:"receive.default-tenant-id"`
-	ReceiveGrpcCompression              GrpcCompressionType            `opt:"receive.grpc-compression"`
-	ReceiveHashringsAlgorithm           string                         `opt:"receive.hashrings-algorithm"`
+	ReceiveGrpcCompression              GrpcCompressionType            `opt:"receive.grpc-compression" since:"v0.28.0"`
+	ReceiveHashringsAlgorithm           string                         `opt:"receive.hashrings-algorithm" since:"v0.27.0"`
 	ReceiveHashrings                    *HashRingsConfig               `opt:"receive.hashrings"`
 	ReceiveHashringsFile                containeropts.ContainerUpdater `opt:"receive.hashrings-file"`
 	ReceiveHashringsFileRefreshInterval time.Duration                  `opt:"receive.hashrings-file-refresh-interval"`
-	ReceiveLimitsConfig                 *ReceiveLimitsConfig           `opt:"receive.limits-config"`
-	ReceiveLimitsConfigFile             containeropts.ContainerUpdater `opt:"receive.limits-config-file"`
+	ReceiveLimitsConfig                 *ReceiveLimitsConfig           `opt:"receive.limits-config" since:"v0.29.0"`
+	ReceiveLimitsConfigFile             containeropts.ContainerUpdater `opt:"receive.limits-config-file" since:"v0.29.0"`
 	ReceiveLocalEndpoint                string                         `opt:"receive.local-endpoint"`
-	ReceiveRelabelConfig                *relabel.Config                `opt:"receive.relabel-config"`
-	ReceiveRelabelConfigFile            string                         `opt:"receive.relabel-config-file"`
+	ReceiveRelabelConfig                *relabel.Config                `opt:"receive.relabel-config" since:"v0.27.0"`
+	ReceiveRelabelConfigFile            string                         `opt:"receive.relabel-config-file" since:"v0.27.0"`
 	ReceiveReplicaHeader                string                         `opt:"receive.replica-header"`
 	ReceiveReplicationFactor            int                            `opt:"receive.replication-factor"`
-	ReceiveTenantCertificateField       string                         `opt:"receive.tenant-certificate-field"`
+	ReceiveTenantCertificateField       string                         `opt:"receive.tenant-certificate-field" since:"v0.27.0"`
 	ReceiveTenantHeader                 string                         `opt:"receive.tenant-header"`
 	ReceiveTenantLabelName              string                         `opt:"receive.tenant-label-name"`
 	RemoteWriteAddress                  *net.TCPAddr                   `opt:"remote-write.address"`
@@ -137,16 +175,17 @@ type ReceiveOptions struct {
 	RemoteWriteServerTlsKey             string                         `opt:"remote-write.server-tls-key"`
 	RequestLoggingConfig                *reqlogging.RequestConfig      `opt:"request.logging-config"`
 	RequestLoggingConfigFile            string                         `opt:"request.logging-config-file"`
-	StoreLimitsRequestSamples           int                            `opt:"store.limits.request-samples"`
-	StoreLimitsRequestSeries            int                            `opt:"store.limits.request-series"`
+	StoreLimitsRequestSamples           int                            `opt:"store.limits.request-samples" since:"v0.31.0"`
+	StoreLimitsRequestSeries            int                            `opt:"store.limits.request-series" since:"v0.31.0"`
 	TracingConfig                       *trclient.TracingConfig        `opt:"tracing.config"`
 	TracingConfigFile                   string                         `opt:"tracing.config-file"`
 	TsdbAllowOverlappingBlocks          bool                           `opt:"tsdb.allow-overlapping-blocks"`
 	TsdbMaxExemplars                    int                            `opt:"tsdb.max-exemplars"`
+	TsdbMaxRetentionBytes               units.Bytes                    `opt:"tsdb.max-retention-bytes" since:"v0.34.0"`
 	TsdbNoLockfile                      bool                           `opt:"tsdb.no-lockfile"`
 	TsdbPath                            string                         `opt:"tsdb.path"`
 	TsdbRetention                       time.Duration                  `opt:"tsdb.retention"`
-	TsdbTooFarInFutureTimeWindow        time.Duration                  `opt:"tsdb.too-far-in-future.time-window"`
+	TsdbTooFarInFutureTimeWindow        time.Duration                  `opt:"tsdb.too-far-in-future.time-window" since:"v0.32.0"`
 	TsdbWalCompression                  bool                           `opt:"tsdb.wal-compression"`
 
 	// Extra options not officially supported by the receive.
@@ -219,10 +258,35 @@ func NewRouter(opts *ReceiveOptions, namespace, imageTag string) *Router {
 	}
 }
 
+// Validate checks that the options are supported by the image tag of the router.
+func (r *Router) Validate() error {
+	return r.validate(&r.PodConfig)
+}
+
 // Manifests returns the manifests for the Router.
 func (r *Router) Objects() []runtime.Object {
-	container := r.makeContainer(&r.PodConfig, r.withRouterContainer())
-	return r.DeploymentWorkload.Objects(container)
+	ret, err := r.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// ObjectsE is like Objects but returns an error instead of panicking when the hashrings file is missing,
+// the limits are invalid or the options are not supported by the image tag.
+func (r *Router) ObjectsE() ([]runtime.Object, error) {
+	container, err := r.makeContainer(&r.PodConfig, r.withRouterContainer())
+	if err != nil {
+		return nil, fmt.Errorf("invalid receive router %s: %w", r.Name, err)
+	}
+
+	ret, err := r.DeploymentWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid receive router %s: %w", r.Name, err)
+	}
+
+	return ret, nil
 }
 
 // Ingestor represents a receive component with ingestor configuration.
@@ -230,6 +294,12 @@ func (r *Router) Objects() []runtime.Object {
 type Ingestor struct {
 	baseReceive
 	workload.StatefulSetWorkload
+
+	// Zone is the availability zone of the ingestors, it tags their hashring endpoints. See NewMultiZoneIngestor.
+	Zone string
+	// Headless makes the Service governing the StatefulSet headless, so that each pod gets a DNS record.
+	// It is required to list the hashring endpoints of the ingestor. It is set by NewIngestor.
+	Headless bool
 }
 
 func NewDefaultIngestorOptions() *ReceiveOptions {
@@ -264,13 +334,88 @@ func NewIngestor(opts *ReceiveOptions, namespace, imageTag string) *Ingestor {
 	return &Ingestor{
 		baseReceive:         *baseReceive,
 		StatefulSetWorkload: ssWorkload,
+		Headless:            true,
 	}
 }
 
+// Validate checks that the options are supported by the image tag of the ingestor.
+func (i *Ingestor) Validate() error {
+	return i.validate(&i.PodConfig)
+}
+
 // Manifests returns the manifests for the Ingestor.
 func (i *Ingestor) Objects() []runtime.Object {
-	container := i.makeContainer(&i.PodConfig, i.withIngestorContainer(i.VolumeType, i.VolumeSize))
-	return i.StatefulSetWorkload.Objects(container)
+	ret, err := i.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// ObjectsE is like Objects but returns an error instead of panicking when the data directory is missing,
+// the probes do not target the HTTP port or the options are not supported by the image tag.
+func (i *Ingestor) ObjectsE() ([]runtime.Object, error) {
+	container, err := i.makeContainer(&i.PodConfig, i.withIngestorContainer(i.VolumeType, i.VolumeSize))
+	if err != nil {
+		return nil, fmt.Errorf("invalid receive ingestor %s: %w", i.Name, err)
+	}
+
+	ret, err := i.StatefulSetWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid receive ingestor %s: %w", i.Name, err)
+	}
+
+	if !i.Headless {
+		return ret, nil
+	}
+
+	// Ingestors are addressed individually by the routers, this requires a headless governing Service.
+	service, err := kghelpers.GetObjectE[*corev1.Service](ret, i.Name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid receive ingestor %s: %w", i.Name, err)
+	}
+	service.Spec.ClusterIP = corev1.ClusterIPNone
+
+	return ret, nil
+}
+
+// Endpoints returns the hashring endpoints of the ingestor pods, tagged with the zone of the ingestor.
+// The pods are addressed through the headless Service governing the StatefulSet.
+// It panics if the replicas can't be listed, see EndpointsE.
+func (i *Ingestor) Endpoints() []Endpoint {
+	ret, err := i.EndpointsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// EndpointsE is like Endpoints but returns an error when the ingestor has no replica, its replicas are
+// a template parameter or its Service isn't headless.
+func (i *Ingestor) EndpointsE() ([]Endpoint, error) {
+	if openshift.IsParameterized(int64(i.Replicas)) {
+		return nil, fmt.Errorf("replicas of the ingestor %s are a template parameter, its endpoints can't be listed", i.Name)
+	}
+	if i.Replicas < 1 {
+		return nil, fmt.Errorf("ingestor %s must have at least one replica to list its endpoints, got %d", i.Name, i.Replicas)
+	}
+	if !i.Headless {
+		return nil, fmt.Errorf("service of the ingestor %s must be headless to list its endpoints", i.Name)
+	}
+
+	port := kghelpers.GetPortOrDefault(defaultGRPCPort, i.options.GrpcAddress)
+
+	ret := make([]Endpoint, 0, i.Replicas)
+	for r := int32(0); r < i.Replicas; r++ {
+		ret = append(ret, Endpoint{
+			Address: fmt.Sprintf("%s-%d.%s.%s.svc.cluster.local:%d", i.Name, r, i.Name, i.Namespace, port),
+			AZ:      i.Zone,
+		})
+	}
+
+	return ret, nil
 }
 
 // IngestorRouter represents a receive component with ingestor and router configuration.
@@ -315,17 +460,43 @@ func NewIngestorRouter(opts *ReceiveOptions, namespace, imageTag string) *Ingest
 	}
 }
 
+// Validate checks that the options are supported by the image tag of the ingestor router.
+func (ir *IngestorRouter) Validate() error {
+	return ir.validate(&ir.PodConfig)
+}
+
 // Manifests returns the manifests for the IngestorRouter.
 func (ir *IngestorRouter) Objects() []runtime.Object {
+	ret, err := ir.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// ObjectsE is like Objects but returns an error instead of panicking when the data directory or the hashrings file
+// is missing, the limits are invalid or the options are not supported by the image tag.
+func (ir *IngestorRouter) ObjectsE() ([]runtime.Object, error) {
 	// Set the local endpoint at Manifests time, as it depends on the name of the resource and gRPC port.
 	// This option, in addition to the router and receive options, is required to be set for the IngestorRouter.
-	ir.options.ReceiveLocalEndpoint = fmt.Sprintf("$(NAME).%s.$(NAMESPACE).svc.cluster.local:%d", ir.Name, ir.options.GrpcAddress.Port)
-	container := ir.makeContainer(
+	grpcPort := kghelpers.GetPortOrDefault(defaultGRPCPort, ir.options.GrpcAddress)
+	ir.options.ReceiveLocalEndpoint = fmt.Sprintf("$(NAME).%s.$(NAMESPACE).svc.cluster.local:%d", ir.Name, grpcPort)
+	container, err := ir.makeContainer(
 		&ir.PodConfig,
 		ir.withIngestorContainer(ir.VolumeType, ir.VolumeSize),
 		ir.withRouterContainer(),
 	)
-	return ir.StatefulSetWorkload.Objects(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid receive ingestor router %s: %w", ir.Name, err)
+	}
+
+	ret, err := ir.StatefulSetWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid receive ingestor router %s: %w", ir.Name, err)
+	}
+
+	return ret, nil
 }
 
 // baseReceive is the base struct for all receive components.
@@ -352,6 +523,7 @@ func newBaseReceive(opts *ReceiveOptions, namespace, imageTag string, commonLabe
 		ContainerResources:   kghelpers.NewResourcesRequirements("1", "2", "10Gi", "20Gi"),
 		Affinity:             kghelpers.NewAntiAffinity(nil, labelSelectors),
 		EnableServiceMonitor: true,
+		PodDisruptionBudget:  workload.NewMaxUnavailableBudget(1),
 		LivenessProbe: kghelpers.NewProbe("/-/healthy", probePort, kghelpers.ProbeConfig{
 			FailureThreshold: 8,
 			PeriodSeconds:    30,
@@ -373,23 +545,31 @@ func newBaseReceive(opts *ReceiveOptions, namespace, imageTag string, commonLabe
 }
 
 func (br *baseReceive) withRouterContainer() ContainerOption {
-	return func(container *workload.Container) {
+	return func(container *workload.Container) error {
 		if br.options.ReceiveHashringsFile == nil {
-			panic(`hashrings file is not specified for the statefulset.`)
+			return errors.New("hashrings file is not specified for the statefulset")
 		}
 
-		br.options.ReceiveHashringsFile.Update(container)
+		errs := []error{containeropts.UpdateContainerE(br.options.ReceiveHashringsFile, container)}
 
 		if br.options.ReceiveLimitsConfigFile != nil {
-			br.options.ReceiveLimitsConfigFile.Update(container)
+			errs = append(errs, containeropts.UpdateContainerE(br.options.ReceiveLimitsConfigFile, container))
+		}
+
+		if br.options.ReceiveLimitsConfig != nil {
+			if err := br.options.ReceiveLimitsConfig.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("invalid receive limits: %w", err))
+			}
 		}
+
+		return errors.Join(errs...)
 	}
 }
 
 func (br *baseReceive) withIngestorContainer(volumeType string, volumeSize string) ContainerOption {
-	return func(container *workload.Container) {
+	return func(container *workload.Container) error {
 		if br.options.TsdbPath == "" {
-			panic(`data directory is not specified for the statefulset.`)
+			return errors.New("data directory is not specified for the statefulset")
 		}
 
 		container.VolumeMounts = []corev1.VolumeMount{
@@ -403,13 +583,23 @@ func (br *baseReceive) withIngestorContainer(volumeType string, volumeSize strin
 			Size:  volumeSize,
 			Class: volumeType,
 		})
+
+		return nil
 	}
 }
 
-func (br *baseReceive) makeContainer(podConfig *workload.PodConfig, opts ...ContainerOption) *workload.Container {
+// validate checks that the options are supported by the image tag of the pod.
+func (br *baseReceive) validate(podConfig *workload.PodConfig) error {
+	return cmdopt.CheckVersion(br.options, podConfig.ImageTag)
+}
+
+func (br *baseReceive) makeContainer(podConfig *workload.PodConfig, opts ...ContainerOption) (*workload.Container, error) {
 	httpPort := kghelpers.GetPortOrDefault(defaultHTTPPort, br.options.HttpAddress)
-	kghelpers.CheckProbePort(httpPort, podConfig.LivenessProbe)
-	kghelpers.CheckProbePort(httpPort, podConfig.ReadinessProbe)
+	errs := []error{
+		kghelpers.CheckProbePortE(httpPort, podConfig.LivenessProbe),
+		kghelpers.CheckProbePortE(httpPort, podConfig.ReadinessProbe),
+		br.validate(podConfig),
+	}
 
 	grpcPort := kghelpers.GetPortOrDefault(defaultGRPCPort, br.options.GrpcAddress)
 
@@ -446,10 +636,15 @@ func (br *baseReceive) makeContainer(podConfig *workload.PodConfig, opts ...Cont
 	}
 
 	for _, opt := range opts {
-		opt(ret)
+		errs = append(errs, opt(ret))
 	}
 
-	return ret
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
 }
 
-type ContainerOption func(*workload.Container)
+// ContainerOption updates the container of a receive component.
+type ContainerOption func(*workload.Container) error
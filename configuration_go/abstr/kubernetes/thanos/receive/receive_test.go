@@ -0,0 +1,26 @@
+package receive_test
+
+import (
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/receive"
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestIngestorHeadlessService(t *testing.T) {
+	for _, headless := range []bool{true, false} {
+		ingestor := receive.NewIngestor(nil, "ns", "v0.34.1")
+		ingestor.Headless = headless
+
+		objs, err := ingestor.ObjectsE()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		svc := kghelpers.GetObject[*corev1.Service](objs, ingestor.Name)
+		if (svc.Spec.ClusterIP == corev1.ClusterIPNone) != headless {
+			t.Errorf("expected service %s to be headless: %t, got cluster IP %q", svc.Name, headless, svc.Spec.ClusterIP)
+		}
+	}
+}
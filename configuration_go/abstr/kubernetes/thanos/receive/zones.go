@@ -0,0 +1,194 @@
+package receive
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ZoneLabel is the well-known label holding the availability zone of nodes.
+// It is also set on the ingestor pods to select them per zone.
+const ZoneLabel string = "topology.kubernetes.io/zone"
+
+// MultiZoneIngestor represents ingestors spread over several availability zones.
+// Each zone has its own StatefulSet, whose pods are scheduled on the nodes of the zone and spread over them.
+type MultiZoneIngestor struct {
+	// Name is the name of the objects shared by the zones, such as the PodDisruptionBudget.
+	Name string
+	// Ingestors holds the ingestor of each zone, in the order of the zones.
+	Ingestors []*Ingestor
+	// PodDisruptionBudget limits the disruptions of the ingestors of all the zones at once: a budget per zone
+	// would allow concurrent disruptions in several zones. The zone ingestors must then not have their own.
+	PodDisruptionBudget *workload.PodDisruptionBudgetConfig
+}
+
+// NewMultiZoneIngestor returns ingestors with default configuration, one per zone, sharing the given options.
+// The ingestors are named after the default ingestor name suffixed with their zone.
+func NewMultiZoneIngestor(opts *ReceiveOptions, namespace, imageTag string, zones ...string) *MultiZoneIngestor {
+	if opts == nil {
+		opts = NewDefaultIngestorOptions()
+	}
+
+	defaults := NewIngestor(opts, namespace, imageTag)
+	ret := &MultiZoneIngestor{
+		Name:                defaults.Name,
+		PodDisruptionBudget: defaults.PodDisruptionBudget,
+	}
+	for _, zone := range zones {
+		ingestor := NewIngestor(opts, namespace, imageTag)
+		ingestor.Name = fmt.Sprintf("%s-%s", ingestor.Name, zone)
+		ingestor.PodDisruptionBudget = nil
+		ingestor.SetZone(zone)
+		ret.Ingestors = append(ret.Ingestors, ingestor)
+	}
+
+	return ret
+}
+
+// SetZone pins the ingestor to the given availability zone. Its pods are labeled with the zone, required
+// to run on the nodes of the zone and spread over them. Its hashring endpoints are tagged with the zone.
+func (i *Ingestor) SetZone(zone string) {
+	i.Zone = zone
+	i.CommonLabels[ZoneLabel] = zone
+
+	if i.Affinity == nil {
+		i.Affinity = &corev1.Affinity{}
+	}
+	i.Affinity.NodeAffinity = &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{
+				{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{
+							Key:      ZoneLabel,
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   []string{zone},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	i.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{
+		{
+			MaxSkew:           1,
+			TopologyKey:       workload.HostnameLabel,
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+			LabelSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					workload.NameLabel:     i.CommonLabels[workload.NameLabel],
+					workload.InstanceLabel: i.CommonLabels[workload.InstanceLabel],
+					ZoneLabel:              zone,
+				},
+			},
+		},
+	}
+}
+
+// Validate checks that the zones of the ingestors are set and unique, and that their disruptions are
+// only limited by the budget shared by all the zones.
+func (m *MultiZoneIngestor) Validate() error {
+	if len(m.Ingestors) == 0 {
+		return errors.New("no zone is specified for the multi-zone ingestor")
+	}
+
+	var errs []error
+	if m.PodDisruptionBudget != nil && m.Name == "" {
+		errs = append(errs, errors.New("name is not specified for the pod disruption budget of the multi-zone ingestor"))
+	}
+
+	zones := map[string]bool{}
+	for _, ingestor := range m.Ingestors {
+		if ingestor.Zone == "" {
+			errs = append(errs, fmt.Errorf("zone is not specified for the ingestor %s", ingestor.Name))
+		} else if zones[ingestor.Zone] {
+			errs = append(errs, fmt.Errorf("zone %s is specified for several ingestors", ingestor.Zone))
+		}
+		zones[ingestor.Zone] = true
+
+		if ingestor.PodDisruptionBudget != nil {
+			errs = append(errs, fmt.Errorf("ingestor %s has its own pod disruption budget, set the one of the multi-zone ingestor instead", ingestor.Name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Objects returns the manifests of the ingestors of all the zones.
+func (m *MultiZoneIngestor) Objects() []runtime.Object {
+	ret, err := m.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// ObjectsE is like Objects but returns an error instead of panicking when the zones are missing or duplicated,
+// or when the configuration of one of the zone ingestors or of the pod disruption budget is invalid.
+// A single PodDisruptionBudget selects the pods of all the zones.
+func (m *MultiZoneIngestor) ObjectsE() ([]runtime.Object, error) {
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	ret := []runtime.Object{}
+	var errs []error
+	for _, ingestor := range m.Ingestors {
+		objs, err := ingestor.ObjectsE()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		ret = append(ret, objs...)
+	}
+
+	if m.PodDisruptionBudget != nil {
+		labels := maps.Clone(m.Ingestors[0].CommonLabels)
+		delete(labels, ZoneLabel)
+		pdb := &workload.PodDisruptionBudget{
+			MetaConfig:                workload.MetaConfig{Name: m.Name, Namespace: m.Ingestors[0].Namespace, Labels: labels},
+			PodDisruptionBudgetConfig: *m.PodDisruptionBudget,
+		}
+		obj, err := pdb.ObjectE()
+		errs = append(errs, err)
+		ret = append(ret, obj)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// Endpoints returns the hashring endpoints of the ingestors of all the zones, tagged with their zone.
+// It panics if the endpoints of an ingestor can't be listed, see Ingestor.EndpointsE.
+func (m *MultiZoneIngestor) Endpoints() []Endpoint {
+	ret := []Endpoint{}
+	for _, ingestor := range m.Ingestors {
+		ret = append(ret, ingestor.Endpoints()...)
+	}
+
+	return ret
+}
+
+// HashRingsConfig returns a hashring made of the ingestors of all the zones, for the given tenants.
+// It uses the ketama algorithm, which places the replicas of a series in distinct zones.
+// The replication factor of the routers must then not exceed the number of zones.
+// It returns an error if the endpoints of an ingestor can't be listed.
+func (m *MultiZoneIngestor) HashRingsConfig(hashring string, tenants ...string) (HashRingsConfig, error) {
+	return NewStaticHashRingsConfig(HashringAssignment{
+		Hashring:  hashring,
+		Tenants:   tenants,
+		Ingestors: m.Ingestors,
+		Algorithm: HashRingAlgorithmKetama,
+	})
+}
@@ -0,0 +1,204 @@
+package receive_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/receive"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestSetZone(t *testing.T) {
+	ingestor := receive.NewIngestor(nil, "ns", "v0.34.1")
+	ingestor.SetZone("eu-west-1a")
+
+	sts := ingestorStatefulSet(t, ingestor)
+	if sts.Spec.Template.Labels[receive.ZoneLabel] != "eu-west-1a" {
+		t.Errorf("expected pods to be labeled with the zone, got %v", sts.Spec.Template.Labels)
+	}
+
+	affinity := sts.Spec.Template.Spec.Affinity
+	if affinity.PodAntiAffinity == nil {
+		t.Error("expected the default anti-affinity to be kept")
+	}
+
+	terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) != 1 || len(terms[0].MatchExpressions) != 1 {
+		t.Fatalf("expected a single node selector requirement, got %v", terms)
+	}
+	req := terms[0].MatchExpressions[0]
+	if req.Key != receive.ZoneLabel || req.Operator != corev1.NodeSelectorOpIn || len(req.Values) != 1 || req.Values[0] != "eu-west-1a" {
+		t.Errorf("expected the pods to be required in the zone, got %v", req)
+	}
+
+	spread := sts.Spec.Template.Spec.TopologySpreadConstraints
+	if len(spread) != 1 || spread[0].TopologyKey != workload.HostnameLabel {
+		t.Fatalf("expected the pods to be spread over the nodes, got %v", spread)
+	}
+	selector := labels.SelectorFromSet(spread[0].LabelSelector.MatchLabels)
+	if !selector.Matches(labels.Set(sts.Spec.Template.Labels)) {
+		t.Errorf("expected the topology spread constraint to select the pods, got %v", spread[0].LabelSelector)
+	}
+	if spread[0].LabelSelector.MatchLabels[receive.ZoneLabel] != "eu-west-1a" {
+		t.Errorf("expected the topology spread constraint to select the pods of the zone only, got %v", spread[0].LabelSelector)
+	}
+
+	endpoints := ingestor.Endpoints()
+	if len(endpoints) != 1 || endpoints[0].AZ != "eu-west-1a" {
+		t.Errorf("expected the endpoints to be tagged with the zone, got %v", endpoints)
+	}
+}
+
+func TestMultiZoneIngestor(t *testing.T) {
+	zones := receive.NewMultiZoneIngestor(nil, "ns", "v0.34.1", "a", "b", "c")
+	for _, ingestor := range zones.Ingestors {
+		ingestor.Replicas = 2
+	}
+
+	objs, err := zones.ObjectsE()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pdbs := []*policyv1.PodDisruptionBudget{}
+	statefulSets := []*appsv1.StatefulSet{}
+	for _, obj := range objs {
+		switch o := obj.(type) {
+		case *policyv1.PodDisruptionBudget:
+			pdbs = append(pdbs, o)
+		case *appsv1.StatefulSet:
+			statefulSets = append(statefulSets, o)
+		case *corev1.Service:
+			if o.Spec.ClusterIP != corev1.ClusterIPNone {
+				t.Errorf("expected service %s to be headless", o.Name)
+			}
+		}
+	}
+
+	if len(pdbs) != 1 || pdbs[0].Name != "observatorium-thanos-receive-ingestor" {
+		t.Fatalf("expected a single pod disruption budget for all the zones, got %v", pdbs)
+	}
+	if pdbs[0].Spec.MaxUnavailable == nil || pdbs[0].Spec.MaxUnavailable.IntValue() != 1 {
+		t.Errorf("expected a single unavailable ingestor across the zones, got %v", pdbs[0].Spec.MaxUnavailable)
+	}
+
+	selector := labels.SelectorFromSet(pdbs[0].Spec.Selector.MatchLabels)
+	if len(statefulSets) != 3 {
+		t.Fatalf("expected a statefulset per zone, got %d", len(statefulSets))
+	}
+	for _, sts := range statefulSets {
+		if !selector.Matches(labels.Set(sts.Spec.Template.Labels)) {
+			t.Errorf("expected the pod disruption budget to select the pods of %s, got %v", sts.Name, pdbs[0].Spec.Selector)
+		}
+	}
+
+	hashrings, err := zones.HashRingsConfig("default", "tenant")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(hashrings) != 1 || hashrings[0].Algorithm != receive.HashRingAlgorithmKetama {
+		t.Fatalf("expected a single ketama hashring, got %v", hashrings)
+	}
+	expected := []string{"a", "a", "b", "b", "c", "c"}
+	if len(hashrings[0].Endpoints) != len(expected) {
+		t.Fatalf("expected %d endpoints, got %v", len(expected), hashrings[0].Endpoints)
+	}
+	for i, endpoint := range hashrings[0].Endpoints {
+		if endpoint.AZ != expected[i] || !strings.HasPrefix(endpoint.Address, "observatorium-thanos-receive-ingestor-"+expected[i]+"-") {
+			t.Errorf("expected endpoint %d in zone %s, got %v", i, expected[i], endpoint)
+		}
+	}
+}
+
+func TestMultiZoneIngestorValidate(t *testing.T) {
+	testCases := map[string]struct {
+		zones    func() *receive.MultiZoneIngestor
+		expected []string
+	}{
+		"valid": {
+			zones: func() *receive.MultiZoneIngestor { return receive.NewMultiZoneIngestor(nil, "ns", "v0.34.1", "a", "b") },
+		},
+		"no zone": {
+			zones:    func() *receive.MultiZoneIngestor { return receive.NewMultiZoneIngestor(nil, "ns", "v0.34.1") },
+			expected: []string{"no zone is specified for the multi-zone ingestor"},
+		},
+		"duplicate and missing zones": {
+			zones: func() *receive.MultiZoneIngestor {
+				ret := receive.NewMultiZoneIngestor(nil, "ns", "v0.34.1", "a", "a", "b")
+				ret.Ingestors[2].Zone = ""
+				return ret
+			},
+			expected: []string{
+				"zone a is specified for several ingestors",
+				"zone is not specified for the ingestor observatorium-thanos-receive-ingestor-b",
+			},
+		},
+		"pod disruption budget per zone": {
+			zones: func() *receive.MultiZoneIngestor {
+				ret := receive.NewMultiZoneIngestor(nil, "ns", "v0.34.1", "a", "b")
+				ret.Ingestors[1].PodDisruptionBudget = workload.NewMaxUnavailableBudget(1)
+				return ret
+			},
+			expected: []string{"ingestor observatorium-thanos-receive-ingestor-b has its own pod disruption budget"},
+		},
+		"unnamed pod disruption budget": {
+			zones: func() *receive.MultiZoneIngestor {
+				ret := receive.NewMultiZoneIngestor(nil, "ns", "v0.34.1", "a")
+				ret.Name = ""
+				return ret
+			},
+			expected: []string{"name is not specified for the pod disruption budget"},
+		},
+		"invalid pod disruption budget": {
+			zones: func() *receive.MultiZoneIngestor {
+				ret := receive.NewMultiZoneIngestor(nil, "ns", "v0.34.1", "a")
+				ret.PodDisruptionBudget = &workload.PodDisruptionBudgetConfig{}
+				return ret
+			},
+			expected: []string{"exactly one of minAvailable and maxUnavailable must be set"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := tc.zones().ObjectsE()
+			if len(tc.expected) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, expected := range tc.expected {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected error to contain %q, got %v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+func ingestorStatefulSet(t *testing.T, ingestor *receive.Ingestor) *appsv1.StatefulSet {
+	t.Helper()
+
+	objs, err := ingestor.ObjectsE()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, obj := range objs {
+		if sts, ok := obj.(*appsv1.StatefulSet); ok {
+			return sts
+		}
+	}
+
+	t.Fatal("no statefulset generated")
+	return nil
+}
@@ -1,6 +1,8 @@
 package ruler
 
 import (
+	"errors"
+	"fmt"
 	"net"
 
 	"github.com/observatorium/observatorium/configuration_go/kubegen/cmdopt"
@@ -19,12 +21,25 @@ const (
 )
 
 // NewRulesObjstoreConfigFile creates a new ConfigFile option for the rules-objstore configuration file.
+// It panics if the objstore config is invalid, see NewRulesObjstoreConfigFileE for a non panicking version.
 func NewRulesObjstoreConfigFile(value *objstore.BucketConfig) *containeropts.ConfigResourceAsFile {
+	ret, err := NewRulesObjstoreConfigFileE(value)
+	if err != nil {
+		panic(err.Error())
+	}
+	return ret
+}
+
+// NewRulesObjstoreConfigFileE is like NewRulesObjstoreConfigFile but returns an error if the objstore config is invalid.
+func NewRulesObjstoreConfigFileE(value *objstore.BucketConfig) (*containeropts.ConfigResourceAsFile, error) {
 	ret := containeropts.NewConfigResourceAsFile("/etc/rules-objstore/objstore", "config.yaml", "objstore", "observatorium-rules-objstore")
 	if value != nil {
+		if err := value.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid objstore config: %w", err)
+		}
 		ret.WithValue(value.String())
 	}
-	return ret
+	return ret, nil
 }
 
 type RulesObjstoreOptions struct {
@@ -107,15 +122,40 @@ func NewRulesObjstore(opts *RulesObjstoreOptions, namespace, imageTag string) *R
 	}
 }
 
+// Objects returns the manifests for the rules objstore.
+// It panics if the configuration is invalid, see ObjectsE.
 func (r *RulesObjstoreDeployment) Objects() []runtime.Object {
-	container := r.makeContainer()
-	return r.DeploymentWorkload.Objects(container)
+	ret, err := r.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
 }
 
-func (r *RulesObjstoreDeployment) makeContainer() *workload.Container {
+// ObjectsE is like Objects but returns an error instead of panicking when a probe does not target the internal port.
+func (r *RulesObjstoreDeployment) ObjectsE() ([]runtime.Object, error) {
+	container, err := r.makeContainer()
+	if err != nil {
+		return nil, fmt.Errorf("invalid rules objstore %s: %w", r.Name, err)
+	}
+
+	ret, err := r.DeploymentWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rules objstore %s: %w", r.Name, err)
+	}
+
+	return ret, nil
+}
+
+func (r *RulesObjstoreDeployment) makeContainer() (*workload.Container, error) {
 	internalPort := kghelpers.GetPortOrDefault(defaultInternalPort, r.options.WebInternalListen)
-	kghelpers.CheckProbePort(internalPort, r.LivenessProbe)
-	kghelpers.CheckProbePort(internalPort, r.ReadinessProbe)
+	if err := errors.Join(
+		kghelpers.CheckProbePortE(internalPort, r.LivenessProbe),
+		kghelpers.CheckProbePortE(internalPort, r.ReadinessProbe),
+	); err != nil {
+		return nil, err
+	}
 
 	publicPort := kghelpers.GetPortOrDefault(defaultPublicPort, r.options.WebListen)
 
@@ -146,8 +186,10 @@ func (r *RulesObjstoreDeployment) makeContainer() *workload.Container {
 	}
 
 	if r.options.ObjstoreConfigFile != nil {
-		r.options.ObjstoreConfigFile.Update(ret)
+		if err := containeropts.UpdateContainerE(r.options.ObjstoreConfigFile, ret); err != nil {
+			return nil, err
+		}
 	}
 
-	return ret
+	return ret, nil
 }
@@ -2,6 +2,7 @@ package ruler
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v2"
@@ -14,11 +15,20 @@ type AlertingConfig struct {
 // String returns a string representation of the AlertingConfig as YAML.
 // We use "gopkg.in/yaml.v2" instead of "github.com/ghodss/yaml" for correct formatting of this config.
 func (a AlertingConfig) String() string {
+	ret, err := a.StringE()
+	if err != nil {
+		panic(err.Error())
+	}
+	return ret
+}
+
+// StringE is like String but returns an error instead of panicking when the AlertingConfig cannot be marshalled.
+func (a AlertingConfig) StringE() (string, error) {
 	ret, err := yaml.Marshal(a)
 	if err != nil {
-		panic(fmt.Sprintf("error mashalling DownstreamTripperConfig to yaml: %v", err))
+		return "", fmt.Errorf("error marshalling AlertingConfig to yaml: %w", err)
 	}
-	return string(ret)
+	return string(ret), nil
 }
 
 // AlertmanagerConfig represents a client to a cluster of Alertmanager endpoints.
@@ -96,3 +106,16 @@ type Label struct {
 func (l Label) String() string {
 	return l.Key + "=" + l.Value
 }
+
+// UnmarshalText parses a label from its string representation.
+// It implements the encoding.TextUnmarshaler interface that is used by the cmdopt package.
+func (l *Label) UnmarshalText(text []byte) error {
+	key, value, ok := strings.Cut(string(text), "=")
+	if !ok || key == "" {
+		return fmt.Errorf("invalid label %q, expected key=value", text)
+	}
+
+	l.Key = key
+	l.Value = value
+	return nil
+}
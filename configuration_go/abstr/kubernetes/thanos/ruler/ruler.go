@@ -1,6 +1,8 @@
 package ruler
 
 import (
+	"errors"
+	"fmt"
 	"net"
 	"path/filepath"
 	"strings"
@@ -12,6 +14,7 @@ import (
 	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
 	"github.com/observatorium/observatorium/configuration_go/schemas/log"
 	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/reqlogging"
 	trclient "github.com/observatorium/observatorium/configuration_go/schemas/thanos/tracing/client"
 	monv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/prometheus/prometheus/model/relabel"
@@ -57,6 +60,33 @@ func NewAlertmanagersConfigFile(value *AlertingConfig) *containeropts.ConfigReso
 	return ret
 }
 
+// NewQueryConfigFile returns a new query config file option, holding the YAML configuration of the query APIs.
+func NewQueryConfigFile(value string) *containeropts.ConfigResourceAsFile {
+	ret := containeropts.NewConfigResourceAsFile("/etc/thanos/query", "config.yaml", "query", "observatorium-rule-query")
+	if value != "" {
+		ret.WithValue(value)
+	}
+	return ret
+}
+
+// NewRemoteWriteConfigFile returns a new remote write config file option, holding the YAML remote write configuration.
+func NewRemoteWriteConfigFile(value string) *containeropts.ConfigResourceAsFile {
+	ret := containeropts.NewConfigResourceAsFile("/etc/thanos/remote-write", "config.yaml", "remote-write", "observatorium-rule-remote-write")
+	if value != "" {
+		ret.WithValue(value)
+	}
+	return ret
+}
+
+// NewRequestLoggingConfigFile returns a new request logging config file option.
+func NewRequestLoggingConfigFile(value *reqlogging.RequestConfig) *containeropts.ConfigResourceAsFile {
+	ret := containeropts.NewConfigResourceAsFile("/etc/thanos/request-logging", "config.yaml", "request-logging", "observatorium-rule-request-logging")
+	if value != nil {
+		ret.WithValue(value.String())
+	}
+	return ret
+}
+
 // NewTracingConfigFile returns a new tracing config file.
 func NewTracingConfigFile(value *trclient.TracingConfig) *containeropts.ConfigResourceAsFile {
 	ret := containeropts.NewConfigResourceAsFile("/etc/thanos/tracing", "config.yaml", "tracing", "observatorium-rule-tracing")
@@ -66,13 +96,26 @@ func NewTracingConfigFile(value *trclient.TracingConfig) *containeropts.ConfigRe
 	return ret
 }
 
-// NewObjstoreConfigFile returns a new alertRelabelConfigFile option
+// NewObjstoreConfigFile returns a new objstore config file option.
+// It panics if the objstore config is invalid, see NewObjstoreConfigFileE for a non panicking version.
 func NewObjstoreConfigFile(value *objstore.BucketConfig) *containeropts.ConfigResourceAsFile {
+	ret, err := NewObjstoreConfigFileE(value)
+	if err != nil {
+		panic(err.Error())
+	}
+	return ret
+}
+
+// NewObjstoreConfigFileE is like NewObjstoreConfigFile but returns an error if the objstore config is invalid.
+func NewObjstoreConfigFileE(value *objstore.BucketConfig) (*containeropts.ConfigResourceAsFile, error) {
 	ret := containeropts.NewConfigResourceAsFile("/etc/thanos/objstore", "config.yaml", "objstore", "observatorium-rule-objstore")
 	if value != nil {
+		if err := value.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid objstore config: %w", err)
+		}
 		ret.WithValue(value.String())
 	}
-	return ret
+	return ret, nil
 }
 
 type RuleFileOption struct {
@@ -99,8 +142,10 @@ func (r RuleFileOption) String() string {
 }
 
 type RulerOptions struct {
-	AlertLabelDrop             []string                       `opt:"alert.label-drop"`
-	AlertQeuryTemplate         string                         `opt:"alert.query-template"`
+	AlertLabelDrop     []string `opt:"alert.label-drop"`
+	AlertQueryTemplate string   `opt:"alert.query-template" since:"v0.33.0"`
+	// Deprecated: use AlertQueryTemplate, which takes precedence if both are set.
+	AlertQeuryTemplate         string
 	AlertQueryUrl              string                         `opt:"alert.query-url"`
 	AlertRelabelConfig         *relabel.Config                `opt:"alert.relabel-config"`
 	AlertRelabelConfigFile     containeropts.ContainerUpdater `opt:"alert.relabel-config-file"`
@@ -111,11 +156,12 @@ type RulerOptions struct {
 	AlertmanagersUrl           []string                       `opt:"alertmanagers.url"`
 	DataDir                    string                         `opt:"data-dir"`
 	EvalInterval               time.Duration                  `opt:"eval-interval"`
-	ForGracePeriod             time.Duration                  `opt:"for-grace-period"`
-	ForOutageTolerance         time.Duration                  `opt:"for-outage-tolerance"`
+	ForGracePeriod             time.Duration                  `opt:"for-grace-period" since:"v0.30.0"`
+	ForOutageTolerance         time.Duration                  `opt:"for-outage-tolerance" since:"v0.30.0"`
 	GrpcAddress                *net.TCPAddr                   `opt:"grpc-address"`
 	GrpcGracePeriod            time.Duration                  `opt:"grpc-grace-period"`
-	GrpcServerMaxConnectionAge time.Duration                  `opt:"grpc-server-max-connection-age"`
+	GrpcQueryEndpoint          []string                       `opt:"grpc-query-endpoint" since:"v0.34.0"`
+	GrpcServerMaxConnectionAge time.Duration                  `opt:"grpc-server-max-connection-age" since:"v0.32.0"`
 	GrpcServerTlsCert          string                         `opt:"grpc-server-tls-cert"`
 	GrpcServerTlsClientCa      string                         `opt:"grpc-server-tls-client-ca"`
 	GrpcServerTlsKey           string                         `opt:"grpc-server-tls-key"`
@@ -129,26 +175,26 @@ type RulerOptions struct {
 	ObjstoreConfig             string                         `opt:"objstore.config"`
 	ObjstoreConfigFile         containeropts.ContainerUpdater `opt:"objstore.config-file"`
 	Query                      []string                       `opt:"query"`
-	QueryConfig                string                         `opt:"query.config"`      //todo
-	QueryConfigFile            string                         `opt:"query.config-file"` //todo
-	QueryDefaultStep           time.Duration                  `opt:"query.default-step"`
+	QueryConfig                string                         `opt:"query.config"`
+	QueryConfigFile            containeropts.ContainerUpdater `opt:"query.config-file"`
+	QueryDefaultStep           time.Duration                  `opt:"query.default-step" since:"v0.30.0"`
 	QueryHttpMethod            QueryHttpMethod                `opt:"query.http-method"`
 	QuerySdDnsInterval         time.Duration                  `opt:"query.sd-dns-interval"`
 	QuerySdFiles               []string                       `opt:"query.sd-files"`
 	QuerySdInterval            time.Duration                  `opt:"query.sd-interval"`
-	RemoteWriteConfig          string                         `opt:"remote-write.config"`         //todo
-	RemoteWriteConfigFile      string                         `opt:"remote-write.config-file"`    //todo
-	RequestLoggingConfig       string                         `opt:"request.logging-config"`      //todo
-	RequestLoggingConfigFile   string                         `opt:"request.logging-config-file"` //todo
+	RemoteWriteConfig          string                         `opt:"remote-write.config"`
+	RemoteWriteConfigFile      containeropts.ContainerUpdater `opt:"remote-write.config-file"`
+	RequestLoggingConfig       *reqlogging.RequestConfig      `opt:"request.logging-config"`
+	RequestLoggingConfigFile   containeropts.ContainerUpdater `opt:"request.logging-config-file"`
 	ResendDelay                time.Duration                  `opt:"resend-delay"`
-	RestoreIgnoredLabel        []string                       `opt:"restore-ignored-label"`
+	RestoreIgnoredLabel        []string                       `opt:"restore-ignored-label" since:"v0.30.0"`
 	RuleFile                   []RuleFileOption               `opt:"rule-file"`
-	ShipperMetaFileName        string                         `opt:"shipper.meta-file-name"`
+	ShipperMetaFileName        string                         `opt:"shipper.meta-file-name" since:"v0.34.0"`
 	ShipperUploadCompacted     bool                           `opt:"shipper.upload-compacted,noval"`
-	StoreLimitsRequestSamples  int                            `opt:"store.limits.request-samples"`
-	StoreLimitsRequestSeries   int                            `opt:"store.limits.request-series"`
-	TracingConfig              *trclient.TracingConfig        `opt:"tracing.config"`      //todo
-	TracingConfigFile          containeropts.ContainerUpdater `opt:"tracing.config-file"` //todo
+	StoreLimitsRequestSamples  int                            `opt:"store.limits.request-samples" since:"v0.31.0"`
+	StoreLimitsRequestSeries   int                            `opt:"store.limits.request-series" since:"v0.31.0"`
+	TracingConfig              *trclient.TracingConfig        `opt:"tracing.config"`
+	TracingConfigFile          containeropts.ContainerUpdater `opt:"tracing.config-file"`
 	TsdbBlockDuration          time.Duration                  `opt:"tsdb.block-duration"`
 	TsdbNoLockfile             bool                           `opt:"tsdb.no-lockfile,noval"`
 	TsdbRetention              time.Duration                  `opt:"tsdb.retention"`
@@ -209,6 +255,7 @@ func NewRuler(opts *RulerOptions, namespace, imageTag string) *RulerStatefulSet
 			ContainerResources:   kghelpers.NewResourcesRequirements("500m", "1", "200Mi", "400Mi"),
 			Affinity:             kghelpers.NewAntiAffinity(nil, labelSelectors),
 			EnableServiceMonitor: true,
+			PodDisruptionBudget:  workload.NewMaxUnavailableBudget(1),
 
 			LivenessProbe: kghelpers.NewProbe("/-/healthy", probePort, kghelpers.ProbeConfig{
 				FailureThreshold: 8,
@@ -235,24 +282,62 @@ func NewRuler(opts *RulerOptions, namespace, imageTag string) *RulerStatefulSet
 }
 
 func (r *RulerStatefulSet) Objects() []runtime.Object {
-	container := r.makeContainer()
-	return r.StatefulSetWorkload.Objects(container)
+	ret, err := r.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
 }
 
-func (s *RulerStatefulSet) makeContainer() *workload.Container {
+// ObjectsE is like Objects but returns an error instead of panicking when the data directory is missing,
+// the probes do not target the HTTP port or the options are not supported by the image tag.
+func (r *RulerStatefulSet) ObjectsE() ([]runtime.Object, error) {
+	container, err := r.makeContainer()
+	if err != nil {
+		return nil, fmt.Errorf("invalid ruler %s: %w", r.Name, err)
+	}
+
+	ret, err := r.StatefulSetWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ruler %s: %w", r.Name, err)
+	}
+
+	return ret, nil
+}
+
+// Validate checks that the options are supported by the image tag of the ruler.
+func (s *RulerStatefulSet) Validate() error {
+	return cmdopt.CheckVersion(s.flagOptions(), s.ImageTag)
+}
+
+// flagOptions returns the options rendered as flags, with the deprecated fields moved to their replacement.
+func (s *RulerStatefulSet) flagOptions() *RulerOptions {
+	ret := *s.options
+	if ret.AlertQueryTemplate == "" {
+		ret.AlertQueryTemplate = ret.AlertQeuryTemplate
+	}
+
+	return &ret
+}
+
+func (s *RulerStatefulSet) makeContainer() (*workload.Container, error) {
 	httpPort := kghelpers.GetPortOrDefault(defaultHTTPPort, s.options.HttpAddress)
 	grpcPort := kghelpers.GetPortOrDefault(defaultGRPCPort, s.options.GrpcAddress)
 
-	kghelpers.CheckProbePort(httpPort, s.LivenessProbe)
-	kghelpers.CheckProbePort(httpPort, s.ReadinessProbe)
+	errs := []error{
+		kghelpers.CheckProbePortE(httpPort, s.LivenessProbe),
+		kghelpers.CheckProbePortE(httpPort, s.ReadinessProbe),
+		s.Validate(),
+	}
 
 	if s.options.DataDir == "" {
-		panic(`data directory is not specified for the statefulset.`)
+		errs = append(errs, errors.New("data directory is not specified for the statefulset"))
 	}
 
 	ret := s.ToContainer()
 	ret.Name = "thanos"
-	ret.Args = append([]string{"rule"}, cmdopt.GetOpts(s.options)...)
+	ret.Args = append([]string{"rule"}, cmdopt.GetOpts(s.flagOptions())...)
 	ret.Ports = []corev1.ContainerPort{
 		{
 			Name:          "http",
@@ -288,19 +373,31 @@ func (s *RulerStatefulSet) makeContainer() *workload.Container {
 	}
 
 	if s.options.AlertRelabelConfigFile != nil {
-		s.options.AlertRelabelConfigFile.Update(ret)
+		errs = append(errs, containeropts.UpdateContainerE(s.options.AlertRelabelConfigFile, ret))
 	}
 
 	if s.options.AlertmanagersConfigFile != nil {
-		s.options.AlertmanagersConfigFile.Update(ret)
+		errs = append(errs, containeropts.UpdateContainerE(s.options.AlertmanagersConfigFile, ret))
+	}
+
+	if s.options.QueryConfigFile != nil {
+		errs = append(errs, containeropts.UpdateContainerE(s.options.QueryConfigFile, ret))
+	}
+
+	if s.options.RemoteWriteConfigFile != nil {
+		errs = append(errs, containeropts.UpdateContainerE(s.options.RemoteWriteConfigFile, ret))
+	}
+
+	if s.options.RequestLoggingConfigFile != nil {
+		errs = append(errs, containeropts.UpdateContainerE(s.options.RequestLoggingConfigFile, ret))
 	}
 
 	if s.options.TracingConfigFile != nil {
-		s.options.TracingConfigFile.Update(ret)
+		errs = append(errs, containeropts.UpdateContainerE(s.options.TracingConfigFile, ret))
 	}
 
 	if s.options.ObjstoreConfigFile != nil {
-		s.options.ObjstoreConfigFile.Update(ret)
+		errs = append(errs, containeropts.UpdateContainerE(s.options.ObjstoreConfigFile, ret))
 	}
 
 	for _, ruleFile := range s.options.RuleFile {
@@ -320,5 +417,9 @@ func (s *RulerStatefulSet) makeContainer() *workload.Container {
 		}
 	}
 
-	return ret
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
 }
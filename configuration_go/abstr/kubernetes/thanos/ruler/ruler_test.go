@@ -0,0 +1,98 @@
+package ruler_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/ruler"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/containeropts"
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore/gcs"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore/s3"
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+func TestObjstoreConfigFileE(t *testing.T) {
+	constructors := map[string]func(*objstore.BucketConfig) (*containeropts.ConfigResourceAsFile, error){
+		"ruler":          ruler.NewObjstoreConfigFileE,
+		"rules-objstore": ruler.NewRulesObjstoreConfigFileE,
+	}
+
+	testCases := map[string]struct {
+		config   *objstore.BucketConfig
+		expected string
+	}{
+		"no config": {},
+		"typed config": {
+			config: &objstore.BucketConfig{Type: objstore.S3, Config: s3.Config{Bucket: "b", Endpoint: "e"}},
+		},
+		"untyped config": {
+			config: &objstore.BucketConfig{Type: objstore.S3, Config: map[string]interface{}{"bucket": "b"}},
+		},
+		"mismatched config": {
+			config:   &objstore.BucketConfig{Type: objstore.S3, Config: gcs.Config{Bucket: "b"}},
+			expected: "invalid objstore config: objstore config of type GCS does not match bucket type S3",
+		},
+	}
+
+	for component, newConfigFile := range constructors {
+		for name, tc := range testCases {
+			t.Run(component+"/"+name, func(t *testing.T) {
+				ret, err := newConfigFile(tc.config)
+				if tc.expected == "" {
+					if err != nil || ret == nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					return
+				}
+
+				if err == nil || !strings.Contains(err.Error(), tc.expected) {
+					t.Errorf("expected error to contain %q, got %v", tc.expected, err)
+				}
+			})
+		}
+	}
+}
+
+func TestDeprecatedAlertQueryTemplate(t *testing.T) {
+	testCases := map[string]struct {
+		template, deprecated string
+		expected             string
+	}{
+		"deprecated field": {deprecated: "old", expected: "--alert.query-template=old"},
+		"both fields":      {template: "new", deprecated: "old", expected: "--alert.query-template=new"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			opts := ruler.NewDefaultOptions()
+			opts.AlertQueryTemplate = tc.template
+			opts.AlertQeuryTemplate = tc.deprecated
+
+			objs, err := ruler.NewRuler(opts, "ns", "v0.34.1").ObjectsE()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			args := kghelpers.GetObject[*appsv1.StatefulSet](objs, "").Spec.Template.Spec.Containers[0].Args
+			templates := slices.DeleteFunc(slices.Clone(args), func(arg string) bool {
+				return !strings.HasPrefix(arg, "--alert.query-template=")
+			})
+			if !slices.Equal(templates, []string{tc.expected}) {
+				t.Errorf("expected a single %s arg, got %v", tc.expected, args)
+			}
+			if opts.AlertQueryTemplate != tc.template {
+				t.Errorf("expected the options to be left untouched, got %q", opts.AlertQueryTemplate)
+			}
+		})
+	}
+
+	// The deprecated field is subject to the version check of its replacement.
+	opts := ruler.NewDefaultOptions()
+	opts.AlertQeuryTemplate = "old"
+	if _, err := ruler.NewRuler(opts, "ns", "v0.32.5").ObjectsE(); err == nil || !strings.Contains(err.Error(), "alert.query-template") {
+		t.Errorf("expected a version error, got %v", err)
+	}
+}
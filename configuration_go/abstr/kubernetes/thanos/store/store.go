@@ -1,6 +1,8 @@
 package store
 
 import (
+	"errors"
+	"fmt"
 	"net"
 	"time"
 
@@ -28,49 +30,52 @@ const (
 // StoreOptions represents the options/flags for the store.
 // See https://thanos.io/tip/components/store.md/#flags for details.
 type StoreOptions struct {
-	BlockMetaFetchConcurrency        int                             `opt:"block-meta-fetch-concurrency"`
-	BlockSyncConcurrency             int                             `opt:"block-sync-concurrency"`
-	BucketWebLabel                   string                          `opt:"bucket-web-label"`
-	CacheIndexHeader                 bool                            `opt:"cache-index-header,noval"`
-	ChunkPoolSize                    units.Bytes                     `opt:"chunk-pool-size"`
-	ConsistencyDelay                 time.Duration                   `opt:"consistency-delay"`
-	DataDir                          string                          `opt:"data-dir"`
-	GrpcAddress                      *net.TCPAddr                    `opt:"grpc-address"`
-	GrpcGracePeriod                  time.Duration                   `opt:"grpc-grace-period"`
-	GrpcServerMaxConnectionAge       time.Duration                   `opt:"grpc-server-max-connection-age"`
-	GrpcServerTlsCert                string                          `opt:"grpc-server-tls-cert"`
-	GrpcServerTlsClientCa            string                          `opt:"grpc-server-tls-client-ca"`
-	GrpcServerTlsKey                 string                          `opt:"grpc-server-tls-key"`
-	HttpAddress                      *net.TCPAddr                    `opt:"http-address"`
-	HttpGracePeriod                  time.Duration                   `opt:"http-grace-period"`
-	HttpConfig                       string                          `opt:"http.config"`
-	IgnoreDeletionMarksDelay         time.Duration                   `opt:"ignore-deletion-marks-delay"`
-	IndexCacheSize                   units.Bytes                     `opt:"index-cache-size"`
-	IndexCacheConfig                 *cache.IndexCacheConfig         `opt:"index-cache.config"`
-	IndexCacheConfigFile             string                          `opt:"index-cache.config-file"`
-	LogFormat                        log.Format                      `opt:"log.format"`
-	LogLevel                         log.Level                       `opt:"log.level"`
-	MaxTime                          *thanostime.TimeOrDurationValue `opt:"max-time"`
-	MinTime                          *thanostime.TimeOrDurationValue `opt:"min-time"`
-	ObjstoreConfig                   string                          `opt:"objstore.config"`
-	ObjstoreConfigFile               string                          `opt:"objstore.config-file"`
-	RequestLoggingConfig             *reqlogging.RequestConfig       `opt:"request.logging-config"`
-	RequestLoggingConfigFile         string                          `opt:"request.logging-config-file"`
-	SelectorRelabelConfig            *relabel.Config                 `opt:"selector.relabel-config"`
-	SelectorRelabelConfigFile        string                          `opt:"selector.relabel-config-file"`
-	StoreEnableIndexHeaderLazyReader bool                            `opt:"store.enable-index-header-lazy-reader,noval"`
-	StoreEnableLazyExpandedPostings  bool                            `opt:"store.enable-lazy-expanded-postings,noval"`
-	StoreGrpcDownloadedBytesLimit    units.Bytes                     `opt:"store.grps.downloaded-bytes-limit"`
-	StoreGrpcSeriesMaxConcurrency    int                             `opt:"store.grps.series-max-concurrency"`
-	StoreLimitsRequestSamples        int                             `opt:"store.limits.request-samples"`
-	StoreLimitsRequestSeries         int                             `opt:"store.limits.request-series"`
-	SyncBlockDuration                time.Duration                   `opt:"sync-block-duration"`
-	TracingConfig                    *trclient.TracingConfig         `opt:"tracing.config"`
-	TracingConfigFile                string                          `opt:"tracing.config-file"`
-	WebDisable                       bool                            `opt:"web.disable,noval"`
-	WebDisableCors                   bool                            `opt:"web.disable-cors,noval"`
-	WebExternalPrefix                string                          `opt:"web.external-prefix"`
-	WebPrefixHeader                  string                          `opt:"web.prefix-header"`
+	BlockMetaFetchConcurrency            int                             `opt:"block-meta-fetch-concurrency"`
+	BlockSyncConcurrency                 int                             `opt:"block-sync-concurrency"`
+	BucketWebLabel                       string                          `opt:"bucket-web-label" since:"v0.32.0"`
+	CacheIndexHeader                     bool                            `opt:"cache-index-header,noval" since:"v0.31.0"`
+	ChunkPoolSize                        units.Bytes                     `opt:"chunk-pool-size"`
+	ConsistencyDelay                     time.Duration                   `opt:"consistency-delay"`
+	DataDir                              string                          `opt:"data-dir"`
+	GrpcAddress                          *net.TCPAddr                    `opt:"grpc-address"`
+	GrpcGracePeriod                      time.Duration                   `opt:"grpc-grace-period"`
+	GrpcServerMaxConnectionAge           time.Duration                   `opt:"grpc-server-max-connection-age" since:"v0.32.0"`
+	GrpcServerTlsCert                    string                          `opt:"grpc-server-tls-cert"`
+	GrpcServerTlsClientCa                string                          `opt:"grpc-server-tls-client-ca"`
+	GrpcServerTlsKey                     string                          `opt:"grpc-server-tls-key"`
+	HttpAddress                          *net.TCPAddr                    `opt:"http-address"`
+	HttpGracePeriod                      time.Duration                   `opt:"http-grace-period"`
+	HttpConfig                           string                          `opt:"http.config"`
+	IgnoreDeletionMarksDelay             time.Duration                   `opt:"ignore-deletion-marks-delay"`
+	IndexCacheSize                       units.Bytes                     `opt:"index-cache-size"`
+	IndexCacheConfig                     *cache.IndexCacheConfig         `opt:"index-cache.config"`
+	IndexCacheConfigFile                 string                          `opt:"index-cache.config-file"`
+	LogFormat                            log.Format                      `opt:"log.format"`
+	LogLevel                             log.Level                       `opt:"log.level"`
+	MaxTime                              *thanostime.TimeOrDurationValue `opt:"max-time"`
+	MinTime                              *thanostime.TimeOrDurationValue `opt:"min-time"`
+	ObjstoreConfig                       string                          `opt:"objstore.config"`
+	ObjstoreConfigFile                   string                          `opt:"objstore.config-file"`
+	RequestLoggingConfig                 *reqlogging.RequestConfig       `opt:"request.logging-config"`
+	RequestLoggingConfigFile             string                          `opt:"request.logging-config-file"`
+	SelectorRelabelConfig                *relabel.Config                 `opt:"selector.relabel-config"`
+	SelectorRelabelConfigFile            string                          `opt:"selector.relabel-config-file"`
+	StoreEnableIndexHeaderLazyReader     bool                            `opt:"store.enable-index-header-lazy-reader,noval"`
+	StoreEnableLazyExpandedPostings      bool                            `opt:"store.enable-lazy-expanded-postings,noval" since:"v0.33.0"`
+	StoreGrpcDownloadedBytesLimit        units.Bytes                     `opt:"store.grpc.downloaded-bytes-limit" since:"v0.30.0"`
+	StoreGrpcSeriesMaxConcurrency        int                             `opt:"store.grpc.series-max-concurrency"`
+	StoreGrpcSeriesSampleLimit           int                             `opt:"store.grpc.series-sample-limit"`  // Deprecated: use StoreLimitsRequestSamples.
+	StoreGrpcTouchedSeriesLimit          int                             `opt:"store.grpc.touched-series-limit"` // Deprecated: use StoreLimitsRequestSeries.
+	StoreIndexHeaderLazyDownloadStrategy string                          `opt:"store.index-header-lazy-download-strategy" since:"v0.34.0"`
+	StoreLimitsRequestSamples            int                             `opt:"store.limits.request-samples" since:"v0.31.0"`
+	StoreLimitsRequestSeries             int                             `opt:"store.limits.request-series" since:"v0.31.0"`
+	SyncBlockDuration                    time.Duration                   `opt:"sync-block-duration"`
+	TracingConfig                        *trclient.TracingConfig         `opt:"tracing.config"`
+	TracingConfigFile                    string                          `opt:"tracing.config-file"`
+	WebDisable                           bool                            `opt:"web.disable,noval" since:"v0.29.0"`
+	WebDisableCors                       bool                            `opt:"web.disable-cors,noval"`
+	WebExternalPrefix                    string                          `opt:"web.external-prefix"`
+	WebPrefixHeader                      string                          `opt:"web.prefix-header"`
 
 	// Extra options not officially supported by the store.
 	cmdopt.ExtraOpts
@@ -124,6 +129,7 @@ func NewStore(opts *StoreOptions, namespace, imageTag string) *StoreStatefulSet
 			ContainerResources:   kghelpers.NewResourcesRequirements("500m", "1", "200Mi", "400Mi"),
 			Affinity:             kghelpers.NewAntiAffinity(nil, labelSelectors),
 			EnableServiceMonitor: true,
+			PodDisruptionBudget:  workload.NewMaxUnavailableBudget(1),
 
 			LivenessProbe: kghelpers.NewProbe("/-/healthy", probePort, kghelpers.ProbeConfig{
 				FailureThreshold: 8,
@@ -151,19 +157,47 @@ func NewStore(opts *StoreOptions, namespace, imageTag string) *StoreStatefulSet
 }
 
 func (s *StoreStatefulSet) Objects() []runtime.Object {
-	container := s.makeContainer()
-	return s.StatefulSetWorkload.Objects(container)
+	ret, err := s.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
 }
 
-func (s *StoreStatefulSet) makeContainer() *workload.Container {
+// ObjectsE is like Objects but returns an error instead of panicking when the data directory is missing,
+// the probes do not target the HTTP port or the options are not supported by the image tag.
+func (s *StoreStatefulSet) ObjectsE() ([]runtime.Object, error) {
+	container, err := s.makeContainer()
+	if err != nil {
+		return nil, fmt.Errorf("invalid store %s: %w", s.Name, err)
+	}
+
+	ret, err := s.StatefulSetWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid store %s: %w", s.Name, err)
+	}
+
+	return ret, nil
+}
+
+// Validate checks that the options are supported by the image tag of the store.
+func (s *StoreStatefulSet) Validate() error {
+	return cmdopt.CheckVersion(s.options, s.ImageTag)
+}
+
+func (s *StoreStatefulSet) makeContainer() (*workload.Container, error) {
 	httpPort := kghelpers.GetPortOrDefault(defaultHTTPPort, s.options.HttpAddress)
-	kghelpers.CheckProbePort(httpPort, s.LivenessProbe)
-	kghelpers.CheckProbePort(httpPort, s.ReadinessProbe)
+	errs := []error{
+		kghelpers.CheckProbePortE(httpPort, s.LivenessProbe),
+		kghelpers.CheckProbePortE(httpPort, s.ReadinessProbe),
+		s.Validate(),
+	}
 
 	grpcPort := kghelpers.GetPortOrDefault(defaultGRPCPort, s.options.GrpcAddress)
 
 	if s.options.DataDir == "" {
-		panic(`data directory is not specified for the statefulset.`)
+		errs = append(errs, errors.New("data directory is not specified for the statefulset"))
 	}
 
 	ret := s.ToContainer()
@@ -203,5 +237,9 @@ func (s *StoreStatefulSet) makeContainer() *workload.Container {
 		},
 	}
 
-	return ret
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
 }
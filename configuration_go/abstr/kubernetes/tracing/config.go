@@ -0,0 +1,129 @@
+package tracing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	otelcolschema "github.com/observatorium/observatorium/configuration_go/schemas/otelcol"
+)
+
+// NewDefaultCollectorConfig returns a collector config receiving OTLP traces and forwarding them
+// to a single OTLP gRPC endpoint, e.g. the one returned by JaegerOTLPEndpoint.
+func NewDefaultCollectorConfig(endpoint string) *otelcolschema.Config {
+	ret := newBaseCollectorConfig()
+	ret.Exporters.OTLP = map[string]otelcolschema.OTLPExporter{
+		"backend": newInsecureOTLPExporter(endpoint),
+	}
+	ret.Service.Pipelines["traces"] = otelcolschema.Pipeline{
+		Receivers:  []string{otelcolschema.OTLPType},
+		Processors: []string{otelcolschema.MemoryLimiterType, otelcolschema.BatchType},
+		Exporters:  []string{otelcolschema.ComponentID(otelcolschema.OTLPType, "backend")},
+	}
+
+	return ret
+}
+
+// NewTenantRoutingCollectorConfig returns a collector config routing traces to a backend per tenant.
+// The tenant is read from the TenantHeader set by the Observatorium API.
+// The endpoints map a tenant name to the OTLP gRPC endpoint of its backend.
+func NewTenantRoutingCollectorConfig(endpoints map[string]string) *otelcolschema.Config {
+	if len(endpoints) == 0 {
+		panic("at least one tenant endpoint must be provided to route traces")
+	}
+
+	tenants := make([]string, 0, len(endpoints))
+	for tenant := range endpoints {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+
+	ret := newBaseCollectorConfig()
+	ret.Exporters.OTLP = make(map[string]otelcolschema.OTLPExporter, len(endpoints))
+	routing := otelcolschema.RoutingProcessor{
+		FromAttribute:   TenantHeader,
+		AttributeSource: "context",
+	}
+	exporters := make([]string, 0, len(tenants))
+
+	for _, tenant := range tenants {
+		name := normalizeName(tenant)
+		id := otelcolschema.ComponentID(otelcolschema.OTLPType, name)
+		ret.Exporters.OTLP[name] = newInsecureOTLPExporter(endpoints[tenant])
+		routing.Table = append(routing.Table, otelcolschema.RoutingEntry{
+			Value:     tenant,
+			Exporters: []string{id},
+		})
+		exporters = append(exporters, id)
+	}
+
+	// Request headers are only visible to the routing processor when included in the context.
+	otlp := ret.Receivers.OTLP[""]
+	otlp.Protocols.GRPC.IncludeMetadata = true
+	otlp.Protocols.HTTP.IncludeMetadata = true
+
+	ret.Processors.Routing = map[string]otelcolschema.RoutingProcessor{"": routing}
+	ret.Service.Pipelines["traces"] = otelcolschema.Pipeline{
+		Receivers: []string{otelcolschema.OTLPType},
+		// The routing processor must be the last one of the pipeline.
+		Processors: []string{otelcolschema.MemoryLimiterType, otelcolschema.BatchType, otelcolschema.RoutingType},
+		Exporters:  exporters,
+	}
+
+	return ret
+}
+
+func newBaseCollectorConfig() *otelcolschema.Config {
+	return &otelcolschema.Config{
+		Receivers: otelcolschema.Receivers{
+			OTLP: map[string]otelcolschema.OTLPReceiver{
+				"": {
+					Protocols: otelcolschema.OTLPProtocols{
+						GRPC: &otelcolschema.ServerConfig{Endpoint: fmt.Sprintf("0.0.0.0:%d", otlpGRPCPort)},
+						HTTP: &otelcolschema.ServerConfig{Endpoint: fmt.Sprintf("0.0.0.0:%d", otlpHTTPPort)},
+					},
+				},
+			},
+		},
+		Processors: otelcolschema.Processors{
+			Batch: map[string]otelcolschema.BatchProcessor{
+				"": {},
+			},
+			MemoryLimiter: map[string]otelcolschema.MemoryLimiterProcessor{
+				"": {
+					CheckInterval:        time.Second,
+					LimitPercentage:      75,
+					SpikeLimitPercentage: 15,
+				},
+			},
+		},
+		Extensions: otelcolschema.Extensions{
+			HealthCheck: map[string]otelcolschema.HealthCheckExtension{
+				"": {Endpoint: fmt.Sprintf("0.0.0.0:%d", healthCheckPort)},
+			},
+		},
+		Service: otelcolschema.ServiceConfig{
+			Extensions: []string{otelcolschema.HealthCheckType},
+			Pipelines:  map[string]otelcolschema.Pipeline{},
+			Telemetry: &otelcolschema.TelemetryConfig{
+				Metrics: &otelcolschema.TelemetryMetricsConfig{
+					Level:   "basic",
+					Address: fmt.Sprintf("0.0.0.0:%d", collectorMetricsPort),
+				},
+			},
+		},
+	}
+}
+
+func newInsecureOTLPExporter(endpoint string) otelcolschema.OTLPExporter {
+	return otelcolschema.OTLPExporter{
+		Endpoint: endpoint,
+		TLS:      &otelcolschema.TLSConfig{Insecure: true},
+	}
+}
+
+// normalizeName makes a tenant name usable in Kubernetes object names.
+func normalizeName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+}
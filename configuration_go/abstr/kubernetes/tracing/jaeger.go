@@ -0,0 +1,237 @@
+package tracing
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/observatorium/observatorium/configuration_go/kubegen/cmdopt"
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	"github.com/observatorium/observatorium/configuration_go/schemas/log"
+	monv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	jaegerQueryPort     int = 16686
+	jaegerCollectorPort int = 14250
+	jaegerAdminPort     int = 14269
+
+	// JaegerName is the name of the default Jaeger workload and service.
+	JaegerName string = "observatorium-jaeger"
+)
+
+// JaegerTenantName returns the name of the Jaeger instance dedicated to the given tenant.
+func JaegerTenantName(tenant string) string {
+	return fmt.Sprintf("%s-%s", JaegerName, normalizeName(tenant))
+}
+
+// JaegerOTLPEndpoint returns the OTLP gRPC endpoint of the Jaeger instance with the given name.
+// If namespace is empty, the short service name is used.
+func JaegerOTLPEndpoint(name, namespace string) string {
+	if namespace == "" {
+		return fmt.Sprintf("%s:%d", name, otlpGRPCPort)
+	}
+
+	return fmt.Sprintf("%s.%s.svc.cluster.local:%d", name, namespace, otlpGRPCPort)
+}
+
+// JaegerQueryEndpoint returns the query HTTP endpoint of the Jaeger instance with the given name.
+// It can be used as the traces read endpoint of the Observatorium API.
+func JaegerQueryEndpoint(name, namespace string) string {
+	return fmt.Sprintf("http://%s.%s.svc.cluster.local:%d", name, namespace, jaegerQueryPort)
+}
+
+// SpanStorageType is the storage backend used by Jaeger.
+type SpanStorageType string
+
+const (
+	SpanStorageMemory        SpanStorageType = "memory"
+	SpanStorageBadger        SpanStorageType = "badger"
+	SpanStorageElasticsearch SpanStorageType = "elasticsearch"
+)
+
+// JaegerOptions represents the options/flags of the Jaeger all-in-one binary.
+// See https://www.jaegertracing.io/docs/latest/cli/ for details.
+type JaegerOptions struct {
+	AdminHTTPHostPort    *net.TCPAddr `opt:"admin.http.host-port"`
+	CollectorOTLPEnabled bool         `opt:"collector.otlp.enabled"`
+	LogLevel             log.Level    `opt:"log-level"`
+	MemoryMaxTraces      int          `opt:"memory.max-traces"`
+	QueryBasePath        string       `opt:"query.base-path"`
+
+	// Extra options not officially supported.
+	cmdopt.ExtraOpts
+}
+
+// NewDefaultJaegerOptions returns the default Jaeger options.
+func NewDefaultJaegerOptions() *JaegerOptions {
+	return &JaegerOptions{
+		CollectorOTLPEnabled: true,
+		LogLevel:             log.LevelWarn,
+		MemoryMaxTraces:      100000,
+	}
+}
+
+// JaegerDeployment represents a Jaeger all-in-one instance, used as traces backend.
+// The span storage is selected with SpanStorageType. Storage specific flags and credentials
+// must be set with the ExtraOpts and the Env of the PodConfig.
+type JaegerDeployment struct {
+	options *JaegerOptions
+
+	SpanStorageType SpanStorageType
+
+	workload.DeploymentWorkload
+}
+
+// NewJaeger returns a new Jaeger all-in-one deployment with default configuration.
+func NewJaeger(opts *JaegerOptions, namespace, imageTag string) *JaegerDeployment {
+	if opts == nil {
+		opts = NewDefaultJaegerOptions()
+	}
+
+	commonLabels := map[string]string{
+		workload.NameLabel:      "jaeger",
+		workload.InstanceLabel:  "observatorium",
+		workload.PartOfLabel:    "observatorium",
+		workload.ComponentLabel: "tracing-backend",
+		workload.VersionLabel:   imageTag,
+	}
+
+	labelSelectors := map[string]string{
+		workload.NameLabel:     commonLabels[workload.NameLabel],
+		workload.InstanceLabel: commonLabels[workload.InstanceLabel],
+	}
+
+	probeCfg := kghelpers.ProbeConfig{
+		FailureThreshold: 3,
+		PeriodSeconds:    10,
+		TimeoutSeconds:   1,
+	}
+
+	return &JaegerDeployment{
+		options:         opts,
+		SpanStorageType: SpanStorageMemory,
+		DeploymentWorkload: workload.DeploymentWorkload{
+			// The memory storage cannot be shared between replicas.
+			Replicas: 1,
+			PodConfig: workload.PodConfig{
+				Image:                         "docker.io/jaegertracing/all-in-one",
+				ImageTag:                      imageTag,
+				ImagePullPolicy:               corev1.PullIfNotPresent,
+				Name:                          JaegerName,
+				Namespace:                     namespace,
+				CommonLabels:                  commonLabels,
+				ContainerResources:            kghelpers.NewResourcesRequirements("100m", "500m", "500Mi", "1Gi"),
+				Affinity:                      kghelpers.NewAntiAffinity(nil, labelSelectors),
+				EnableServiceMonitor:          true,
+				LivenessProbe:                 kghelpers.NewProbe("/", jaegerAdminPort, probeCfg),
+				ReadinessProbe:                kghelpers.NewProbe("/", jaegerAdminPort, probeCfg),
+				TerminationGracePeriodSeconds: 120,
+				ConfigMaps:                    make(map[string]map[string]string),
+				Secrets:                       make(map[string]map[string][]byte),
+			},
+		},
+	}
+}
+
+// NewTenantJaeger returns a Jaeger deployment dedicated to a single tenant.
+// Its endpoint can be passed to NewTenantRoutingCollectorConfig using JaegerOTLPEndpoint(JaegerTenantName(tenant), namespace).
+func NewTenantJaeger(tenant string, opts *JaegerOptions, namespace, imageTag string) *JaegerDeployment {
+	ret := NewJaeger(opts, namespace, imageTag)
+	ret.Name = JaegerTenantName(tenant)
+	ret.CommonLabels[workload.InstanceLabel] = ret.Name
+	ret.Affinity = kghelpers.NewAntiAffinity(nil, map[string]string{
+		workload.NameLabel:     ret.CommonLabels[workload.NameLabel],
+		workload.InstanceLabel: ret.Name,
+	})
+	return ret
+}
+
+// Objects returns the manifests for the Jaeger instance.
+// It panics if the configuration is invalid, see ObjectsE.
+func (j *JaegerDeployment) Objects() []runtime.Object {
+	ret, err := j.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// ObjectsE is like Objects but returns an error instead of panicking when a probe does not target the admin port.
+func (j *JaegerDeployment) ObjectsE() ([]runtime.Object, error) {
+	container, err := j.makeContainer()
+	if err != nil {
+		return nil, fmt.Errorf("invalid jaeger %s: %w", j.Name, err)
+	}
+
+	ret, err := j.DeploymentWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jaeger %s: %w", j.Name, err)
+	}
+
+	return ret, nil
+}
+
+func (j *JaegerDeployment) makeContainer() (*workload.Container, error) {
+	adminPort := kghelpers.GetPortOrDefault(jaegerAdminPort, j.options.AdminHTTPHostPort)
+	if err := errors.Join(
+		kghelpers.CheckProbePortE(adminPort, j.PodConfig.LivenessProbe),
+		kghelpers.CheckProbePortE(adminPort, j.PodConfig.ReadinessProbe),
+	); err != nil {
+		return nil, err
+	}
+
+	ret := j.ToContainer()
+	ret.Name = "jaeger"
+	ret.Args = cmdopt.GetOpts(j.options)
+	ret.Env = append(ret.Env, corev1.EnvVar{
+		Name:  "SPAN_STORAGE_TYPE",
+		Value: string(j.SpanStorageType),
+	})
+	ret.Ports = []corev1.ContainerPort{
+		{
+			Name:          "query",
+			ContainerPort: int32(jaegerQueryPort),
+			Protocol:      corev1.ProtocolTCP,
+		},
+		{
+			Name:          "grpc",
+			ContainerPort: int32(jaegerCollectorPort),
+			Protocol:      corev1.ProtocolTCP,
+		},
+		{
+			Name:          "otlp-grpc",
+			ContainerPort: int32(otlpGRPCPort),
+			Protocol:      corev1.ProtocolTCP,
+		},
+		{
+			Name:          "otlp-http",
+			ContainerPort: int32(otlpHTTPPort),
+			Protocol:      corev1.ProtocolTCP,
+		},
+		{
+			Name:          "admin-http",
+			ContainerPort: int32(adminPort),
+			Protocol:      corev1.ProtocolTCP,
+		},
+	}
+	ret.ServicePorts = []corev1.ServicePort{
+		kghelpers.NewServicePort("query", jaegerQueryPort, jaegerQueryPort),
+		kghelpers.NewServicePort("grpc", jaegerCollectorPort, jaegerCollectorPort),
+		kghelpers.NewServicePort("otlp-grpc", otlpGRPCPort, otlpGRPCPort),
+		kghelpers.NewServicePort("otlp-http", otlpHTTPPort, otlpHTTPPort),
+		kghelpers.NewServicePort("admin-http", adminPort, adminPort),
+	}
+	ret.MonitorPorts = []monv1.Endpoint{
+		{
+			Port:           "admin-http",
+			RelabelConfigs: kghelpers.GetDefaultServiceMonitorRelabelConfig(),
+		},
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,199 @@
+package tracing
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/observatorium/observatorium/configuration_go/kubegen/cmdopt"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/containeropts"
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	otelcolschema "github.com/observatorium/observatorium/configuration_go/schemas/otelcol"
+	monv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	otlpGRPCPort         int = 4317
+	otlpHTTPPort         int = 4318
+	collectorMetricsPort int = 8888
+	healthCheckPort      int = 13133
+
+	// CollectorName is the name of the OpenTelemetry Collector workload and service.
+	CollectorName string = "observatorium-otel-collector"
+	// TenantHeader is the header set by the Observatorium API to identify the tenant of the traces.
+	TenantHeader string = "X-Tenant"
+)
+
+// NewCollectorConfigFile returns a new OpenTelemetry Collector config file option.
+func NewCollectorConfigFile(value *otelcolschema.Config) *containeropts.ConfigResourceAsFile {
+	ret := containeropts.NewConfigResourceAsFile("/etc/otelcol", "config.yaml", "config", "observatorium-otel-collector-config")
+	if value != nil {
+		if err := value.Validate(); err != nil {
+			panic(fmt.Sprintf("invalid otel collector config: %v", err))
+		}
+		ret.WithValue(value.String())
+	}
+	return ret
+}
+
+// CollectorOptions represents the options/flags of the OpenTelemetry Collector.
+// See https://opentelemetry.io/docs/collector/configuration/ for details.
+type CollectorOptions struct {
+	Config       containeropts.ContainerUpdater `opt:"config"`
+	FeatureGates []string                       `opt:"feature-gates"`
+
+	// Extra options not officially supported.
+	cmdopt.ExtraOpts
+}
+
+// NewDefaultCollectorOptions returns the default collector options, forwarding all traces to the default Jaeger backend.
+func NewDefaultCollectorOptions() *CollectorOptions {
+	return &CollectorOptions{
+		Config: NewCollectorConfigFile(NewDefaultCollectorConfig(JaegerOTLPEndpoint(JaegerName, ""))),
+	}
+}
+
+// CollectorDeployment represents the OpenTelemetry Collector receiving traces from the Observatorium API.
+type CollectorDeployment struct {
+	options *CollectorOptions
+
+	workload.DeploymentWorkload
+}
+
+// NewOTelCollector returns a new OpenTelemetry Collector deployment with default configuration.
+// The contrib distribution is used as the core one lacks the routing processor.
+func NewOTelCollector(opts *CollectorOptions, namespace, imageTag string) *CollectorDeployment {
+	if opts == nil {
+		opts = NewDefaultCollectorOptions()
+	}
+
+	commonLabels := map[string]string{
+		workload.NameLabel:      "otelcol",
+		workload.InstanceLabel:  "observatorium",
+		workload.PartOfLabel:    "observatorium",
+		workload.ComponentLabel: "tracing-collector",
+		workload.VersionLabel:   imageTag,
+	}
+
+	labelSelectors := map[string]string{
+		workload.NameLabel:     commonLabels[workload.NameLabel],
+		workload.InstanceLabel: commonLabels[workload.InstanceLabel],
+	}
+
+	probeCfg := kghelpers.ProbeConfig{
+		FailureThreshold: 3,
+		PeriodSeconds:    10,
+		TimeoutSeconds:   1,
+	}
+
+	return &CollectorDeployment{
+		options: opts,
+		DeploymentWorkload: workload.DeploymentWorkload{
+			Replicas: 1,
+			PodConfig: workload.PodConfig{
+				Image:                         "ghcr.io/open-telemetry/opentelemetry-collector-releases/opentelemetry-collector-contrib",
+				ImageTag:                      imageTag,
+				ImagePullPolicy:               corev1.PullIfNotPresent,
+				Name:                          CollectorName,
+				Namespace:                     namespace,
+				CommonLabels:                  commonLabels,
+				ContainerResources:            kghelpers.NewResourcesRequirements("100m", "500m", "200Mi", "500Mi"),
+				Affinity:                      kghelpers.NewAntiAffinity(nil, labelSelectors),
+				EnableServiceMonitor:          true,
+				LivenessProbe:                 kghelpers.NewProbe("/", healthCheckPort, probeCfg),
+				ReadinessProbe:                kghelpers.NewProbe("/", healthCheckPort, probeCfg),
+				TerminationGracePeriodSeconds: 120,
+				ConfigMaps:                    make(map[string]map[string]string),
+				Secrets:                       make(map[string]map[string][]byte),
+			},
+		},
+	}
+}
+
+// Objects returns the manifests for the OpenTelemetry Collector.
+// It panics if the configuration is invalid, see ObjectsE.
+func (c *CollectorDeployment) Objects() []runtime.Object {
+	ret, err := c.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// ObjectsE is like Objects but returns an error instead of panicking when the collector config is missing
+// or a probe does not target the health check extension port.
+func (c *CollectorDeployment) ObjectsE() ([]runtime.Object, error) {
+	container, err := c.makeContainer()
+	if err != nil {
+		return nil, fmt.Errorf("invalid otel collector %s: %w", c.Name, err)
+	}
+
+	ret, err := c.DeploymentWorkload.ObjectsE(container)
+	if err != nil {
+		return nil, fmt.Errorf("invalid otel collector %s: %w", c.Name, err)
+	}
+
+	return ret, nil
+}
+
+func (c *CollectorDeployment) makeContainer() (*workload.Container, error) {
+	errs := []error{
+		kghelpers.CheckProbePortE(healthCheckPort, c.PodConfig.LivenessProbe),
+		kghelpers.CheckProbePortE(healthCheckPort, c.PodConfig.ReadinessProbe),
+	}
+
+	if c.options.Config == nil {
+		errs = append(errs, errors.New("config file is not specified for otel collector"))
+	}
+
+	// The config is updating the container below, so stop before rendering it.
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	ret := c.ToContainer()
+	ret.Name = "otel-collector"
+	ret.Args = cmdopt.GetOpts(c.options)
+	ret.Ports = []corev1.ContainerPort{
+		{
+			Name:          "otlp-grpc",
+			ContainerPort: int32(otlpGRPCPort),
+			Protocol:      corev1.ProtocolTCP,
+		},
+		{
+			Name:          "otlp-http",
+			ContainerPort: int32(otlpHTTPPort),
+			Protocol:      corev1.ProtocolTCP,
+		},
+		{
+			Name:          "monitoring",
+			ContainerPort: int32(collectorMetricsPort),
+			Protocol:      corev1.ProtocolTCP,
+		},
+		{
+			Name:          "health",
+			ContainerPort: int32(healthCheckPort),
+			Protocol:      corev1.ProtocolTCP,
+		},
+	}
+	ret.ServicePorts = []corev1.ServicePort{
+		kghelpers.NewServicePort("otlp-grpc", otlpGRPCPort, otlpGRPCPort),
+		kghelpers.NewServicePort("otlp-http", otlpHTTPPort, otlpHTTPPort),
+		kghelpers.NewServicePort("monitoring", collectorMetricsPort, collectorMetricsPort),
+	}
+	ret.MonitorPorts = []monv1.Endpoint{
+		{
+			Port:           "monitoring",
+			RelabelConfigs: kghelpers.GetDefaultServiceMonitorRelabelConfig(),
+		},
+	}
+
+	if err := containeropts.UpdateContainerE(c.options.Config, ret); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
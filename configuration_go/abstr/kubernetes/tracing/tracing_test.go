@@ -0,0 +1,196 @@
+package tracing_test
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/tracing"
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"gopkg.in/yaml.v2"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// renderedConfig is the part of the collector config checked by the tests.
+type renderedConfig struct {
+	Receivers  map[string]interface{} `yaml:"receivers"`
+	Processors map[string]interface{} `yaml:"processors"`
+	Exporters  map[string]struct {
+		Endpoint string `yaml:"endpoint"`
+	} `yaml:"exporters"`
+	Service struct {
+		Pipelines map[string]struct {
+			Receivers  []string `yaml:"receivers"`
+			Processors []string `yaml:"processors"`
+			Exporters  []string `yaml:"exporters"`
+		} `yaml:"pipelines"`
+	} `yaml:"service"`
+}
+
+func collectorConfig(t *testing.T, objs []runtime.Object) renderedConfig {
+	t.Helper()
+
+	cm := kghelpers.GetObject[*corev1.ConfigMap](objs, "observatorium-otel-collector-config")
+	ret := renderedConfig{}
+	if err := yaml.Unmarshal([]byte(cm.Data["config.yaml"]), &ret); err != nil {
+		t.Fatalf("failed to parse the rendered config: %v", err)
+	}
+
+	return ret
+}
+
+func TestCollectorConfig(t *testing.T) {
+	testCases := map[string]struct {
+		options    *tracing.CollectorOptions
+		processors []string
+		exporters  map[string]string
+	}{
+		"default": {
+			processors: []string{"memory_limiter", "batch"},
+			exporters:  map[string]string{"otlp/backend": "observatorium-jaeger:4317"},
+		},
+		"tenant routing": {
+			options: &tracing.CollectorOptions{
+				Config: tracing.NewCollectorConfigFile(tracing.NewTenantRoutingCollectorConfig(map[string]string{
+					"team_B": tracing.JaegerOTLPEndpoint(tracing.JaegerTenantName("team_B"), "ns"),
+					"a":      tracing.JaegerOTLPEndpoint(tracing.JaegerTenantName("a"), "ns"),
+				})),
+			},
+			processors: []string{"memory_limiter", "batch", "routing"},
+			exporters: map[string]string{
+				"otlp/a":      "observatorium-jaeger-a.ns.svc.cluster.local:4317",
+				"otlp/team-b": "observatorium-jaeger-team-b.ns.svc.cluster.local:4317",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			objs, err := tracing.NewOTelCollector(tc.options, "ns", "0.98.0").ObjectsE()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			config := collectorConfig(t, objs)
+			if _, ok := config.Receivers["otlp"]; !ok || len(config.Receivers) != 1 {
+				t.Errorf("expected a single otlp receiver, got %v", config.Receivers)
+			}
+
+			exporters := map[string]string{}
+			for id, exporter := range config.Exporters {
+				exporters[id] = exporter.Endpoint
+			}
+			if fmt.Sprint(exporters) != fmt.Sprint(tc.exporters) {
+				t.Errorf("expected exporters %v, got %v", tc.exporters, exporters)
+			}
+
+			pipeline, ok := config.Service.Pipelines["traces"]
+			if !ok || len(config.Service.Pipelines) != 1 {
+				t.Fatalf("expected a single traces pipeline, got %v", config.Service.Pipelines)
+			}
+			if !slices.Equal(pipeline.Receivers, []string{"otlp"}) {
+				t.Errorf("expected the pipeline to receive from otlp, got %v", pipeline.Receivers)
+			}
+			if !slices.Equal(pipeline.Processors, tc.processors) {
+				t.Errorf("expected processors %v, got %v", tc.processors, pipeline.Processors)
+			}
+			for _, p := range pipeline.Processors {
+				if _, ok := config.Processors[p]; !ok {
+					t.Errorf("pipeline processor %s is not configured", p)
+				}
+			}
+			exporterIDs := slices.Sorted(maps.Keys(tc.exporters))
+			if !slices.Equal(pipeline.Exporters, exporterIDs) {
+				t.Errorf("expected the pipeline to export to %v, got %v", exporterIDs, pipeline.Exporters)
+			}
+
+			container := kghelpers.GetObject[*appsv1.Deployment](objs, tracing.CollectorName).Spec.Template.Spec.Containers[0]
+			if !slices.Equal(container.Args, []string{"--config=/etc/otelcol/config.yaml"}) {
+				t.Errorf("unexpected collector args %v", container.Args)
+			}
+
+			svc := kghelpers.GetObject[*corev1.Service](objs, tracing.CollectorName)
+			if got := servicePorts(svc); got != "otlp-grpc:4317,otlp-http:4318,monitoring:8888" {
+				t.Errorf("unexpected collector service ports %s", got)
+			}
+		})
+	}
+}
+
+func TestTenantRoutingCollectorConfigPanics(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "at least one tenant endpoint must be provided") {
+			t.Errorf("expected a panic for missing endpoints, got %v", r)
+		}
+	}()
+
+	tracing.NewTenantRoutingCollectorConfig(nil)
+}
+
+func TestJaeger(t *testing.T) {
+	testCases := map[string]struct {
+		jaeger   *tracing.JaegerDeployment
+		name     string
+		instance string
+	}{
+		"default": {
+			jaeger:   tracing.NewJaeger(nil, "ns", "1.57.0"),
+			name:     "observatorium-jaeger",
+			instance: "observatorium",
+		},
+		"tenant": {
+			jaeger:   tracing.NewTenantJaeger("team_B", nil, "ns", "1.57.0"),
+			name:     "observatorium-jaeger-team-b",
+			instance: "observatorium-jaeger-team-b",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			objs, err := tc.jaeger.ObjectsE()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			svc := kghelpers.GetObject[*corev1.Service](objs, tc.name)
+			if got := servicePorts(svc); got != "query:16686,grpc:14250,otlp-grpc:4317,otlp-http:4318,admin-http:14269" {
+				t.Errorf("unexpected jaeger service ports %s", got)
+			}
+			if got := svc.Spec.Selector["app.kubernetes.io/instance"]; got != tc.instance {
+				t.Errorf("expected the service to select instance %s, got %s", tc.instance, got)
+			}
+
+			container := kghelpers.GetObject[*appsv1.Deployment](objs, tc.name).Spec.Template.Spec.Containers[0]
+			ports := []string{}
+			for _, p := range container.Ports {
+				ports = append(ports, fmt.Sprintf("%s:%d", p.Name, p.ContainerPort))
+			}
+			if got := strings.Join(ports, ","); got != "query:16686,grpc:14250,otlp-grpc:4317,otlp-http:4318,admin-http:14269" {
+				t.Errorf("unexpected jaeger container ports %s", got)
+			}
+			if !slices.Contains(container.Args, "--collector.otlp.enabled=true") {
+				t.Errorf("expected the OTLP collector to be enabled, got args %v", container.Args)
+			}
+			if !slices.Contains(container.Env, corev1.EnvVar{Name: "SPAN_STORAGE_TYPE", Value: "memory"}) {
+				t.Errorf("expected the memory span storage, got env %v", container.Env)
+			}
+		})
+	}
+
+	if got := tracing.JaegerQueryEndpoint(tracing.JaegerName, "ns"); got != "http://observatorium-jaeger.ns.svc.cluster.local:16686" {
+		t.Errorf("unexpected query endpoint %s", got)
+	}
+}
+
+func servicePorts(svc *corev1.Service) string {
+	ret := []string{}
+	for _, p := range svc.Spec.Ports {
+		ret = append(ret, fmt.Sprintf("%s:%d", p.Name, p.Port))
+	}
+
+	return strings.Join(ret, ",")
+}
@@ -0,0 +1,119 @@
+// Command manifestdiff reports the Kubernetes objects added, removed or modified between two manifests directories,
+// with a field level diff of the modified objects. It exits 1 if any object changed, so that it can gate reviews.
+//
+// Usage:
+//
+//	go run main.go generate --output=/tmp/manifests
+//	go run ./cmd/manifestdiff --from=manifests/config-new --to=/tmp/manifests/config-new
+//
+// Sub directories are compared recursively when --recursive is set, e.g. to compare all the outputs of mimic at once.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/observatorium/observatorium/configuration_go/kubegen/kubeyaml"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func main() {
+	from := flag.String("from", "", "Directory holding the current manifests.")
+	to := flag.String("to", "", "Directory holding the new manifests.")
+	recursive := flag.Bool("recursive", false, "Compare the sub directories too.")
+	flag.Parse()
+
+	if *from == "" || *to == "" {
+		exitf("both --from and --to must be specified")
+	}
+
+	dirs := []string{"."}
+	if *recursive {
+		var err error
+		if dirs, err = subDirs(*from, *to); err != nil {
+			exitf("error listing directories: %v", err)
+		}
+	}
+
+	drift := false
+	for _, dir := range dirs {
+		oldObjects, err := readObjects(filepath.Join(*from, dir), *recursive)
+		if err != nil {
+			exitf("error reading %s: %v", dir, err)
+		}
+
+		newObjects, err := readObjects(filepath.Join(*to, dir), *recursive)
+		if err != nil {
+			exitf("error reading %s: %v", dir, err)
+		}
+
+		report, err := kubeyaml.DiffObjects(oldObjects, newObjects)
+		if err != nil {
+			exitf("error comparing %s: %v", dir, err)
+		}
+
+		if report.Empty() {
+			continue
+		}
+
+		drift = true
+		if dir != "." {
+			fmt.Printf("# %s\n", dir)
+		}
+		fmt.Print(report.String())
+	}
+
+	if drift {
+		os.Exit(1)
+	}
+}
+
+// readObjects reads the objects in dir. Directories missing on one side of a recursive comparison are read as empty.
+func readObjects(dir string, recursive bool) ([]runtime.Object, error) {
+	if _, err := os.Stat(dir); recursive && errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	return kubeyaml.ReadObjectsInDir(dir)
+}
+
+// subDirs returns the directories found in from or to, relative to them.
+func subDirs(from, to string) ([]string, error) {
+	dirs := map[string]bool{}
+	for _, root := range []string{from, to} {
+		if err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if d.IsDir() {
+				rel, err := filepath.Rel(root, path)
+				if err != nil {
+					return err
+				}
+				dirs[rel] = true
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	ret := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		ret = append(ret, dir)
+	}
+	sort.Strings(ret)
+
+	return ret, nil
+}
+
+func exitf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(2)
+}
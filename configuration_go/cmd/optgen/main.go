@@ -0,0 +1,89 @@
+// Command optgen generates or checks the options structs of the Thanos abstractions
+// against the --help output of a Thanos component.
+//
+// Usage:
+//
+//	thanos store --help 2> store-help.txt  # or the help output in docs/components/store.md
+//	go run ./cmd/optgen --help-file=store-help.txt --component=store          # reports drift, exits 1 if any
+//	go run ./cmd/optgen --help-file=store-help.txt --generate=StoreOptions    # prints a new struct
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/compactor"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/query"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/queryfrontend"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/receive"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/ruler"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/store"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/optgen"
+)
+
+// components maps the Thanos subcommands to the options struct of their abstraction.
+var components = map[string]interface{}{
+	"compact":        compactor.CompactorOptions{},
+	"query":          query.QueryOptions{},
+	"query-frontend": queryfrontend.QueryFrontendOptions{},
+	"receive":        receive.ReceiveOptions{},
+	"rule":           ruler.RulerOptions{},
+	"store":          store.StoreOptions{},
+}
+
+func main() {
+	helpFile := flag.String("help-file", "", "Path to the saved `thanos <component> --help` output. Reads stdin if empty.")
+	component := flag.String("component", "", fmt.Sprintf("Component whose options struct is checked, one of: %s.", strings.Join(componentNames(), ", ")))
+	generate := flag.String("generate", "", "Name of the options struct to generate instead of checking an existing one.")
+	flag.Parse()
+
+	in := os.Stdin
+	if *helpFile != "" {
+		f, err := os.Open(*helpFile)
+		if err != nil {
+			exitf("error opening help file: %v", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	flags, err := optgen.ParseHelp(in)
+	if err != nil {
+		exitf("error parsing help output: %v", err)
+	}
+
+	if *generate != "" {
+		fmt.Print(optgen.Generate(*generate, flags))
+		return
+	}
+
+	obj, ok := components[*component]
+	if !ok {
+		exitf("unknown component %q, expected one of: %s", *component, strings.Join(componentNames(), ", "))
+	}
+
+	report := optgen.Diff(flags, obj)
+	if report.Empty() {
+		return
+	}
+
+	fmt.Print(report.String())
+	os.Exit(1)
+}
+
+func componentNames() []string {
+	ret := make([]string, 0, len(components))
+	for name := range components {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func exitf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(2)
+}
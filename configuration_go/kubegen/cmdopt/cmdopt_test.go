@@ -2,10 +2,29 @@ package cmdopt_test
 
 import (
 	"fmt"
+	"net"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/alertmanager"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/loki"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/memcached"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/api"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/up"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/prometheus/avalanche"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/compactor"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/query"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/queryfrontend"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/receive"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/ruler"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/store"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/tracing"
 	"github.com/observatorium/observatorium/configuration_go/kubegen/cmdopt"
+	thanostime "github.com/observatorium/observatorium/configuration_go/schemas/thanos/time"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/units"
 )
 
 type SubStruct struct {
@@ -335,3 +354,423 @@ func TestCmdOptionsExtraOpts(t *testing.T) {
 		t.Fatalf("expected 1 args, got %d: %s", len(args), args)
 	}
 }
+
+func TestGetEnv(t *testing.T) {
+	zero := 0
+	testCases := map[string]struct {
+		obj      interface{}
+		expected []string
+	}{
+		"nil": {
+			obj:      nil,
+			expected: []string{},
+		},
+		"fields": {
+			obj: &struct {
+				String   string        `env:"STRING"`
+				Int      int           `env:"INT"` // Zero value is ignored
+				IntPtr   *int          `env:"INT_PTR"`
+				Duration time.Duration `env:"DURATION"`
+				Repeat   []string      `env:"REPEAT"`
+				Opt      string        `opt:"opt"`
+				private  string        `env:"PRIVATE"`
+			}{
+				String:   "string",
+				IntPtr:   &zero,
+				Duration: time.Minute,
+				Repeat:   []string{"a", "b"},
+				Opt:      "opt",
+				private:  "private",
+			},
+			expected: []string{"STRING=string", "INT_PTR=0", "DURATION=1m0s", "REPEAT=a,b"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			env := []string{}
+			for _, e := range cmdopt.GetEnv(tc.obj) {
+				env = append(env, e.Name+"="+e.Value)
+			}
+
+			if !slices.Equal(env, tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, env)
+			}
+		})
+	}
+}
+
+type ParseOptions struct {
+	String       string          `opt:"string"`
+	Int          int             `opt:"int"`
+	IntPtr       *int            `opt:"intptr"`
+	Uint64       uint64          `opt:"uint64"`
+	Float        float64         `opt:"float"`
+	Bool         bool            `opt:"bool"`
+	BoolPtr      *bool           `opt:"boolptr"`
+	Duration     time.Duration   `opt:"duration"`
+	Address      *net.TCPAddr    `opt:"address"`
+	NoValue      bool            `opt:"no-value,noval"`
+	Repeat       []string        `opt:"repeat"`
+	SingleHyphen int             `opt:"single,single-hyphen"`
+	Text         *TextOpt        `opt:"text"`
+	Interface    fmt.Stringer    `opt:"stringer"`
+	Durations    []time.Duration `opt:"durations"`
+
+	cmdopt.ExtraOpts
+}
+
+type TextOpt struct {
+	Key, Value string
+}
+
+func (t TextOpt) String() string {
+	return t.Key + ":" + t.Value
+}
+
+func (t *TextOpt) UnmarshalText(text []byte) error {
+	key, value, ok := strings.Cut(string(text), ":")
+	if !ok {
+		return fmt.Errorf("invalid text %q", text)
+	}
+
+	t.Key, t.Value = key, value
+	return nil
+}
+
+func TestParseOpts(t *testing.T) {
+	testCases := map[string]struct {
+		args        []string
+		initial     ParseOptions
+		expect      []string
+		expectExtra []string
+		expectErr   bool
+	}{
+		"empty": {
+			args:   []string{},
+			expect: []string{},
+		},
+		"scalars": {
+			args:   []string{"--string=s", "--int=1", "--float=1.5", "--bool=true", "-single=3"},
+			expect: []string{"--string=s", "--int=1", "--float=1.5", "--bool=true", "-single=3"},
+		},
+		"pointers keep zero values": {
+			args:   []string{"--intptr=0", "--boolptr=false"},
+			expect: []string{"--intptr=0", "--boolptr=false"},
+		},
+		"separate value": {
+			args:   []string{"--string", "s", "--int", "1"},
+			expect: []string{"--string=s", "--int=1"},
+		},
+		"noval": {
+			args:   []string{"--no-value"},
+			expect: []string{"--no-value"},
+		},
+		"durations": {
+			args:   []string{"--duration=1d", "--durations=1m", "--durations=2h30m"},
+			expect: []string{"--duration=24h0m0s", "--durations=1m0s", "--durations=2h30m0s"},
+		},
+		"address": {
+			args:   []string{"--address=0.0.0.0:10902"},
+			expect: []string{"--address=0.0.0.0:10902"},
+		},
+		"text unmarshaler": {
+			args:   []string{"--text=k:v"},
+			expect: []string{"--text=k:v"},
+		},
+		"repeat replaces initial values": {
+			args:    []string{"--repeat=a", "--repeat=b"},
+			initial: ParseOptions{Repeat: []string{"default"}},
+			expect:  []string{"--repeat=a", "--repeat=b"},
+		},
+		"unknown and unsupported options are kept as extra": {
+			args:        []string{"receive", "--unknown=1", "--stringer=x", "--string=s"},
+			expect:      []string{"--string=s", "receive", "--unknown=1", "--stringer=x"},
+			expectExtra: []string{"receive", "--unknown=1", "--stringer=x"},
+		},
+		"invalid int": {
+			args:      []string{"--int=a"},
+			expectErr: true,
+		},
+		"invalid address": {
+			args:      []string{"--address=localhost:10902"},
+			expectErr: true,
+		},
+		"missing value": {
+			args:      []string{"--string"},
+			expectErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			opts := tc.initial
+			err := cmdopt.ParseOpts(tc.args, &opts)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if extra := opts.GetExtraOpts(); len(tc.expectExtra) > 0 && !slices.Equal(extra, tc.expectExtra) {
+				t.Fatalf("expected extra opts %s, got %s", tc.expectExtra, extra)
+			}
+
+			args := cmdopt.GetOpts(&opts)
+			if !slices.Equal(args, tc.expect) {
+				t.Fatalf("expected %s, got %s", tc.expect, args)
+			}
+		})
+	}
+}
+
+func TestParseOptsWithoutExtraOpts(t *testing.T) {
+	opts := struct {
+		String string `opt:"string"`
+	}{}
+
+	if err := cmdopt.ParseOpts([]string{"--unknown"}, &opts); err == nil {
+		t.Fatalf("expected error for unknown option without extra opts")
+	}
+
+	if err := cmdopt.ParseOpts([]string{"--string=s"}, opts); err == nil {
+		t.Fatalf("expected error for non pointer object")
+	}
+}
+
+func TestParseOptsRoundTrip(t *testing.T) {
+	testCases := map[string]struct {
+		options func() any
+	}{
+		"receive ingestor": {
+			options: func() any { return receive.NewDefaultIngestorOptions() },
+		},
+		"receive router": {
+			options: func() any { return receive.NewDefaultRouterOptions() },
+		},
+		"query": {
+			options: func() any { return query.NewDefaultOptions() },
+		},
+		"query frontend": {
+			options: func() any { return queryfrontend.NewDefaultOptions() },
+		},
+		"store": {
+			options: func() any { return store.NewDefaultOptions() },
+		},
+		"store with sizes and time range": {
+			options: func() any {
+				opts := store.NewDefaultOptions()
+				opts.ChunkPoolSize = 2 * units.GiB
+				opts.IndexCacheSize = units.GiB + 512*units.MiB
+				opts.StoreGrpcDownloadedBytesLimit = 100
+				minTime, maxTime := -14*24*time.Hour, -2*time.Hour
+				opts.MinTime = &thanostime.TimeOrDurationValue{Dur: &minTime}
+				opts.MaxTime = &thanostime.TimeOrDurationValue{Dur: &maxTime}
+				return opts
+			},
+		},
+		"compactor": {
+			options: func() any { return compactor.NewDefaultOptions() },
+		},
+		"compactor with time range": {
+			options: func() any {
+				opts := compactor.NewDefaultOptions()
+				minTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+				opts.MinTime = &thanostime.TimeOrDurationValue{Time: &minTime}
+				return opts
+			},
+		},
+		"ruler": {
+			options: func() any { return ruler.NewDefaultOptions() },
+		},
+		"rules objstore": {
+			options: func() any { return ruler.NewRulesObjstoreDefaultOptions() },
+		},
+		"rules syncer": {
+			options: func() any {
+				return &ruler.RulesSyncerOptions{File: "/rules.yaml", Interval: 60, Tenant: "t", ThanosRuleUrl: &net.TCPAddr{Port: 10902}}
+			},
+		},
+		"receive controller": {
+			options: func() any { return receive.NewControllerDefaultOptions() },
+		},
+		"api": {
+			options: func() any {
+				return &api.ObservatoriumAPIOptions{LogLevel: "info", WebListen: &net.TCPAddr{Port: 8080}, MetricsReadEndpoint: "http://read"}
+			},
+		},
+		"opa ams": {
+			options: func() any {
+				return &api.OpaAmsOptions{AmsURL: "http://ams", AmsMappings: []string{"a=b", "c=d"}, MemcachedExpire: 60}
+			},
+		},
+		"up": {
+			options: func() any {
+				duration := time.Minute
+				return &up.UpOptions{Duration: &duration, EndpointType: "metrics", Labels: []string{"a=b"}, Latency: time.Second, LogLevel: "info"}
+			},
+		},
+		"memcached": {
+			options: func() any {
+				return &memcached.MemcachedOptions{MemoryLimit: 1024, MaxItemSize: "5m", Verbose: true}
+			},
+		},
+		"avalanche": {
+			options: func() any {
+				return &avalanche.AvalancheOptions{MetricCount: 10, ConstLabels: []string{"a=b"}, RemoteURL: "http://remote"}
+			},
+		},
+		"alertmanager": {
+			options: func() any { return alertmanager.NewDefaultOptions() },
+		},
+		"loki": {
+			options: func() any { return loki.NewDefaultOptions() },
+		},
+		"jaeger": {
+			options: func() any { return tracing.NewDefaultJaegerOptions() },
+		},
+		"otel collector": {
+			options: func() any { return tracing.NewDefaultCollectorOptions() },
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			args := cmdopt.GetOpts(tc.options())
+
+			// Parse into a zero value of the same type.
+			parsed := reflect.New(reflect.TypeOf(tc.options()).Elem()).Interface()
+			if err := cmdopt.ParseOpts(args, parsed); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// Options of unsupported types are moved to the extra options, at the end.
+			got := cmdopt.GetOpts(parsed)
+			slices.Sort(args)
+			slices.Sort(got)
+			if !slices.Equal(args, got) {
+				t.Fatalf("expected %s, got %s", args, got)
+			}
+		})
+	}
+}
+
+type VersionedOptions struct {
+	Stable     string `opt:"stable"`
+	NewFlag    bool   `opt:"new-flag,noval" since:"v0.33.0"`
+	NewValue   int    `opt:"new-value" since:"v0.31.0"`
+	Deprecated string `opt:"deprecated" until:"v0.32.0"`
+}
+
+func TestCheckVersion(t *testing.T) {
+	testCases := map[string]struct {
+		opts     VersionedOptions
+		version  string
+		expected []string
+	}{
+		"unset options are accepted": {
+			opts:    VersionedOptions{Stable: "foo"},
+			version: "v0.20.0",
+		},
+		"supported options are accepted": {
+			opts:    VersionedOptions{NewFlag: true, NewValue: 1},
+			version: "v0.33.1",
+		},
+		"pre-release supports options of its release": {
+			opts:    VersionedOptions{NewFlag: true},
+			version: "v0.33.0-rc.0",
+		},
+		"non semantic versions are accepted": {
+			opts:    VersionedOptions{NewFlag: true, Deprecated: "foo"},
+			version: "main-2023-01-24-v0.1.2-318-g5f4fdf4",
+		},
+		"options newer than the version are rejected": {
+			opts:     VersionedOptions{NewFlag: true, NewValue: 1},
+			version:  "0.30.2",
+			expected: []string{"--new-flag requires version v0.33.0", "--new-value requires version v0.31.0"},
+		},
+		"removed options are rejected": {
+			opts:     VersionedOptions{Deprecated: "foo"},
+			version:  "v0.32",
+			expected: []string{"--deprecated is not supported since version v0.32.0"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := cmdopt.CheckVersion(&tc.opts, tc.version)
+			if len(tc.expected) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+
+			for _, exp := range tc.expected {
+				if !strings.Contains(err.Error(), exp) {
+					t.Errorf("expected error to contain %q, got %q", exp, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestCheckVersionComponents(t *testing.T) {
+	compactorOpts := compactor.NewDefaultOptions()
+	compactorOpts.WebDisable = true
+
+	queryFrontendOpts := queryfrontend.NewDefaultOptions()
+	queryFrontendOpts.QueryRangeHorizontalShards = 4
+
+	queryOpts := query.NewDefaultOptions()
+	queryOpts.QueryTenantHeader = "X-Tenant"
+
+	testCases := map[string]struct {
+		component interface{ Validate() error }
+		expected  string
+	}{
+		"compactor downgrade": {
+			component: compactor.NewCompactor(compactorOpts, "ns", "v0.28.0"),
+			expected:  "--web.disable requires version v0.29.0",
+		},
+		"compactor": {
+			component: compactor.NewCompactor(compactorOpts, "ns", "v0.34.1"),
+		},
+		"query frontend downgrade": {
+			component: queryfrontend.NewQueryFrontend(queryFrontendOpts, "ns", "v0.28.0"),
+			expected:  "--query-range.horizontal-shards requires version v0.29.0",
+		},
+		"query frontend": {
+			component: queryfrontend.NewQueryFrontend(queryFrontendOpts, "ns", "v0.34.1"),
+		},
+		"query downgrade": {
+			component: query.NewQuery(queryOpts, "ns", "v0.32.0"),
+			expected:  "--query.tenant-header requires version v0.33.0",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.component.Validate()
+			if tc.expected == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Fatalf("expected error containing %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
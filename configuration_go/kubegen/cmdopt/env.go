@@ -0,0 +1,51 @@
+package cmdopt
+
+import (
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const envTagName = "env"
+
+// GetEnv is like GetOpts but generates environment variables, for programs configured through their environment.
+// Field values are mapped to the variable named in the env tag, following the same rules as GetOpts.
+// Slices are joined with commas. Variables are returned in the order of the fields.
+func GetEnv(obj interface{}) []corev1.EnvVar {
+	ret := []corev1.EnvVar{}
+
+	if obj == nil {
+		return ret
+	}
+
+	v := reflect.ValueOf(obj)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ret
+		}
+		v = v.Elem()
+	}
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		// If the field is not exported, skip it.
+		if t.Field(i).PkgPath != "" {
+			continue
+		}
+
+		name := t.Field(i).Tag.Get(envTagName)
+		if name == "" {
+			continue
+		}
+
+		values := getOptValue(t.Field(i).Type.Kind(), v.Field(i))
+		if len(values) == 0 {
+			continue
+		}
+
+		ret = append(ret, corev1.EnvVar{Name: name, Value: strings.Join(values, ",")})
+	}
+
+	return ret
+}
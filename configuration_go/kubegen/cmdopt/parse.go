@@ -0,0 +1,248 @@
+package cmdopt
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	prommodel "github.com/prometheus/common/model"
+)
+
+var (
+	durationType      = reflect.TypeOf(time.Duration(0))
+	tcpAddrType       = reflect.TypeOf(net.TCPAddr{})
+	textUnmarshalType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+	errUnsupportedType = errors.New("unsupported type")
+)
+
+// ParseOpts is the inverse of GetOpts: it sets the fields of obj from command line options,
+// by matching the option names with the opt tags of the fields.
+// obj must be a pointer to a struct. Fields that are not matched by any option are left untouched.
+// Following types are supported: string, integers, bool, float64, time.Duration (also in Prometheus format, e.g. 1d),
+// *net.TCPAddr, types implementing encoding.TextUnmarshaler, pointers and slices of supported types.
+// Slices are reset by the first matching option and extended by the following ones.
+// Options are accepted as "--name=value" or "--name value", bool options may omit their value.
+// Unknown options, positional arguments and options of fields whose type is not supported (e.g. ContainerUpdater)
+// are kept in ExtraOpts if obj implements AddExtraOpts, otherwise an error is returned.
+func ParseOpts(args []string, obj interface{}) error {
+	v := reflect.ValueOf(obj)
+	if !v.IsValid() || v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cmdopt: expected a non-nil pointer to a struct, got %T", obj)
+	}
+
+	addExtraOpts, _ := obj.(interface{ AddExtraOpts(...string) })
+	extra := []string{}
+
+	fields := optFields(v.Elem())
+	resetSlices := map[int]bool{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+
+		fieldIdx, ok := fields[name]
+		if !strings.HasPrefix(arg, "-") || !ok {
+			extra = append(extra, arg)
+			continue
+		}
+
+		field := v.Elem().Field(fieldIdx)
+		consumed := []string{arg}
+
+		if !hasValue {
+			if isBoolField(field.Type()) {
+				value = "true"
+			} else {
+				if i+1 >= len(args) {
+					return fmt.Errorf("cmdopt: missing value for option %s", name)
+				}
+				i++
+				value = args[i]
+				consumed = append(consumed, value)
+			}
+		}
+
+		target := field
+		resetSlice := field.Kind() == reflect.Slice && !resetSlices[fieldIdx]
+		if resetSlice {
+			// Replace the existing values (e.g. defaults) instead of extending them.
+			target = reflect.New(field.Type()).Elem()
+		}
+
+		if err := setValue(target, value); err != nil {
+			if errors.Is(err, errUnsupportedType) {
+				extra = append(extra, consumed...)
+				continue
+			}
+			return fmt.Errorf("cmdopt: invalid value %q for option %s: %w", value, name, err)
+		}
+
+		if resetSlice {
+			resetSlices[fieldIdx] = true
+			field.Set(target)
+		}
+	}
+
+	if len(extra) == 0 {
+		return nil
+	}
+
+	if addExtraOpts == nil {
+		return fmt.Errorf("cmdopt: unknown options %v and %T does not support extra options", extra, obj)
+	}
+
+	addExtraOpts.AddExtraOpts(extra...)
+
+	return nil
+}
+
+// optFields maps the option names, as rendered by GetOpts, to the index of their field.
+// When several fields share an option name, the first one wins.
+func optFields(v reflect.Value) map[string]int {
+	ret := map[string]int{}
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath != "" {
+			continue
+		}
+
+		optName := getOptName(strings.Split(t.Field(i).Tag.Get(tagName), ","))
+		if optName == "" {
+			continue
+		}
+
+		if _, ok := ret[optName]; !ok {
+			ret[optName] = i
+		}
+	}
+
+	return ret
+}
+
+func isBoolField(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool
+}
+
+// setValue parses value into field, allocating pointers and appending to slices.
+func setValue(field reflect.Value, value string) error {
+	t := field.Type()
+
+	// Types with their own parser take precedence.
+	if t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface && reflect.PointerTo(t).Implements(textUnmarshalType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	switch {
+	case t == durationType:
+		d, err := parseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	case t == tcpAddrType:
+		addr, err := parseTCPAddr(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(*addr))
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(t.Elem())
+		if err := setValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+	case reflect.Slice:
+		elem := reflect.New(t.Elem()).Elem()
+		if err := setValue(elem, value); err != nil {
+			return err
+		}
+		field.Set(reflect.Append(field, elem))
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, t.Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, t.Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, t.Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return errUnsupportedType
+	}
+
+	return nil
+}
+
+// parseDuration accepts Go durations as rendered by GetOpts, and Prometheus durations as used by Thanos flags.
+func parseDuration(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err == nil {
+		return d, nil
+	}
+
+	pd, perr := prommodel.ParseDuration(value)
+	if perr != nil {
+		return 0, err
+	}
+
+	return time.Duration(pd), nil
+}
+
+// parseTCPAddr parses a host:port address without resolving it. The host must be empty or an IP.
+func parseTCPAddr(value string) (*net.TCPAddr, error) {
+	host, portStr, err := net.SplitHostPort(value)
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q: %w", portStr, err)
+	}
+
+	ret := &net.TCPAddr{Port: port}
+	if host == "" {
+		return ret, nil
+	}
+
+	host, zone, _ := strings.Cut(host, "%")
+	ret.IP = net.ParseIP(host)
+	ret.Zone = zone
+	if ret.IP == nil {
+		return nil, fmt.Errorf("host %q is not an IP address", host)
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,135 @@
+package cmdopt
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	sinceTagName = "since"
+	untilTagName = "until"
+)
+
+// CheckVersion checks that the options set on obj are supported by the given version of the component.
+// Fields are annotated with the since tag, holding the first version supporting the option,
+// and the until tag, holding the first version not supporting it anymore. For example:
+//
+//	StoreEnableLazyExpandedPostings bool `opt:"store.enable-lazy-expanded-postings,noval" since:"v0.33.0"`
+//
+// The tags of the Thanos options structs are checked against the --help output of the releases since v0.26.0,
+// checked in under kubegen/optgen/testdata; options available in all of them carry no tag.
+// Only options that are rendered by GetOpts are checked, unset options are always accepted.
+// Versions that are not semantic versions, such as latest or main-2023-01-24-5f4fdf4, cannot be compared and are accepted.
+// Pre-release suffixes are ignored, so that v0.33.0-rc.0 supports options added in v0.33.0.
+func CheckVersion(obj interface{}, version string) error {
+	if obj == nil {
+		return nil
+	}
+
+	current, ok := parseVersion(version)
+	if !ok {
+		return nil
+	}
+
+	v := reflect.ValueOf(obj)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("cmdopt: expected a struct, got %T", obj)
+	}
+
+	t := v.Type()
+	var errs []error
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		since, until := field.Tag.Get(sinceTagName), field.Tag.Get(untilTagName)
+		if since == "" && until == "" {
+			continue
+		}
+
+		optTagVals := strings.Split(field.Tag.Get(tagName), ",")
+		optName := getOptName(optTagVals)
+		if optName == "" || !isOptSet(optTagVals, v.Field(i)) {
+			continue
+		}
+
+		if since != "" {
+			min, ok := parseVersion(since)
+			if !ok {
+				return fmt.Errorf("cmdopt: invalid since version %q on field %s", since, field.Name)
+			}
+
+			if compareVersions(current, min) < 0 {
+				errs = append(errs, fmt.Errorf("option %s requires version %s or later, got %s", optName, since, version))
+			}
+		}
+
+		if until != "" {
+			max, ok := parseVersion(until)
+			if !ok {
+				return fmt.Errorf("cmdopt: invalid until version %q on field %s", until, field.Name)
+			}
+
+			if compareVersions(current, max) >= 0 {
+				errs = append(errs, fmt.Errorf("option %s is not supported since version %s, got %s", optName, until, version))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// isOptSet returns true if GetOpts renders the field.
+func isOptSet(optTagVals []string, v reflect.Value) bool {
+	return isNoVal(optTagVals, v.Kind(), v) || len(getOptValue(v.Kind(), v)) > 0
+}
+
+// parseVersion parses versions such as v0.33.0, 0.33 or v0.33.0-rc.0 into their major, minor and patch numbers.
+func parseVersion(version string) ([3]int, bool) {
+	ret := [3]int{}
+
+	version = strings.TrimPrefix(version, "v")
+	version, _, _ = strings.Cut(version, "-")
+	version, _, _ = strings.Cut(version, "+")
+
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return ret, false
+	}
+
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return ret, false
+		}
+		ret[i] = n
+	}
+
+	return ret, true
+}
+
+func compareVersions(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
@@ -1,6 +1,7 @@
 package containeropts
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 	"reflect"
@@ -15,6 +16,29 @@ type ContainerUpdater interface {
 	Update(*workload.Container)
 }
 
+// ContainerUpdaterE is implemented by container updaters that can report errors instead of panicking.
+type ContainerUpdaterE interface {
+	UpdateE(*workload.Container) error
+}
+
+// UpdateContainerE updates the container with the given updater and returns an error instead of panicking.
+// If the updater doesn't implement ContainerUpdaterE, panics raised by Update are converted into errors.
+func UpdateContainerE(updater ContainerUpdater, container *workload.Container) (err error) {
+	if updaterE, ok := updater.(ContainerUpdaterE); ok {
+		return updaterE.UpdateE(container)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	updater.Update(container)
+
+	return nil
+}
+
 // FileInVolume is a configuration file that must be consumed by the container.
 // It encapsulates the data needed to mount the file as a volume in a container.
 // It is used when the config file is generated by a sidecar container or an init container.
@@ -41,7 +65,14 @@ func (c *FileInVolume) String() string {
 
 // Update mount the required volume to the container.
 func (c *FileInVolume) Update(container *workload.Container) {
-	addVolumeMountToContainer(container, c.volumeName, c.mountPath)
+	if err := c.UpdateE(container); err != nil {
+		panic(err.Error())
+	}
+}
+
+// UpdateE is like Update but returns an error instead of panicking.
+func (c *FileInVolume) UpdateE(container *workload.Container) error {
+	return addVolumeMountToContainer(container, c.volumeName, c.mountPath)
 }
 
 // ConfigResourceAsFile represents a configuration file that must be consumed by a container.
@@ -113,32 +144,53 @@ func (c *ConfigResourceAsFile) String() string {
 // Update adds the config file to the container.
 // It configures volumes, volume mounts and resources (ConfigMap or Secret) for the container.
 // It includes some logic to avoid creating duplicate resources, volumes and volume mounts.
+// It panics if the config file is invalid, see UpdateE for a non panicking version.
 func (c *ConfigResourceAsFile) Update(container *workload.Container) {
+	if err := c.UpdateE(container); err != nil {
+		panic(err.Error())
+	}
+}
+
+// UpdateE is like Update but returns an error instead of panicking.
+// All the missing fields are reported at once.
+func (c *ConfigResourceAsFile) UpdateE(container *workload.Container) error {
+	var errs []error
+
 	if c.resourceName == "" {
-		panic("resource name is empty")
+		errs = append(errs, errors.New("resource name is empty"))
 	}
 
 	if c.mountPath == "" {
-		panic("mount path is empty")
+		errs = append(errs, errors.New("mount path is empty"))
 	}
 
 	if c.volumeName == "" {
-		panic("volume name is empty")
+		errs = append(errs, errors.New("volume name is empty"))
 	}
 
 	if c.key == "" {
-		panic("key is empty")
+		errs = append(errs, errors.New("key is empty"))
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if err := c.addResourceToContainer(container); err != nil {
+		return err
+	}
+
+	if err := c.addVolumeToContainer(container); err != nil {
+		return err
 	}
 
-	c.addResourceToContainer(container)
-	c.addVolumeToContainer(container)
-	addVolumeMountToContainer(container, c.volumeName, c.mountPath)
+	return addVolumeMountToContainer(container, c.volumeName, c.mountPath)
 }
 
-func (c *ConfigResourceAsFile) addResourceToContainer(container *workload.Container) {
+func (c *ConfigResourceAsFile) addResourceToContainer(container *workload.Container) error {
 	// If resource must be created, add it to the container
 	if c.value == "" {
-		return
+		return nil
 	}
 
 	if c.isSecret {
@@ -154,10 +206,10 @@ func (c *ConfigResourceAsFile) addResourceToContainer(container *workload.Contai
 		if val, ok := container.Secrets[c.resourceName]; ok {
 			// Check if content is the same
 			if reflect.DeepEqual(val, newSecret) {
-				return
+				return nil
 			}
 
-			panic(fmt.Sprintf("secret %q already exists", c.resourceName))
+			return fmt.Errorf("secret %q already exists", c.resourceName)
 		}
 
 		container.Secrets[c.resourceName] = newSecret
@@ -174,38 +226,40 @@ func (c *ConfigResourceAsFile) addResourceToContainer(container *workload.Contai
 		if val, ok := container.ConfigMaps[c.resourceName]; ok {
 			// Check if content is the same
 			if reflect.DeepEqual(val, newConfigMap) {
-				return
+				return nil
 			}
 
-			panic(fmt.Sprintf("configmap %q already exists", c.resourceName))
+			return fmt.Errorf("configmap %q already exists", c.resourceName)
 		}
 
 		container.ConfigMaps[c.resourceName] = newConfigMap
 	}
+
+	return nil
 }
 
-func (c *ConfigResourceAsFile) addVolumeToContainer(container *workload.Container) {
+func (c *ConfigResourceAsFile) addVolumeToContainer(container *workload.Container) error {
 	// Check if a volume with this resource name already exists
 	for _, vol := range container.Volumes {
 		if c.isSecret && vol.VolumeSource.Secret != nil && vol.VolumeSource.Secret.SecretName == c.resourceName {
 			// Update volume name
 			c.volumeName = vol.Name
 			// existingVolume = &vol
-			return
+			return nil
 		}
 
 		if !c.isSecret && vol.VolumeSource.ConfigMap != nil && vol.VolumeSource.ConfigMap.Name == c.resourceName {
 			// Update volume name
 			c.volumeName = vol.Name
 			// existingVolume = &vol
-			return
+			return nil
 		}
 	}
 
 	// Check that the volume name is not already used
 	for _, vol := range container.Volumes {
 		if vol.Name == c.volumeName {
-			panic(fmt.Sprintf("volume name %q is already used", c.volumeName))
+			return fmt.Errorf("volume name %q is already used", c.volumeName)
 		}
 	}
 
@@ -215,20 +269,22 @@ func (c *ConfigResourceAsFile) addVolumeToContainer(container *workload.Containe
 	} else {
 		container.Volumes = append(container.Volumes, helpers.NewPodVolumeFromConfigMap(c.volumeName, c.resourceName))
 	}
+
+	return nil
 }
 
-func addVolumeMountToContainer(container *workload.Container, volumeName, mountPath string) {
+func addVolumeMountToContainer(container *workload.Container, volumeName, mountPath string) error {
 	// Check if the volume is already mounted
 	for _, mount := range container.VolumeMounts {
 		if mount.Name == volumeName {
-			return
+			return nil
 		}
 	}
 
 	// Check if mount path is already used
 	for _, mount := range container.VolumeMounts {
 		if strings.HasPrefix(mountPath, mount.MountPath) {
-			panic(fmt.Sprintf("mount path %q is already used", mountPath))
+			return fmt.Errorf("mount path %q is already used", mountPath)
 		}
 	}
 
@@ -238,4 +294,6 @@ func addVolumeMountToContainer(container *workload.Container, volumeName, mountP
 		MountPath: mountPath,
 		ReadOnly:  true,
 	})
+
+	return nil
 }
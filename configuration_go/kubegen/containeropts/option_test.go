@@ -3,6 +3,7 @@ package containeropts_test
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/observatorium/observatorium/configuration_go/kubegen/containeropts"
@@ -205,3 +206,59 @@ func makeVolumeMount(name, path string) corev1.VolumeMount {
 		MountPath: path,
 	}
 }
+
+func TestConfigFileUpdateE(t *testing.T) {
+	testCases := map[string]struct {
+		container *workload.Container
+		option    *containeropts.ConfigResourceAsFile
+		expected  []string
+	}{
+		"valid option": {
+			container: &workload.Container{},
+			option:    containeropts.NewConfigResourceAsFile("/etc/config", "config.yaml", "config-volume", "configmap-name"),
+		},
+		"all missing fields are reported": {
+			container: &workload.Container{},
+			option:    containeropts.NewConfigResourceAsFile("", "", "config-volume", ""),
+			expected:  []string{"resource name is empty", "mount path is empty", "key is empty"},
+		},
+		"conflicting configmap": {
+			container: &workload.Container{
+				ConfigMaps: map[string]map[string]string{
+					"configmap-name": {"config.yaml": "other"},
+				},
+			},
+			option:   containeropts.NewConfigResourceAsFile("/etc/config", "config.yaml", "config-volume", "configmap-name").WithValue("value"),
+			expected: []string{`configmap "configmap-name" already exists`},
+		},
+		"conflicting mount path": {
+			container: &workload.Container{
+				VolumeMounts: []corev1.VolumeMount{makeVolumeMount("other-volume", "/etc")},
+			},
+			option:   containeropts.NewConfigResourceAsFile("/etc/config", "config.yaml", "config-volume", "configmap-name"),
+			expected: []string{`mount path "/etc/config" is already used`},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.option.UpdateE(tc.container)
+			if len(tc.expected) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+
+			for _, exp := range tc.expected {
+				if !strings.Contains(err.Error(), exp) {
+					t.Errorf("expected error to contain %q, got %q", exp, err.Error())
+				}
+			}
+		})
+	}
+}
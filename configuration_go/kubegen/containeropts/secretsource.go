@@ -0,0 +1,325 @@
+package containeropts
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// SecretSource provides the secrets consumed by the containers in the cluster, so that the generated
+// manifests don't contain their data. Pods keep referencing the secrets by name and key.
+type SecretSource interface {
+	// SecretObjects returns the objects replacing the given secret in the manifests.
+	SecretObjects(secret *corev1.Secret) ([]runtime.Object, error)
+}
+
+// ApplySecretSource replaces the Secrets of the objects with the objects of the given source, in place.
+// Only the Secrets with the given names are replaced, or all of them if no name is given. Secrets of
+// OpenShift templates are not replaced: the source must be applied before wrapping the objects in a template.
+func ApplySecretSource(objects []runtime.Object, source SecretSource, names ...string) ([]runtime.Object, error) {
+	ret := make([]runtime.Object, 0, len(objects))
+	var errs []error
+	for _, obj := range objects {
+		secret, ok := obj.(*corev1.Secret)
+		if !ok || (len(names) > 0 && !slices.Contains(names, secret.Name)) {
+			ret = append(ret, obj)
+			continue
+		}
+
+		objs, err := source.SecretObjects(secret)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("secret %s: %w", secret.Name, err))
+			continue
+		}
+
+		ret = append(ret, objs...)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// secretData returns the data of the secret, merging its string data like the API server does.
+func secretData(secret *corev1.Secret) map[string][]byte {
+	ret := maps.Clone(secret.Data)
+	if ret == nil {
+		ret = map[string][]byte{}
+	}
+
+	for k, v := range secret.StringData {
+		ret[k] = []byte(v)
+	}
+
+	return ret
+}
+
+// secretKeys returns the sorted keys of the secret, so that generated objects are stable.
+func secretKeys(secret *corev1.Secret) []string {
+	ret := slices.Collect(maps.Keys(secretData(secret)))
+	sort.Strings(ret)
+
+	return ret
+}
+
+// ExistingSecrets is the source of secrets created out of band, e.g. by hand or by another pipeline.
+// The secrets are removed from the manifests and must exist in the cluster with the same names and keys.
+type ExistingSecrets struct{}
+
+// SecretObjects returns no object.
+func (ExistingSecrets) SecretObjects(secret *corev1.Secret) ([]runtime.Object, error) {
+	return nil, nil
+}
+
+// ExternalSecrets is the source of secrets synced by the external-secrets operator from a secret
+// management tool, such as Vault. Each secret is replaced by an ExternalSecret creating it.
+type ExternalSecrets struct {
+	// StoreName is the name of the SecretStore to read from.
+	StoreName string
+	// StoreKind is SecretStore or ClusterSecretStore. It defaults to SecretStore.
+	StoreKind string
+	// RefreshInterval is the interval at which secrets are synced. It defaults to one hour.
+	RefreshInterval time.Duration
+	// RemoteKey returns the key of the secret in the store. Each key of the secret is read from the property
+	// of the same name. It defaults to <namespace>/<name>.
+	RemoteKey func(secret *corev1.Secret) string
+}
+
+// SecretObjects returns the ExternalSecret creating the secret. The data of the secret is not used.
+func (e ExternalSecrets) SecretObjects(secret *corev1.Secret) ([]runtime.Object, error) {
+	if e.StoreName == "" {
+		return nil, errors.New("secret store name is not specified")
+	}
+
+	storeKind := e.StoreKind
+	if storeKind == "" {
+		storeKind = "SecretStore"
+	}
+	if storeKind != "SecretStore" && storeKind != "ClusterSecretStore" {
+		return nil, fmt.Errorf("unsupported secret store kind %s, expected SecretStore or ClusterSecretStore", storeKind)
+	}
+
+	refreshInterval := e.RefreshInterval
+	if refreshInterval == 0 {
+		refreshInterval = time.Hour
+	}
+
+	remoteKey := fmt.Sprintf("%s/%s", secret.Namespace, secret.Name)
+	if e.RemoteKey != nil {
+		remoteKey = e.RemoteKey(secret)
+	}
+
+	keys := secretKeys(secret)
+	if len(keys) == 0 {
+		return nil, errors.New("secret has no key")
+	}
+
+	data := make([]interface{}, 0, len(keys))
+	for _, k := range keys {
+		data = append(data, map[string]interface{}{
+			"secretKey": k,
+			"remoteRef": map[string]interface{}{
+				"key":      remoteKey,
+				"property": k,
+			},
+		})
+	}
+
+	ret := newUnstructuredSecret(workload.ExternalSecretMeta.APIVersion, workload.ExternalSecretMeta.Kind, secret, map[string]interface{}{
+		"refreshInterval": refreshInterval.String(),
+		"secretStoreRef": map[string]interface{}{
+			"name": e.StoreName,
+			"kind": storeKind,
+		},
+		"target": map[string]interface{}{
+			"name":           secret.Name,
+			"creationPolicy": "Owner",
+			"template":       secretTemplate(secret),
+		},
+		"data": data,
+	})
+
+	return []runtime.Object{ret}, nil
+}
+
+// SealedSecrets is the source of secrets decrypted in the cluster by the sealed-secrets controller.
+// Each secret is replaced by a SealedSecret holding its data encrypted with the public key of the controller,
+// in the strict scope: the SealedSecret can only be decrypted with the name and namespace of the secret.
+// The encryption is randomized, so generated manifests change on each run.
+type SealedSecrets struct {
+	PublicKey *rsa.PublicKey
+	// Rand is the source of randomness of the encryption. It defaults to crypto/rand.
+	Rand io.Reader
+}
+
+// NewSealedSecrets returns a source sealing secrets with the given PEM encoded public key or certificate,
+// such as the one printed by kubeseal --fetch-cert.
+func NewSealedSecrets(pemData []byte) (*SealedSecrets, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("no PEM data found in the public key")
+	}
+
+	var pub interface{}
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		pub = cert.PublicKey
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		pub = key
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %s, expected CERTIFICATE or PUBLIC KEY", block.Type)
+	}
+
+	rsaKey, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type %T, expected an RSA key", pub)
+	}
+
+	return &SealedSecrets{PublicKey: rsaKey}, nil
+}
+
+// SecretObjects returns the SealedSecret holding the encrypted data of the secret.
+func (s SealedSecrets) SecretObjects(secret *corev1.Secret) ([]runtime.Object, error) {
+	if s.PublicKey == nil {
+		return nil, errors.New("public key is not specified")
+	}
+
+	rnd := s.Rand
+	if rnd == nil {
+		rnd = rand.Reader
+	}
+
+	data := secretData(secret)
+	label := []byte(fmt.Sprintf("%s/%s", secret.Namespace, secret.Name))
+	encrypted := map[string]interface{}{}
+	for _, k := range secretKeys(secret) {
+		ciphertext, err := hybridEncrypt(rnd, s.PublicKey, data[k], label)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt key %s: %w", k, err)
+		}
+		encrypted[k] = base64.StdEncoding.EncodeToString(ciphertext)
+	}
+
+	ret := newUnstructuredSecret(workload.SealedSecretMeta.APIVersion, workload.SealedSecretMeta.Kind, secret, map[string]interface{}{
+		"encryptedData": encrypted,
+		"template":      secretTemplate(secret),
+	})
+
+	return []runtime.Object{ret}, nil
+}
+
+// hybridEncrypt encrypts the plaintext like kubeseal does: with AES-GCM and a random session key, itself
+// encrypted with RSA-OAEP and prefixed to the ciphertext with its length.
+func hybridEncrypt(rnd io.Reader, pub *rsa.PublicKey, plaintext, label []byte) ([]byte, error) {
+	sessionKey := make([]byte, 32)
+	if _, err := io.ReadFull(rnd, sessionKey); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaCiphertext, err := rsa.EncryptOAEP(sha256.New(), rnd, pub, sessionKey, label)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := binary.BigEndian.AppendUint16(nil, uint16(len(rsaCiphertext)))
+	ret = append(ret, rsaCiphertext...)
+
+	// The session key is used once, so a zero nonce is safe.
+	return aead.Seal(ret, make([]byte, aead.NonceSize()), plaintext, nil), nil
+}
+
+// secretTemplate returns the template of the secret created by the operators, keeping its metadata and type.
+func secretTemplate(secret *corev1.Secret) map[string]interface{} {
+	metadata := map[string]interface{}{}
+	if len(secret.Labels) > 0 {
+		labels := map[string]interface{}{}
+		for k, v := range secret.Labels {
+			labels[k] = v
+		}
+		metadata["labels"] = labels
+	}
+
+	ret := map[string]interface{}{"metadata": metadata}
+	if secret.Type != "" {
+		ret["type"] = string(secret.Type)
+	}
+
+	return ret
+}
+
+func newUnstructuredSecret(apiVersion, kind string, secret *corev1.Secret, spec map[string]interface{}) *unstructured.Unstructured {
+	ret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{},
+		"spec":     spec,
+	}}
+	ret.SetAPIVersion(apiVersion)
+	ret.SetKind(kind)
+	ret.SetName(secret.Name)
+	ret.SetNamespace(secret.Namespace)
+	ret.SetLabels(maps.Clone(secret.Labels))
+
+	return ret
+}
+
+// SecretValueFromEnv returns the value of the given environment variable, so that secret values of the
+// configuration, e.g. the client secrets of tenants, are read when generating the manifests.
+// It returns an error if the variable is not set.
+func SecretValueFromEnv(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+
+	return value, nil
+}
+
+// SecretValueFromFile is like SecretValueFromEnv but reads the value from a file.
+// A trailing newline is removed, as editors usually add one.
+func SecretValueFromFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret value: %w", err)
+	}
+
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
@@ -0,0 +1,193 @@
+package containeropts_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/kubegen/containeropts"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestApplySecretSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sealed, err := containeropts.NewSealedSecrets(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newObjects := func() []runtime.Object {
+		w := workload.DeploymentWorkload{
+			Replicas: 1,
+			PodConfig: workload.PodConfig{
+				Name:         "api",
+				Namespace:    "ns",
+				Image:        "api",
+				ImageTag:     "v1",
+				CommonLabels: map[string]string{workload.NameLabel: "api"},
+				ConfigMaps:   map[string]map[string]string{"config": {"config.yaml": ""}},
+				Secrets: map[string]map[string][]byte{
+					"tenants": {"tenants.yaml": []byte("clientSecret: s3cr3t")},
+					"other":   {"key": []byte("value")},
+				},
+			},
+		}
+		return w.Objects(w.ToContainer())
+	}
+
+	testCases := map[string]struct {
+		source        containeropts.SecretSource
+		names         []string
+		expectedKinds []string
+		expectedErr   string
+		check         func(t *testing.T, obj *unstructured.Unstructured)
+	}{
+		"existing secrets": {
+			source:        containeropts.ExistingSecrets{},
+			expectedKinds: []string{"ConfigMap"},
+		},
+		"existing secrets with names": {
+			source:        containeropts.ExistingSecrets{},
+			names:         []string{"tenants"},
+			expectedKinds: []string{"ConfigMap", "Secret"},
+		},
+		"external secrets": {
+			source:        containeropts.ExternalSecrets{StoreName: "vault", StoreKind: "ClusterSecretStore"},
+			names:         []string{"tenants"},
+			expectedKinds: []string{"ConfigMap", "ExternalSecret", "Secret"},
+			check: func(t *testing.T, obj *unstructured.Unstructured) {
+				data, _, _ := unstructured.NestedSlice(obj.Object, "spec", "data")
+				if len(data) != 1 {
+					t.Fatalf("expected 1 data entry, got %v", data)
+				}
+				key, _, _ := unstructured.NestedString(data[0].(map[string]interface{}), "remoteRef", "key")
+				if key != "ns/tenants" {
+					t.Errorf("expected remote key ns/tenants, got %s", key)
+				}
+				target, _, _ := unstructured.NestedString(obj.Object, "spec", "target", "name")
+				if target != "tenants" {
+					t.Errorf("expected target tenants, got %s", target)
+				}
+			},
+		},
+		"external secrets without store": {
+			source:      containeropts.ExternalSecrets{},
+			expectedErr: "secret tenants: secret store name is not specified",
+		},
+		"external secrets with unknown store kind": {
+			source:      containeropts.ExternalSecrets{StoreName: "vault", StoreKind: "Vault"},
+			names:       []string{"tenants"},
+			expectedErr: "unsupported secret store kind Vault",
+		},
+		"sealed secrets": {
+			source:        sealed,
+			names:         []string{"tenants"},
+			expectedKinds: []string{"ConfigMap", "SealedSecret", "Secret"},
+			check: func(t *testing.T, obj *unstructured.Unstructured) {
+				value, _, _ := unstructured.NestedString(obj.Object, "spec", "encryptedData", "tenants.yaml")
+				if strings.Contains(value, "s3cr3t") {
+					t.Fatal("sealed secret contains the plaintext")
+				}
+				if got := unseal(t, key, value, "ns/tenants"); got != "clientSecret: s3cr3t" {
+					t.Errorf("expected decrypted value to match the secret, got %q", got)
+				}
+				labels, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "template", "metadata", "labels")
+				if labels[workload.NameLabel] != "api" {
+					t.Errorf("expected template to keep the secret labels, got %v", labels)
+				}
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			objs, err := containeropts.ApplySecretSource(newObjects(), tc.source, tc.names...)
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			kinds := []string{}
+			for _, obj := range objs {
+				switch o := obj.(type) {
+				case *corev1.ConfigMap:
+					kinds = append(kinds, "ConfigMap")
+				case *corev1.Secret:
+					kinds = append(kinds, "Secret")
+				case *unstructured.Unstructured:
+					kinds = append(kinds, o.GetKind())
+					if o.GetName() != "tenants" || o.GetNamespace() != "ns" {
+						t.Errorf("expected object to be named after the secret, got %s/%s", o.GetNamespace(), o.GetName())
+					}
+					if tc.check != nil {
+						tc.check(t, o)
+					}
+				}
+			}
+
+			slices.Sort(kinds)
+			if strings.Join(kinds, ",") != strings.Join(tc.expectedKinds, ",") {
+				t.Errorf("expected kinds %v, got %v", tc.expectedKinds, kinds)
+			}
+		})
+	}
+}
+
+// unseal decrypts a value of a SealedSecret like the sealed-secrets controller does.
+func unseal(t *testing.T, key *rsa.PrivateKey, value, label string) string {
+	t.Helper()
+
+	ciphertext, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaLen := int(binary.BigEndian.Uint16(ciphertext))
+	sessionKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, key, ciphertext[2:2+rsaLen], []byte(label))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext, err := aead.Open(nil, make([]byte, aead.NonceSize()), ciphertext[2+rsaLen:], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(plaintext)
+}
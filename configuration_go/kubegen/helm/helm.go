@@ -0,0 +1,294 @@
+package helm
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/observatorium/observatorium/configuration_go/kubegen/kubeyaml"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	chartFile       = "Chart.yaml"
+	valuesFile      = "values.yaml"
+	templatesDir    = "templates"
+	chartAPIVersion = "v2"
+
+	// placeholderPrefix marks the fields lifted into values in the rendered objects,
+	// before they are replaced by template expressions.
+	placeholderPrefix = "__helm_value_"
+)
+
+var (
+	// templateDelimsRe matches the template delimiters already present in objects, e.g. in configuration files.
+	templateDelimsRe = regexp.MustCompile(`{{|}}`)
+	placeholderRe    = regexp.MustCompile(`(?m)^(\s*)(- )?(\w+): ` + placeholderPrefix + `(\d+)$`)
+)
+
+// Chart holds the metadata of the generated chart.
+// See https://helm.sh/docs/topics/charts/#the-chartyaml-file for details.
+type Chart struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	AppVersion  string `json:"appVersion,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Write writes the chart generated from the given objects in dir.
+func Write(dir string, chart Chart, objects []runtime.Object) error {
+	files, err := Generate(chart, objects)
+	if err != nil {
+		return err
+	}
+
+	return kubeyaml.WriteFilesInDir(files, dir)
+}
+
+// Generate returns the files of the chart generated from the given objects, keyed by their
+// slash separated path relative to the chart directory.
+// Following fields are lifted into values, the objects' values being used as defaults:
+//   - namespace: the namespace of all the objects, defaulting to the release namespace if empty.
+//   - <workload>.replicas: the replicas of each Deployment and StatefulSet.
+//   - <workload>.image.repository and <workload>.image.tag: the image of their main container (the first one).
+//   - <workload>.resources: the resources of their main container.
+//
+// Workload keys are the camel cased names of the workloads, e.g. observatoriumThanosQuery.
+// Template delimiters found in the objects, e.g. in configuration files, are escaped.
+func Generate(chart Chart, objects []runtime.Object) (map[string][]byte, error) {
+	var errs []error
+	if chart.Name == "" {
+		errs = append(errs, errors.New("chart name must be specified"))
+	}
+
+	if chart.Version == "" {
+		errs = append(errs, errors.New("chart version must be specified"))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	ret := map[string][]byte{}
+	values := map[string]interface{}{}
+
+	for _, obj := range objects {
+		name, err := kubeyaml.KubeObjectNameAndKindE(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		fileName := path.Join(templatesDir, name+".yaml")
+		if _, ok := ret[fileName]; ok {
+			return nil, fmt.Errorf("duplicate object %s", name)
+		}
+
+		tmpl, err := newTemplate(obj, values)
+		if err != nil {
+			return nil, fmt.Errorf("failed to templatize %s: %w", name, err)
+		}
+
+		ret[fileName] = tmpl
+	}
+
+	if _, ok := values["namespace"]; !ok {
+		values["namespace"] = ""
+	}
+
+	var err error
+	if ret[valuesFile], err = yaml.Marshal(values); err != nil {
+		return nil, fmt.Errorf("failed to marshal values: %w", err)
+	}
+
+	if ret[chartFile], err = yaml.Marshal(struct {
+		APIVersion string `json:"apiVersion"`
+		Type       string `json:"type"`
+		Chart      `json:",inline"`
+	}{
+		APIVersion: chartAPIVersion,
+		Type:       "application",
+		Chart:      chart,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to marshal chart: %w", err)
+	}
+
+	return ret, nil
+}
+
+// template renders an object, replacing the lifted fields with placeholders
+// that are then substituted with template expressions.
+type template struct {
+	obj         map[string]interface{}
+	expressions []string
+}
+
+func newTemplate(obj runtime.Object, values map[string]interface{}) ([]byte, error) {
+	unstructured, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &template{obj: unstructured}
+
+	if err := t.liftNamespace(unstructured, values); err != nil {
+		return nil, err
+	}
+
+	switch w := obj.(type) {
+	case *appsv1.Deployment:
+		if err := t.liftWorkload(w.Name, w.Spec.Replicas, values); err != nil {
+			return nil, err
+		}
+	case *appsv1.StatefulSet:
+		if err := t.liftWorkload(w.Name, w.Spec.Replicas, values); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := yaml.Marshal(t.obj)
+	if err != nil {
+		return nil, err
+	}
+
+	// Escape the existing delimiters before adding the template expressions.
+	ret := templateDelimsRe.ReplaceAllStringFunc(string(data), func(s string) string {
+		return fmt.Sprintf(`{{ %q }}`, s)
+	})
+
+	ret = placeholderRe.ReplaceAllStringFunc(ret, func(line string) string {
+		m := placeholderRe.FindStringSubmatch(line)
+		indent, item, key, idx := m[1], m[2], m[3], m[4]
+
+		i, _ := strconv.Atoi(idx)
+		expr := t.expressions[i]
+		if key != "resources" {
+			return fmt.Sprintf("%s%s%s: %s", indent, item, key, expr)
+		}
+
+		// Resources are a block rendered from values.
+		nindent := len(indent) + len(item) + 2
+		return fmt.Sprintf("%s%s%s:\n%s{{- toYaml %s | nindent %d }}", indent, item, key, strings.Repeat(" ", nindent), expr, nindent)
+	})
+
+	return []byte(ret), nil
+}
+
+// liftNamespace lifts the namespace of the metadata of obj into values.
+func (t *template) liftNamespace(obj map[string]interface{}, values map[string]interface{}) error {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	ns, _ := metadata["namespace"].(string)
+	if ns == "" {
+		return nil
+	}
+
+	if prev, ok := values["namespace"]; ok && prev != ns {
+		return fmt.Errorf("objects must share the same namespace, got %s and %s", prev, ns)
+	}
+
+	values["namespace"] = ns
+	metadata["namespace"] = t.placeholder(`{{ .Values.namespace | default .Release.Namespace }}`)
+
+	return nil
+}
+
+// liftWorkload lifts the replicas, image and resources of a workload into values.
+func (t *template) liftWorkload(name string, replicas *int32, values map[string]interface{}) error {
+	key := valuesKey(name)
+	if _, ok := values[key]; ok {
+		return fmt.Errorf("duplicate values key %s", key)
+	}
+
+	workloadValues := map[string]interface{}{}
+	values[key] = workloadValues
+	ref := ".Values." + key
+
+	spec, _ := t.obj["spec"].(map[string]interface{})
+	if spec == nil {
+		return errors.New("workload has no spec")
+	}
+
+	if replicas != nil {
+		workloadValues["replicas"] = *replicas
+		spec["replicas"] = t.placeholder(fmt.Sprintf("{{ %s.replicas }}", ref))
+	}
+
+	podTemplate, _ := spec["template"].(map[string]interface{})
+	if err := t.liftNamespace(podTemplate, values); err != nil {
+		return err
+	}
+
+	podSpec, _ := podTemplate["spec"].(map[string]interface{})
+	containers, _ := podSpec["containers"].([]interface{})
+	if len(containers) == 0 {
+		return nil
+	}
+
+	container, _ := containers[0].(map[string]interface{})
+	if container == nil {
+		return nil
+	}
+
+	if image, ok := container["image"].(string); ok && image != "" {
+		repository, tag := splitImage(image)
+		workloadValues["image"] = map[string]interface{}{
+			"repository": repository,
+			"tag":        tag,
+		}
+		container["image"] = t.placeholder(fmt.Sprintf(`"{{ %[1]s.image.repository }}{{ with %[1]s.image.tag }}:{{ . }}{{ end }}"`, ref))
+	}
+
+	resources, _ := container["resources"].(map[string]interface{})
+	if resources == nil {
+		resources = map[string]interface{}{}
+	}
+	workloadValues["resources"] = resources
+	container["resources"] = t.placeholder(fmt.Sprintf("%s.resources", ref))
+
+	return nil
+}
+
+// placeholder registers a template expression and returns the placeholder to set in the object.
+func (t *template) placeholder(expr string) string {
+	t.expressions = append(t.expressions, expr)
+	return fmt.Sprintf("%s%d", placeholderPrefix, len(t.expressions)-1)
+}
+
+// valuesKey returns the camel cased name of a workload, e.g. observatoriumThanosQuery for observatorium-thanos-query.
+func valuesKey(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = b.Len() > 0
+			continue
+		}
+
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// splitImage splits an image into its repository and tag. Digests are kept in the repository.
+func splitImage(image string) (string, string) {
+	if strings.Contains(image, "@") {
+		return image, ""
+	}
+
+	// The tag separator is after the last slash, which allows registries with a port.
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+
+	return image, ""
+}
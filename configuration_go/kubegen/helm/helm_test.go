@@ -0,0 +1,91 @@
+package helm_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/kubegen/helm"
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+func TestGenerate(t *testing.T) {
+	replicas := int32(2)
+	objects := []runtime.Object{
+		&appsv1.Deployment{
+			TypeMeta:   workload.DeploymentMeta,
+			ObjectMeta: metav1.ObjectMeta{Name: "my-app", Namespace: "ns"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{
+							Name:      "main",
+							Image:     "registry:5000/org/app:v1",
+							Resources: kghelpers.NewResourcesRequirements("1", "2", "1Gi", "2Gi"),
+						}},
+					},
+				},
+			},
+		},
+		&corev1.ConfigMap{
+			TypeMeta:   workload.ConfigMapMeta,
+			ObjectMeta: metav1.ObjectMeta{Name: "my-app", Namespace: "ns"},
+			Data:       map[string]string{"template.tmpl": "{{ .Labels }}"},
+		},
+	}
+
+	files, err := helm.Generate(helm.Chart{Name: "app", Version: "0.1.0"}, objects)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(files["values.yaml"], &values); err != nil {
+		t.Fatalf("failed to unmarshal values: %v", err)
+	}
+
+	expectedValues := `myApp:
+  image:
+    repository: registry:5000/org/app
+    tag: v1
+  replicas: 2
+  resources:
+    limits:
+      cpu: "2"
+      memory: 2Gi
+    requests:
+      cpu: "1"
+      memory: 1Gi
+namespace: ns
+`
+	if string(files["values.yaml"]) != expectedValues {
+		t.Errorf("expected values:\n%s\ngot:\n%s", expectedValues, files["values.yaml"])
+	}
+
+	deployment := string(files["templates/my-app_Deployment.yaml"])
+	for _, expected := range []string{
+		"  namespace: {{ .Values.namespace | default .Release.Namespace }}\n",
+		"  replicas: {{ .Values.myApp.replicas }}\n",
+		`      - image: "{{ .Values.myApp.image.repository }}{{ with .Values.myApp.image.tag }}:{{ . }}{{ end }}"` + "\n",
+		"        resources:\n          {{- toYaml .Values.myApp.resources | nindent 10 }}\n",
+	} {
+		if !strings.Contains(deployment, expected) {
+			t.Errorf("expected deployment template to contain %q, got:\n%s", expected, deployment)
+		}
+	}
+
+	configMap := string(files["templates/my-app_ConfigMap.yaml"])
+	if expected := `template.tmpl: '{{ "{{" }} .Labels {{ "}}" }}'`; !strings.Contains(configMap, expected) {
+		t.Errorf("expected existing delimiters to be escaped as %q, got:\n%s", expected, configMap)
+	}
+
+	if _, err := helm.Generate(helm.Chart{}, objects); err == nil {
+		t.Errorf("expected error for missing chart name and version")
+	}
+}
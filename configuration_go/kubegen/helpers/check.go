@@ -16,17 +16,27 @@ func GetPortOrDefault(defaultValue int, addr *net.TCPAddr) int {
 }
 
 // CheckProbePort checks that the probe port matches the http port.
+// It panics if the ports don't match, see CheckProbePortE for a non panicking version.
 func CheckProbePort(port int, probe *corev1.Probe) {
+	if err := CheckProbePortE(port, probe); err != nil {
+		panic(err.Error())
+	}
+}
+
+// CheckProbePortE is like CheckProbePort but returns an error if the ports don't match.
+func CheckProbePortE(port int, probe *corev1.Probe) error {
 	if probe == nil {
-		return
+		return nil
 	}
 
 	if probe.ProbeHandler.HTTPGet == nil {
-		return
+		return nil
 	}
 
 	probePort := probe.ProbeHandler.HTTPGet.Port.IntVal
 	if int(probePort) != port {
-		panic(fmt.Sprintf(`probe port %d does not match http port %d`, probePort, port))
+		return fmt.Errorf(`probe port %d does not match http port %d`, probePort, port)
 	}
+
+	return nil
 }
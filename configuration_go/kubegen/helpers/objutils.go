@@ -17,7 +17,18 @@ const hostnameLabel string = "kubernetes.io/hostname"
 // GetObject returns the object of type T from the given list of kubernetes objects.
 // When specifying a name, it will return the object with the given name.
 // This helper can be used for doing post processing on the objects.
+// It panics if no object or multiple objects are found, see GetObjectE for a non panicking version.
 func GetObject[T metav1.Object](objects []runtime.Object, name string) T {
+	ret, err := GetObjectE[T](objects, name)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// GetObjectE is like GetObject but returns an error if no object or multiple objects are found.
+func GetObjectE[T metav1.Object](objects []runtime.Object, name string) (T, error) {
 	var ret T
 	found := false
 
@@ -27,9 +38,9 @@ func GetObject[T metav1.Object](objects []runtime.Object, name string) T {
 				continue
 			}
 
-			// Check if we already found an object of this type. If so, panic.
+			// Check if we already found an object of this type.
 			if found {
-				panic(fmt.Sprintf("found multiple objects of type %T", *new(T)))
+				return *new(T), fmt.Errorf("found multiple objects of type %T", *new(T))
 			}
 
 			ret = typedObject
@@ -38,10 +49,10 @@ func GetObject[T metav1.Object](objects []runtime.Object, name string) T {
 	}
 
 	if !found {
-		panic(fmt.Sprintf("could not find object of type %T", *new(T)))
+		return ret, fmt.Errorf("could not find object of type %T", *new(T))
 	}
 
-	return ret
+	return ret, nil
 }
 
 // NewResourcesRequirements returns a new resource requirements object for a container.
@@ -0,0 +1,206 @@
+package helpers_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/alertmanager"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/loki"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/memcached"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/api"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/up"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/prometheus/avalanche"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/compactor"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/receive"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/ruler"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/store"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/tracing"
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// objectsE is implemented by all the components of the library.
+type objectsE interface {
+	ObjectsE() ([]runtime.Object, error)
+}
+
+func TestObjectsE(t *testing.T) {
+	badProbe := func() *corev1.Probe {
+		return kghelpers.NewProbe("/ready", 1234, kghelpers.ProbeConfig{})
+	}
+
+	testCases := map[string]struct {
+		component func() objectsE
+		expected  []string
+	}{
+		"alertmanager": {
+			component: func() objectsE {
+				opts := alertmanager.NewDefaultOptions()
+				opts.StoragePath = ""
+				opts.ClusterListenAddress = "0.0.0.0"
+				ret := alertmanager.NewAlertManager(opts, "ns", "v0.26.0")
+				ret.LivenessProbe = badProbe()
+				return ret
+			},
+			expected: []string{
+				"invalid alertmanager",
+				"probe port 1234 does not match http port 9093",
+				"failed to parse cluster listen address 0.0.0.0",
+				"data directory is not specified",
+			},
+		},
+		"loki querier": {
+			component: func() objectsE {
+				opts := loki.NewDefaultOptions()
+				opts.ConfigFile = nil
+				ret := loki.NewQuerier(opts, "ns", "2.9.0")
+				ret.ReadinessProbe = badProbe()
+				return ret
+			},
+			expected: []string{"invalid loki querier", "probe port 1234 does not match http port 3100", "config file is not specified"},
+		},
+		"loki ingester": {
+			component: func() objectsE {
+				opts := loki.NewDefaultOptions()
+				opts.ConfigFile = nil
+				return loki.NewIngester(opts, "ns", "2.9.0")
+			},
+			expected: []string{"invalid loki ingester", "config file is not specified"},
+		},
+		"loki compactor": {
+			component: func() objectsE { return loki.NewCompactor(nil, "ns", "2.9.0") },
+		},
+		"loki gossip ring": {
+			component: func() objectsE { return loki.NewGossipRing("ns") },
+		},
+		"otel collector": {
+			component: func() objectsE {
+				opts := tracing.NewDefaultCollectorOptions()
+				opts.Config = nil
+				ret := tracing.NewOTelCollector(opts, "ns", "0.90.0")
+				ret.LivenessProbe = badProbe()
+				return ret
+			},
+			expected: []string{"invalid otel collector", "probe port 1234 does not match", "config file is not specified"},
+		},
+		"jaeger": {
+			component: func() objectsE {
+				ret := tracing.NewJaeger(nil, "ns", "1.50.0")
+				ret.LivenessProbe = badProbe()
+				ret.ReadinessProbe = badProbe()
+				return ret
+			},
+			expected: []string{"invalid jaeger", "probe port 1234 does not match"},
+		},
+		"memcached with invalid pod disruption budget": {
+			component: func() objectsE {
+				ret := memcached.NewMemcached()
+				ret.PodDisruptionBudget = &workload.PodDisruptionBudgetConfig{}
+				return ret
+			},
+			expected: []string{"exactly one of minAvailable and maxUnavailable must be set"},
+		},
+		"memcached": {
+			component: func() objectsE { return memcached.NewMemcached() },
+		},
+		"receive controller": {
+			component: func() objectsE {
+				return receive.NewController(&receive.ControllerOptions{FileName: "hashrings.json"}, "ns", "main")
+			},
+			expected: []string{"invalid receive controller", "configmap name is not specified", "generated configmap name is not specified"},
+		},
+		"receive ingestor": {
+			component: func() objectsE {
+				opts := receive.NewDefaultIngestorOptions()
+				opts.TsdbPath = ""
+				ret := receive.NewIngestor(opts, "ns", "v0.34.1")
+				ret.ReadinessProbe = badProbe()
+				return ret
+			},
+			expected: []string{"invalid receive ingestor", "probe port 1234 does not match http port 10902", "data directory is not specified"},
+		},
+		"rules objstore": {
+			component: func() objectsE {
+				ret := ruler.NewRulesObjstore(nil, "ns", "main")
+				ret.ReadinessProbe = badProbe()
+				return ret
+			},
+			expected: []string{"invalid rules objstore", "probe port 1234 does not match"},
+		},
+		"up": {
+			component: func() objectsE {
+				ret := up.NewUp(nil, "ns", "main")
+				ret.LivenessProbe = badProbe()
+				return ret
+			},
+			expected: []string{"invalid up", "probe port 1234 does not match http port 8080", "neither read nor write endpoint is specified"},
+		},
+		"avalanche": {
+			component: func() objectsE {
+				ret := avalanche.NewAvalanche(nil, "ns", "main")
+				ret.ReadinessProbe = badProbe()
+				return ret
+			},
+			expected: []string{"invalid avalanche", "probe port 1234 does not match http port 9001"},
+		},
+		"api": {
+			component: func() objectsE {
+				ret := api.NewObservatoriumAPI(nil, "ns", "main")
+				ret.LivenessProbe = badProbe()
+				return ret
+			},
+			expected: []string{"invalid observatorium api", "probe port 1234 does not match"},
+		},
+		"compactor": {
+			component: func() objectsE {
+				opts := compactor.NewDefaultOptions()
+				opts.DataDir = ""
+				ret := compactor.NewCompactor(opts, "ns", "v0.34.1")
+				ret.LivenessProbe = badProbe()
+				return ret
+			},
+			expected: []string{"invalid compactor", "probe port 1234 does not match http port 10902", "data directory is not specified"},
+		},
+		"store": {
+			component: func() objectsE {
+				opts := store.NewDefaultOptions()
+				opts.DataDir = ""
+				ret := store.NewStore(opts, "ns", "v0.34.1")
+				ret.ReadinessProbe = badProbe()
+				return ret
+			},
+			expected: []string{"invalid store", "probe port 1234 does not match http port 10902", "data directory is not specified"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			objs, err := tc.component().ObjectsE()
+			if len(tc.expected) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(objs) == 0 {
+					t.Fatalf("expected objects")
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+
+			if objs != nil {
+				t.Errorf("expected no objects with an error, got %d", len(objs))
+			}
+
+			for _, exp := range tc.expected {
+				if !strings.Contains(err.Error(), exp) {
+					t.Errorf("expected error to contain %q, got %q", exp, err.Error())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,362 @@
+package kubeyaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// ChangeType is the type of change of an object between two sets of manifests.
+type ChangeType string
+
+const (
+	Added    ChangeType = "added"
+	Removed  ChangeType = "removed"
+	Modified ChangeType = "modified"
+)
+
+// ObjectDiff describes the change of a single object.
+type ObjectDiff struct {
+	// Name is the name and kind of the object, as returned by KubeObjectNameAndKind.
+	Name   string
+	Change ChangeType
+	// Fields lists the changed fields of modified objects.
+	Fields []FieldDiff
+}
+
+// FieldDiff describes the change of a single field, identified by its path, e.g. spec.template.spec.containers[name=thanos].args[3].
+// Old is nil if the field was added, New is nil if it was removed.
+type FieldDiff struct {
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+// DiffReport lists the changed objects, sorted by name.
+type DiffReport []ObjectDiff
+
+// Empty returns true if no object changed.
+func (r DiffReport) Empty() bool {
+	return len(r) == 0
+}
+
+// String returns a human readable report of the changes.
+func (r DiffReport) String() string {
+	var b strings.Builder
+	for _, obj := range r {
+		fmt.Fprintf(&b, "%s: %s\n", obj.Name, obj.Change)
+		for _, field := range obj.Fields {
+			switch {
+			case field.Old == nil:
+				fmt.Fprintf(&b, "  + %s: %s\n", field.Path, formatValue(field.New))
+			case field.New == nil:
+				fmt.Fprintf(&b, "  - %s: %s\n", field.Path, formatValue(field.Old))
+			default:
+				fmt.Fprintf(&b, "  ~ %s: %s -> %s\n", field.Path, formatValue(field.Old), formatValue(field.New))
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// Diff compares the given objects with the manifests found in dir, such as the ones written by WriteObjectsInDir
+// or GenerateWithMimic. The objects in dir are the old state and the given objects the new one.
+// Objects are compared field by field after being rendered to YAML, so that formatting and comments are ignored.
+func Diff(objects []runtime.Object, dir string) (DiffReport, error) {
+	oldObjects, err := ReadObjectsInDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffObjects(oldObjects, objects)
+}
+
+// DiffDirs compares the manifests found in the from and to directories.
+func DiffDirs(from, to string) (DiffReport, error) {
+	oldObjects, err := ReadObjectsInDir(from)
+	if err != nil {
+		return nil, err
+	}
+
+	newObjects, err := ReadObjectsInDir(to)
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffObjects(oldObjects, newObjects)
+}
+
+// ReadObjectsInDir reads the objects of the YAML and JSON files found in dir. Sub directories are not read.
+// Files can hold several YAML documents.
+func ReadObjectsInDir(dir string) ([]runtime.Object, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+
+	var ret []runtime.Object
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		dec := k8syaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+		for {
+			obj := map[string]interface{}{}
+			if err := dec.Decode(&obj); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+			}
+
+			if len(obj) == 0 {
+				continue
+			}
+
+			ret = append(ret, &unstructured.Unstructured{Object: obj})
+		}
+	}
+
+	return ret, nil
+}
+
+// DiffObjects compares the old and new objects.
+func DiffObjects(oldObjects, newObjects []runtime.Object) (DiffReport, error) {
+	oldByName, err := objectsByName(oldObjects)
+	if err != nil {
+		return nil, err
+	}
+
+	newByName, err := objectsByName(newObjects)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret DiffReport
+	for name, oldObj := range oldByName {
+		newObj, ok := newByName[name]
+		if !ok {
+			ret = append(ret, ObjectDiff{Name: name, Change: Removed})
+			continue
+		}
+
+		var fields []FieldDiff
+		diffValues("", oldObj, newObj, &fields)
+		if len(fields) > 0 {
+			ret = append(ret, ObjectDiff{Name: name, Change: Modified, Fields: fields})
+		}
+	}
+
+	for name := range newByName {
+		if _, ok := oldByName[name]; !ok {
+			ret = append(ret, ObjectDiff{Name: name, Change: Added})
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+
+	return ret, nil
+}
+
+// objectsByName indexes the content of the objects by their name and kind. The namespace is part of
+// the name of namespaced objects, so that objects with the same name in different namespaces are told apart.
+// Objects are rendered to YAML and back, so that typed objects compare with the objects read from files.
+func objectsByName(objects []runtime.Object) (map[string]map[string]interface{}, error) {
+	ret := make(map[string]map[string]interface{}, len(objects))
+	for _, obj := range objects {
+		name, err := KubeObjectNameAndKindE(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s: %w", name, err)
+		}
+
+		u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		if err := yaml.Unmarshal(data, &u.Object); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s: %w", name, err)
+		}
+
+		if ns := u.GetNamespace(); ns != "" {
+			name = ns + "/" + name
+		}
+
+		if _, ok := ret[name]; ok {
+			return nil, fmt.Errorf("duplicate object %s", name)
+		}
+
+		ret[name] = u.Object
+	}
+
+	return ret, nil
+}
+
+// diffValues appends the differences between old and new to fields.
+func diffValues(path string, old, new interface{}, fields *[]FieldDiff) {
+	switch o := old.(type) {
+	case map[string]interface{}:
+		n, ok := new.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := map[string]bool{}
+		for k := range o {
+			keys[k] = true
+		}
+		for k := range n {
+			keys[k] = true
+		}
+
+		for _, k := range sortedKeys(keys) {
+			oldValue, inOld := o[k]
+			newValue, inNew := n[k]
+			switch {
+			case !inOld:
+				*fields = append(*fields, FieldDiff{Path: joinPath(path, k), New: newValue})
+			case !inNew:
+				*fields = append(*fields, FieldDiff{Path: joinPath(path, k), Old: oldValue})
+			default:
+				diffValues(joinPath(path, k), oldValue, newValue, fields)
+			}
+		}
+		return
+	case []interface{}:
+		n, ok := new.([]interface{})
+		if !ok {
+			break
+		}
+
+		diffLists(path, o, n, fields)
+		return
+	}
+
+	if !reflect.DeepEqual(old, new) {
+		*fields = append(*fields, FieldDiff{Path: path, Old: old, New: new})
+	}
+}
+
+// diffLists compares lists of named items, such as containers, ports or env vars, by name.
+// Other lists are compared by index.
+func diffLists(path string, old, new []interface{}, fields *[]FieldDiff) {
+	oldByName, oldNamed := itemsByName(old)
+	newByName, newNamed := itemsByName(new)
+
+	if oldNamed && newNamed {
+		names := map[string]bool{}
+		for name := range oldByName {
+			names[name] = true
+		}
+		for name := range newByName {
+			names[name] = true
+		}
+
+		for _, name := range sortedKeys(names) {
+			itemPath := fmt.Sprintf("%s[name=%s]", path, name)
+			oldItem, inOld := oldByName[name]
+			newItem, inNew := newByName[name]
+			switch {
+			case !inOld:
+				*fields = append(*fields, FieldDiff{Path: itemPath, New: newItem})
+			case !inNew:
+				*fields = append(*fields, FieldDiff{Path: itemPath, Old: oldItem})
+			default:
+				diffValues(itemPath, oldItem, newItem, fields)
+			}
+		}
+		return
+	}
+
+	for i := 0; i < len(old) || i < len(new); i++ {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case i >= len(old):
+			*fields = append(*fields, FieldDiff{Path: itemPath, New: new[i]})
+		case i >= len(new):
+			*fields = append(*fields, FieldDiff{Path: itemPath, Old: old[i]})
+		default:
+			diffValues(itemPath, old[i], new[i], fields)
+		}
+	}
+}
+
+// itemsByName indexes the items of a list by their name field.
+// It returns false if some item has no name or if names are not unique.
+func itemsByName(items []interface{}) (map[string]interface{}, bool) {
+	ret := make(map[string]interface{}, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		name, ok := m["name"].(string)
+		if !ok || name == "" {
+			return nil, false
+		}
+
+		if _, ok := ret[name]; ok {
+			return nil, false
+		}
+
+		ret[name] = item
+	}
+
+	return ret, true
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func sortedKeys(m map[string]bool) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
+func formatValue(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	case string:
+		return fmt.Sprintf("%q", v)
+	}
+
+	return fmt.Sprintf("%v", v)
+}
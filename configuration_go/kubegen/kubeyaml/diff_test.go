@@ -0,0 +1,94 @@
+package kubeyaml_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/kubegen/kubeyaml"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestDiff(t *testing.T) {
+	newDeployment := func(replicas int32, args ...string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			TypeMeta:   workload.DeploymentMeta,
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "ns"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{Name: "sidecar", Image: "sidecar:v1"},
+							{Name: "main", Image: "app:v1", Args: args},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	configMap := &corev1.ConfigMap{
+		TypeMeta:   workload.ConfigMapMeta,
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "ns"},
+		Data:       map[string]string{"config.yaml": "foo: bar"},
+	}
+
+	dir := t.TempDir()
+	kubeyaml.WriteObjectsInDir([]runtime.Object{newDeployment(1, "--a"), configMap}, dir)
+	// Comments are ignored.
+	path := filepath.Join(dir, "app_Deployment.yaml")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, append([]byte("# Generated. DO NOT EDIT.\n"), data...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		objects  []runtime.Object
+		expected string
+	}{
+		"no drift": {
+			objects: []runtime.Object{newDeployment(1, "--a"), configMap},
+		},
+		"modified": {
+			objects: []runtime.Object{newDeployment(2, "--a", "--b"), configMap},
+			expected: `ns/app_Deployment: modified
+  ~ spec.replicas: 1 -> 2
+  + spec.template.spec.containers[name=main].args[1]: "--b"
+`,
+		},
+		"added and removed": {
+			objects: []runtime.Object{newDeployment(1, "--a"), &corev1.Secret{
+				TypeMeta:   workload.SecretMeta,
+				ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "ns"},
+			}},
+			expected: `ns/app_ConfigMap: removed
+ns/app_Secret: added
+`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			report, err := kubeyaml.Diff(tc.objects, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if report.Empty() != (tc.expected == "") {
+				t.Errorf("expected drift %t, got %t", tc.expected != "", !report.Empty())
+			}
+
+			if got := report.String(); got != tc.expected {
+				t.Errorf("expected report:\n%s\ngot:\n%s", tc.expected, got)
+			}
+		})
+	}
+}
@@ -30,20 +30,32 @@ func GenerateWithMimicCustomFilename(g *mimic.Generator, objects []runtime.Objec
 	}
 }
 
+// KubeObjectNameAndKind returns the name and kind of the object, e.g. observatorium-thanos-store_StatefulSet.
+// It panics if the object has no name, see KubeObjectNameAndKindE for a non panicking version.
 func KubeObjectNameAndKind(obj runtime.Object) string {
+	ret, err := KubeObjectNameAndKindE(obj)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// KubeObjectNameAndKindE is like KubeObjectNameAndKind but returns an error if the object has no name.
+func KubeObjectNameAndKindE(obj runtime.Object) (string, error) {
 	metaObj, ok := obj.(metav1.Object)
 	if !ok {
-		panic(fmt.Sprintf("object %v has no name", obj))
+		return "", fmt.Errorf("object %v has no name", obj)
 	}
 
 	objName := metaObj.GetName()
 	if objName == "" {
-		panic(fmt.Sprintf("object %v has no name", obj))
+		return "", fmt.Errorf("object %v has no name", obj)
 	}
 
 	objType := obj.GetObjectKind().GroupVersionKind().Kind
 
-	return fmt.Sprintf("%s_%s", objName, objType)
+	return fmt.Sprintf("%s_%s", objName, objType), nil
 }
 
 // WriteObjectsInDir writes given objects to the given directory.
@@ -52,21 +64,52 @@ func WriteObjectsInDir(objects []runtime.Object, dir string) {
 }
 
 // WriteObjectsInDirWithSerializer writes given objects to the given directory.
+// It panics on error, see WriteObjectsInDirWithSerializerE for a non panicking version.
 func WriteObjectsInDirWithSerializer(objects []runtime.Object, dir string, enc ObjectSerializer) {
+	if err := WriteObjectsInDirWithSerializerE(objects, dir, enc); err != nil {
+		panic(err.Error())
+	}
+}
+
+// WriteObjectsInDirWithSerializerE is like WriteObjectsInDirWithSerializer but returns an error instead of panicking.
+func WriteObjectsInDirWithSerializerE(objects []runtime.Object, dir string, enc ObjectSerializer) error {
 	for _, obj := range objects {
-		name := KubeObjectNameAndKind(obj)
+		name, err := KubeObjectNameAndKindE(obj)
+		if err != nil {
+			return err
+		}
+
 		path := filepath.Join(dir, name) + ".yaml"
-		data, err := yaml.Marshal(obj)
+		data, err := enc(obj)
 		if err != nil {
-			panic(fmt.Sprintf("failed to marshal manifest %s: %v", name, err))
+			return fmt.Errorf("failed to marshal manifest %s: %w", name, err)
 		}
 
 		if err := os.MkdirAll(dir, 0755); err != nil {
-			panic(fmt.Sprintf("failed to create directory %s: %v", dir, err))
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
 		}
 
 		if err := os.WriteFile(path, data, 0644); err != nil {
-			panic(fmt.Sprintf("failed to write manifest %s: %v", name, err))
+			return fmt.Errorf("failed to write manifest %s: %w", name, err)
 		}
 	}
+
+	return nil
+}
+
+// WriteFilesInDir writes the given files to the given directory.
+// Files are keyed by their slash separated path relative to the directory, sub directories are created as needed.
+func WriteFilesInDir(files map[string][]byte, dir string) error {
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(path), err)
+		}
+
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,78 @@
+package kustomize
+
+// The types below are the subset of the kustomization.yaml schema used by this package.
+// See https://kubectl.docs.kubernetes.io/references/kustomize/kustomization/ for details.
+
+const (
+	kustomizationAPIVersion = "kustomize.config.k8s.io/v1beta1"
+	kustomizationKind       = "Kustomization"
+
+	// KustomizationFile is the name of the file holding the kustomization in a base or an overlay.
+	KustomizationFile = "kustomization.yaml"
+)
+
+// Kustomization represents a kustomization.yaml file.
+type Kustomization struct {
+	APIVersion         string          `json:"apiVersion"`
+	Kind               string          `json:"kind"`
+	Namespace          string          `json:"namespace,omitempty"`
+	Resources          []string        `json:"resources,omitempty"`
+	Images             []Image         `json:"images,omitempty"`
+	Replicas           []Replica       `json:"replicas,omitempty"`
+	Patches            []Patch         `json:"patches,omitempty"`
+	ConfigMapGenerator []GeneratorArgs `json:"configMapGenerator,omitempty"`
+	SecretGenerator    []GeneratorArgs `json:"secretGenerator,omitempty"`
+}
+
+// NewKustomization returns an empty kustomization.
+func NewKustomization() *Kustomization {
+	return &Kustomization{
+		APIVersion: kustomizationAPIVersion,
+		Kind:       kustomizationKind,
+	}
+}
+
+// Image overrides the tag of the containers using the named image.
+type Image struct {
+	Name    string `json:"name"`
+	NewName string `json:"newName,omitempty"`
+	NewTag  string `json:"newTag,omitempty"`
+}
+
+// Replica overrides the number of replicas of the named Deployment or StatefulSet.
+type Replica struct {
+	Name  string `json:"name"`
+	Count int32  `json:"count"`
+}
+
+// Patch is an inline strategic merge patch applied to the target objects.
+type Patch struct {
+	Patch  string    `json:"patch"`
+	Target *Selector `json:"target,omitempty"`
+}
+
+// Selector selects the objects targeted by a patch.
+type Selector struct {
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// GeneratorArgs describes a ConfigMap or Secret generated by kustomize.
+// The name of the generated resource is suffixed with a hash of its content,
+// so that the workloads referencing it are rolled out when it changes.
+type GeneratorArgs struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace,omitempty"`
+	Type      string            `json:"type,omitempty"`
+	Files     []string          `json:"files,omitempty"`
+	Options   *GeneratorOptions `json:"options,omitempty"`
+}
+
+// GeneratorOptions modifies the generated resource.
+type GeneratorOptions struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
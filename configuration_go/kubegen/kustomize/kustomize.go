@@ -0,0 +1,360 @@
+package kustomize
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/observatorium/observatorium/configuration_go/kubegen/kubeyaml"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	baseDir     = "base"
+	overlaysDir = "overlays"
+)
+
+// Base represents a kustomize base made of generated objects.
+type Base struct {
+	Objects []runtime.Object
+
+	// UseGenerators emits the ConfigMaps and Secrets as configMapGenerator and secretGenerator entries.
+	// Their names are then suffixed with a hash of their content, which triggers a rollout of the
+	// workloads mounting them when the content changes.
+	UseGenerators bool
+
+	// ExcludeFromGenerators lists the ConfigMaps and Secrets kept as plain resources when UseGenerators is set.
+	// This is needed for resources referenced by name in places that kustomize doesn't rewrite, such as command line arguments.
+	ExcludeFromGenerators []string
+}
+
+// Overlay represents per-environment deltas applied on top of the base.
+type Overlay struct {
+	// Name is the name of the environment, used as the overlay directory name.
+	Name string
+
+	// Namespace overrides the namespace of all the objects.
+	Namespace string
+
+	// Replicas overrides the replicas of the Deployments and StatefulSets, keyed by name.
+	Replicas map[string]int32
+
+	// ImageTags overrides the tags of the images, keyed by image name, e.g. quay.io/thanos/thanos.
+	ImageTags map[string]string
+
+	// Resources overrides the resources of the main container (the first one) of the Deployments and StatefulSets, keyed by name.
+	Resources map[string]corev1.ResourceRequirements
+}
+
+// Write writes the base in <dir>/base and each overlay in <dir>/overlays/<name>.
+func Write(dir string, base Base, overlays ...Overlay) error {
+	files, err := Generate(base, overlays...)
+	if err != nil {
+		return err
+	}
+
+	return kubeyaml.WriteFilesInDir(files, dir)
+}
+
+// Generate returns the content of the base and overlays files, keyed by their slash separated path
+// relative to the output directory.
+func Generate(base Base, overlays ...Overlay) (map[string][]byte, error) {
+	baseFiles, err := base.Files()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string][]byte, len(baseFiles))
+	for name, data := range baseFiles {
+		ret[path.Join(baseDir, name)] = data
+	}
+
+	var errs []error
+	for _, overlay := range overlays {
+		if overlay.Name == "" {
+			errs = append(errs, errors.New("overlay name must be specified"))
+			continue
+		}
+
+		if _, ok := ret[path.Join(overlaysDir, overlay.Name, KustomizationFile)]; ok {
+			errs = append(errs, fmt.Errorf("duplicate overlay %s", overlay.Name))
+			continue
+		}
+
+		k, err := overlay.Kustomization(base, path.Join("..", "..", baseDir))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid overlay %s: %w", overlay.Name, err))
+			continue
+		}
+
+		data, err := yaml.Marshal(k)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal overlay %s: %w", overlay.Name, err)
+		}
+
+		ret[path.Join(overlaysDir, overlay.Name, KustomizationFile)] = data
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// Files returns the content of the base files, keyed by their slash separated path relative to the base directory.
+// It includes one file per resource, the data files of the generators and the kustomization.yaml file.
+func (b Base) Files() (map[string][]byte, error) {
+	ret := map[string][]byte{}
+	k := NewKustomization()
+
+	for _, obj := range b.Objects {
+		name, err := kubeyaml.KubeObjectNameAndKindE(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		if b.UseGenerators {
+			generated, err := b.addGenerator(k, obj, ret)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate %s: %w", name, err)
+			}
+
+			if generated {
+				continue
+			}
+		}
+
+		fileName := name + ".yaml"
+		if _, ok := ret[fileName]; ok {
+			return nil, fmt.Errorf("duplicate object %s", name)
+		}
+
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s: %w", name, err)
+		}
+
+		ret[fileName] = data
+		k.Resources = append(k.Resources, fileName)
+	}
+
+	sort.Strings(k.Resources)
+	sort.Slice(k.ConfigMapGenerator, func(i, j int) bool { return k.ConfigMapGenerator[i].Name < k.ConfigMapGenerator[j].Name })
+	sort.Slice(k.SecretGenerator, func(i, j int) bool { return k.SecretGenerator[i].Name < k.SecretGenerator[j].Name })
+
+	data, err := yaml.Marshal(k)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal kustomization: %w", err)
+	}
+	ret[KustomizationFile] = data
+
+	return ret, nil
+}
+
+// addGenerator adds a generator for ConfigMaps and Secrets, and writes their data files.
+// It returns false if the object must be kept as a plain resource.
+func (b Base) addGenerator(k *Kustomization, obj runtime.Object, files map[string][]byte) (bool, error) {
+	var (
+		meta metav1.ObjectMeta
+		dir  string
+		data = map[string][]byte{}
+	)
+
+	switch o := obj.(type) {
+	case *corev1.ConfigMap:
+		meta, dir = o.ObjectMeta, "configmaps"
+		for key, value := range o.Data {
+			data[key] = []byte(value)
+		}
+		for key, value := range o.BinaryData {
+			data[key] = value
+		}
+	case *corev1.Secret:
+		meta, dir = o.ObjectMeta, "secrets"
+		for key, value := range o.Data {
+			data[key] = value
+		}
+		for key, value := range o.StringData {
+			data[key] = []byte(value)
+		}
+	default:
+		return false, nil
+	}
+
+	if slices.Contains(b.ExcludeFromGenerators, meta.Name) {
+		return false, nil
+	}
+
+	args := GeneratorArgs{
+		Name:      meta.Name,
+		Namespace: meta.Namespace,
+	}
+
+	if len(meta.Labels) > 0 || len(meta.Annotations) > 0 {
+		args.Options = &GeneratorOptions{
+			Labels:      meta.Labels,
+			Annotations: meta.Annotations,
+		}
+	}
+
+	for key, value := range data {
+		fileName := path.Join(dir, meta.Name, key)
+		if _, ok := files[fileName]; ok {
+			return false, fmt.Errorf("duplicate file %s", fileName)
+		}
+
+		files[fileName] = value
+		args.Files = append(args.Files, fmt.Sprintf("%s=%s", key, fileName))
+	}
+	sort.Strings(args.Files)
+
+	if secret, ok := obj.(*corev1.Secret); ok {
+		if secret.Type != "" && secret.Type != corev1.SecretTypeOpaque {
+			args.Type = string(secret.Type)
+		}
+		k.SecretGenerator = append(k.SecretGenerator, args)
+	} else {
+		k.ConfigMapGenerator = append(k.ConfigMapGenerator, args)
+	}
+
+	return true, nil
+}
+
+// Kustomization returns the kustomization of the overlay, referencing the base at the given relative path.
+// It checks that the workloads and images overridden by the overlay exist in the base.
+func (o Overlay) Kustomization(base Base, basePath string) (*Kustomization, error) {
+	k := NewKustomization()
+	k.Namespace = o.Namespace
+	k.Resources = []string{basePath}
+
+	workloads := map[string]*corev1.PodSpec{}
+	kinds := map[string]metav1.TypeMeta{}
+	images := map[string]bool{}
+
+	for _, obj := range base.Objects {
+		var podSpec *corev1.PodSpec
+		var name string
+
+		switch w := obj.(type) {
+		case *appsv1.Deployment:
+			name, podSpec = w.Name, &w.Spec.Template.Spec
+			kinds[name] = w.TypeMeta
+		case *appsv1.StatefulSet:
+			name, podSpec = w.Name, &w.Spec.Template.Spec
+			kinds[name] = w.TypeMeta
+		default:
+			continue
+		}
+
+		workloads[name] = podSpec
+		for _, c := range slices.Concat(podSpec.InitContainers, podSpec.Containers) {
+			images[imageName(c.Image)] = true
+		}
+	}
+
+	var errs []error
+
+	for _, name := range sortedKeys(o.Replicas) {
+		if _, ok := workloads[name]; !ok {
+			errs = append(errs, fmt.Errorf("replicas: workload %s not found in base", name))
+			continue
+		}
+
+		k.Replicas = append(k.Replicas, Replica{Name: name, Count: o.Replicas[name]})
+	}
+
+	for _, name := range sortedKeys(o.ImageTags) {
+		if !images[name] {
+			errs = append(errs, fmt.Errorf("images: image %s not found in base", name))
+			continue
+		}
+
+		k.Images = append(k.Images, Image{Name: name, NewTag: o.ImageTags[name]})
+	}
+
+	for _, name := range sortedKeys(o.Resources) {
+		podSpec, ok := workloads[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("resources: workload %s not found in base", name))
+			continue
+		}
+
+		if len(podSpec.Containers) == 0 {
+			errs = append(errs, fmt.Errorf("resources: workload %s has no container", name))
+			continue
+		}
+
+		patch, err := resourcesPatch(kinds[name], name, podSpec.Containers[0].Name, o.Resources[name])
+		if err != nil {
+			return nil, err
+		}
+
+		k.Patches = append(k.Patches, patch)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// resourcesPatch returns a strategic merge patch setting the resources of a container.
+func resourcesPatch(typeMeta metav1.TypeMeta, name, container string, resources corev1.ResourceRequirements) (Patch, error) {
+	patch := map[string]interface{}{
+		"apiVersion": typeMeta.APIVersion,
+		"kind":       typeMeta.Kind,
+		"metadata": map[string]interface{}{
+			"name": name,
+		},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":      container,
+							"resources": resources,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	data, err := yaml.Marshal(patch)
+	if err != nil {
+		return Patch{}, fmt.Errorf("failed to marshal resources patch for %s: %w", name, err)
+	}
+
+	return Patch{Patch: string(data)}, nil
+}
+
+// imageName returns the image without its tag or digest.
+func imageName(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+
+	// The tag separator is after the last slash, which allows registries with a port.
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i]
+	}
+
+	return image
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+
+	return ret
+}
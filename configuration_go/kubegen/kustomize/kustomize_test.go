@@ -0,0 +1,124 @@
+package kustomize_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/kubegen/kustomize"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+func testObjects() []runtime.Object {
+	return []runtime.Object{
+		&appsv1.Deployment{
+			TypeMeta:   workload.DeploymentMeta,
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "ns"},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "main", Image: "registry:5000/org/app:v1"}},
+					},
+				},
+			},
+		},
+		&corev1.ConfigMap{
+			TypeMeta:   workload.ConfigMapMeta,
+			ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "ns"},
+			Data:       map[string]string{"config.yaml": "foo: bar"},
+		},
+		&corev1.Secret{
+			TypeMeta:   workload.SecretMeta,
+			ObjectMeta: metav1.ObjectMeta{Name: "app-secret", Namespace: "ns"},
+			Data:       map[string][]byte{"token": []byte("secret")},
+		},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	testCases := map[string]struct {
+		base              kustomize.Base
+		overlays          []kustomize.Overlay
+		expectedFiles     []string
+		expectedResources []string
+		expectedErr       []string
+	}{
+		"plain resources": {
+			base:              kustomize.Base{Objects: testObjects()},
+			expectedFiles:     []string{"base/kustomization.yaml", "base/app_Deployment.yaml", "base/app-config_ConfigMap.yaml", "base/app-secret_Secret.yaml"},
+			expectedResources: []string{"app-config_ConfigMap.yaml", "app-secret_Secret.yaml", "app_Deployment.yaml"},
+		},
+		"generators": {
+			base:              kustomize.Base{Objects: testObjects(), UseGenerators: true, ExcludeFromGenerators: []string{"app-secret"}},
+			expectedFiles:     []string{"base/kustomization.yaml", "base/app_Deployment.yaml", "base/configmaps/app-config/config.yaml", "base/app-secret_Secret.yaml"},
+			expectedResources: []string{"app-secret_Secret.yaml", "app_Deployment.yaml"},
+		},
+		"overlay": {
+			base: kustomize.Base{Objects: testObjects()},
+			overlays: []kustomize.Overlay{{
+				Name:      "production",
+				Replicas:  map[string]int32{"app": 3},
+				ImageTags: map[string]string{"registry:5000/org/app": "v2"},
+				Resources: map[string]corev1.ResourceRequirements{"app": {}},
+			}},
+			expectedFiles: []string{"base/kustomization.yaml", "overlays/production/kustomization.yaml"},
+		},
+		"invalid overlays": {
+			base: kustomize.Base{Objects: testObjects()},
+			overlays: []kustomize.Overlay{
+				{Name: "staging", Replicas: map[string]int32{"app-config": 3}, ImageTags: map[string]string{"org/app": "v2"}},
+				{},
+			},
+			expectedErr: []string{
+				"invalid overlay staging: replicas: workload app-config not found in base",
+				"images: image org/app not found in base",
+				"overlay name must be specified",
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			files, err := kustomize.Generate(tc.base, tc.overlays...)
+			if len(tc.expectedErr) > 0 {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+
+				for _, exp := range tc.expectedErr {
+					if !strings.Contains(err.Error(), exp) {
+						t.Errorf("expected error to contain %q, got %q", exp, err.Error())
+					}
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, file := range tc.expectedFiles {
+				if _, ok := files[file]; !ok {
+					t.Errorf("expected file %s to be generated", file)
+				}
+			}
+
+			if tc.expectedResources == nil {
+				return
+			}
+
+			k := kustomize.Kustomization{}
+			if err := yaml.Unmarshal(files["base/kustomization.yaml"], &k); err != nil {
+				t.Fatalf("failed to unmarshal kustomization: %v", err)
+			}
+
+			if strings.Join(k.Resources, ",") != strings.Join(tc.expectedResources, ",") {
+				t.Errorf("expected resources %v, got %v", tc.expectedResources, k.Resources)
+			}
+		})
+	}
+}
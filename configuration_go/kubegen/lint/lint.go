@@ -0,0 +1,200 @@
+// Package lint checks generated objects against security and reliability rules, such as resource limits
+// or pinned image tags. Rules are pluggable and can be suppressed per object with the IgnoreAnnotation.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/observatorium/observatorium/configuration_go/kubegen/kubeyaml"
+	templatev1 "github.com/openshift/api/template/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// IgnoreAnnotation holds the comma separated names of the rules that are not checked on the annotated object.
+// For workloads, it must be set on the workload itself, not on its pod template.
+const IgnoreAnnotation = "lint.observatorium.io/ignore"
+
+// Severity is the severity of a finding.
+type Severity int
+
+const (
+	Info Severity = iota
+	Warning
+	Error
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// Violation is a rule violation found in an object.
+type Violation struct {
+	// Path is the path of the offending field, e.g. spec.template.spec.containers[0].resources.
+	Path    string
+	Message string
+}
+
+// Rule checks a single object.
+type Rule struct {
+	// Name identifies the rule in findings and in the IgnoreAnnotation.
+	Name        string
+	Description string
+	Severity    Severity
+	Check       func(obj runtime.Object) []Violation
+}
+
+// Finding is a violation of a rule by an object.
+type Finding struct {
+	Rule     string
+	Severity Severity
+	// Object is the name and kind of the object, as returned by kubeyaml.KubeObjectNameAndKind.
+	Object string
+	Violation
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s: %s: %s", f.Severity, f.Object, f.Rule, f.Path, f.Message)
+}
+
+// Findings is a list of findings, sorted by object and rule.
+type Findings []Finding
+
+// Max returns the highest severity of the findings. It returns Info if there is none.
+func (f Findings) Max() Severity {
+	ret := Info
+	for _, finding := range f {
+		if finding.Severity > ret {
+			ret = finding.Severity
+		}
+	}
+
+	return ret
+}
+
+// AtLeast returns the findings with the given severity or a higher one.
+func (f Findings) AtLeast(severity Severity) Findings {
+	var ret Findings
+	for _, finding := range f {
+		if finding.Severity >= severity {
+			ret = append(ret, finding)
+		}
+	}
+
+	return ret
+}
+
+// String returns one finding per line.
+func (f Findings) String() string {
+	var b strings.Builder
+	for _, finding := range f {
+		b.WriteString(finding.String())
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// Linter runs rules over objects.
+type Linter struct {
+	rules []Rule
+}
+
+// NewLinter returns a linter running the given rules. Use DefaultRules for the built-in ones.
+// It panics if rules are not named uniquely or have no check.
+func NewLinter(rules ...Rule) *Linter {
+	names := map[string]bool{}
+	for _, rule := range rules {
+		if rule.Name == "" || rule.Check == nil {
+			panic(fmt.Sprintf("rule %q must have a name and a check", rule.Name))
+		}
+
+		if names[rule.Name] {
+			panic(fmt.Sprintf("duplicate rule %s", rule.Name))
+		}
+		names[rule.Name] = true
+	}
+
+	return &Linter{rules: rules}
+}
+
+// Lint runs the rules over the objects and returns the findings. Objects of OpenShift templates are linted too,
+// when they are typed objects.
+func (l *Linter) Lint(objects []runtime.Object) Findings {
+	var ret Findings
+	for _, obj := range objects {
+		ret = append(ret, l.lintObject(obj)...)
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Object != ret[j].Object {
+			return ret[i].Object < ret[j].Object
+		}
+		return ret[i].Rule < ret[j].Rule
+	})
+
+	return ret
+}
+
+func (l *Linter) lintObject(obj runtime.Object) Findings {
+	var ret Findings
+
+	if tmpl, ok := obj.(*templatev1.Template); ok {
+		for _, raw := range tmpl.Objects {
+			if raw.Object != nil {
+				ret = append(ret, l.lintObject(raw.Object)...)
+			}
+		}
+	}
+
+	name, err := kubeyaml.KubeObjectNameAndKindE(obj)
+	if err != nil {
+		name = fmt.Sprintf("%T", obj)
+	}
+
+	ignored := ignoredRules(obj)
+	for _, rule := range l.rules {
+		if ignored[rule.Name] {
+			continue
+		}
+
+		for _, v := range rule.Check(obj) {
+			ret = append(ret, Finding{
+				Rule:      rule.Name,
+				Severity:  rule.Severity,
+				Object:    name,
+				Violation: v,
+			})
+		}
+	}
+
+	return ret
+}
+
+// ignoredRules returns the rules listed in the IgnoreAnnotation of obj.
+func ignoredRules(obj runtime.Object) map[string]bool {
+	metaObj, ok := obj.(metav1.Object)
+	if !ok {
+		return nil
+	}
+
+	ret := map[string]bool{}
+	for _, name := range strings.Split(metaObj.GetAnnotations()[IgnoreAnnotation], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			ret[name] = true
+		}
+	}
+
+	return ret
+}
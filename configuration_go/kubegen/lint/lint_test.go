@@ -0,0 +1,133 @@
+package lint_test
+
+import (
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/query"
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/lint"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestLint(t *testing.T) {
+	// newDeployment returns a replicated query Deployment with a security context, which passes all the default rules.
+	newDeployment := func(mutate func(*appsv1.Deployment)) *appsv1.Deployment {
+		q := query.NewQuery(nil, "ns", "v0.34.1")
+		q.Replicas = 2
+		q.SecurityContext = &corev1.PodSecurityContext{}
+		ret := kghelpers.GetObject[*appsv1.Deployment](q.Objects(), "")
+		if mutate != nil {
+			mutate(ret)
+		}
+		return ret
+	}
+
+	testCases := map[string]struct {
+		obj      runtime.Object
+		expected []string
+	}{
+		"valid": {
+			obj: newDeployment(nil),
+		},
+		"missing limits": {
+			obj: newDeployment(func(d *appsv1.Deployment) {
+				delete(d.Spec.Template.Spec.Containers[0].Resources.Limits, corev1.ResourceMemory)
+			}),
+			expected: []string{"resource-limits"},
+		},
+		"undeclared probe port": {
+			obj: newDeployment(func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.Containers[0].Ports = nil
+			}),
+			expected: []string{"probe-ports", "probe-ports"},
+		},
+		"latest tag": {
+			obj: newDeployment(func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.Containers[0].Image = "registry:5000/test:latest"
+			}),
+			expected: []string{"pinned-image-tag"},
+		},
+		"no tag": {
+			obj: newDeployment(func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.Containers[0].Image = "registry:5000/test"
+			}),
+			expected: []string{"pinned-image-tag"},
+		},
+		"no security context": {
+			obj: newDeployment(func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.SecurityContext = nil
+			}),
+			expected: []string{"security-context"},
+		},
+		"no anti-affinity": {
+			obj: newDeployment(func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.Affinity = nil
+			}),
+			expected: []string{"anti-affinity"},
+		},
+		"ignored rules": {
+			obj: newDeployment(func(d *appsv1.Deployment) {
+				d.Annotations = map[string]string{lint.IgnoreAnnotation: "anti-affinity, pinned-image-tag"}
+				d.Spec.Template.Spec.Affinity = nil
+				d.Spec.Template.Spec.Containers[0].Image = "test"
+			}),
+		},
+	}
+
+	linter := lint.NewLinter(lint.DefaultRules()...)
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			findings := linter.Lint([]runtime.Object{tc.obj})
+			if len(findings) != len(tc.expected) {
+				t.Fatalf("expected %d findings, got:\n%s", len(tc.expected), findings)
+			}
+
+			for i, expected := range tc.expected {
+				if findings[i].Rule != expected {
+					t.Errorf("expected finding %d to be %s, got %s", i, expected, findings[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLintGeneratedObjects(t *testing.T) {
+	testCases := map[string]struct {
+		query    func() *query.QueryDeployment
+		expected []string
+	}{
+		"default query": {
+			query:    func() *query.QueryDeployment { return query.NewQuery(nil, "ns", "v0.34.1") },
+			expected: []string{"security-context"},
+		},
+		"query with a security context": {
+			query: func() *query.QueryDeployment {
+				ret := query.NewQuery(nil, "ns", "v0.34.1")
+				ret.SecurityContext = &corev1.PodSecurityContext{}
+				return ret
+			},
+		},
+		"query with the latest image": {
+			query:    func() *query.QueryDeployment { return query.NewQuery(nil, "ns", "latest") },
+			expected: []string{"pinned-image-tag", "security-context"},
+		},
+	}
+
+	linter := lint.NewLinter(lint.DefaultRules()...)
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			findings := linter.Lint(tc.query().Objects())
+			if len(findings) != len(tc.expected) {
+				t.Fatalf("expected %d findings, got:\n%s", len(tc.expected), findings)
+			}
+
+			for i, expected := range tc.expected {
+				if findings[i].Rule != expected || findings[i].Object != "observatorium-thanos-query_Deployment" {
+					t.Errorf("expected finding %d to be %s on the query deployment, got %s", i, expected, findings[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// podTemplatePath is the path of the pod spec in Deployments, StatefulSets and DaemonSets.
+const podTemplatePath = "spec.template.spec"
+
+var (
+	// ResourceLimits requires CPU and memory limits on every container.
+	ResourceLimits = Rule{
+		Name:        "resource-limits",
+		Description: "Containers must have CPU and memory limits.",
+		Severity:    Warning,
+		Check: forEachContainer(func(c *corev1.Container, path string) []Violation {
+			var ret []Violation
+			for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+				if _, ok := c.Resources.Limits[name]; !ok {
+					ret = append(ret, Violation{
+						Path:    path + ".resources.limits",
+						Message: fmt.Sprintf("container %s has no %s limit", c.Name, name),
+					})
+				}
+			}
+			return ret
+		}),
+	}
+
+	// ProbePorts requires probes to point at ports declared by their container.
+	ProbePorts = Rule{
+		Name:        "probe-ports",
+		Description: "Probes must point at ports declared by their container.",
+		Severity:    Error,
+		Check: forEachContainer(func(c *corev1.Container, path string) []Violation {
+			var ret []Violation
+			for _, probe := range []struct {
+				name  string
+				probe *corev1.Probe
+			}{
+				{"livenessProbe", c.LivenessProbe},
+				{"readinessProbe", c.ReadinessProbe},
+				{"startupProbe", c.StartupProbe},
+			} {
+				if probe.probe == nil {
+					continue
+				}
+
+				var port intstr.IntOrString
+				switch {
+				case probe.probe.HTTPGet != nil:
+					port = probe.probe.HTTPGet.Port
+				case probe.probe.TCPSocket != nil:
+					port = probe.probe.TCPSocket.Port
+				case probe.probe.GRPC != nil:
+					port = intstr.FromInt32(probe.probe.GRPC.Port)
+				default:
+					continue
+				}
+
+				if !hasPort(c, port) {
+					ret = append(ret, Violation{
+						Path:    fmt.Sprintf("%s.%s", path, probe.name),
+						Message: fmt.Sprintf("container %s doesn't declare port %s", c.Name, port.String()),
+					})
+				}
+			}
+			return ret
+		}),
+	}
+
+	// PinnedImageTag forbids images without a tag or with the latest tag. Digests are accepted.
+	PinnedImageTag = Rule{
+		Name:        "pinned-image-tag",
+		Description: "Images must be pinned to a tag other than latest, or a digest.",
+		Severity:    Error,
+		Check: forEachContainer(func(c *corev1.Container, path string) []Violation {
+			if strings.Contains(c.Image, "@") {
+				return nil
+			}
+
+			tag := ""
+			if i := strings.LastIndex(c.Image, ":"); i > strings.LastIndex(c.Image, "/") {
+				tag = c.Image[i+1:]
+			}
+
+			if tag == "" || tag == "latest" {
+				return []Violation{{
+					Path:    path + ".image",
+					Message: fmt.Sprintf("container %s uses unpinned image %s", c.Name, c.Image),
+				}}
+			}
+			return nil
+		}),
+	}
+
+	// SecurityContext requires a security context on the pod or on every container.
+	SecurityContext = Rule{
+		Name:        "security-context",
+		Description: "Pods or all their containers must set a security context.",
+		Severity:    Warning,
+		Check: func(obj runtime.Object) []Violation {
+			spec, _, ok := podSpec(obj)
+			if !ok || spec.SecurityContext != nil {
+				return nil
+			}
+
+			var ret []Violation
+			for i, c := range spec.Containers {
+				if c.SecurityContext == nil {
+					ret = append(ret, Violation{
+						Path:    fmt.Sprintf("%s.containers[%d].securityContext", podTemplatePath, i),
+						Message: fmt.Sprintf("neither the pod nor container %s set a security context", c.Name),
+					})
+				}
+			}
+			return ret
+		},
+	}
+
+	// AntiAffinity requires workloads with several replicas to spread their pods, with a pod anti-affinity
+	// or topology spread constraints.
+	AntiAffinity = Rule{
+		Name:        "anti-affinity",
+		Description: "Workloads with several replicas must spread their pods with a pod anti-affinity or topology spread constraints.",
+		Severity:    Warning,
+		Check: func(obj runtime.Object) []Violation {
+			spec, replicas, ok := podSpec(obj)
+			if !ok || replicas == nil || *replicas <= 1 {
+				return nil
+			}
+
+			if len(spec.TopologySpreadConstraints) > 0 || (spec.Affinity != nil && spec.Affinity.PodAntiAffinity != nil) {
+				return nil
+			}
+
+			return []Violation{{
+				Path:    podTemplatePath + ".affinity",
+				Message: fmt.Sprintf("workload has %d replicas but no pod anti-affinity", *replicas),
+			}}
+		},
+	}
+)
+
+// DefaultRules returns the built-in rules.
+func DefaultRules() []Rule {
+	return []Rule{ResourceLimits, ProbePorts, PinnedImageTag, SecurityContext, AntiAffinity}
+}
+
+// podSpec returns the pod spec and replicas of Deployments, StatefulSets and DaemonSets.
+// Replicas are nil for DaemonSets.
+func podSpec(obj runtime.Object) (*corev1.PodSpec, *int32, bool) {
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		return &o.Spec.Template.Spec, o.Spec.Replicas, true
+	case *appsv1.StatefulSet:
+		return &o.Spec.Template.Spec, o.Spec.Replicas, true
+	case *appsv1.DaemonSet:
+		return &o.Spec.Template.Spec, nil, true
+	default:
+		return nil, nil, false
+	}
+}
+
+// forEachContainer returns a check running f on the init containers and containers of workloads.
+func forEachContainer(f func(c *corev1.Container, path string) []Violation) func(runtime.Object) []Violation {
+	return func(obj runtime.Object) []Violation {
+		spec, _, ok := podSpec(obj)
+		if !ok {
+			return nil
+		}
+
+		var ret []Violation
+		for i := range spec.InitContainers {
+			ret = append(ret, f(&spec.InitContainers[i], fmt.Sprintf("%s.initContainers[%d]", podTemplatePath, i))...)
+		}
+		for i := range spec.Containers {
+			ret = append(ret, f(&spec.Containers[i], fmt.Sprintf("%s.containers[%d]", podTemplatePath, i))...)
+		}
+		return ret
+	}
+}
+
+func hasPort(c *corev1.Container, port intstr.IntOrString) bool {
+	for _, p := range c.Ports {
+		if (port.Type == intstr.String && p.Name == port.StrVal) || (port.Type == intstr.Int && p.ContainerPort == port.IntVal) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,376 @@
+package openshift
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	templatev1 "github.com/openshift/api/template/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	templateTagName = "template"
+	optTagName      = "opt"
+
+	// sentinelBase is the first value used to mark non-string fields.
+	// It is unlikely to be a value set by the user, and fits in an int32.
+	sentinelBase int64 = 1987650000
+)
+
+var (
+	quantityType      = reflect.TypeOf(resource.Quantity{})
+	resourcesType     = reflect.TypeOf(corev1.ResourceRequirements{})
+	stringerType      = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	resourcesSuffixes = []struct {
+		name    corev1.ResourceName
+		request bool
+		suffix  string
+	}{
+		{corev1.ResourceCPU, true, "CPU_REQUEST"},
+		{corev1.ResourceCPU, false, "CPU_LIMIT"},
+		{corev1.ResourceMemory, true, "MEMORY_REQUEST"},
+		{corev1.ResourceMemory, false, "MEMORY_LIMIT"},
+	}
+)
+
+// Parameters collects OpenShift template parameters from struct fields.
+// String fields are replaced with ${NAME} placeholders. Non-string fields, such as Replicas int32 or resources,
+// are replaced with sentinel values that WrapInTemplate substitutes with placeholders once the objects are generated.
+type Parameters struct {
+	params    []templatev1.Parameter
+	sentinels map[int64]string
+}
+
+// NewParameters returns an empty set of parameters.
+func NewParameters() *Parameters {
+	return &Parameters{
+		sentinels: map[int64]string{},
+	}
+}
+
+// Add adds parameters that are set manually in the objects, e.g. the namespace.
+func (p *Parameters) Add(params ...templatev1.Parameter) error {
+	for _, param := range params {
+		if err := p.add(param); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// List returns the parameters, in the order they were added.
+func (p *Parameters) List() []templatev1.Parameter {
+	return slices.Clone(p.params)
+}
+
+// Parameterize replaces the values of the fields of obj, which must be a pointer to a struct, with template parameters.
+// The current values are used as the parameters' defaults. obj must be parameterized before generating its objects.
+// When fields are given, only the fields with these names are parameterized. Otherwise, the fields with a template tag are,
+// such as the replicas, image, image tag and resources of the workloads. Embedded structs are walked recursively.
+// The parameter names are prefixed with prefix and named after the template tag if set, the opt tag otherwise, e.g.
+// OBSERVATORIUM_API_LOG_LEVEL for a field tagged with opt:"log.level", or the field name.
+// Supported types are strings, integers, resource.Quantity and corev1.ResourceRequirements.
+// Resources are expanded into <prefix>_CPU_REQUEST, <prefix>_CPU_LIMIT, <prefix>_MEMORY_REQUEST and <prefix>_MEMORY_LIMIT parameters,
+// for the values that are set.
+func (p *Parameters) Parameterize(obj interface{}, prefix string, fields ...string) error {
+	v := reflect.ValueOf(obj)
+	if !v.IsValid() || v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expected a non-nil pointer to a struct, got %T", obj)
+	}
+
+	found := map[string]bool{}
+	if err := p.parameterizeStruct(v.Elem(), prefix, fields, found); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, field := range fields {
+		if !found[field] {
+			errs = append(errs, fmt.Errorf("field %s not found in %T", field, obj))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (p *Parameters) parameterizeStruct(v reflect.Value, prefix string, fields []string, found map[string]bool) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := p.parameterizeStruct(v.Field(i), prefix, fields, found); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tagName, hasTag := field.Tag.Lookup(templateTagName)
+		if len(fields) > 0 {
+			if !slices.Contains(fields, field.Name) {
+				continue
+			}
+			found[field.Name] = true
+		} else if !hasTag {
+			continue
+		}
+
+		name := paramName(prefix, tagName, field)
+		if err := p.parameterizeField(v.Field(i), prefix, name); err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *Parameters) parameterizeField(v reflect.Value, prefix, name string) error {
+	switch v.Type() {
+	case resourcesType:
+		resources := v.Addr().Interface().(*corev1.ResourceRequirements)
+		for _, res := range resourcesSuffixes {
+			list := resources.Limits
+			if res.request {
+				list = resources.Requests
+			}
+
+			quantity, ok := list[res.name]
+			if !ok {
+				continue
+			}
+
+			sentinel, err := p.addSentinel(templatev1.Parameter{Name: joinName(prefix, res.suffix), Value: quantity.String()})
+			if err != nil {
+				return err
+			}
+			list[res.name] = *resource.NewQuantity(sentinel, resource.DecimalSI)
+		}
+		return nil
+	case quantityType:
+		quantity := v.Addr().Interface().(*resource.Quantity)
+		sentinel, err := p.addSentinel(templatev1.Parameter{Name: name, Value: quantity.String()})
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(*resource.NewQuantity(sentinel, resource.DecimalSI)))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if err := p.add(templatev1.Parameter{Name: name, Value: v.String()}); err != nil {
+			return err
+		}
+		v.SetString(fmt.Sprintf("${%s}", name))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// Types such as time.Duration are not rendered as plain numbers, which would lose the sentinel.
+		if v.Type().Implements(stringerType) {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+
+		sentinel := sentinelBase + int64(len(p.sentinels))
+		if v.OverflowInt(sentinel) {
+			return fmt.Errorf("type %s is too small to be parameterized", v.Type())
+		}
+
+		if _, err := p.addSentinel(templatev1.Parameter{Name: name, Value: strconv.FormatInt(v.Int(), 10)}); err != nil {
+			return err
+		}
+		v.SetInt(sentinel)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+func (p *Parameters) add(param templatev1.Parameter) error {
+	if param.Name == "" {
+		return errors.New("parameter name must be specified")
+	}
+
+	for _, existing := range p.params {
+		if existing.Name == param.Name {
+			return fmt.Errorf("duplicate parameter %s", param.Name)
+		}
+	}
+
+	p.params = append(p.params, param)
+
+	return nil
+}
+
+// IsParameterized reports whether v is the value set by Parameters.Parameterize in a non-string field, such as
+// the replicas of a workload. Such a value is a placeholder, that can't be used to derive other values before
+// the template is processed, e.g. to list the pods of a workload.
+func IsParameterized(v int64) bool {
+	return v >= sentinelBase && v <= math.MaxInt32
+}
+
+// addSentinel adds a parameter for a non-string field and returns the sentinel value to set in the field.
+func (p *Parameters) addSentinel(param templatev1.Parameter) (int64, error) {
+	if err := p.add(param); err != nil {
+		return 0, err
+	}
+
+	sentinel := sentinelBase + int64(len(p.sentinels))
+	p.sentinels[sentinel] = param.Name
+
+	return sentinel, nil
+}
+
+// WrapInTemplate wraps the given objects in an OpenShift Template with the collected parameters.
+// The sentinel values of non-string fields are substituted field by field: numbers are replaced with ${{NAME}}
+// placeholders, and strings such as resource quantities with ${NAME} placeholders. In the args and command
+// of the containers, the values of flags like --replicas=<sentinel> are replaced as well.
+// It returns an error if a sentinel is rendered as part of another value, such as a config file.
+func (p *Parameters) WrapInTemplate(objects []runtime.Object, meta metav1.ObjectMeta) (*templatev1.Template, error) {
+	ret := WrapInTemplate(objects, meta, p.List())
+	if len(p.sentinels) == 0 {
+		return ret, nil
+	}
+
+	var errs []error
+	for i, raw := range ret.Objects {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(raw.Object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert object %d: %w", i, err)
+		}
+
+		if err := p.substitute(content, ""); err != nil {
+			errs = append(errs, fmt.Errorf("object %d: %w", i, err))
+		}
+
+		ret.Objects[i].Object = &unstructured.Unstructured{Object: content}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// substitute replaces the sentinels of the values of v, a map or a list converted from an object.
+// key is the name of the field holding v.
+func (p *Parameters) substitute(v interface{}, key string) error {
+	var errs []error
+	replace := func(item interface{}, itemKey string) interface{} {
+		switch val := item.(type) {
+		case map[string]interface{}, []interface{}:
+			errs = append(errs, p.substitute(val, itemKey))
+		case int64:
+			if name, ok := p.sentinels[val]; ok {
+				return fmt.Sprintf("${{%s}}", name)
+			}
+		case string:
+			ret, err := p.substituteString(val, itemKey == "args" || itemKey == "command")
+			errs = append(errs, err)
+			return ret
+		}
+		return item
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = replace(item, k)
+		}
+	case []interface{}:
+		// The items of a list are held by the field of the list.
+		for i, item := range val {
+			val[i] = replace(item, key)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// substituteString replaces a string that is a sentinel, or the value of a flag set to a sentinel if flag is true.
+func (p *Parameters) substituteString(s string, flag bool) (string, error) {
+	if name, ok := p.sentinelName(s); ok {
+		return fmt.Sprintf("${%s}", name), nil
+	}
+
+	if flag && strings.HasPrefix(s, "-") {
+		if opt, value, ok := strings.Cut(s, "="); ok {
+			if name, ok := p.sentinelName(value); ok {
+				return fmt.Sprintf("%s=${%s}", opt, name), nil
+			}
+		}
+	}
+
+	for sentinel, name := range p.sentinels {
+		if strings.Contains(s, strconv.FormatInt(sentinel, 10)) {
+			return s, fmt.Errorf("parameter %s is rendered as part of the value %q, only whole values and flags can be parameterized", name, s)
+		}
+	}
+
+	return s, nil
+}
+
+// sentinelName returns the name of the parameter of a sentinel rendered as a number or a quantity, e.g. 1987650k.
+func (p *Parameters) sentinelName(s string) (string, bool) {
+	quantity, err := resource.ParseQuantity(s)
+	if err != nil {
+		return "", false
+	}
+
+	sentinel, ok := quantity.AsInt64()
+	if !ok {
+		return "", false
+	}
+
+	name, ok := p.sentinels[sentinel]
+
+	return name, ok
+}
+
+// paramName returns the name of the parameter of a field.
+func paramName(prefix, tagName string, field reflect.StructField) string {
+	if tagName != "" {
+		return joinName(prefix, tagName)
+	}
+
+	if optName, _, _ := strings.Cut(field.Tag.Get(optTagName), ","); optName != "" {
+		return joinName(prefix, strings.ToUpper(strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return '_'
+		}, optName)))
+	}
+
+	var b strings.Builder
+	for i, r := range field.Name {
+		if i > 0 && unicode.IsUpper(r) {
+			b.WriteRune('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+
+	return joinName(prefix, b.String())
+}
+
+func joinName(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+
+	return prefix + "_" + name
+}
@@ -0,0 +1,207 @@
+package openshift_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/query"
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/openshift"
+	templatev1 "github.com/openshift/api/template/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+func TestParameterize(t *testing.T) {
+	params := openshift.NewParameters()
+	if err := params.Add(templatev1.Parameter{Name: "NAMESPACE", Value: "observatorium"}); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := query.NewDefaultOptions()
+	if err := params.Parameterize(opts, "THANOS_QUERY", "LogLevel"); err != nil {
+		t.Fatal(err)
+	}
+
+	q := query.NewQuery(opts, "${NAMESPACE}", "v0.32.0")
+	q.Replicas = 3
+	if err := params.Parameterize(q, "THANOS_QUERY"); err != nil {
+		t.Fatal(err)
+	}
+	if !openshift.IsParameterized(int64(q.Replicas)) || openshift.IsParameterized(3) {
+		t.Errorf("expected only the parameterized replicas to be reported, got %d", q.Replicas)
+	}
+
+	tmpl, err := params.WrapInTemplate(q.Objects(), metav1.ObjectMeta{Name: "thanos-query"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedParams := map[string]string{
+		"NAMESPACE":                   "observatorium",
+		"THANOS_QUERY_LOG_LEVEL":      "warn",
+		"THANOS_QUERY_REPLICAS":       "3",
+		"THANOS_QUERY_CPU_REQUEST":    "500m",
+		"THANOS_QUERY_CPU_LIMIT":      "2",
+		"THANOS_QUERY_MEMORY_REQUEST": "1Gi",
+		"THANOS_QUERY_MEMORY_LIMIT":   "8Gi",
+		"THANOS_QUERY_IMAGE":          "quay.io/thanos/thanos",
+		"THANOS_QUERY_IMAGE_TAG":      "v0.32.0",
+	}
+
+	if len(tmpl.Parameters) != len(expectedParams) {
+		t.Errorf("expected %d parameters, got %d: %v", len(expectedParams), len(tmpl.Parameters), tmpl.Parameters)
+	}
+
+	for _, param := range tmpl.Parameters {
+		if expected, ok := expectedParams[param.Name]; !ok || expected != param.Value {
+			t.Errorf("unexpected parameter %s=%s", param.Name, param.Value)
+		}
+	}
+
+	data, err := yaml.Marshal(tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"replicas: ${{THANOS_QUERY_REPLICAS}}",
+		"--log.level=${THANOS_QUERY_LOG_LEVEL}",
+		"cpu: ${THANOS_QUERY_CPU_REQUEST}",
+		"memory: ${THANOS_QUERY_MEMORY_LIMIT}",
+		"image: ${THANOS_QUERY_IMAGE}:${THANOS_QUERY_IMAGE_TAG}",
+		"namespace: ${NAMESPACE}",
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("expected template to contain %q", expected)
+		}
+	}
+
+	if strings.Contains(string(data), "198765") {
+		t.Errorf("expected all sentinels to be replaced:\n%s", data)
+	}
+}
+
+func TestParameterizeErrors(t *testing.T) {
+	testCases := map[string]struct {
+		obj      interface{}
+		existing []templatev1.Parameter
+		fields   []string
+	}{
+		"not a pointer": {
+			obj: *query.NewDefaultOptions(),
+		},
+		"unknown field": {
+			obj:    query.NewDefaultOptions(),
+			fields: []string{"Unknown"},
+		},
+		"unsupported type": {
+			obj:    query.NewDefaultOptions(),
+			fields: []string{"QueryTimeout"},
+		},
+		"duplicate parameter": {
+			obj:      query.NewDefaultOptions(),
+			existing: []templatev1.Parameter{{Name: "THANOS_QUERY_LOG_LEVEL"}},
+			fields:   []string{"LogLevel"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			params := openshift.NewParameters()
+			if err := params.Add(tc.existing...); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := params.Parameterize(tc.obj, "THANOS_QUERY", tc.fields...); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestWrapInTemplateSubstitution(t *testing.T) {
+	type fields struct {
+		Resources corev1.ResourceRequirements `template:"RESOURCES"`
+		Port      int32                       `opt:"port"`
+	}
+
+	newObjects := func(f fields, config string) []runtime.Object {
+		return []runtime.Object{
+			&appsv1.Deployment{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:      "test",
+						Args:      []string{fmt.Sprintf("--port=%d", f.Port)},
+						Resources: f.Resources,
+					}},
+				}}},
+			},
+			&corev1.ConfigMap{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Data:       map[string]string{"config.yaml": config},
+			},
+		}
+	}
+
+	testCases := map[string]struct {
+		config      func(f fields) string
+		expected    []string
+		expectedErr string
+	}{
+		"whole values and flags": {
+			config: func(fields) string { return "port: 8080" },
+			expected: []string{
+				// The first sentinel is rendered as 1987650k.
+				"cpu: ${TEST_CPU_REQUEST}",
+				"memory: ${TEST_MEMORY_LIMIT}",
+				"--port=${TEST_PORT}",
+			},
+		},
+		"sentinel in a config file": {
+			config:      func(f fields) string { return fmt.Sprintf("port: %d", f.Port) },
+			expectedErr: `parameter TEST_PORT is rendered as part of the value "port: `,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			f := fields{Resources: kghelpers.NewResourcesRequirements("1", "", "", "1Gi"), Port: 8080}
+			params := openshift.NewParameters()
+			if err := params.Parameterize(&f, "TEST"); err != nil {
+				t.Fatal(err)
+			}
+			if err := params.Parameterize(&f, "TEST", "Port"); err != nil {
+				t.Fatal(err)
+			}
+
+			tmpl, err := params.WrapInTemplate(newObjects(f, tc.config(f)), metav1.ObjectMeta{Name: "test"})
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Errorf("expected error to contain %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			data, err := yaml.Marshal(tmpl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, expected := range tc.expected {
+				if !strings.Contains(string(data), expected) {
+					t.Errorf("expected template to contain %q:\n%s", expected, data)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,477 @@
+// Package optgen generates and checks options structs from the --help output of kingpin based binaries, like Thanos.
+package optgen
+
+import (
+	"bufio"
+	"fmt"
+	"go/format"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	prommodel "github.com/prometheus/common/model"
+)
+
+const (
+	optTagName    = "opt"
+	maxFlagIndent = 6
+)
+
+// ignoredFlags are the flags added by kingpin to every command.
+var ignoredFlags = map[string]bool{
+	"help":      true,
+	"help-long": true,
+	"help-man":  true,
+	"version":   true,
+}
+
+// hiddenFlags are registered by Thanos but not listed in the --help output.
+var hiddenFlags = map[string]bool{
+	"receive.limits-config":      true,
+	"receive.limits-config-file": true,
+}
+
+// FlagType is the kind of value expected by a flag.
+type FlagType string
+
+const (
+	FlagTypeBool     FlagType = "bool"
+	FlagTypeString   FlagType = "string"
+	FlagTypeInt      FlagType = "int"
+	FlagTypeFloat    FlagType = "float"
+	FlagTypeDuration FlagType = "duration"
+	FlagTypeAddress  FlagType = "address"
+)
+
+// Flag is a flag described in a --help output.
+type Flag struct {
+	Name string
+	// Default is the default value, empty if the flag has none or only a placeholder.
+	Default    string
+	Type       FlagType
+	Repeatable bool
+	Help       string
+}
+
+var (
+	intRe      = regexp.MustCompile(`^-?\d+$`)
+	floatRe    = regexp.MustCompile(`^-?\d+\.\d+$`)
+	hostPortRe = regexp.MustCompile(`^[\w.\-\[\]:]*:\d+$`)
+)
+
+// ParseHelp parses the flags of a kingpin --help output, as printed by `thanos <component> --help`.
+// Flags are returned sorted by name.
+func ParseHelp(r io.Reader) ([]Flag, error) {
+	ret := []Flag{}
+	inFlags := false
+	var current *Flag
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "Flags:":
+			inFlags = true
+			continue
+		case !inFlags:
+			continue
+		case trimmed == "":
+			// Flags are listed in a single block, skip the blank lines around it.
+			continue
+		case !strings.HasPrefix(line, " "):
+			// A new section (e.g. "Subcommands:") ends the flags block.
+			inFlags = false
+			continue
+		}
+
+		// Flags are indented by a few spaces, their wrapped help text much further.
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		spec, help, _ := strings.Cut(trimmed, "  ")
+		if len(spec) > 3 && spec[0] == '-' && spec[1] != '-' && spec[2] == ',' {
+			// Short flag alias, e.g. "-h, --help".
+			spec = strings.TrimSpace(spec[3:])
+		}
+
+		if indent > maxFlagIndent || !strings.HasPrefix(spec, "--") {
+			if current == nil {
+				return nil, fmt.Errorf("unexpected line in flags block: %q", line)
+			}
+			current.Help = strings.TrimSpace(current.Help + " " + trimmed)
+			continue
+		}
+
+		f, err := parseFlagSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		f.Help = strings.TrimSpace(help)
+
+		if ignoredFlags[f.Name] {
+			current = &Flag{}
+			continue
+		}
+
+		ret = append(ret, f)
+		current = &ret[len(ret)-1]
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+
+	return ret, nil
+}
+
+// parseFlagSpec parses the flag part of a help line, e.g. `--[no-]web.disable`, `--http-address="0.0.0.0:10902"`
+// or `--label=key="value" ...`.
+func parseFlagSpec(spec string) (Flag, error) {
+	ret := Flag{Type: FlagTypeString}
+
+	spec = strings.TrimSpace(spec)
+	if strings.HasSuffix(spec, "...") {
+		ret.Repeatable = true
+		spec = strings.TrimSpace(strings.TrimSuffix(spec, "..."))
+	}
+
+	spec = strings.TrimPrefix(spec, "--")
+	if strings.HasPrefix(spec, "[no-]") {
+		ret.Type = FlagTypeBool
+		spec = strings.TrimPrefix(spec, "[no-]")
+	}
+
+	name, value, hasValue := strings.Cut(spec, "=")
+	if name == "" {
+		return ret, fmt.Errorf("invalid flag %q", spec)
+	}
+	ret.Name = name
+
+	if !hasValue {
+		// Older kingpin versions print bool flags without the [no-] prefix.
+		ret.Type = FlagTypeBool
+		return ret, nil
+	}
+
+	ret.Type, ret.Default = inferType(name, value)
+
+	return ret, nil
+}
+
+// inferType guesses the type of a flag from its name and default value or placeholder.
+func inferType(name, value string) (FlagType, string) {
+	// Quoted defaults are checked first, as e.g. "0.0.0.0:10901" would look like a placeholder.
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		if hostPortRe.MatchString(unquoted) && strings.Contains(name, "address") {
+			return FlagTypeAddress, unquoted
+		}
+		return FlagTypeString, unquoted
+	}
+
+	if strings.HasPrefix(value, "<") || isPlaceholder(value) {
+		if strings.HasSuffix(name, "-address") || strings.HasSuffix(name, ".address") || name == "address" {
+			return FlagTypeAddress, ""
+		}
+		if strings.Contains(value, "DURATION") || strings.Contains(value, "duration") {
+			return FlagTypeDuration, ""
+		}
+		return FlagTypeString, ""
+	}
+
+	switch {
+	case intRe.MatchString(value):
+		return FlagTypeInt, value
+	case floatRe.MatchString(value):
+		return FlagTypeFloat, value
+	case isDuration(value):
+		return FlagTypeDuration, value
+	}
+
+	return FlagTypeString, value
+}
+
+func isPlaceholder(value string) bool {
+	for _, r := range value {
+		if unicode.IsLower(r) {
+			return false
+		}
+	}
+	return value != "" && !intRe.MatchString(value) && !floatRe.MatchString(value)
+}
+
+func isDuration(value string) bool {
+	if _, err := time.ParseDuration(value); err == nil {
+		return true
+	}
+	_, err := prommodel.ParseDuration(value)
+	return err == nil
+}
+
+// GoType returns the Go type used for the flag in options structs.
+func (f Flag) GoType() string {
+	var ret string
+	switch f.Type {
+	case FlagTypeBool:
+		ret = "bool"
+	case FlagTypeInt:
+		ret = "int"
+	case FlagTypeFloat:
+		ret = "float64"
+	case FlagTypeDuration:
+		ret = "time.Duration"
+	case FlagTypeAddress:
+		ret = "*net.TCPAddr"
+	default:
+		ret = "string"
+	}
+
+	if f.Repeatable {
+		return "[]" + ret
+	}
+	return ret
+}
+
+// FieldName returns the Go field name of the flag, e.g. HttpAddress for http-address.
+func (f Flag) FieldName() string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(f.Name, func(r rune) bool { return r == '.' || r == '-' || r == '_' }) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// Tag returns the opt tag of the flag.
+func (f Flag) Tag() string {
+	if f.Type == FlagTypeBool && !f.Repeatable {
+		return fmt.Sprintf(`opt:"%s,noval"`, f.Name)
+	}
+	return fmt.Sprintf(`opt:"%s"`, f.Name)
+}
+
+// Generate returns the Go source of an options struct with a field for each flag.
+// The output is meant to be reviewed before replacing a hand-written struct.
+func Generate(structName string, flags []Flag) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "type %s struct {\n", structName)
+	for _, f := range flags {
+		fmt.Fprintf(&b, "\t%s %s `%s`\n", f.FieldName(), f.GoType(), f.Tag())
+	}
+	b.WriteString("\n\t// Extra options not officially supported.\n\tcmdopt.ExtraOpts\n}\n")
+
+	ret, err := format.Source([]byte(b.String()))
+	if err != nil {
+		panic(fmt.Sprintf("error formatting generated struct %s: %v", structName, err))
+	}
+
+	return string(ret)
+}
+
+// Report lists the differences between the flags of a binary and an options struct.
+type Report struct {
+	// Missing flags are supported by the binary but have no field in the struct.
+	Missing []Flag
+	// Removed flags have a field in the struct but are not supported by the binary.
+	Removed []string
+	// Misspelled maps a struct flag name to the binary flag it most likely stands for.
+	Misspelled map[string]string
+	// Mistyped maps a flag name to a description of the type mismatch.
+	Mistyped map[string]string
+}
+
+// Empty returns true if the struct matches the binary flags.
+func (r Report) Empty() bool {
+	return len(r.Missing) == 0 && len(r.Removed) == 0 && len(r.Misspelled) == 0 && len(r.Mistyped) == 0
+}
+
+// String returns a human readable report.
+func (r Report) String() string {
+	var b strings.Builder
+
+	for _, name := range sortedKeys(r.Misspelled) {
+		fmt.Fprintf(&b, "misspelled: %s should be %s\n", name, r.Misspelled[name])
+	}
+	for _, name := range r.Removed {
+		fmt.Fprintf(&b, "removed: %s\n", name)
+	}
+	for _, f := range r.Missing {
+		fmt.Fprintf(&b, "missing: %s %s `%s`\n", f.FieldName(), f.GoType(), f.Tag())
+	}
+	for _, name := range sortedKeys(r.Mistyped) {
+		fmt.Fprintf(&b, "mistyped: %s: %s\n", name, r.Mistyped[name])
+	}
+
+	return b.String()
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// Diff compares the flags of a binary with the opt tags of an options struct (or a pointer to it).
+func Diff(flags []Flag, obj interface{}) Report {
+	ret := Report{
+		Misspelled: map[string]string{},
+		Mistyped:   map[string]string{},
+	}
+
+	fields := structFields(obj)
+
+	byName := make(map[string]Flag, len(flags))
+	for _, f := range flags {
+		byName[f.Name] = f
+
+		field, ok := fields[f.Name]
+		if !ok {
+			continue
+		}
+
+		if msg := checkType(f, field.Type); msg != "" {
+			ret.Mistyped[f.Name] = fmt.Sprintf("field %s is %s, %s", field.Name, field.Type, msg)
+		}
+	}
+
+	removed := []string{}
+	for name := range fields {
+		if ignoredFlags[name] || hiddenFlags[name] {
+			continue
+		}
+		if _, ok := byName[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+
+	missing := map[string]Flag{}
+	for _, f := range flags {
+		if _, ok := fields[f.Name]; !ok {
+			missing[f.Name] = f
+		}
+	}
+
+	// Match removed and missing flags that only differ by a few characters.
+	for _, name := range removed {
+		if match := closest(name, missing); match != "" {
+			ret.Misspelled[name] = match
+			delete(missing, match)
+			continue
+		}
+		ret.Removed = append(ret.Removed, name)
+	}
+
+	for _, name := range sortedKeys(missing) {
+		ret.Missing = append(ret.Missing, missing[name])
+	}
+
+	return ret
+}
+
+// structFields maps the option names of the struct to their field.
+func structFields(obj interface{}) map[string]reflect.StructField {
+	t := reflect.TypeOf(obj)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	ret := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get(optTagName), ",")
+		if name == "" {
+			continue
+		}
+
+		ret[name] = field
+	}
+
+	return ret
+}
+
+// checkType returns a description of the mismatch between the flag and the field type, if any.
+// Only unambiguous mismatches are reported, as string fields are commonly used for any flag.
+func checkType(f Flag, t reflect.Type) string {
+	// Pointers implementing fmt.Stringer are rendered as a single value by cmdopt, whatever their underlying type.
+	stringer := t.Kind() == reflect.Ptr && t.Implements(stringerType)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	isSlice := t.Kind() == reflect.Slice && !stringer
+	if f.Repeatable && !isSlice {
+		return "flag is repeatable"
+	}
+	if !f.Repeatable && isSlice {
+		return "flag is not repeatable"
+	}
+	if isSlice {
+		t = t.Elem()
+	}
+
+	isBool := t.Kind() == reflect.Bool
+	if f.Type == FlagTypeBool && !isBool {
+		return "flag is a bool"
+	}
+	if f.Type != FlagTypeBool && isBool {
+		return fmt.Sprintf("flag expects a %s value", f.Type)
+	}
+
+	if f.Type == FlagTypeDuration && t.Kind() != reflect.String && t.Kind() != reflect.Interface && t != durationType {
+		return "flag is a duration"
+	}
+
+	return ""
+}
+
+// closest returns the candidate at an edit distance of at most 2 from name, if any.
+func closest(name string, candidates map[string]Flag) string {
+	best, bestDist := "", 3
+	for _, candidate := range sortedKeys(candidates) {
+		if d := levenshtein(name, candidate); d < bestDist {
+			best, bestDist = candidate, d
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
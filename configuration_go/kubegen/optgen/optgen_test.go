@@ -0,0 +1,256 @@
+package optgen_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/compactor"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/query"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/queryfrontend"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/receive"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/ruler"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/store"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/optgen"
+)
+
+// testdata/<version>/<component>.txt is the `thanos <component> --help` output of a Thanos release, as embedded
+// by mdox in its docs/components/<component>.md.
+func parseHelpFile(t *testing.T, version, component string) []optgen.Flag {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", version, component+".txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	flags, err := optgen.ParseHelp(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return flags
+}
+
+func TestParseHelp(t *testing.T) {
+	flags := parseHelpFile(t, "v0.34.1", "store")
+
+	byName := map[string]optgen.Flag{}
+	for _, f := range flags {
+		byName[f.Name] = f
+	}
+
+	for _, ignored := range []string{"help", "version", "help-long"} {
+		if _, ok := byName[ignored]; ok {
+			t.Fatalf("expected flag %s to be ignored", ignored)
+		}
+	}
+
+	testCases := map[string]struct {
+		flagType   optgen.FlagType
+		def        string
+		repeatable bool
+	}{
+		"cache-index-header":                {flagType: optgen.FlagTypeBool},
+		"web.disable":                       {flagType: optgen.FlagTypeBool},
+		"data-dir":                          {flagType: optgen.FlagTypeString, def: "./data"},
+		"grpc-address":                      {flagType: optgen.FlagTypeAddress, def: "0.0.0.0:10901"},
+		"grpc-grace-period":                 {flagType: optgen.FlagTypeDuration, def: "2m"},
+		"store.grpc.series-max-concurrency": {flagType: optgen.FlagTypeInt, def: "20"},
+		"objstore.config":                   {flagType: optgen.FlagTypeString},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			f, ok := byName[name]
+			if !ok {
+				t.Fatalf("flag %s not found", name)
+			}
+
+			if f.Type != tc.flagType || f.Default != tc.def || f.Repeatable != tc.repeatable {
+				t.Fatalf("expected type %s, default %q, repeatable %t, got %+v", tc.flagType, tc.def, tc.repeatable, f)
+			}
+		})
+	}
+
+	if help := byName["data-dir"].Help; !strings.Contains(help, "caching purposes (index-header") {
+		t.Fatalf("expected wrapped help text to be joined, got %q", help)
+	}
+}
+
+// components maps the Thanos subcommands to the options struct of their abstraction.
+var components = map[string]interface{}{
+	"compact":        &compactor.CompactorOptions{},
+	"query":          &query.QueryOptions{},
+	"query-frontend": &queryfrontend.QueryFrontendOptions{},
+	"receive":        &receive.ReceiveOptions{},
+	"rule":           &ruler.RulerOptions{},
+	"store":          &store.StoreOptions{},
+}
+
+// releases are the Thanos releases whose help output is checked in, from the oldest to the latest.
+// Not every patch release is checked in, as their flags don't change.
+var releases = []string{"v0.26.0", "v0.27.0", "v0.28.0", "v0.29.0", "v0.30.0", "v0.31.0", "v0.32.2", "v0.32.5", "v0.33.0", "v0.34.0", "v0.34.1"}
+
+// TestOptionsInSync checks the options structs against the latest supported release,
+// as `go run ./cmd/optgen --component=<component>` does.
+func TestOptionsInSync(t *testing.T) {
+	for component, opts := range components {
+		t.Run(component, func(t *testing.T) {
+			if report := optgen.Diff(parseHelpFile(t, releases[len(releases)-1], component), opts); !report.Empty() {
+				t.Fatalf("expected the options to match the %s help output, got:\n%s", component, report)
+			}
+		})
+	}
+}
+
+// TestVersionTags checks the since and until tags read by cmdopt.CheckVersion against the releases listing each flag.
+// As not every release is checked in, a tag may hold any version after the last release without the flag,
+// up to the first release with it.
+func TestVersionTags(t *testing.T) {
+	for component, opts := range components {
+		t.Run(component, func(t *testing.T) {
+			// listed holds, for each flag, whether each release lists it.
+			listed := map[string][]bool{}
+			for i, release := range releases {
+				for _, f := range parseHelpFile(t, release, component) {
+					if listed[f.Name] == nil {
+						listed[f.Name] = make([]bool, len(releases))
+					}
+					listed[f.Name][i] = true
+				}
+			}
+
+			typ := reflect.TypeOf(opts).Elem()
+			for i := 0; i < typ.NumField(); i++ {
+				field := typ.Field(i)
+				name, _, _ := strings.Cut(field.Tag.Get("opt"), ",")
+				if _, ok := listed[name]; !ok {
+					// Not an option, or a hidden flag which can't be checked.
+					continue
+				}
+
+				first := slices.Index(listed[name], true)
+				last := len(releases) - 1
+				for !listed[name][last] {
+					last--
+				}
+
+				if since := field.Tag.Get("since"); first == 0 {
+					if since != "" && compareVersions(t, since, releases[0]) > 0 {
+						t.Errorf("%s: flag %s is listed by %s, got since %s", field.Name, name, releases[0], since)
+					}
+				} else if since == "" || compareVersions(t, since, releases[first-1]) <= 0 || compareVersions(t, since, releases[first]) > 0 {
+					t.Errorf("%s: flag %s is listed since %s but not by %s, got since %q", field.Name, name, releases[first], releases[first-1], since)
+				}
+
+				if until := field.Tag.Get("until"); last == len(releases)-1 {
+					if until != "" {
+						t.Errorf("%s: flag %s is listed by %s, got until %s", field.Name, name, releases[last], until)
+					}
+				} else if until == "" || compareVersions(t, until, releases[last]) <= 0 || compareVersions(t, until, releases[last+1]) > 0 {
+					t.Errorf("%s: flag %s is listed until %s but not by %s, got until %q", field.Name, name, releases[last], releases[last+1], until)
+				}
+			}
+		})
+	}
+}
+
+// compareVersions compares two vX.Y.Z versions.
+func compareVersions(t *testing.T, a, b string) int {
+	t.Helper()
+
+	parse := func(version string) [3]int {
+		ret := [3]int{}
+		if _, err := fmt.Sscanf(version, "v%d.%d.%d", &ret[0], &ret[1], &ret[2]); err != nil {
+			t.Fatalf("invalid version %q: %v", version, err)
+		}
+		return ret
+	}
+
+	va, vb := parse(a), parse(b)
+	for i := range va {
+		if va[i] != vb[i] {
+			return va[i] - vb[i]
+		}
+	}
+	return 0
+}
+
+func TestParseHelpRepeatable(t *testing.T) {
+	help := `Flags:
+      --endpoint=<endpoint> ...  Addresses of statically configured store API
+                                 servers (repeatable).
+      --[no-]web.disable         Disable the web UI.
+`
+
+	flags, err := optgen.ParseHelp(strings.NewReader(help))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []optgen.Flag{
+		{Name: "endpoint", Type: optgen.FlagTypeString, Repeatable: true, Help: "Addresses of statically configured store API servers (repeatable)."},
+		{Name: "web.disable", Type: optgen.FlagTypeBool, Help: "Disable the web UI."},
+	}
+	if !slices.Equal(flags, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, flags)
+	}
+}
+
+// hashrings is rendered by cmdopt as a single value, through its String method.
+type hashrings []string
+
+func (h *hashrings) String() string { return strings.Join(*h, ",") }
+
+func TestDiff(t *testing.T) {
+	flags := []optgen.Flag{
+		{Name: "data-dir", Type: optgen.FlagTypeString},
+		{Name: "grpc-grace-period", Type: optgen.FlagTypeDuration},
+		{Name: "label", Type: optgen.FlagTypeString, Repeatable: true},
+		{Name: "store.grpc.series-max-concurrency", Type: optgen.FlagTypeInt},
+		{Name: "web.disable", Type: optgen.FlagTypeBool},
+		{Name: "new-flag", Type: optgen.FlagTypeString},
+		{Name: "receive.hashrings", Type: optgen.FlagTypeString},
+	}
+
+	opts := struct {
+		DataDir                       string        `opt:"data-dir"`
+		GrpcGracePeriod               time.Duration `opt:"grpc-grace-period"`
+		Label                         string        `opt:"label"`
+		StoreGrpcSeriesMaxConcurrency int           `opt:"store.grps.series-max-concurrency"`
+		WebDisable                    string        `opt:"web.disable"`
+		OldFlag                       string        `opt:"old-flag-that-was-dropped"`
+		Hashrings                     *hashrings    `opt:"receive.hashrings"`
+		Version                       bool          `opt:"version,noval"`
+		LimitsConfig                  string        `opt:"receive.limits-config"`
+	}{}
+
+	report := optgen.Diff(flags, &opts)
+
+	if report.Empty() {
+		t.Fatalf("expected differences")
+	}
+
+	if len(report.Missing) != 1 || report.Missing[0].Name != "new-flag" {
+		t.Fatalf("expected new-flag to be missing, got %v", report.Missing)
+	}
+
+	if !slices.Equal(report.Removed, []string{"old-flag-that-was-dropped"}) {
+		t.Fatalf("expected old-flag-that-was-dropped to be removed, got %v", report.Removed)
+	}
+
+	if report.Misspelled["store.grps.series-max-concurrency"] != "store.grpc.series-max-concurrency" {
+		t.Fatalf("expected misspelled store.grps.series-max-concurrency, got %v", report.Misspelled)
+	}
+
+	if len(report.Mistyped) != 2 || report.Mistyped["label"] == "" || report.Mistyped["web.disable"] == "" {
+		t.Fatalf("expected label and web.disable to be mistyped, got %v", report.Mistyped)
+	}
+}
@@ -0,0 +1,248 @@
+package validate
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	openapierrors "k8s.io/kube-openapi/pkg/validation/errors"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"k8s.io/kube-openapi/pkg/validation/strfmt"
+	openapivalidate "k8s.io/kube-openapi/pkg/validation/validate"
+)
+
+// openAPIDocuments holds the OpenAPI v3 documents of the API groups validated against a schema, named after the
+// discovery paths of the API server. Only the schemas reachable from the kinds of each group are kept, without
+// their descriptions:
+//   - core/v1, apps/v1 and batch/v1 are the documents served by Kubernetes, from the client-go v0.32 test data.
+//   - rbac.authorization.k8s.io/v1, policy/v1, autoscaling/v2 and networking.k8s.io/v1 are converted from the
+//     OpenAPI v2 document of Kubernetes v1.27, from the kube-openapi test data.
+//   - monitoring.coreos.com/v1 holds the schemas of the ServiceMonitor, PodMonitor and PrometheusRule CRDs of
+//     configuration/examples/local/manifests/kube-prometheus.
+//
+// The CRDs of KEDA, External Secrets and Sealed Secrets are not bundled, so their custom resources only get their
+// metadata validated.
+//
+//go:embed openapi/*.json
+var openAPIDocuments embed.FS
+
+const schemaRefPrefix = "#/components/schemas/"
+
+// openAPISchemas indexes the schemas of the bundled documents by name and the names of the kinds by GVK.
+type openAPISchemas struct {
+	schemas map[string]interface{}
+	kinds   map[schema.GroupVersionKind]string
+
+	mu       sync.Mutex
+	expanded map[schema.GroupVersionKind]*spec.Schema
+}
+
+var loadOpenAPISchemas = sync.OnceValue(func() *openAPISchemas {
+	ret := &openAPISchemas{
+		schemas:  map[string]interface{}{},
+		kinds:    map[schema.GroupVersionKind]string{},
+		expanded: map[schema.GroupVersionKind]*spec.Schema{},
+	}
+
+	paths, err := fs.Glob(openAPIDocuments, "openapi/*.json")
+	if err != nil {
+		panic(fmt.Sprintf("failed to list the OpenAPI documents: %v", err))
+	}
+
+	for _, path := range paths {
+		data, err := openAPIDocuments.ReadFile(path)
+		if err != nil {
+			panic(fmt.Sprintf("failed to read %s: %v", path, err))
+		}
+
+		doc := struct {
+			Components struct {
+				Schemas map[string]map[string]interface{} `json:"schemas"`
+			} `json:"components"`
+		}{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			panic(fmt.Sprintf("failed to parse %s: %v", path, err))
+		}
+
+		for name, s := range doc.Components.Schemas {
+			ret.schemas[name] = s
+
+			gvks, _ := s["x-kubernetes-group-version-kind"].([]interface{})
+			for _, gvk := range gvks {
+				gvk, _ := gvk.(map[string]interface{})
+				group, _ := gvk["group"].(string)
+				version, _ := gvk["version"].(string)
+				kind, _ := gvk["kind"].(string)
+				ret.kinds[schema.GroupVersionKind{Group: group, Version: version, Kind: kind}] = name
+			}
+		}
+	}
+
+	return ret
+})
+
+// schemaFor returns the schema of the given kind, with all references expanded, or nil if no document defines it.
+func (o *openAPISchemas) schemaFor(gvk schema.GroupVersionKind) (*spec.Schema, error) {
+	name, ok := o.kinds[gvk]
+	if !ok {
+		return nil, nil
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if s, ok := o.expanded[gvk]; ok {
+		return s, nil
+	}
+
+	data, err := json.Marshal(o.expand(o.schemas[name], map[string]bool{name: true}))
+	if err != nil {
+		return nil, err
+	}
+
+	s := &spec.Schema{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI schema %s: %w", name, err)
+	}
+	o.expanded[gvk] = s
+
+	return s, nil
+}
+
+// expand replaces the references of s by the schemas they point to, as the validator doesn't resolve them.
+// Recursive references, which the generated objects don't use, are replaced by an empty schema accepting anything.
+func (o *openAPISchemas) expand(s interface{}, visiting map[string]bool) interface{} {
+	switch s := s.(type) {
+	case map[string]interface{}:
+		ret := map[string]interface{}{}
+		if ref, ok := s["$ref"].(string); ok {
+			name := strings.TrimPrefix(ref, schemaRefPrefix)
+			if !visiting[name] {
+				visiting[name] = true
+				for k, v := range o.expand(o.schemas[name], visiting).(map[string]interface{}) {
+					ret[k] = v
+				}
+				delete(visiting, name)
+			}
+		}
+
+		for k, v := range s {
+			switch k {
+			case "$ref":
+			case "default", "enum":
+				ret[k] = v
+			case "format":
+				// The oneOf of the schema already describes int-or-string, which isn't a known format.
+				if v != "int-or-string" {
+					ret[k] = v
+				}
+			default:
+				ret[k] = o.expand(v, visiting)
+			}
+		}
+
+		// Documents wrap references in a single allOf to set their defaults, which would duplicate all the errors.
+		if allOf, ok := ret["allOf"].([]interface{}); ok && len(allOf) == 1 {
+			delete(ret, "allOf")
+			for k, v := range allOf[0].(map[string]interface{}) {
+				if _, ok := ret[k]; !ok {
+					ret[k] = v
+				}
+			}
+		}
+
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, 0, len(s))
+		for _, v := range s {
+			ret = append(ret, o.expand(v, visiting))
+		}
+
+		return ret
+	case nil:
+		return map[string]interface{}{}
+	default:
+		return s
+	}
+}
+
+// validateSchema validates obj against the OpenAPI schema of its kind, if one is bundled.
+// The status is ignored, as the API server drops it on creation, and so are null fields.
+func validateSchema(obj runtime.Object, gvk schema.GroupVersionKind, fldPath *field.Path) field.ErrorList {
+	s, err := loadOpenAPISchemas().schemaFor(gvk)
+	if err != nil {
+		return field.ErrorList{field.InternalError(fldPath, err)}
+	}
+	if s == nil {
+		return nil
+	}
+
+	var data map[string]interface{}
+	switch o := obj.(type) {
+	case *unstructured.Unstructured:
+		data = o.Object
+	case metav1.Object:
+		if data, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			return field.ErrorList{field.InternalError(fldPath, err)}
+		}
+	}
+	data = pruneNulls(data).(map[string]interface{})
+	delete(data, "status")
+
+	var allErrs field.ErrorList
+	res := openapivalidate.NewSchemaValidator(s, nil, "", strfmt.Default).Validate(data)
+	for _, err := range res.Errors {
+		verr, ok := err.(*openapierrors.Validation)
+		if !ok {
+			allErrs = append(allErrs, field.Invalid(fldPath, nil, err.Error()))
+			continue
+		}
+
+		errPath := fldPath
+		if verr.Name != "" && verr.Name != "." {
+			errPath = fldPath.Child(verr.Name)
+		}
+
+		if verr.Code() == openapierrors.RequiredFailCode {
+			allErrs = append(allErrs, field.Required(errPath, ""))
+			continue
+		}
+
+		detail := strings.TrimPrefix(strings.TrimPrefix(verr.Error(), verr.Name), " in "+verr.In+" ")
+		allErrs = append(allErrs, field.Invalid(errPath, verr.Value, detail))
+	}
+
+	return allErrs
+}
+
+// pruneNulls returns a copy of v without its null fields.
+func pruneNulls(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			if item != nil {
+				ret[k] = pruneNulls(item)
+			}
+		}
+
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			ret = append(ret, pruneNulls(item))
+		}
+
+		return ret
+	default:
+		return v
+	}
+}
@@ -0,0 +1,104 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	templatev1 "github.com/openshift/api/template/v1"
+	monv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// scheme holds the schemas the objects are validated against. They are the Go API types of the
+// Kubernetes, prometheus-operator and OpenShift template modules, so that validation doesn't need a cluster.
+var scheme = runtime.NewScheme()
+
+func init() {
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		corev1.AddToScheme,
+		appsv1.AddToScheme,
+		batchv1.AddToScheme,
+		rbacv1.AddToScheme,
+		policyv1.AddToScheme,
+		autoscalingv2.AddToScheme,
+		networkingv1.AddToScheme,
+		monv1.AddToScheme,
+		templatev1.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			panic(fmt.Sprintf("failed to build validation scheme: %v", err))
+		}
+	}
+}
+
+// clusterScopedKinds lists the kinds of the scheme that are not namespaced.
+var clusterScopedKinds = map[schema.GroupKind]bool{
+	{Kind: "Namespace"}:                                   true,
+	{Kind: "PersistentVolume"}:                            true,
+	{Kind: "Node"}:                                        true,
+	{Group: rbacv1.GroupName, Kind: "ClusterRole"}:        true,
+	{Group: rbacv1.GroupName, Kind: "ClusterRoleBinding"}: true,
+}
+
+// toTyped returns the typed version of obj, checking that its apiVersion and kind are set and known.
+// Unstructured objects are decoded strictly, so that unknown fields are reported.
+// Objects of API groups missing from the scheme, such as KEDA ScaledObjects or ExternalSecrets, are returned as is
+// so that only their metadata is validated. Unknown kinds of the scheme groups are still rejected, as they are typos.
+func toTyped(obj runtime.Object) (runtime.Object, schema.GroupVersionKind, error) {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if gvk.Kind == "" || gvk.Version == "" {
+		return nil, gvk, fmt.Errorf("apiVersion and kind must be set")
+	}
+
+	if !scheme.IsGroupRegistered(gvk.Group) {
+		if _, ok := obj.(metav1.Object); !ok {
+			return nil, gvk, fmt.Errorf("no schema for %s and %T has no metadata", gvk, obj)
+		}
+		return obj, gvk, nil
+	}
+
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		kinds, _, err := scheme.ObjectKinds(obj)
+		if err != nil {
+			return nil, gvk, fmt.Errorf("no schema for %T", obj)
+		}
+
+		for _, kind := range kinds {
+			if kind == gvk {
+				return obj, gvk, nil
+			}
+		}
+
+		return nil, gvk, fmt.Errorf("apiVersion and kind %s don't match the object type %T", gvk, obj)
+	}
+
+	typed, err := scheme.New(gvk)
+	if err != nil {
+		return nil, gvk, fmt.Errorf("no schema for %s", gvk)
+	}
+
+	data, err := json.Marshal(u.Object)
+	if err != nil {
+		return nil, gvk, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(typed); err != nil {
+		return nil, gvk, fmt.Errorf("object doesn't match the %s schema: %w", gvk, err)
+	}
+
+	return typed, gvk, nil
+}
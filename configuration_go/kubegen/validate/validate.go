@@ -0,0 +1,420 @@
+// Package validate validates generated objects offline, against the schemas of their API types and the main rules
+// enforced by the API server, such as the format of names, labels and ports. This allows catching errors in unit tests
+// instead of when applying the objects to a cluster.
+//
+// Objects are decoded strictly into the Go types of the Kubernetes, prometheus-operator and OpenShift template APIs,
+// then validated against the bundled OpenAPI v3 documents of the core/v1, apps/v1, batch/v1, rbac/v1, policy/v1,
+// autoscaling/v2, networking/v1 and monitoring.coreos.com/v1 groups. The server-side rules are hand-written checks on
+// top of them. Custom resources of other API groups, such as KEDA ScaledObjects, ExternalSecrets or SealedSecrets,
+// only get their metadata validated, as their CRDs aren't bundled.
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/observatorium/observatorium/configuration_go/kubegen/kubeyaml"
+	templatev1 "github.com/openshift/api/template/v1"
+	monv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/prometheus/common/model"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/api/validation/path"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	metavalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Objects validates the given objects, such as the ones returned by Objects(), and returns all the errors found.
+// Objects of OpenShift templates are validated too, after replacing the parameters with their default values.
+func Objects(objects []runtime.Object) error {
+	var errs []error
+	for _, obj := range objects {
+		if err := Object(obj); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Object validates a single object. Errors are prefixed with the name and kind of the object.
+func Object(obj runtime.Object) error {
+	name, err := kubeyaml.KubeObjectNameAndKindE(obj)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, err := range validateObject(obj, nil) {
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateObject(obj runtime.Object, fldPath *field.Path) field.ErrorList {
+	typed, gvk, err := toTyped(obj)
+	if err != nil {
+		return field.ErrorList{field.Invalid(fldPath, gvk.String(), err.Error())}
+	}
+
+	metaObj, ok := typed.(metav1.Object)
+	if !ok {
+		return field.ErrorList{field.InternalError(fldPath, fmt.Errorf("%T has no metadata", typed))}
+	}
+
+	allErrs := validateMeta(metaObj, gvk.GroupKind(), fldPath.Child("metadata"))
+	allErrs = append(allErrs, validateSchema(obj, gvk, fldPath)...)
+	specPath := fldPath.Child("spec")
+
+	switch o := typed.(type) {
+	case *appsv1.Deployment:
+		allErrs = append(allErrs, validateWorkload(o.Spec.Selector, &o.Spec.Template, nil, specPath)...)
+	case *appsv1.StatefulSet:
+		allErrs = append(allErrs, validateWorkload(o.Spec.Selector, &o.Spec.Template, o.Spec.VolumeClaimTemplates, specPath)...)
+	case *appsv1.DaemonSet:
+		allErrs = append(allErrs, validateWorkload(o.Spec.Selector, &o.Spec.Template, nil, specPath)...)
+	case *corev1.Service:
+		allErrs = append(allErrs, validateService(o, specPath)...)
+	case *corev1.ConfigMap:
+		allErrs = append(allErrs, validateKeys(sets.KeySet(o.Data).Union(sets.KeySet(o.BinaryData)), fldPath.Child("data"))...)
+	case *corev1.Secret:
+		allErrs = append(allErrs, validateKeys(sets.KeySet(o.Data).Union(sets.KeySet(o.StringData)), fldPath.Child("data"))...)
+	case *rbacv1.RoleBinding:
+		allErrs = append(allErrs, validateRoleBinding(o.RoleRef, o.Subjects, fldPath)...)
+	case *rbacv1.ClusterRoleBinding:
+		allErrs = append(allErrs, validateRoleBinding(o.RoleRef, o.Subjects, fldPath)...)
+	case *monv1.ServiceMonitor:
+		allErrs = append(allErrs, validateServiceMonitor(o, specPath)...)
+	case *templatev1.Template:
+		allErrs = append(allErrs, validateTemplate(o, fldPath.Child("objects"))...)
+	}
+
+	return allErrs
+}
+
+func validateMeta(obj metav1.Object, gk schema.GroupKind, fldPath *field.Path) field.ErrorList {
+	nameFn := apivalidation.NameIsDNSSubdomain
+	switch {
+	case gk.Group == "" && (gk.Kind == "Service" || gk.Kind == "Namespace"):
+		nameFn = apivalidation.NameIsDNSLabel
+	case gk.Group == rbacv1.GroupName:
+		nameFn = path.ValidatePathSegmentName
+	}
+
+	// Namespaces are optional for namespaced objects, as they can be set when applying them.
+	requiresNamespace := !clusterScopedKinds[gk] && obj.GetNamespace() != ""
+
+	return apivalidation.ValidateObjectMetaAccessor(obj, requiresNamespace, nameFn, fldPath)
+}
+
+// validateWorkload validates the selector and pod template of Deployments, StatefulSets and DaemonSets.
+func validateWorkload(selector *metav1.LabelSelector, template *corev1.PodTemplateSpec, claims []corev1.PersistentVolumeClaim, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if selector == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("selector"), ""))
+	} else {
+		allErrs = append(allErrs, metavalidation.ValidateLabelSelector(selector, metavalidation.LabelSelectorValidationOptions{}, fldPath.Child("selector"))...)
+		if len(selector.MatchLabels)+len(selector.MatchExpressions) == 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("selector"), selector, "empty selector is invalid for a workload"))
+		} else if s, err := metav1.LabelSelectorAsSelector(selector); err == nil && !s.Matches(labels.Set(template.Labels)) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("template", "metadata", "labels"), template.Labels, "`selector` does not match template `labels`"))
+		}
+	}
+
+	templatePath := fldPath.Child("template")
+	allErrs = append(allErrs, metavalidation.ValidateLabels(template.Labels, templatePath.Child("metadata", "labels"))...)
+	allErrs = append(allErrs, apivalidation.ValidateAnnotations(template.Annotations, templatePath.Child("metadata", "annotations"))...)
+
+	volumes := sets.New[string]()
+	for i, claim := range claims {
+		claimPath := fldPath.Child("volumeClaimTemplates").Index(i)
+		allErrs = append(allErrs, validateDNSLabel(claim.Name, claimPath.Child("metadata", "name"))...)
+		volumes.Insert(claim.Name)
+	}
+
+	return append(allErrs, validatePodSpec(&template.Spec, volumes, templatePath.Child("spec"))...)
+}
+
+func validatePodSpec(spec *corev1.PodSpec, volumes sets.Set[string], fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, volume := range spec.Volumes {
+		volumePath := fldPath.Child("volumes").Index(i)
+		allErrs = append(allErrs, validateDNSLabel(volume.Name, volumePath.Child("name"))...)
+		if volumes.Has(volume.Name) {
+			allErrs = append(allErrs, field.Duplicate(volumePath.Child("name"), volume.Name))
+		}
+		volumes.Insert(volume.Name)
+	}
+
+	if len(spec.Containers) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("containers"), ""))
+	}
+
+	containerNames := sets.New[string]()
+	portNames := sets.New[string]()
+	for _, containers := range []struct {
+		name  string
+		items []corev1.Container
+	}{
+		{"initContainers", spec.InitContainers},
+		{"containers", spec.Containers},
+	} {
+		for i, c := range containers.items {
+			containerPath := fldPath.Child(containers.name).Index(i)
+			allErrs = append(allErrs, validateDNSLabel(c.Name, containerPath.Child("name"))...)
+			if containerNames.Has(c.Name) {
+				allErrs = append(allErrs, field.Duplicate(containerPath.Child("name"), c.Name))
+			}
+			containerNames.Insert(c.Name)
+
+			allErrs = append(allErrs, validateContainer(&c, volumes, portNames, containerPath)...)
+		}
+	}
+
+	return allErrs
+}
+
+func validateContainer(c *corev1.Container, volumes, podPortNames sets.Set[string], fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if c.Image == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("image"), ""))
+	}
+
+	portNames := sets.New[string]()
+	for i, port := range c.Ports {
+		portPath := fldPath.Child("ports").Index(i)
+		if port.Name != "" {
+			for _, msg := range validation.IsValidPortName(port.Name) {
+				allErrs = append(allErrs, field.Invalid(portPath.Child("name"), port.Name, msg))
+			}
+			if podPortNames.Has(port.Name) {
+				allErrs = append(allErrs, field.Duplicate(portPath.Child("name"), port.Name))
+			}
+			podPortNames.Insert(port.Name)
+			portNames.Insert(port.Name)
+		}
+
+		for _, msg := range validation.IsValidPortNum(int(port.ContainerPort)) {
+			allErrs = append(allErrs, field.Invalid(portPath.Child("containerPort"), port.ContainerPort, msg))
+		}
+	}
+
+	for i, env := range c.Env {
+		for _, msg := range validation.IsEnvVarName(env.Name) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("env").Index(i).Child("name"), env.Name, msg))
+		}
+	}
+
+	for i, mount := range c.VolumeMounts {
+		mountPath := fldPath.Child("volumeMounts").Index(i)
+		if !volumes.Has(mount.Name) {
+			allErrs = append(allErrs, field.NotFound(mountPath.Child("name"), mount.Name))
+		}
+		if mount.MountPath == "" {
+			allErrs = append(allErrs, field.Required(mountPath.Child("mountPath"), ""))
+		}
+	}
+
+	for _, probe := range []struct {
+		name  string
+		probe *corev1.Probe
+	}{
+		{"livenessProbe", c.LivenessProbe},
+		{"readinessProbe", c.ReadinessProbe},
+		{"startupProbe", c.StartupProbe},
+	} {
+		if probe.probe == nil {
+			continue
+		}
+
+		probePath := fldPath.Child(probe.name)
+		switch {
+		case probe.probe.HTTPGet != nil:
+			allErrs = append(allErrs, validateProbePort(probe.probe.HTTPGet.Port, portNames, probePath.Child("httpGet", "port"))...)
+		case probe.probe.TCPSocket != nil:
+			allErrs = append(allErrs, validateProbePort(probe.probe.TCPSocket.Port, portNames, probePath.Child("tcpSocket", "port"))...)
+		}
+	}
+
+	return allErrs
+}
+
+// validateProbePort checks that named probe ports are declared by the container.
+func validateProbePort(port intstr.IntOrString, portNames sets.Set[string], fldPath *field.Path) field.ErrorList {
+	if port.Type == intstr.Int {
+		var allErrs field.ErrorList
+		for _, msg := range validation.IsValidPortNum(port.IntValue()) {
+			allErrs = append(allErrs, field.Invalid(fldPath, port.IntValue(), msg))
+		}
+		return allErrs
+	}
+
+	if !portNames.Has(port.StrVal) {
+		return field.ErrorList{field.NotFound(fldPath, port.StrVal)}
+	}
+
+	return nil
+}
+
+func validateService(svc *corev1.Service, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	names := sets.New[string]()
+	for i, port := range svc.Spec.Ports {
+		portPath := fldPath.Child("ports").Index(i)
+		if port.Name == "" {
+			if len(svc.Spec.Ports) > 1 {
+				allErrs = append(allErrs, field.Required(portPath.Child("name"), "names are required when there are several ports"))
+			}
+		} else {
+			allErrs = append(allErrs, validateDNSLabel(port.Name, portPath.Child("name"))...)
+			if names.Has(port.Name) {
+				allErrs = append(allErrs, field.Duplicate(portPath.Child("name"), port.Name))
+			}
+			names.Insert(port.Name)
+		}
+
+		for _, msg := range validation.IsValidPortNum(int(port.Port)) {
+			allErrs = append(allErrs, field.Invalid(portPath.Child("port"), port.Port, msg))
+		}
+
+		if port.TargetPort.Type == intstr.String {
+			for _, msg := range validation.IsValidPortName(port.TargetPort.StrVal) {
+				allErrs = append(allErrs, field.Invalid(portPath.Child("targetPort"), port.TargetPort.StrVal, msg))
+			}
+		} else if port.TargetPort.IntValue() != 0 {
+			for _, msg := range validation.IsValidPortNum(port.TargetPort.IntValue()) {
+				allErrs = append(allErrs, field.Invalid(portPath.Child("targetPort"), port.TargetPort.IntValue(), msg))
+			}
+		}
+	}
+
+	return append(allErrs, metavalidation.ValidateLabels(svc.Spec.Selector, fldPath.Child("selector"))...)
+}
+
+func validateKeys(keys sets.Set[string], fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, key := range sets.List(keys) {
+		for _, msg := range validation.IsConfigMapKey(key) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(key), key, msg))
+		}
+	}
+
+	return allErrs
+}
+
+func validateRoleBinding(roleRef rbacv1.RoleRef, subjects []rbacv1.Subject, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if roleRef.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("roleRef", "name"), ""))
+	}
+
+	if roleRef.Kind != "Role" && roleRef.Kind != "ClusterRole" {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("roleRef", "kind"), roleRef.Kind, []string{"Role", "ClusterRole"}))
+	}
+
+	for i, subject := range subjects {
+		subjectPath := fldPath.Child("subjects").Index(i)
+		if subject.Name == "" {
+			allErrs = append(allErrs, field.Required(subjectPath.Child("name"), ""))
+		}
+
+		switch subject.Kind {
+		case rbacv1.ServiceAccountKind, rbacv1.UserKind, rbacv1.GroupKind:
+		default:
+			allErrs = append(allErrs, field.NotSupported(subjectPath.Child("kind"), subject.Kind, []string{rbacv1.ServiceAccountKind, rbacv1.UserKind, rbacv1.GroupKind}))
+		}
+	}
+
+	return allErrs
+}
+
+func validateServiceMonitor(sm *monv1.ServiceMonitor, fldPath *field.Path) field.ErrorList {
+	allErrs := metavalidation.ValidateLabelSelector(&sm.Spec.Selector, metavalidation.LabelSelectorValidationOptions{}, fldPath.Child("selector"))
+
+	for i, endpoint := range sm.Spec.Endpoints {
+		endpointPath := fldPath.Child("endpoints").Index(i)
+		switch {
+		case endpoint.Port != "":
+			allErrs = append(allErrs, validateDNSLabel(endpoint.Port, endpointPath.Child("port"))...)
+		case endpoint.TargetPort == nil:
+			allErrs = append(allErrs, field.Required(endpointPath.Child("port"), "port or targetPort is required"))
+		}
+
+		for name, d := range map[string]monv1.Duration{"interval": endpoint.Interval, "scrapeTimeout": endpoint.ScrapeTimeout} {
+			if d == "" {
+				continue
+			}
+			if _, err := model.ParseDuration(string(d)); err != nil {
+				allErrs = append(allErrs, field.Invalid(endpointPath.Child(name), d, err.Error()))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// validateTemplate validates the objects of a template, after replacing the parameters with their default values.
+func validateTemplate(t *templatev1.Template, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	replacements := make([]string, 0, 4*len(t.Parameters))
+	for _, param := range t.Parameters {
+		quoted, _ := json.Marshal(param.Value)
+		replacements = append(replacements,
+			// Non-string parameters replace the whole JSON string holding them.
+			fmt.Sprintf(`"${{%s}}"`, param.Name), param.Value,
+			fmt.Sprintf("${%s}", param.Name), strings.Trim(string(quoted), `"`),
+		)
+	}
+	replacer := strings.NewReplacer(replacements...)
+
+	for i, raw := range t.Objects {
+		objPath := fldPath.Index(i)
+
+		data := raw.Raw
+		if raw.Object != nil {
+			var err error
+			if data, err = json.Marshal(raw.Object); err != nil {
+				allErrs = append(allErrs, field.InternalError(objPath, err))
+				continue
+			}
+		}
+
+		u := &unstructured.Unstructured{}
+		if err := json.Unmarshal([]byte(replacer.Replace(string(data))), &u.Object); err != nil {
+			allErrs = append(allErrs, field.Invalid(objPath, string(data), fmt.Sprintf("invalid object once parameters are replaced: %v", err)))
+			continue
+		}
+
+		allErrs = append(allErrs, validateObject(u, objPath)...)
+	}
+
+	return allErrs
+}
+
+func validateDNSLabel(value string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, msg := range validation.IsDNS1123Label(value) {
+		allErrs = append(allErrs, field.Invalid(fldPath, value, msg))
+	}
+
+	return allErrs
+}
@@ -0,0 +1,183 @@
+package validate_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/query"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/containeropts"
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/openshift"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/validate"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	templatev1 "github.com/openshift/api/template/v1"
+	monv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestObjects(t *testing.T) {
+	// queryDeployment returns the Deployment generated for Thanos query, changed by mutate.
+	queryDeployment := func(mutate func(*appsv1.Deployment)) *appsv1.Deployment {
+		ret := kghelpers.GetObject[*appsv1.Deployment](query.NewQuery(nil, "ns", "v0.32.0").Objects(), "")
+		mutate(ret)
+		return ret
+	}
+
+	scaledObject := (&workload.Autoscaler{
+		MetaConfig: workload.MetaConfig{Name: "test", Namespace: "ns", Labels: map[string]string{"app": "test"}},
+		Autoscaling: workload.Autoscaling{
+			MinReplicas: 1,
+			MaxReplicas: 3,
+			KEDA: &workload.KEDAConfig{
+				Triggers: []workload.PrometheusTrigger{{ServerAddress: "http://prometheus:9090", Query: "up", Threshold: "1"}},
+			},
+		},
+	}).Object()
+
+	externalSecrets, err := containeropts.ExternalSecrets{StoreName: "vault"}.SecretObjects(&corev1.Secret{
+		TypeMeta:   workload.SecretMeta,
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns"},
+		StringData: map[string]string{"token": "secret"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := query.NewQuery(nil, "${NAMESPACE}", "${IMAGE_TAG}")
+	template := openshift.WrapInTemplate(q.Objects(), metav1.ObjectMeta{Name: "query"}, []templatev1.Parameter{
+		{Name: "NAMESPACE", Value: "observatorium"},
+		{Name: "IMAGE_TAG", Value: "v0.32.0"},
+	})
+
+	testCases := map[string]struct {
+		objects  []runtime.Object
+		expected []string
+	}{
+		"valid": {
+			objects: query.NewQuery(nil, "ns", "v0.32.0").Objects(),
+		},
+		"valid template": {
+			objects: []runtime.Object{template},
+		},
+		"invalid label value": {
+			objects:  []runtime.Object{queryDeployment(func(d *appsv1.Deployment) { d.Labels["version"] = "main/v1" })},
+			expected: []string{"observatorium-thanos-query_Deployment: metadata.labels: Invalid value: \"main/v1\""},
+		},
+		"name too long": {
+			objects: []runtime.Object{&corev1.Service{
+				TypeMeta:   workload.ServiceMeta,
+				ObjectMeta: metav1.ObjectMeta{Name: strings.Repeat("a", 64)},
+			}},
+			expected: []string{"metadata.name: Invalid value", "must be no more than 63 characters"},
+		},
+		"port name too long": {
+			objects: []runtime.Object{queryDeployment(func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.Containers[0].Ports[0].Name = "http-long-port-name"
+				d.Spec.Template.Spec.Containers[0].ReadinessProbe.HTTPGet.Port = intstr.FromString("http")
+			})},
+			expected: []string{
+				"spec.template.spec.containers[0].ports[0].name: Invalid value: \"http-long-port-name\": must be no more than 15 characters",
+				"spec.template.spec.containers[0].readinessProbe.httpGet.port: Not found: \"http\"",
+			},
+		},
+		"selector mismatch": {
+			objects: []runtime.Object{queryDeployment(func(d *appsv1.Deployment) {
+				d.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "other"}}
+			})},
+			expected: []string{"`selector` does not match template `labels`"},
+		},
+		"missing volume": {
+			objects: []runtime.Object{queryDeployment(func(d *appsv1.Deployment) {
+				d.Spec.Template.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{{Name: "data", MountPath: "/data"}}
+			})},
+			expected: []string{"spec.template.spec.containers[0].volumeMounts[0].name: Not found: \"data\""},
+		},
+		"missing type meta": {
+			objects:  []runtime.Object{&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test"}}},
+			expected: []string{"apiVersion and kind must be set"},
+		},
+		"custom resources of unknown groups": {
+			objects: append([]runtime.Object{scaledObject}, externalSecrets...),
+		},
+		"invalid custom resource metadata": {
+			objects: []runtime.Object{&unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "keda.sh/v1alpha1",
+				"kind":       "ScaledObject",
+				"metadata":   map[string]interface{}{"name": "Invalid_Name"},
+			}}},
+			expected: []string{"metadata.name: Invalid value: \"Invalid_Name\""},
+		},
+		"unknown kind of a known group": {
+			objects: []runtime.Object{&unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deploymnt",
+				"metadata":   map[string]interface{}{"name": "test"},
+			}}},
+			expected: []string{"no schema for apps/v1, Kind=Deploymnt"},
+		},
+		"missing required field": {
+			objects: []runtime.Object{&unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Service",
+				"metadata":   map[string]interface{}{"name": "test"},
+				"spec":       map[string]interface{}{"ports": []interface{}{map[string]interface{}{"name": "http"}}},
+			}}},
+			expected: []string{"test_Service: spec.ports[0].port: Required value"},
+		},
+		"unsupported enum value": {
+			objects: []runtime.Object{&monv1.ServiceMonitor{
+				TypeMeta:   metav1.TypeMeta{APIVersion: monv1.SchemeGroupVersion.String(), Kind: monv1.ServiceMonitorsKind},
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec: monv1.ServiceMonitorSpec{
+					Endpoints: []monv1.Endpoint{{Port: "http", RelabelConfigs: []monv1.RelabelConfig{{Action: "rename"}}}},
+				},
+			}},
+			expected: []string{"test_ServiceMonitor: spec.endpoints[0].relabelings[0].action: Invalid value: \"rename\": should be one of"},
+		},
+		"custom resource schema": {
+			objects: []runtime.Object{&unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "monitoring.coreos.com/v1",
+				"kind":       "PrometheusRule",
+				"metadata":   map[string]interface{}{"name": "test"},
+				"spec":       map[string]interface{}{"groups": []interface{}{map[string]interface{}{"name": "test"}}},
+			}}},
+			expected: []string{"test_PrometheusRule: spec.groups[0].rules: Required value"},
+		},
+		"unknown field": {
+			objects: []runtime.Object{&unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]interface{}{"name": "test"},
+				"datas":      map[string]interface{}{},
+			}}},
+			expected: []string{"unknown field \"datas\""},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := validate.Objects(tc.objects)
+			if len(tc.expected) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			for _, expected := range tc.expected {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected error to contain %q, got %v", expected, err)
+				}
+			}
+		})
+	}
+}
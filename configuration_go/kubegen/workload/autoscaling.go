@@ -0,0 +1,209 @@
+package workload
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Autoscaling configures the autoscaling of a Deployment. A HorizontalPodAutoscaler is generated from the Metrics,
+// or a KEDA ScaledObject if KEDA is set. The replicas of the Deployment are then left unset, so that they are
+// managed by the autoscaler only.
+type Autoscaling struct {
+	// MinReplicas is required. It does not default to the replicas of the workload,
+	// which may be a template parameter when generating OpenShift templates.
+	MinReplicas int32
+	MaxReplicas int32
+
+	// Metrics are the metrics of the HorizontalPodAutoscaler, such as the ones returned by NewResourceMetric.
+	// Custom and external metrics are set with their own metric source.
+	Metrics  []autoscalingv2.MetricSpec
+	Behavior *autoscalingv2.HorizontalPodAutoscalerBehavior
+
+	// KEDA generates a KEDA ScaledObject instead of a HorizontalPodAutoscaler, Metrics must then be empty.
+	KEDA *KEDAConfig
+}
+
+// KEDAConfig configures a KEDA ScaledObject.
+// See https://keda.sh/docs/latest/reference/scaledobject-spec/ for details.
+type KEDAConfig struct {
+	PollingInterval *int32
+	CooldownPeriod  *int32
+	Triggers        []PrometheusTrigger
+}
+
+// PrometheusTrigger scales a workload on the result of a Prometheus query.
+// See https://keda.sh/docs/latest/scalers/prometheus/ for details.
+type PrometheusTrigger struct {
+	ServerAddress       string
+	Query               string
+	Threshold           string
+	ActivationThreshold string
+	// AuthenticationRef is the name of the TriggerAuthentication used to query the server, if any.
+	AuthenticationRef string
+}
+
+// NewResourceMetric returns a metric targeting the given average utilization of a resource, in percent of the requests.
+func NewResourceMetric(name corev1.ResourceName, averageUtilization int32) autoscalingv2.MetricSpec {
+	return autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricSource{
+			Name: name,
+			Target: autoscalingv2.MetricTarget{
+				Type:               autoscalingv2.UtilizationMetricType,
+				AverageUtilization: &averageUtilization,
+			},
+		},
+	}
+}
+
+// Autoscaler represents the autoscaler of a Deployment.
+type Autoscaler struct {
+	MetaConfig
+	Autoscaling
+}
+
+// Object returns a HorizontalPodAutoscaler, or a KEDA ScaledObject if KEDA is set.
+// It panics if the configuration is invalid.
+func (a *Autoscaler) Object() runtime.Object {
+	ret, err := a.ObjectE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// ObjectE is like Object but returns an error joining all the problems of the configuration instead of panicking.
+func (a *Autoscaler) ObjectE() (runtime.Object, error) {
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+
+	minReplicas := a.MinReplicas
+
+	metaCfg := a.MetaConfig.MakeMeta()
+	delete(metaCfg.Labels, VersionLabel)
+
+	if a.KEDA == nil {
+		return &autoscalingv2.HorizontalPodAutoscaler{
+			TypeMeta:   HorizontalPodAutoscalerMeta,
+			ObjectMeta: metaCfg,
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+					APIVersion: DeploymentMeta.APIVersion,
+					Kind:       DeploymentMeta.Kind,
+					Name:       a.Name,
+				},
+				MinReplicas: &minReplicas,
+				MaxReplicas: a.MaxReplicas,
+				Metrics:     a.Metrics,
+				Behavior:    a.Behavior,
+			},
+		}, nil
+	}
+
+	spec := map[string]interface{}{
+		"scaleTargetRef": map[string]interface{}{
+			"apiVersion": DeploymentMeta.APIVersion,
+			"kind":       DeploymentMeta.Kind,
+			"name":       a.Name,
+		},
+		"minReplicaCount": int64(minReplicas),
+		"maxReplicaCount": int64(a.MaxReplicas),
+	}
+
+	if a.KEDA.PollingInterval != nil {
+		spec["pollingInterval"] = int64(*a.KEDA.PollingInterval)
+	}
+
+	if a.KEDA.CooldownPeriod != nil {
+		spec["cooldownPeriod"] = int64(*a.KEDA.CooldownPeriod)
+	}
+
+	if a.Behavior != nil {
+		behavior, err := runtime.DefaultUnstructuredConverter.ToUnstructured(a.Behavior)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert the behavior of the autoscaler %s: %w", a.Name, err)
+		}
+		spec["advanced"] = map[string]interface{}{
+			"horizontalPodAutoscalerConfig": map[string]interface{}{
+				"behavior": behavior,
+			},
+		}
+	}
+
+	triggers := make([]interface{}, 0, len(a.KEDA.Triggers))
+	for _, t := range a.KEDA.Triggers {
+		metadata := map[string]interface{}{
+			"serverAddress": t.ServerAddress,
+			"query":         t.Query,
+			"threshold":     t.Threshold,
+		}
+		if t.ActivationThreshold != "" {
+			metadata["activationThreshold"] = t.ActivationThreshold
+		}
+
+		trigger := map[string]interface{}{
+			"type":     "prometheus",
+			"metadata": metadata,
+		}
+		if t.AuthenticationRef != "" {
+			trigger["authenticationRef"] = map[string]interface{}{"name": t.AuthenticationRef}
+		}
+
+		triggers = append(triggers, trigger)
+	}
+	spec["triggers"] = triggers
+
+	ret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{},
+		"spec":     spec,
+	}}
+	ret.SetAPIVersion(ScaledObjectMeta.APIVersion)
+	ret.SetKind(ScaledObjectMeta.Kind)
+	ret.SetName(metaCfg.Name)
+	ret.SetNamespace(metaCfg.Namespace)
+	ret.SetLabels(maps.Clone(metaCfg.Labels))
+
+	return ret, nil
+}
+
+func (a *Autoscaler) validate() error {
+	var errs []error
+	if a.MinReplicas < 1 {
+		errs = append(errs, fmt.Errorf("min replicas of the autoscaler %s must be at least 1, got %d", a.Name, a.MinReplicas))
+	} else if a.MaxReplicas < a.MinReplicas {
+		errs = append(errs, fmt.Errorf("max replicas of the autoscaler %s must be at least %d, got %d", a.Name, a.MinReplicas, a.MaxReplicas))
+	}
+
+	if a.KEDA == nil {
+		if len(a.Metrics) == 0 {
+			errs = append(errs, fmt.Errorf("metrics are not specified for the autoscaler %s", a.Name))
+		}
+
+		return errors.Join(errs...)
+	}
+
+	if len(a.Metrics) > 0 {
+		errs = append(errs, fmt.Errorf("metrics and KEDA cannot be both specified for the autoscaler %s", a.Name))
+	}
+
+	if len(a.KEDA.Triggers) == 0 {
+		errs = append(errs, fmt.Errorf("KEDA triggers are not specified for the autoscaler %s", a.Name))
+	}
+
+	for _, t := range a.KEDA.Triggers {
+		if t.ServerAddress == "" || t.Query == "" || t.Threshold == "" {
+			errs = append(errs, fmt.Errorf("server address, query and threshold must be specified for the triggers of the autoscaler %s", a.Name))
+			break
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,156 @@
+package workload_test
+
+import (
+	"strings"
+	"testing"
+
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/openshift"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestAutoscaling(t *testing.T) {
+	newWorkload := func(autoscaling *workload.Autoscaling) workload.DeploymentWorkload {
+		return workload.DeploymentWorkload{
+			Replicas:    2,
+			Autoscaling: autoscaling,
+			PodConfig: workload.PodConfig{
+				Name:         "test",
+				Namespace:    "ns",
+				Image:        "test",
+				ImageTag:     "v1",
+				CommonLabels: map[string]string{workload.NameLabel: "test"},
+			},
+		}
+	}
+
+	t.Run("no autoscaling", func(t *testing.T) {
+		w := newWorkload(nil)
+		objects := w.Objects(w.ToContainer())
+		dep := kghelpers.GetObject[*appsv1.Deployment](objects, "test")
+		if dep.Spec.Replicas == nil || *dep.Spec.Replicas != 2 {
+			t.Errorf("expected 2 replicas, got %v", dep.Spec.Replicas)
+		}
+	})
+
+	t.Run("hpa", func(t *testing.T) {
+		w := newWorkload(&workload.Autoscaling{
+			MinReplicas: 2,
+			MaxReplicas: 5,
+			Metrics:     []autoscalingv2.MetricSpec{workload.NewResourceMetric(corev1.ResourceCPU, 80)},
+		})
+		objects := w.Objects(w.ToContainer())
+
+		dep := kghelpers.GetObject[*appsv1.Deployment](objects, "test")
+		if dep.Spec.Replicas != nil {
+			t.Errorf("expected replicas to be unset, got %d", *dep.Spec.Replicas)
+		}
+
+		hpa := kghelpers.GetObject[*autoscalingv2.HorizontalPodAutoscaler](objects, "test")
+		if *hpa.Spec.MinReplicas != 2 || hpa.Spec.MaxReplicas != 5 || hpa.Spec.ScaleTargetRef.Name != "test" {
+			t.Errorf("unexpected autoscaler spec: %+v", hpa.Spec)
+		}
+	})
+
+	t.Run("keda", func(t *testing.T) {
+		w := newWorkload(&workload.Autoscaling{
+			MinReplicas: 1,
+			MaxReplicas: 10,
+			KEDA: &workload.KEDAConfig{
+				Triggers: []workload.PrometheusTrigger{{
+					ServerAddress: "http://prometheus:9090",
+					Query:         `sum(rate(http_requests_total{job="test"}[2m]))`,
+					Threshold:     "100",
+				}},
+			},
+		})
+		objects := w.Objects(w.ToContainer())
+
+		so := kghelpers.GetObject[*unstructured.Unstructured](objects, "test")
+		if so.GetKind() != workload.ScaledObjectMeta.Kind {
+			t.Fatalf("expected a ScaledObject, got %s", so.GetKind())
+		}
+
+		maxReplicas, _, _ := unstructured.NestedInt64(so.Object, "spec", "maxReplicaCount")
+		triggers, _, _ := unstructured.NestedSlice(so.Object, "spec", "triggers")
+		if maxReplicas != 10 || len(triggers) != 1 {
+			t.Fatalf("unexpected scaled object: %v", so.Object)
+		}
+
+		triggerType, _, _ := unstructured.NestedString(triggers[0].(map[string]interface{}), "type")
+		if triggerType != "prometheus" {
+			t.Errorf("expected a prometheus trigger, got %s", triggerType)
+		}
+	})
+
+	t.Run("parameterized replicas", func(t *testing.T) {
+		w := newWorkload(&workload.Autoscaling{
+			MinReplicas: 2,
+			MaxReplicas: 5,
+			Metrics:     []autoscalingv2.MetricSpec{workload.NewResourceMetric(corev1.ResourceCPU, 80)},
+		})
+		params := openshift.NewParameters()
+		if err := params.Parameterize(&w, "TEST"); err != nil {
+			t.Fatal(err)
+		}
+
+		objects := w.Objects(w.ToContainer())
+		hpa := kghelpers.GetObject[*autoscalingv2.HorizontalPodAutoscaler](objects, "test")
+		if *hpa.Spec.MinReplicas != 2 || hpa.Spec.MaxReplicas != 5 {
+			t.Errorf("expected the autoscaler not to inherit the replicas parameter, got %+v", hpa.Spec)
+		}
+
+		if _, err := params.WrapInTemplate(objects, metav1.ObjectMeta{Name: "test"}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	invalidCases := map[string]struct {
+		autoscaling *workload.Autoscaling
+		expected    string
+	}{
+		"no min replicas": {
+			autoscaling: &workload.Autoscaling{
+				MaxReplicas: 5,
+				Metrics:     []autoscalingv2.MetricSpec{workload.NewResourceMetric(corev1.ResourceCPU, 80)},
+			},
+			expected: "min replicas of the autoscaler test must be at least 1, got 0",
+		},
+		"max replicas below min replicas": {
+			autoscaling: &workload.Autoscaling{
+				MinReplicas: 3,
+				MaxReplicas: 2,
+				Metrics:     []autoscalingv2.MetricSpec{workload.NewResourceMetric(corev1.ResourceCPU, 80)},
+			},
+			expected: "max replicas of the autoscaler test must be at least 3, got 2",
+		},
+		"no metrics": {
+			autoscaling: &workload.Autoscaling{MinReplicas: 1, MaxReplicas: 1},
+			expected:    "metrics are not specified for the autoscaler test",
+		},
+		"metrics and keda": {
+			autoscaling: &workload.Autoscaling{
+				MinReplicas: 1,
+				MaxReplicas: 2,
+				Metrics:     []autoscalingv2.MetricSpec{workload.NewResourceMetric(corev1.ResourceCPU, 80)},
+				KEDA:        &workload.KEDAConfig{Triggers: []workload.PrometheusTrigger{{Query: "up"}}},
+			},
+			expected: "metrics and KEDA cannot be both specified for the autoscaler test\n" +
+				"server address, query and threshold must be specified for the triggers of the autoscaler test",
+		},
+	}
+
+	for name, tc := range invalidCases {
+		t.Run(name, func(t *testing.T) {
+			w := newWorkload(tc.autoscaling)
+			if _, err := w.ObjectsE(w.ToContainer()); err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("expected error containing %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
@@ -6,6 +6,9 @@ import (
 	mon "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring"
 	monv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -48,6 +51,26 @@ var ServiceMonitorMeta = metav1.TypeMeta{
 	APIVersion: fmt.Sprintf("%s/%s", mon.GroupName, monv1.Version),
 }
 
+var PodDisruptionBudgetMeta = metav1.TypeMeta{
+	Kind:       "PodDisruptionBudget",
+	APIVersion: policyv1.SchemeGroupVersion.String(),
+}
+
+var HorizontalPodAutoscalerMeta = metav1.TypeMeta{
+	Kind:       "HorizontalPodAutoscaler",
+	APIVersion: autoscalingv2.SchemeGroupVersion.String(),
+}
+
+var ScaledObjectMeta = metav1.TypeMeta{
+	Kind:       "ScaledObject",
+	APIVersion: "keda.sh/v1alpha1",
+}
+
+var NetworkPolicyMeta = metav1.TypeMeta{
+	Kind:       "NetworkPolicy",
+	APIVersion: networkingv1.SchemeGroupVersion.String(),
+}
+
 var OpenShiftTemplateMeta = metav1.TypeMeta{
 	Kind:       "Template",
 	APIVersion: "template.openshift.io/v1",
@@ -63,6 +86,26 @@ var RoleBindingMeta = metav1.TypeMeta{
 	APIVersion: rbacv1.SchemeGroupVersion.String(),
 }
 
+var ClusterRoleMeta = metav1.TypeMeta{
+	Kind:       "ClusterRole",
+	APIVersion: rbacv1.SchemeGroupVersion.String(),
+}
+
+var ClusterRoleBindingMeta = metav1.TypeMeta{
+	Kind:       "ClusterRoleBinding",
+	APIVersion: rbacv1.SchemeGroupVersion.String(),
+}
+
+var ExternalSecretMeta = metav1.TypeMeta{
+	Kind:       "ExternalSecret",
+	APIVersion: "external-secrets.io/v1beta1",
+}
+
+var SealedSecretMeta = metav1.TypeMeta{
+	Kind:       "SealedSecret",
+	APIVersion: "bitnami.com/v1alpha1",
+}
+
 // K8s recommended label constants.
 
 const ComponentLabel string = "app.kubernetes.io/component"
@@ -0,0 +1,193 @@
+package workload
+
+import (
+	"fmt"
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+// NetworkPolicyConfig restricts the traffic of the pods of a workload to the declared peers.
+// Ingress is only allowed from the Consumers, on the ports exposed by the Service of the workload.
+// Egress is only allowed to the Dependencies and to DNS servers.
+type NetworkPolicyConfig struct {
+	// Consumers are the peers allowed to reach the workload, e.g. other components or Prometheus.
+	// No ingress is allowed if empty.
+	Consumers []NetworkPeer
+	// Dependencies are the peers the workload is allowed to reach, e.g. upstream components or the object storage.
+	Dependencies []NetworkDependency
+}
+
+// NetworkPeer selects the pods or the IP block of a peer.
+type NetworkPeer struct {
+	// PodLabels selects the pods of the peer. All the pods of the namespace are selected if empty.
+	PodLabels map[string]string
+	// Namespace is the namespace of the pods. The namespace of the workload is used if empty.
+	Namespace string
+	// IPBlock selects peers outside of the cluster, such as an object storage. Pod labels and namespace are then ignored.
+	IPBlock *networkingv1.IPBlock
+}
+
+// NetworkDependency is a peer reached by a workload on the given ports.
+// Ports are the container ports of the peer, by number or name. All the ports are allowed if empty.
+type NetworkDependency struct {
+	NetworkPeer
+	Ports []intstr.IntOrString
+}
+
+// NetworkPeer returns the peer selecting the pods of the workload, to be used as consumer or dependency of other workloads.
+func (d PodConfig) NetworkPeer() NetworkPeer {
+	labels := maps.Clone(d.CommonLabels)
+	delete(labels, VersionLabel)
+
+	return NetworkPeer{
+		PodLabels: labels,
+		Namespace: d.Namespace,
+	}
+}
+
+// NetworkDependency returns the dependency on the pods of the workload, on the given named container ports.
+func (d PodConfig) NetworkDependency(portNames ...string) NetworkDependency {
+	ports := make([]intstr.IntOrString, 0, len(portNames))
+	for _, name := range portNames {
+		ports = append(ports, intstr.FromString(name))
+	}
+
+	return NetworkDependency{
+		NetworkPeer: d.NetworkPeer(),
+		Ports:       ports,
+	}
+}
+
+// NetworkPolicyObject returns the NetworkPolicy of the workload for the given pod.
+func (d PodConfig) NetworkPolicyObject(pod *Pod) (runtime.Object, error) {
+	np := &NetworkPolicy{
+		MetaConfig:          *d.ObjectMeta(),
+		NetworkPolicyConfig: *d.NetworkPolicy,
+		ServicePorts:        pod,
+	}
+
+	return np.ObjectE()
+}
+
+// NetworkPolicy represents a Kubernetes NetworkPolicy.
+type NetworkPolicy struct {
+	MetaConfig
+	NetworkPolicyConfig
+	ServicePorts ServiceProvider
+}
+
+// Object returns a Kubernetes NetworkPolicy selecting the pods of the workload.
+// It panics if the workload has consumers but exposes no port.
+func (n *NetworkPolicy) Object() runtime.Object {
+	ret, err := n.ObjectE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// ObjectE is like Object but returns an error if the workload has consumers but exposes no port.
+func (n *NetworkPolicy) ObjectE() (runtime.Object, error) {
+	selector := maps.Clone(n.MetaConfig.Labels)
+	delete(selector, VersionLabel)
+	metaCfg := n.MetaConfig.MakeMeta()
+	delete(metaCfg.Labels, VersionLabel)
+
+	ret := &networkingv1.NetworkPolicy{
+		TypeMeta:   NetworkPolicyMeta,
+		ObjectMeta: metaCfg,
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: selector,
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			Ingress:     []networkingv1.NetworkPolicyIngressRule{},
+			Egress:      []networkingv1.NetworkPolicyEgressRule{dnsEgressRule()},
+		},
+	}
+
+	if len(n.Consumers) > 0 {
+		var ports []networkingv1.NetworkPolicyPort
+		if n.ServicePorts != nil {
+			for _, sp := range n.ServicePorts.GetServicePorts() {
+				port := sp.TargetPort
+				if port.Type == intstr.Int && port.IntVal == 0 {
+					port = intstr.FromInt32(sp.Port)
+				}
+				ports = append(ports, networkingv1.NetworkPolicyPort{Protocol: protocolOrTCP(sp.Protocol), Port: &port})
+			}
+		}
+
+		if len(ports) == 0 {
+			return nil, fmt.Errorf("workload %s has consumers but exposes no port", n.Name)
+		}
+
+		rule := networkingv1.NetworkPolicyIngressRule{Ports: ports}
+		for _, peer := range n.Consumers {
+			rule.From = append(rule.From, n.policyPeer(peer))
+		}
+		ret.Spec.Ingress = append(ret.Spec.Ingress, rule)
+	}
+
+	for _, dep := range n.Dependencies {
+		rule := networkingv1.NetworkPolicyEgressRule{
+			To: []networkingv1.NetworkPolicyPeer{n.policyPeer(dep.NetworkPeer)},
+		}
+		for i := range dep.Ports {
+			rule.Ports = append(rule.Ports, networkingv1.NetworkPolicyPort{Protocol: protocolOrTCP(""), Port: &dep.Ports[i]})
+		}
+		ret.Spec.Egress = append(ret.Spec.Egress, rule)
+	}
+
+	return ret, nil
+}
+
+func (n *NetworkPolicy) policyPeer(peer NetworkPeer) networkingv1.NetworkPolicyPeer {
+	if peer.IPBlock != nil {
+		return networkingv1.NetworkPolicyPeer{IPBlock: peer.IPBlock}
+	}
+
+	ret := networkingv1.NetworkPolicyPeer{
+		PodSelector: &metav1.LabelSelector{MatchLabels: peer.PodLabels},
+	}
+
+	if peer.Namespace != "" && peer.Namespace != n.Namespace {
+		ret.NamespaceSelector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{namespaceNameLabel: peer.Namespace},
+		}
+	}
+
+	return ret
+}
+
+// dnsEgressRule allows resolving names with the DNS servers of any namespace.
+func dnsEgressRule() networkingv1.NetworkPolicyEgressRule {
+	port := intstr.FromInt32(53)
+	udp, tcp := corev1.ProtocolUDP, corev1.ProtocolTCP
+
+	return networkingv1.NetworkPolicyEgressRule{
+		To: []networkingv1.NetworkPolicyPeer{
+			{NamespaceSelector: &metav1.LabelSelector{}},
+		},
+		Ports: []networkingv1.NetworkPolicyPort{
+			{Protocol: &udp, Port: &port},
+			{Protocol: &tcp, Port: &port},
+		},
+	}
+}
+
+func protocolOrTCP(protocol corev1.Protocol) *corev1.Protocol {
+	if protocol == "" {
+		protocol = corev1.ProtocolTCP
+	}
+
+	return &protocol
+}
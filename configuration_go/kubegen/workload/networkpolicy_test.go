@@ -0,0 +1,84 @@
+package workload_test
+
+import (
+	"strings"
+	"testing"
+
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestNetworkPolicy(t *testing.T) {
+	newPodConfig := func(name string) workload.PodConfig {
+		return workload.PodConfig{
+			Name:         name,
+			Namespace:    "ns",
+			Image:        name,
+			ImageTag:     "v1",
+			CommonLabels: map[string]string{workload.NameLabel: name, workload.VersionLabel: "v1"},
+		}
+	}
+
+	upstream := newPodConfig("upstream")
+	w := workload.DeploymentWorkload{
+		Replicas:  1,
+		PodConfig: newPodConfig("test"),
+	}
+	w.NetworkPolicy = &workload.NetworkPolicyConfig{
+		Consumers: []workload.NetworkPeer{
+			{Namespace: "monitoring", PodLabels: map[string]string{"app": "prometheus"}},
+		},
+		Dependencies: []workload.NetworkDependency{
+			upstream.NetworkDependency("grpc"),
+		},
+	}
+
+	container := w.ToContainer()
+	container.ServicePorts = []corev1.ServicePort{
+		{Name: "http", Port: 8080, TargetPort: intstr.FromString("http")},
+		{Name: "metrics", Port: 9090},
+	}
+
+	np := kghelpers.GetObject[*networkingv1.NetworkPolicy](w.Objects(container), "test")
+	if _, ok := np.Spec.PodSelector.MatchLabels[workload.VersionLabel]; ok {
+		t.Errorf("expected pod selector without version label, got %v", np.Spec.PodSelector.MatchLabels)
+	}
+
+	if len(np.Spec.Ingress) != 1 {
+		t.Fatalf("expected 1 ingress rule, got %d", len(np.Spec.Ingress))
+	}
+
+	ingress := np.Spec.Ingress[0]
+	if len(ingress.Ports) != 2 || ingress.Ports[0].Port.String() != "http" || ingress.Ports[1].Port.String() != "9090" {
+		t.Errorf("unexpected ingress ports: %+v", ingress.Ports)
+	}
+
+	if len(ingress.From) != 1 || ingress.From[0].NamespaceSelector == nil ||
+		ingress.From[0].NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"] != "monitoring" {
+		t.Errorf("unexpected ingress peers: %+v", ingress.From)
+	}
+
+	if len(np.Spec.Egress) != 2 {
+		t.Fatalf("expected DNS and dependency egress rules, got %d", len(np.Spec.Egress))
+	}
+
+	if port := np.Spec.Egress[0].Ports[0].Port; port.IntValue() != 53 {
+		t.Errorf("expected DNS egress rule first, got port %s", port.String())
+	}
+
+	dep := np.Spec.Egress[1]
+	if dep.To[0].NamespaceSelector != nil || dep.To[0].PodSelector.MatchLabels[workload.NameLabel] != "upstream" {
+		t.Errorf("unexpected dependency peer: %+v", dep.To)
+	}
+
+	if len(dep.Ports) != 1 || dep.Ports[0].Port.String() != "grpc" {
+		t.Errorf("unexpected dependency ports: %+v", dep.Ports)
+	}
+
+	if _, err := w.ObjectsE(w.ToContainer()); err == nil || !strings.Contains(err.Error(), "workload test has consumers but exposes no port") {
+		t.Errorf("expected an error for consumers without port, got %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package workload_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/api"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/compactor"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/query"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/queryfrontend"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/receive"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/ruler"
+	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/thanos/store"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestPodDisruptionBudget(t *testing.T) {
+	one := intstr.FromInt(1)
+
+	testCases := map[string]struct {
+		config      *workload.PodDisruptionBudgetConfig
+		expectedErr string
+	}{
+		"max unavailable": {
+			config: workload.NewMaxUnavailableBudget(1),
+		},
+		"min available": {
+			config: workload.NewMinAvailableBudget(1),
+		},
+		"none set": {
+			config:      &workload.PodDisruptionBudgetConfig{},
+			expectedErr: "exactly one of minAvailable and maxUnavailable must be set for the pod disruption budget test",
+		},
+		"both set": {
+			config:      &workload.PodDisruptionBudgetConfig{MinAvailable: &one, MaxUnavailable: &one},
+			expectedErr: "exactly one of minAvailable and maxUnavailable must be set for the pod disruption budget test",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := workload.DeploymentWorkload{
+				Replicas: 2,
+				PodConfig: workload.PodConfig{
+					Name:         "test",
+					Namespace:    "ns",
+					Image:        "test",
+					ImageTag:     "v1",
+					CommonLabels: map[string]string{workload.NameLabel: "test"},
+				},
+			}
+			w.PodDisruptionBudget = tc.config
+
+			obj, err := w.PodDisruptionBudgetObject()
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Errorf("expected error containing %q, got %v", tc.expectedErr, err)
+				}
+				if _, err := w.ObjectsE(w.ToContainer()); err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Errorf("expected the workload error to contain %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			pdb := obj.(*policyv1.PodDisruptionBudget)
+
+			if pdb.Spec.MinAvailable != tc.config.MinAvailable || pdb.Spec.MaxUnavailable != tc.config.MaxUnavailable {
+				t.Errorf("expected budget %v/%v, got %v/%v", tc.config.MinAvailable, tc.config.MaxUnavailable, pdb.Spec.MinAvailable, pdb.Spec.MaxUnavailable)
+			}
+
+			// The version label changes on upgrades, the budget must keep selecting the pods of both versions.
+			if _, ok := pdb.Spec.Selector.MatchLabels[workload.VersionLabel]; ok {
+				t.Errorf("expected selector without the version label, got %v", pdb.Spec.Selector.MatchLabels)
+			}
+			if _, ok := pdb.Labels[workload.VersionLabel]; ok {
+				t.Errorf("expected labels without the version label, got %v", pdb.Labels)
+			}
+			if pdb.Spec.Selector.MatchLabels[workload.NameLabel] != "test" {
+				t.Errorf("expected selector on the name label, got %v", pdb.Spec.Selector.MatchLabels)
+			}
+		})
+	}
+}
+
+func TestPodDisruptionBudgetDefaults(t *testing.T) {
+	type component interface {
+		ObjectsE() ([]runtime.Object, error)
+	}
+
+	testCases := map[string]struct {
+		component      component
+		name           string
+		maxUnavailable int
+	}{
+		"observatorium api": {
+			component:      api.NewObservatoriumAPI(nil, "ns", "main"),
+			name:           "observatorium-api",
+			maxUnavailable: 1,
+		},
+		"query": {
+			component:      query.NewQuery(nil, "ns", "v0.34.1"),
+			name:           "observatorium-thanos-query",
+			maxUnavailable: 1,
+		},
+		"query frontend": {
+			component:      queryfrontend.NewQueryFrontend(nil, "ns", "v0.34.1"),
+			name:           "observatorium-thanos-query-frontend",
+			maxUnavailable: 1,
+		},
+		"receive ingestor": {
+			component:      receive.NewIngestor(receive.NewDefaultIngestorOptions(), "ns", "v0.34.1"),
+			name:           "observatorium-thanos-receive-ingestor",
+			maxUnavailable: 1,
+		},
+		"ruler": {
+			component:      ruler.NewRuler(ruler.NewDefaultOptions(), "ns", "v0.34.1"),
+			name:           "observatorium-thanos-ruler",
+			maxUnavailable: 1,
+		},
+		"store": {
+			component:      store.NewStore(store.NewDefaultOptions(), "ns", "v0.34.1"),
+			name:           "observatorium-thanos-store",
+			maxUnavailable: 1,
+		},
+		"compactor": {
+			component: compactor.NewCompactor(compactor.NewDefaultOptions(), "ns", "v0.34.1"),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			objs, err := tc.component.ObjectsE()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			pdbs := []*policyv1.PodDisruptionBudget{}
+			for _, obj := range objs {
+				if pdb, ok := obj.(*policyv1.PodDisruptionBudget); ok {
+					pdbs = append(pdbs, pdb)
+				}
+			}
+
+			if tc.maxUnavailable == 0 {
+				if len(pdbs) != 0 {
+					t.Fatalf("expected no pod disruption budget, got %d", len(pdbs))
+				}
+				return
+			}
+
+			if len(pdbs) != 1 || pdbs[0].Name != tc.name {
+				t.Fatalf("expected a single pod disruption budget named %s, got %v", tc.name, pdbs)
+			}
+
+			if budget := pdbs[0].Spec.MaxUnavailable; budget == nil || budget.IntValue() != tc.maxUnavailable {
+				t.Errorf("expected %d unavailable pod, got %v", tc.maxUnavailable, budget)
+			}
+		})
+	}
+}
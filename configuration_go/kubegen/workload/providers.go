@@ -15,9 +15,12 @@ import (
 	monv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // ObjectProvider is the interface that all final objects (deployment, statefulset, service etc.) must implement.
@@ -67,6 +70,7 @@ type ContainerProvider interface {
 	ServiceMonitorProvider
 	PersistentVolumeClaimsProvider
 	ConfigMapsAndSecretsProvider
+	RBACProvider
 }
 
 // Container represents a container in a pod.
@@ -93,6 +97,10 @@ type Container struct {
 	MonitorPorts []monv1.Endpoint
 	ConfigMaps   map[string]map[string]string
 	Secrets      map[string]map[string][]byte
+	// Rules and ClusterRules are the permissions required on the Kubernetes API, in the namespace of the workload
+	// and cluster-wide respectively.
+	Rules        []rbacv1.PolicyRule
+	ClusterRules []rbacv1.PolicyRule
 }
 
 // GetContainer returns a Kubernetes Container.
@@ -151,6 +159,16 @@ func (c *Container) GetSecrets() map[string]map[string][]byte {
 	return c.Secrets
 }
 
+// GetRules returns the permissions that the container requires in the namespace of the workload.
+func (c *Container) GetRules() []rbacv1.PolicyRule {
+	return c.Rules
+}
+
+// GetClusterRules returns the permissions that the container requires cluster-wide.
+func (c *Container) GetClusterRules() []rbacv1.PolicyRule {
+	return c.ClusterRules
+}
+
 // PersistentVolumeClaim represents a volume claim.
 type PersistentVolumeClaim struct {
 	Name  string
@@ -196,6 +214,7 @@ type PodProvider interface {
 	ServiceMonitorProvider
 	PersistentVolumeClaimsProvider
 	ConfigMapsAndSecretsProvider
+	RBACProvider
 }
 
 // Pod represents a pod.
@@ -203,8 +222,11 @@ type PodProvider interface {
 type Pod struct {
 	TerminationGracePeriodSeconds *int64
 	Affinity                      *corev1.Affinity
+	TopologySpreadConstraints     []corev1.TopologySpreadConstraint
 	SecurityContext               *corev1.PodSecurityContext
 	ServiceAccountName            string
+	AutomountServiceAccountToken  *bool
+	ImagePullSecrets              []corev1.LocalObjectReference
 
 	ContainerProviders      []ContainerProvider
 	InitContainersProviders []ContainerProvider
@@ -229,9 +251,12 @@ func (p *Pod) MakePodSpec() corev1.PodSpec {
 	return corev1.PodSpec{
 		TerminationGracePeriodSeconds: p.TerminationGracePeriodSeconds,
 		Affinity:                      p.Affinity,
+		TopologySpreadConstraints:     p.TopologySpreadConstraints,
 		Containers:                    containers,
 		InitContainers:                initContainers,
 		ServiceAccountName:            p.ServiceAccountName,
+		AutomountServiceAccountToken:  p.AutomountServiceAccountToken,
+		ImagePullSecrets:              p.ImagePullSecrets,
 		SecurityContext:               p.SecurityContext,
 		NodeSelector: map[string]string{
 			OsLabel: LinuxOs,
@@ -303,6 +328,28 @@ func (p *Pod) GetSecrets() map[string]map[string][]byte {
 	return ret
 }
 
+// GetRules returns the permissions that the containers of the pod require in the namespace of the workload.
+func (p *Pod) GetRules() []rbacv1.PolicyRule {
+	ret := []rbacv1.PolicyRule{}
+
+	for _, cp := range append(append([]ContainerProvider{}, p.ContainerProviders...), p.InitContainersProviders...) {
+		ret = append(ret, cp.GetRules()...)
+	}
+
+	return ret
+}
+
+// GetClusterRules returns the permissions that the containers of the pod require cluster-wide.
+func (p *Pod) GetClusterRules() []rbacv1.PolicyRule {
+	ret := []rbacv1.PolicyRule{}
+
+	for _, cp := range append(append([]ContainerProvider{}, p.ContainerProviders...), p.InitContainersProviders...) {
+		ret = append(ret, cp.GetClusterRules()...)
+	}
+
+	return ret
+}
+
 // Deployment represents a Kubernetes Deployment.
 type Deployment struct {
 	Replicas   int32
@@ -424,6 +471,66 @@ func (s *Service) Object() runtime.Object {
 	}
 }
 
+// PodDisruptionBudgetConfig configures the disruptions allowed for the pods of a workload.
+// Only one of MinAvailable and MaxUnavailable can be set.
+type PodDisruptionBudgetConfig struct {
+	MinAvailable   *intstr.IntOrString
+	MaxUnavailable *intstr.IntOrString
+}
+
+// NewMaxUnavailableBudget returns a PodDisruptionBudgetConfig allowing the given number of unavailable pods.
+func NewMaxUnavailableBudget(maxUnavailable int) *PodDisruptionBudgetConfig {
+	value := intstr.FromInt(maxUnavailable)
+	return &PodDisruptionBudgetConfig{MaxUnavailable: &value}
+}
+
+// NewMinAvailableBudget returns a PodDisruptionBudgetConfig requiring the given number of available pods.
+func NewMinAvailableBudget(minAvailable int) *PodDisruptionBudgetConfig {
+	value := intstr.FromInt(minAvailable)
+	return &PodDisruptionBudgetConfig{MinAvailable: &value}
+}
+
+// PodDisruptionBudget represents a Kubernetes PodDisruptionBudget.
+type PodDisruptionBudget struct {
+	MetaConfig
+	PodDisruptionBudgetConfig
+}
+
+// Object returns a Kubernetes PodDisruptionBudget selecting the pods of the workload.
+// It panics if both or none of MinAvailable and MaxUnavailable are set.
+func (p *PodDisruptionBudget) Object() runtime.Object {
+	ret, err := p.ObjectE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// ObjectE is like Object but returns an error if both or none of MinAvailable and MaxUnavailable are set.
+func (p *PodDisruptionBudget) ObjectE() (runtime.Object, error) {
+	if (p.MinAvailable == nil) == (p.MaxUnavailable == nil) {
+		return nil, fmt.Errorf("exactly one of minAvailable and maxUnavailable must be set for the pod disruption budget %s", p.Name)
+	}
+
+	selector := maps.Clone(p.MetaConfig.Labels)
+	delete(selector, VersionLabel)
+	metaCfg := p.MetaConfig.MakeMeta()
+	delete(metaCfg.Labels, VersionLabel)
+
+	return &policyv1.PodDisruptionBudget{
+		TypeMeta:   PodDisruptionBudgetMeta,
+		ObjectMeta: metaCfg,
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable:   p.MinAvailable,
+			MaxUnavailable: p.MaxUnavailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: selector,
+			},
+		},
+	}, nil
+}
+
 // ServiceMonitorProvider is the interface to be implemented by pods that require a service monitor.
 type ServiceMonitorProvider interface {
 	GetServiceMonitorEndpoints() []monv1.Endpoint
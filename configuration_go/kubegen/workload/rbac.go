@@ -0,0 +1,146 @@
+package workload
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// RBACProvider is the interface implemented by containers and pods that require permissions on the Kubernetes API.
+type RBACProvider interface {
+	// GetRules returns the permissions required in the namespace of the workload.
+	GetRules() []rbacv1.PolicyRule
+	// GetClusterRules returns the permissions required cluster-wide.
+	GetClusterRules() []rbacv1.PolicyRule
+}
+
+// NewPolicyRule returns a rule granting the verbs on the resources of the given API group.
+// The core API group is the empty string.
+func NewPolicyRule(apiGroup string, resources []string, verbs ...string) rbacv1.PolicyRule {
+	return rbacv1.PolicyRule{
+		APIGroups: []string{apiGroup},
+		Resources: resources,
+		Verbs:     verbs,
+	}
+}
+
+// RBAC represents the Role, ClusterRole and their bindings granting the permissions required by a pod to its ServiceAccount.
+type RBAC struct {
+	MetaConfig
+	ServiceAccountName string
+	Rules              RBACProvider
+}
+
+// Objects returns a Role and a RoleBinding if namespaced permissions are required, and a ClusterRole and a
+// ClusterRoleBinding if cluster-wide permissions are required. As cluster-scoped objects are shared by all
+// the namespaces, the latter are prefixed with the namespace of the workload.
+// It panics if the ServiceAccount name is empty.
+func (r *RBAC) Objects() []runtime.Object {
+	ret, err := r.ObjectsE()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// ObjectsE is like Objects but returns an error if the ServiceAccount name is empty.
+func (r *RBAC) ObjectsE() ([]runtime.Object, error) {
+	if r.ServiceAccountName == "" {
+		return nil, fmt.Errorf("service account name is not specified for the RBAC of %s", r.Name)
+	}
+
+	ret := []runtime.Object{}
+	subjects := []rbacv1.Subject{
+		{
+			Kind:      ServiceAccountMeta.Kind,
+			Name:      r.ServiceAccountName,
+			Namespace: r.Namespace,
+		},
+	}
+
+	makeMeta := func(clusterScoped bool) metav1.ObjectMeta {
+		metaCfg := r.MetaConfig.Clone()
+		delete(metaCfg.Labels, VersionLabel)
+		if clusterScoped {
+			metaCfg.Name = fmt.Sprintf("%s-%s", r.Namespace, r.Name)
+			metaCfg.Namespace = ""
+		}
+		return metaCfg.MakeMeta()
+	}
+
+	if rules := r.Rules.GetRules(); len(rules) > 0 {
+		role := &rbacv1.Role{
+			TypeMeta:   RoleMeta,
+			ObjectMeta: makeMeta(false),
+			Rules:      rules,
+		}
+		ret = append(ret, role, &rbacv1.RoleBinding{
+			TypeMeta:   RoleBindingMeta,
+			ObjectMeta: makeMeta(false),
+			Subjects:   subjects,
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     RoleMeta.Kind,
+				Name:     role.Name,
+			},
+		})
+	}
+
+	if rules := r.Rules.GetClusterRules(); len(rules) > 0 {
+		role := &rbacv1.ClusterRole{
+			TypeMeta:   ClusterRoleMeta,
+			ObjectMeta: makeMeta(true),
+			Rules:      rules,
+		}
+		ret = append(ret, role, &rbacv1.ClusterRoleBinding{
+			TypeMeta:   ClusterRoleBindingMeta,
+			ObjectMeta: makeMeta(true),
+			Subjects:   subjects,
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     ClusterRoleMeta.Kind,
+				Name:     role.Name,
+			},
+		})
+	}
+
+	return ret, nil
+}
+
+// GetServiceAccountName returns the name of the ServiceAccount of the pods, the existing one if set.
+func (d PodConfig) GetServiceAccountName() string {
+	if d.ServiceAccountName != "" {
+		return d.ServiceAccountName
+	}
+
+	return d.Name
+}
+
+// RBACObjects returns the Roles, ClusterRoles and bindings granting the permissions required by the containers of the pod.
+func (d PodConfig) RBACObjects(pod *Pod) ([]runtime.Object, error) {
+	rbac := &RBAC{
+		MetaConfig:         *d.ObjectMeta(),
+		ServiceAccountName: d.GetServiceAccountName(),
+		Rules:              pod,
+	}
+
+	return rbac.ObjectsE()
+}
+
+// imagePullSecrets returns the references to the image pull secrets of the pod.
+func (d PodConfig) imagePullSecrets() []corev1.LocalObjectReference {
+	if len(d.ImagePullSecrets) == 0 {
+		return nil
+	}
+
+	ret := make([]corev1.LocalObjectReference, 0, len(d.ImagePullSecrets))
+	for _, name := range d.ImagePullSecrets {
+		ret = append(ret, corev1.LocalObjectReference{Name: name})
+	}
+
+	return ret
+}
@@ -0,0 +1,140 @@
+package workload_test
+
+import (
+	"testing"
+
+	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/validate"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/workload"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestRBAC(t *testing.T) {
+	sidecar := &workload.Container{
+		Name:         "sidecar",
+		Image:        "sidecar",
+		ImageTag:     "v1",
+		ClusterRules: []rbacv1.PolicyRule{workload.NewPolicyRule("", []string{"nodes"}, "get")},
+	}
+
+	testCases := map[string]struct {
+		serviceAccountName string
+		sidecars           []workload.ContainerProvider
+		rules              []rbacv1.PolicyRule
+		expectedKinds      []string
+		expectedSubject    string
+	}{
+		"no rules": {
+			expectedKinds: []string{"ServiceAccount", "Deployment"},
+		},
+		"namespaced rules": {
+			rules:           []rbacv1.PolicyRule{workload.NewPolicyRule("", []string{"configmaps"}, "get")},
+			expectedKinds:   []string{"ServiceAccount", "Role", "RoleBinding", "Deployment"},
+			expectedSubject: "test",
+		},
+		"cluster rules from sidecar": {
+			sidecars:        []workload.ContainerProvider{sidecar},
+			expectedKinds:   []string{"ServiceAccount", "ClusterRole", "ClusterRoleBinding", "Deployment"},
+			expectedSubject: "test",
+		},
+		"existing service account": {
+			serviceAccountName: "existing",
+			rules:              []rbacv1.PolicyRule{workload.NewPolicyRule("", []string{"configmaps"}, "get")},
+			expectedKinds:      []string{"Role", "RoleBinding", "Deployment"},
+			expectedSubject:    "existing",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := workload.DeploymentWorkload{
+				Replicas: 1,
+				PodConfig: workload.PodConfig{
+					Name:         "test",
+					Namespace:    "ns",
+					Image:        "test",
+					ImageTag:     "v1",
+					CommonLabels: map[string]string{workload.NameLabel: "test"},
+				},
+			}
+			w.ServiceAccountName = tc.serviceAccountName
+			w.Sidecars = tc.sidecars
+			container := w.ToContainer()
+			container.Rules = tc.rules
+
+			objects := w.Objects(container)
+			if err := validate.Objects(objects); err != nil {
+				t.Fatalf("invalid objects: %v", err)
+			}
+
+			kinds := []string{}
+			for _, obj := range objects {
+				kinds = append(kinds, obj.GetObjectKind().GroupVersionKind().Kind)
+			}
+			if len(kinds) != len(tc.expectedKinds) {
+				t.Fatalf("expected kinds %v, got %v", tc.expectedKinds, kinds)
+			}
+			for i := range kinds {
+				if kinds[i] != tc.expectedKinds[i] {
+					t.Fatalf("expected kinds %v, got %v", tc.expectedKinds, kinds)
+				}
+			}
+
+			dep := kghelpers.GetObject[*appsv1.Deployment](objects, "test")
+			expectedSA := tc.serviceAccountName
+			if expectedSA == "" {
+				expectedSA = "test"
+			}
+			if dep.Spec.Template.Spec.ServiceAccountName != expectedSA {
+				t.Errorf("expected service account %s, got %s", expectedSA, dep.Spec.Template.Spec.ServiceAccountName)
+			}
+
+			if tc.expectedSubject != "" {
+				if subject := bindingSubjects(objects)[0]; subject.Name != tc.expectedSubject || subject.Namespace != "ns" {
+					t.Errorf("unexpected binding subject: %+v", subject)
+				}
+			}
+		})
+	}
+}
+
+func TestPodServiceAccountOptions(t *testing.T) {
+	automount := false
+	w := workload.DeploymentWorkload{
+		Replicas: 1,
+		PodConfig: workload.PodConfig{
+			Name:                         "test",
+			Namespace:                    "ns",
+			Image:                        "test",
+			ImageTag:                     "v1",
+			CommonLabels:                 map[string]string{workload.NameLabel: "test"},
+			AutomountServiceAccountToken: &automount,
+			ImagePullSecrets:             []string{"registry"},
+		},
+	}
+
+	spec := kghelpers.GetObject[*appsv1.Deployment](w.Objects(w.ToContainer()), "test").Spec.Template.Spec
+	if spec.AutomountServiceAccountToken == nil || *spec.AutomountServiceAccountToken {
+		t.Errorf("expected service account token not to be mounted")
+	}
+
+	if len(spec.ImagePullSecrets) != 1 || spec.ImagePullSecrets[0] != (corev1.LocalObjectReference{Name: "registry"}) {
+		t.Errorf("unexpected image pull secrets: %v", spec.ImagePullSecrets)
+	}
+}
+
+func bindingSubjects(objects []runtime.Object) []rbacv1.Subject {
+	for _, obj := range objects {
+		switch o := obj.(type) {
+		case *rbacv1.RoleBinding:
+			return o.Subjects
+		case *rbacv1.ClusterRoleBinding:
+			return o.Subjects
+		}
+	}
+
+	return nil
+}
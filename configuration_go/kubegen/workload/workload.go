@@ -1,6 +1,7 @@
 package workload
 
 import (
+	"errors"
 	"maps"
 	"unicode/utf8"
 
@@ -12,18 +13,47 @@ import (
 // DeploymentWorkload represents a generic deployment workload with most commonly used options.
 type DeploymentWorkload struct {
 	DeploymentStrategy appsv1.DeploymentStrategy
-	Replicas           int32
+	Replicas           int32 `template:"REPLICAS"`
+
+	// Autoscaling enables the autoscaling of the Deployment, whose replicas are then left unset.
+	Autoscaling *Autoscaling
 
 	PodConfig
 }
 
 // Objects returns the list of runtime objects for the given workload.
+// It panics if the pod disruption budget, the network policy, the RBAC or the autoscaling of the workload is invalid.
 func (d DeploymentWorkload) Objects(container *Container) []runtime.Object {
+	ret, err := d.ObjectsE(container)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return ret
+}
+
+// ObjectsE is like Objects but returns an error joining the problems of the workload instead of panicking.
+func (d DeploymentWorkload) ObjectsE(container *Container) ([]runtime.Object, error) {
 	pod := d.Pod(container)
-	ret := d.generateCommonObjects(pod)
+	ret, err := d.generateCommonObjects(pod)
+	errs := []error{err}
 	ret = append(ret, d.deployment(pod))
 
-	return ret
+	if d.Autoscaling != nil {
+		autoscaler := &Autoscaler{
+			MetaConfig:  *d.ObjectMeta(),
+			Autoscaling: *d.Autoscaling,
+		}
+		obj, err := autoscaler.ObjectE()
+		errs = append(errs, err)
+		ret = append(ret, obj)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
 }
 
 func (d DeploymentWorkload) deployment(pod *Pod) runtime.Object {
@@ -34,12 +64,18 @@ func (d DeploymentWorkload) deployment(pod *Pod) runtime.Object {
 		Pod:        pod,
 	}
 
-	return dep.Object()
+	ret := dep.Object().(*appsv1.Deployment)
+	if d.Autoscaling != nil {
+		// Leave the replicas to the autoscaler.
+		ret.Spec.Replicas = nil
+	}
+
+	return ret
 }
 
 // StatefulSetWorkload represents a generic statefulset workload with most commonly used options.
 type StatefulSetWorkload struct {
-	Replicas   int32
+	Replicas   int32 `template:"REPLICAS"`
 	VolumeType string
 	VolumeSize string
 
@@ -47,14 +83,27 @@ type StatefulSetWorkload struct {
 }
 
 // Objects returns the list of runtime objects for the given workload.
+// It panics if the pod disruption budget, the network policy or the RBAC of the workload is invalid.
 func (s StatefulSetWorkload) Objects(container *Container) []runtime.Object {
-	pod := s.Pod(container)
-	ret := s.generateCommonObjects(pod)
-	ret = append(ret, s.statefulSet(pod))
+	ret, err := s.ObjectsE(container)
+	if err != nil {
+		panic(err.Error())
+	}
 
 	return ret
 }
 
+// ObjectsE is like Objects but returns an error joining the problems of the workload instead of panicking.
+func (s StatefulSetWorkload) ObjectsE(container *Container) ([]runtime.Object, error) {
+	pod := s.Pod(container)
+	ret, err := s.generateCommonObjects(pod)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(ret, s.statefulSet(pod)), nil
+}
+
 func (s StatefulSetWorkload) statefulSet(pod *Pod) runtime.Object {
 	statefulset := &StatefulSet{
 		MetaConfig: *s.ObjectMeta(),
@@ -66,20 +115,29 @@ func (s StatefulSetWorkload) statefulSet(pod *Pod) runtime.Object {
 }
 
 // PodConfig represents a generic pod configuration with most commonly used options.
+// The template tags name the fields parameterized by default in OpenShift templates, see the openshift package.
 type PodConfig struct {
 	// Container fields
-	ContainerResources corev1.ResourceRequirements
+	ContainerResources corev1.ResourceRequirements `template:"RESOURCES"`
 	Env                []corev1.EnvVar
-	Image              string
-	ImageTag           string
+	Image              string `template:"IMAGE"`
+	ImageTag           string `template:"IMAGE_TAG"`
 	ImagePullPolicy    corev1.PullPolicy
 	LivenessProbe      *corev1.Probe
 	ReadinessProbe     *corev1.Probe
 
 	// Pod fields
 	Affinity                      *corev1.Affinity
+	TopologySpreadConstraints     []corev1.TopologySpreadConstraint
 	SecurityContext               *corev1.PodSecurityContext
 	TerminationGracePeriodSeconds int64
+	// ServiceAccountName is the name of an existing ServiceAccount used by the pods.
+	// If empty, a ServiceAccount named after the workload is generated.
+	ServiceAccountName string
+	// AutomountServiceAccountToken disables the mount of the ServiceAccount token in the pods if false.
+	AutomountServiceAccountToken *bool
+	// ImagePullSecrets are the names of the secrets used to pull the images of the pods.
+	ImagePullSecrets []string
 
 	// Workload fields
 	CommonLabels map[string]string
@@ -88,6 +146,12 @@ type PodConfig struct {
 
 	EnableServiceMonitor bool
 
+	// PodDisruptionBudget configures the PodDisruptionBudget of the workload, none is generated if nil.
+	PodDisruptionBudget *PodDisruptionBudgetConfig
+
+	// NetworkPolicy restricts the traffic of the pods to the declared peers, none is generated if nil.
+	NetworkPolicy *NetworkPolicyConfig
+
 	// Container dependencies
 	// ConfigMaps and Secrets are the ones required by the main container, others are directly defined in Sidecars
 	ConfigMaps     map[string]map[string]string // maps a configmap name to its data of type map[string]string
@@ -131,8 +195,11 @@ func (d PodConfig) Pod(container *Container) *Pod {
 	return &Pod{
 		TerminationGracePeriodSeconds: &d.TerminationGracePeriodSeconds,
 		Affinity:                      d.Affinity,
+		TopologySpreadConstraints:     d.TopologySpreadConstraints,
 		SecurityContext:               d.SecurityContext,
-		ServiceAccountName:            d.Name,
+		ServiceAccountName:            d.GetServiceAccountName(),
+		AutomountServiceAccountToken:  d.AutomountServiceAccountToken,
+		ImagePullSecrets:              d.imagePullSecrets(),
 		ContainerProviders:            append([]ContainerProvider{container}, d.Sidecars...),
 		InitContainersProviders:       d.InitContainers,
 	}
@@ -158,6 +225,16 @@ func (d PodConfig) ServiceMonitor(pod *Pod) runtime.Object {
 	return serviceMonitor.Object()
 }
 
+// PodDisruptionBudgetObject returns a PodDisruptionBudget object selecting the pods of the workload.
+func (d PodConfig) PodDisruptionBudgetObject() (runtime.Object, error) {
+	pdb := &PodDisruptionBudget{
+		MetaConfig:                *d.ObjectMeta(),
+		PodDisruptionBudgetConfig: *d.PodDisruptionBudget,
+	}
+
+	return pdb.ObjectE()
+}
+
 // ServiceAccount returns a ServiceAccount object.
 func (d PodConfig) ServiceAccount() runtime.Object {
 	metaCfg := d.ObjectMeta().MakeMeta()
@@ -215,11 +292,17 @@ func (d PodConfig) ConfigMapsAndSecrets(pod *Pod) []runtime.Object {
 	return ret
 }
 
-func (d PodConfig) generateCommonObjects(pod *Pod) []runtime.Object {
-	ret := []runtime.Object{
-		d.ServiceAccount(),
+func (d PodConfig) generateCommonObjects(pod *Pod) ([]runtime.Object, error) {
+	ret := []runtime.Object{}
+
+	if d.ServiceAccountName == "" {
+		ret = append(ret, d.ServiceAccount())
 	}
 
+	rbac, err := d.RBACObjects(pod)
+	errs := []error{err}
+	ret = append(ret, rbac...)
+
 	if len(pod.GetServicePorts()) > 0 {
 		ret = append(ret, d.Service(pod))
 	}
@@ -228,7 +311,19 @@ func (d PodConfig) generateCommonObjects(pod *Pod) []runtime.Object {
 		ret = append(ret, d.ServiceMonitor(pod))
 	}
 
+	if d.PodDisruptionBudget != nil {
+		pdb, err := d.PodDisruptionBudgetObject()
+		errs = append(errs, err)
+		ret = append(ret, pdb)
+	}
+
+	if d.NetworkPolicy != nil {
+		np, err := d.NetworkPolicyObject(pod)
+		errs = append(errs, err)
+		ret = append(ret, np)
+	}
+
 	ret = append(ret, d.ConfigMapsAndSecrets(pod)...)
 
-	return ret
+	return ret, errors.Join(errs...)
 }
@@ -1,12 +1,14 @@
 package main
 
 import (
+	stdlog "log"
 	"time"
 
 	"github.com/bwplotka/mimic"
 
 	obsrbac "github.com/observatorium/api/rbac"
 	"github.com/observatorium/observatorium/configuration_go/abstr/kubernetes/observatorium/api"
+	"github.com/observatorium/observatorium/configuration_go/kubegen/containeropts"
 	kghelpers "github.com/observatorium/observatorium/configuration_go/kubegen/helpers"
 	"github.com/observatorium/observatorium/configuration_go/kubegen/kubeyaml"
 	"github.com/observatorium/observatorium/configuration_go/kubegen/openshift"
@@ -53,7 +55,7 @@ func main() {
 		},
 	}
 
-	// Create tenants. Ideally, this should come from secret management tool like vault.
+	// Create tenants. The secret holding them is not generated: it is synced from vault, or created out of band, see below.
 	tenants := &api.Tenants{
 		Tenants: []api.Tenant{
 			{
@@ -110,8 +112,11 @@ func main() {
 	apiK8s.Replicas = 3
 	apiK8s.ContainerResources = kghelpers.NewResourcesRequirements("2", "3", "2Gi", "3Gi")
 
+	// Sync the tenants secret from vault with the external-secrets operator, so that manifests don't contain credentials.
+	tenantsSource := containeropts.ExternalSecrets{StoreName: "vault", StoreKind: "ClusterSecretStore"}
+
 	// Generate manifests.
-	kubeyaml.GenerateWithMimic(g, apiK8s.Objects(), "config-new")
+	kubeyaml.GenerateWithMimic(g, withSecretSource(apiK8s.Objects(), tenantsSource, "observatorium-xyz-tenants"), "config-new")
 
 	// Example 2
 	// Create sidecar container.
@@ -179,10 +184,15 @@ func main() {
 	apiK8s.Sidecars = append(apiK8s.Sidecars, dummyContainer)
 
 	// Generate manifests.
-	kubeyaml.GenerateWithMimic(g, apiK8s.Objects(), "config-w-sidecar")
+	kubeyaml.GenerateWithMimic(g, withSecretSource(apiK8s.Objects(), tenantsSource, "observatorium-xyz-tenants"), "config-w-sidecar")
 
 	// Example 3
 	// Observatorium API with no sidecar, packaged as Observatorium template.
+	// The fields to be set when processing the template are parameterized before generating the objects.
+	params := openshift.NewParameters()
+	if err := params.Add(templatev1.Parameter{Name: "NAMESPACE", Value: "observatorium"}); err != nil {
+		stdlog.Fatalf("failed to add the NAMESPACE template parameter: %v", err)
+	}
 
 	apiOpts = &api.ObservatoriumAPIOptions{
 		// Metrics endpoints.
@@ -202,37 +212,37 @@ func main() {
 		// RBAC and tenants.
 		RbacConfig:    api.NewRbacConfig(rbac),
 		TenantsConfig: api.NewTenantsConfig(tenants),
+		LogLevel:      log.LevelDebug,
+	}
+	if err := params.Parameterize(apiOpts, "OBSERVATORIUM_API", "LogLevel"); err != nil {
+		stdlog.Fatalf("failed to parameterize the observatorium API options: %v", err)
 	}
-	apiOpts.AddExtraOpts("--log-level=${OBSERVATORIUM_API_LOG_LEVEL}")
 
-	// Configure Kubernetes resources.
-	apiK8s = api.NewObservatoriumAPI(apiOpts, "${NAMESPACE}", "${OBSERVATORIUM_API_IMAGE_TAG}")
+	// Configure Kubernetes resources. Their replicas, image, image tag and resources are parameterized.
+	apiK8s = api.NewObservatoriumAPI(apiOpts, "${NAMESPACE}", "main-2023-01-24-v0.1.2-318-g5f4fdf4")
 	apiK8s.Replicas = 3
 	apiK8s.ContainerResources = kghelpers.NewResourcesRequirements("2", "3", "2Gi", "3Gi")
-	apiK8s.Image = "${OBSERVATORIUM_API_IMAGE}"
+	if err := params.Parameterize(apiK8s, "OBSERVATORIUM_API"); err != nil {
+		stdlog.Fatalf("failed to parameterize the observatorium API workload: %v", err)
+	}
 
-	// Generate manifests.
-	objects := []runtime.Object{
-		openshift.WrapInTemplate(apiK8s.Objects(), metav1.ObjectMeta{
-			Name: "observatorium",
-		}, []templatev1.Parameter{
-			{
-				Name:  "OBSERVATORIUM_API_IMAGE",
-				Value: "quay.io/observatorium/api",
-			},
-			{
-				Name:  "OBSERVATORIUM_API_IMAGE_TAG",
-				Value: "main-2023-01-24-v0.1.2-318-g5f4fdf4",
-			},
-			{
-				Name:  "NAMESPACE",
-				Value: "observatorium",
-			},
-			{
-				Name:  "OBSERVATORIUM_API_LOG_LEVEL",
-				Value: "debug",
-			},
-		}),
+	// Generate manifests. The tenants secret must exist in the namespace before processing the template.
+	template, err := params.WrapInTemplate(withSecretSource(apiK8s.Objects(), containeropts.ExistingSecrets{}, "observatorium-tenants"), metav1.ObjectMeta{
+		Name: "observatorium",
+	})
+	if err != nil {
+		stdlog.Fatalf("failed to wrap the observatorium API objects in a template: %v", err)
 	}
-	kubeyaml.GenerateWithMimic(g, objects, "openshift-config-new")
+	kubeyaml.GenerateWithMimic(g, []runtime.Object{template}, "openshift-config-new")
+}
+
+// withSecretSource replaces the named secrets of the objects with the objects of the source.
+// It exits if the secrets can't be replaced, e.g. if one of them is missing from the objects.
+func withSecretSource(objects []runtime.Object, source containeropts.SecretSource, names ...string) []runtime.Object {
+	ret, err := containeropts.ApplySecretSource(objects, source, names...)
+	if err != nil {
+		stdlog.Fatalf("failed to replace the secrets %v with the %T secret source: %v", names, source, err)
+	}
+
+	return ret
 }
@@ -0,0 +1,466 @@
+package loki
+
+import (
+	"fmt"
+
+	prommodel "github.com/prometheus/common/model"
+	"gopkg.in/yaml.v2"
+)
+
+// Taken from https://grafana.com/docs/loki/v2.9.x/configure/
+// Only the most commonly used fields are exposed. Empty fields are omitted so that Loki defaults apply.
+
+type KVStore string
+
+const (
+	KVStoreMemberlist KVStore = "memberlist"
+	KVStoreInmemory   KVStore = "inmemory"
+	KVStoreConsul     KVStore = "consul"
+	KVStoreEtcd       KVStore = "etcd"
+)
+
+type IndexStore string
+
+const (
+	IndexStoreTSDB          IndexStore = "tsdb"
+	IndexStoreBoltDBShipper IndexStore = "boltdb-shipper"
+)
+
+type ObjectStore string
+
+const (
+	ObjectStoreS3         ObjectStore = "s3"
+	ObjectStoreGCS        ObjectStore = "gcs"
+	ObjectStoreAzure      ObjectStore = "azure"
+	ObjectStoreSwift      ObjectStore = "swift"
+	ObjectStoreFilesystem ObjectStore = "filesystem"
+)
+
+type ChunkEncoding string
+
+const (
+	ChunkEncodingNone   ChunkEncoding = "none"
+	ChunkEncodingGzip   ChunkEncoding = "gzip"
+	ChunkEncodingLz4    ChunkEncoding = "lz4"
+	ChunkEncodingSnappy ChunkEncoding = "snappy"
+)
+
+// Config is the root configuration of Loki.
+type Config struct {
+	AuthEnabled    bool                  `yaml:"auth_enabled"`
+	Server         *ServerConfig         `yaml:"server,omitempty"`
+	Common         *CommonConfig         `yaml:"common,omitempty"`
+	Distributor    *DistributorConfig    `yaml:"distributor,omitempty"`
+	Ingester       *IngesterConfig       `yaml:"ingester,omitempty"`
+	IngesterClient *IngesterClientConfig `yaml:"ingester_client,omitempty"`
+	Querier        *QuerierConfig        `yaml:"querier,omitempty"`
+	QueryRange     *QueryRangeConfig     `yaml:"query_range,omitempty"`
+	Frontend       *FrontendConfig       `yaml:"frontend,omitempty"`
+	FrontendWorker *FrontendWorkerConfig `yaml:"frontend_worker,omitempty"`
+	Compactor      *CompactorConfig      `yaml:"compactor,omitempty"`
+	Ruler          *RulerConfig          `yaml:"ruler,omitempty"`
+	Memberlist     *MemberlistConfig     `yaml:"memberlist,omitempty"`
+	SchemaConfig   *SchemaConfig         `yaml:"schema_config,omitempty"`
+	StorageConfig  *StorageConfig        `yaml:"storage_config,omitempty"`
+	ChunkStore     *ChunkStoreConfig     `yaml:"chunk_store_config,omitempty"`
+	LimitsConfig   *LimitsConfig         `yaml:"limits_config,omitempty"`
+	Analytics      *AnalyticsConfig      `yaml:"analytics,omitempty"`
+}
+
+// String returns a string representation of the Config as YAML.
+// We use "gopkg.in/yaml.v2" instead of "github.com/ghodss/yaml" for correct formatting of this config.
+func (c Config) String() string {
+	ret, err := c.StringE()
+	if err != nil {
+		panic(err.Error())
+	}
+	return ret
+}
+
+// StringE is like String but returns an error instead of panicking when the Config cannot be marshalled.
+func (c Config) StringE() (string, error) {
+	ret, err := yaml.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling Config to yaml: %w", err)
+	}
+	return string(ret), nil
+}
+
+// ServerConfig configures the HTTP and gRPC servers of every Loki component.
+type ServerConfig struct {
+	HTTPListenPort                 int                `yaml:"http_listen_port,omitempty"`
+	GRPCListenPort                 int                `yaml:"grpc_listen_port,omitempty"`
+	GRPCServerMaxRecvMsgSize       int                `yaml:"grpc_server_max_recv_msg_size,omitempty"`
+	GRPCServerMaxSendMsgSize       int                `yaml:"grpc_server_max_send_msg_size,omitempty"`
+	GRPCServerMaxConcurrentStreams int                `yaml:"grpc_server_max_concurrent_streams,omitempty"`
+	HTTPServerReadTimeout          prommodel.Duration `yaml:"http_server_read_timeout,omitempty"`
+	HTTPServerWriteTimeout         prommodel.Duration `yaml:"http_server_write_timeout,omitempty"`
+	HTTPServerIdleTimeout          prommodel.Duration `yaml:"http_server_idle_timeout,omitempty"`
+	GracefulShutdownTimeout        prommodel.Duration `yaml:"graceful_shutdown_timeout,omitempty"`
+	LogLevel                       string             `yaml:"log_level,omitempty"`
+	LogFormat                      string             `yaml:"log_format,omitempty"`
+}
+
+// CommonConfig holds the configuration shared by all Loki components.
+type CommonConfig struct {
+	PathPrefix            string               `yaml:"path_prefix,omitempty"`
+	ReplicationFactor     int                  `yaml:"replication_factor,omitempty"`
+	Storage               *CommonStorageConfig `yaml:"storage,omitempty"`
+	Ring                  *RingConfig          `yaml:"ring,omitempty"`
+	InstanceAddr          string               `yaml:"instance_addr,omitempty"`
+	CompactorAddress      string               `yaml:"compactor_address,omitempty"`
+	CompactorGRPCAddress  string               `yaml:"compactor_grpc_address,omitempty"`
+	PersistTokens         bool                 `yaml:"persist_tokens,omitempty"`
+	InstanceInterfaceName []string             `yaml:"instance_interface_names,omitempty"`
+}
+
+// CommonStorageConfig is the object storage shared by all Loki components.
+type CommonStorageConfig struct {
+	S3         *S3Config         `yaml:"s3,omitempty"`
+	GCS        *GCSConfig        `yaml:"gcs,omitempty"`
+	Azure      *AzureConfig      `yaml:"azure,omitempty"`
+	Filesystem *FilesystemConfig `yaml:"filesystem,omitempty"`
+}
+
+// S3Config configures the AWS S3 (or compatible) object storage client.
+type S3Config struct {
+	S3               string `yaml:"s3,omitempty"`
+	S3ForcePathStyle bool   `yaml:"s3forcepathstyle,omitempty"`
+	BucketNames      string `yaml:"bucketnames,omitempty"`
+	Endpoint         string `yaml:"endpoint,omitempty"`
+	Region           string `yaml:"region,omitempty"`
+	AccessKeyID      string `yaml:"access_key_id,omitempty"`
+	SecretAccessKey  string `yaml:"secret_access_key,omitempty"`
+	Insecure         bool   `yaml:"insecure,omitempty"`
+}
+
+// GCSConfig configures the Google Cloud Storage client.
+type GCSConfig struct {
+	BucketName       string `yaml:"bucket_name,omitempty"`
+	ServiceAccount   string `yaml:"service_account,omitempty"`
+	ChunkBufferSize  int    `yaml:"chunk_buffer_size,omitempty"`
+	EnableHTTP2      bool   `yaml:"enable_http2,omitempty"`
+	EnableOpenCensus bool   `yaml:"enable_opencensus,omitempty"`
+}
+
+// AzureConfig configures the Azure Blob Storage client.
+type AzureConfig struct {
+	AccountName        string `yaml:"account_name,omitempty"`
+	AccountKey         string `yaml:"account_key,omitempty"`
+	ContainerName      string `yaml:"container_name,omitempty"`
+	EndpointSuffix     string `yaml:"endpoint_suffix,omitempty"`
+	UseManagedIdentity bool   `yaml:"use_managed_identity,omitempty"`
+}
+
+// FilesystemConfig configures the local filesystem storage. It is only suitable for testing.
+type FilesystemConfig struct {
+	ChunksDirectory string `yaml:"chunks_directory,omitempty"`
+	RulesDirectory  string `yaml:"rules_directory,omitempty"`
+}
+
+// RingConfig configures a hash ring.
+type RingConfig struct {
+	KVStore           *KVStoreConfig     `yaml:"kvstore,omitempty"`
+	HeartbeatTimeout  prommodel.Duration `yaml:"heartbeat_timeout,omitempty"`
+	ReplicationFactor int                `yaml:"replication_factor,omitempty"`
+	ZoneAwareness     bool               `yaml:"zone_awareness_enabled,omitempty"`
+}
+
+// KVStoreConfig configures the backend storage of a hash ring.
+type KVStoreConfig struct {
+	Store  KVStore            `yaml:"store"`
+	Prefix string             `yaml:"prefix,omitempty"`
+	Etcd   *EtcdConfig        `yaml:"etcd,omitempty"`
+	Consul *ConsulStoreConfig `yaml:"consul,omitempty"`
+}
+
+// EtcdConfig configures the etcd KV store client.
+type EtcdConfig struct {
+	Endpoints   []string           `yaml:"endpoints"`
+	DialTimeout prommodel.Duration `yaml:"dial_timeout,omitempty"`
+	MaxRetries  int                `yaml:"max_retries,omitempty"`
+}
+
+// ConsulStoreConfig configures the consul KV store client.
+type ConsulStoreConfig struct {
+	Host        string             `yaml:"host"`
+	ACLToken    string             `yaml:"acl_token,omitempty"`
+	HTTPTimeout prommodel.Duration `yaml:"http_client_timeout,omitempty"`
+}
+
+// LifecyclerConfig configures how an ingester joins its ring.
+type LifecyclerConfig struct {
+	Ring             *RingConfig        `yaml:"ring,omitempty"`
+	NumTokens        int                `yaml:"num_tokens,omitempty"`
+	HeartbeatPeriod  prommodel.Duration `yaml:"heartbeat_period,omitempty"`
+	JoinAfter        prommodel.Duration `yaml:"join_after,omitempty"`
+	ObservePeriod    prommodel.Duration `yaml:"observe_period,omitempty"`
+	MinReadyDuration prommodel.Duration `yaml:"min_ready_duration,omitempty"`
+	FinalSleep       prommodel.Duration `yaml:"final_sleep,omitempty"`
+	Zone             string             `yaml:"availability_zone,omitempty"`
+}
+
+// DistributorConfig configures the distributor.
+type DistributorConfig struct {
+	Ring *RingConfig `yaml:"ring,omitempty"`
+}
+
+// WALConfig configures the ingester write ahead log.
+type WALConfig struct {
+	Enabled             bool               `yaml:"enabled"`
+	Dir                 string             `yaml:"dir,omitempty"`
+	CheckpointDuration  prommodel.Duration `yaml:"checkpoint_duration,omitempty"`
+	FlushOnShutdown     bool               `yaml:"flush_on_shutdown,omitempty"`
+	ReplayMemoryCeiling string             `yaml:"replay_memory_ceiling,omitempty"`
+}
+
+// IngesterConfig configures the ingester.
+type IngesterConfig struct {
+	Lifecycler                  *LifecyclerConfig  `yaml:"lifecycler,omitempty"`
+	ConcurrentFlushes           int                `yaml:"concurrent_flushes,omitempty"`
+	FlushCheckPeriod            prommodel.Duration `yaml:"flush_check_period,omitempty"`
+	FlushOpTimeout              prommodel.Duration `yaml:"flush_op_timeout,omitempty"`
+	ChunkRetainPeriod           prommodel.Duration `yaml:"chunk_retain_period,omitempty"`
+	ChunkIdlePeriod             prommodel.Duration `yaml:"chunk_idle_period,omitempty"`
+	ChunkBlockSize              int                `yaml:"chunk_block_size,omitempty"`
+	ChunkTargetSize             int                `yaml:"chunk_target_size,omitempty"`
+	ChunkEncoding               ChunkEncoding      `yaml:"chunk_encoding,omitempty"`
+	MaxChunkAge                 prommodel.Duration `yaml:"max_chunk_age,omitempty"`
+	MaxTransferRetries          int                `yaml:"max_transfer_retries,omitempty"`
+	QueryStoreMaxLookBackPeriod prommodel.Duration `yaml:"query_store_max_look_back_period,omitempty"`
+	WAL                         *WALConfig         `yaml:"wal,omitempty"`
+}
+
+// IngesterClientConfig configures how components connect to ingesters.
+type IngesterClientConfig struct {
+	RemoteTimeout prommodel.Duration `yaml:"remote_timeout,omitempty"`
+	PoolConfig    *PoolConfig        `yaml:"pool_config,omitempty"`
+}
+
+// PoolConfig configures the ingester client pool.
+type PoolConfig struct {
+	HealthCheckIngesters bool               `yaml:"health_check_ingesters,omitempty"`
+	ClientCleanupPeriod  prommodel.Duration `yaml:"client_cleanup_period,omitempty"`
+	RemoteTimeout        prommodel.Duration `yaml:"remote_timeout,omitempty"`
+}
+
+// QuerierConfig configures the querier.
+type QuerierConfig struct {
+	MaxConcurrent             int                `yaml:"max_concurrent,omitempty"`
+	QueryIngestersWithin      prommodel.Duration `yaml:"query_ingesters_within,omitempty"`
+	QueryTimeout              prommodel.Duration `yaml:"query_timeout,omitempty"`
+	TailMaxDuration           prommodel.Duration `yaml:"tail_max_duration,omitempty"`
+	ExtraQueryDelay           prommodel.Duration `yaml:"extra_query_delay,omitempty"`
+	MultiTenantQueriesEnabled bool               `yaml:"multi_tenant_queries_enabled,omitempty"`
+}
+
+// QueryRangeConfig configures query splitting and results caching in the query frontend.
+type QueryRangeConfig struct {
+	AlignQueriesWithStep        bool                `yaml:"align_queries_with_step,omitempty"`
+	MaxRetries                  int                 `yaml:"max_retries,omitempty"`
+	CacheResults                bool                `yaml:"cache_results,omitempty"`
+	ParallelizeShardableQueries bool                `yaml:"parallelise_shardable_queries,omitempty"`
+	ResultsCache                *ResultsCacheConfig `yaml:"results_cache,omitempty"`
+}
+
+// ResultsCacheConfig configures the query frontend results cache.
+type ResultsCacheConfig struct {
+	Cache *CacheConfig `yaml:"cache,omitempty"`
+}
+
+// CacheConfig configures a Loki cache.
+type CacheConfig struct {
+	EmbeddedCache   *EmbeddedCacheConfig   `yaml:"embedded_cache,omitempty"`
+	MemcachedClient *MemcachedClientConfig `yaml:"memcached_client,omitempty"`
+	DefaultValidity prommodel.Duration     `yaml:"default_validity,omitempty"`
+}
+
+// EmbeddedCacheConfig configures the in-process cache.
+type EmbeddedCacheConfig struct {
+	Enabled   bool               `yaml:"enabled"`
+	MaxSizeMB int                `yaml:"max_size_mb,omitempty"`
+	TTL       prommodel.Duration `yaml:"ttl,omitempty"`
+}
+
+// MemcachedClientConfig configures a memcached cache client.
+type MemcachedClientConfig struct {
+	Addresses      string             `yaml:"addresses,omitempty"`
+	Host           string             `yaml:"host,omitempty"`
+	Service        string             `yaml:"service,omitempty"`
+	Timeout        prommodel.Duration `yaml:"timeout,omitempty"`
+	MaxIdleConns   int                `yaml:"max_idle_conns,omitempty"`
+	ConsistentHash bool               `yaml:"consistent_hash,omitempty"`
+}
+
+// FrontendConfig configures the query frontend.
+type FrontendConfig struct {
+	CompressResponses       bool               `yaml:"compress_responses,omitempty"`
+	LogQueriesLongerThan    prommodel.Duration `yaml:"log_queries_longer_than,omitempty"`
+	MaxOutstandingPerTenant int                `yaml:"max_outstanding_per_tenant,omitempty"`
+	SchedulerAddress        string             `yaml:"scheduler_address,omitempty"`
+	TailProxyURL            string             `yaml:"tail_proxy_url,omitempty"`
+}
+
+// FrontendWorkerConfig configures how queriers pull work from the query frontend.
+type FrontendWorkerConfig struct {
+	FrontendAddress    string            `yaml:"frontend_address,omitempty"`
+	SchedulerAddress   string            `yaml:"scheduler_address,omitempty"`
+	Parallelism        int               `yaml:"parallelism,omitempty"`
+	MatchMaxConcurrent bool              `yaml:"match_max_concurrent,omitempty"`
+	GRPCClientConfig   *GRPCClientConfig `yaml:"grpc_client_config,omitempty"`
+}
+
+// GRPCClientConfig configures a gRPC client.
+type GRPCClientConfig struct {
+	MaxRecvMsgSize int `yaml:"max_recv_msg_size,omitempty"`
+	MaxSendMsgSize int `yaml:"max_send_msg_size,omitempty"`
+}
+
+// CompactorConfig configures the compactor.
+type CompactorConfig struct {
+	WorkingDirectory           string             `yaml:"working_directory,omitempty"`
+	SharedStore                ObjectStore        `yaml:"shared_store,omitempty"`
+	CompactionInterval         prommodel.Duration `yaml:"compaction_interval,omitempty"`
+	RetentionEnabled           bool               `yaml:"retention_enabled,omitempty"`
+	RetentionDeleteDelay       prommodel.Duration `yaml:"retention_delete_delay,omitempty"`
+	RetentionDeleteWorkerCount int                `yaml:"retention_delete_worker_count,omitempty"`
+	DeleteRequestStore         ObjectStore        `yaml:"delete_request_store,omitempty"`
+	CompactorRing              *RingConfig        `yaml:"compactor_ring,omitempty"`
+	MaxCompactionParallelism   int                `yaml:"max_compaction_parallelism,omitempty"`
+	UploadParallelism          int                `yaml:"upload_parallelism,omitempty"`
+	TablesToCompact            int                `yaml:"tables_to_compact,omitempty"`
+	SkipLatestNTables          int                `yaml:"skip_latest_n_tables,omitempty"`
+	RunOnce                    bool               `yaml:"run_once,omitempty"`
+	ApplyRetentionInterval     prommodel.Duration `yaml:"apply_retention_interval,omitempty"`
+	DeleteMaxInterval          prommodel.Duration `yaml:"delete_max_interval,omitempty"`
+	DeleteRequestCancelPeriod  prommodel.Duration `yaml:"delete_request_cancel_period,omitempty"`
+}
+
+// RulerConfig configures the ruler.
+type RulerConfig struct {
+	ExternalURL          string              `yaml:"external_url,omitempty"`
+	AlertmanagerURL      string              `yaml:"alertmanager_url,omitempty"`
+	EnableAPI            bool                `yaml:"enable_api,omitempty"`
+	EnableAlertmanagerV2 bool                `yaml:"enable_alertmanager_v2,omitempty"`
+	EnableSharding       bool                `yaml:"enable_sharding,omitempty"`
+	EvaluationInterval   prommodel.Duration  `yaml:"evaluation_interval,omitempty"`
+	PollInterval         prommodel.Duration  `yaml:"poll_interval,omitempty"`
+	RulePath             string              `yaml:"rule_path,omitempty"`
+	Ring                 *RingConfig         `yaml:"ring,omitempty"`
+	Storage              *RulerStorageConfig `yaml:"storage,omitempty"`
+	WAL                  *RulerWALConfig     `yaml:"wal,omitempty"`
+}
+
+// RulerStorageConfig configures where the ruler reads its rule groups from.
+type RulerStorageConfig struct {
+	Type  string            `yaml:"type"`
+	Local *RulerLocalConfig `yaml:"local,omitempty"`
+	S3    *S3Config         `yaml:"s3,omitempty"`
+}
+
+// RulerLocalConfig configures the local rule storage.
+type RulerLocalConfig struct {
+	Directory string `yaml:"directory"`
+}
+
+// RulerWALConfig configures the ruler remote write WAL.
+type RulerWALConfig struct {
+	Dir string `yaml:"dir,omitempty"`
+}
+
+// MemberlistConfig configures the memberlist gossip KV store.
+type MemberlistConfig struct {
+	JoinMembers       []string           `yaml:"join_members,omitempty"`
+	BindPort          int                `yaml:"bind_port,omitempty"`
+	AbortIfJoinFails  bool               `yaml:"abort_if_cluster_join_fails"`
+	MinJoinBackoff    prommodel.Duration `yaml:"min_join_backoff,omitempty"`
+	MaxJoinBackoff    prommodel.Duration `yaml:"max_join_backoff,omitempty"`
+	MaxJoinRetries    int                `yaml:"max_join_retries,omitempty"`
+	RandomizeNodeName bool               `yaml:"randomize_node_name"`
+}
+
+// SchemaConfig configures the chunk index schema over time.
+type SchemaConfig struct {
+	Configs []PeriodConfig `yaml:"configs"`
+}
+
+// PeriodConfig configures the schema from a given date.
+type PeriodConfig struct {
+	From        string       `yaml:"from"`
+	Store       IndexStore   `yaml:"store"`
+	ObjectStore ObjectStore  `yaml:"object_store"`
+	Schema      string       `yaml:"schema"`
+	Index       *IndexConfig `yaml:"index,omitempty"`
+}
+
+// IndexConfig configures the index tables.
+type IndexConfig struct {
+	Prefix string             `yaml:"prefix"`
+	Period prommodel.Duration `yaml:"period"`
+}
+
+// StorageConfig configures the index shipper and the object storage clients.
+type StorageConfig struct {
+	AWS           *S3Config         `yaml:"aws,omitempty"`
+	GCS           *GCSConfig        `yaml:"gcs,omitempty"`
+	Azure         *AzureConfig      `yaml:"azure,omitempty"`
+	Filesystem    *FilesystemConfig `yaml:"filesystem,omitempty"`
+	BoltDBShipper *ShipperConfig    `yaml:"boltdb_shipper,omitempty"`
+	TSDBShipper   *ShipperConfig    `yaml:"tsdb_shipper,omitempty"`
+	IndexQueries  *CacheConfig      `yaml:"index_queries_cache_config,omitempty"`
+}
+
+// ChunkStoreConfig configures the chunk cache.
+type ChunkStoreConfig struct {
+	ChunkCacheConfig *CacheConfig `yaml:"chunk_cache_config,omitempty"`
+}
+
+// ShipperConfig configures the boltdb or tsdb index shipper.
+type ShipperConfig struct {
+	ActiveIndexDirectory string                    `yaml:"active_index_directory,omitempty"`
+	CacheLocation        string                    `yaml:"cache_location,omitempty"`
+	CacheTTL             prommodel.Duration        `yaml:"cache_ttl,omitempty"`
+	SharedStore          ObjectStore               `yaml:"shared_store,omitempty"`
+	IndexGatewayClient   *IndexGatewayClientConfig `yaml:"index_gateway_client,omitempty"`
+}
+
+// IndexGatewayClientConfig configures the index gateway client.
+type IndexGatewayClientConfig struct {
+	ServerAddress string `yaml:"server_address,omitempty"`
+}
+
+// LimitsConfig configures global and per-tenant limits.
+type LimitsConfig struct {
+	IngestionRateStrategy     string             `yaml:"ingestion_rate_strategy,omitempty"`
+	IngestionRateMB           float64            `yaml:"ingestion_rate_mb,omitempty"`
+	IngestionBurstSizeMB      float64            `yaml:"ingestion_burst_size_mb,omitempty"`
+	MaxLabelNameLength        int                `yaml:"max_label_name_length,omitempty"`
+	MaxLabelValueLength       int                `yaml:"max_label_value_length,omitempty"`
+	MaxLabelNamesPerSeries    int                `yaml:"max_label_names_per_series,omitempty"`
+	RejectOldSamples          bool               `yaml:"reject_old_samples,omitempty"`
+	RejectOldSamplesMaxAge    prommodel.Duration `yaml:"reject_old_samples_max_age,omitempty"`
+	CreationGracePeriod       prommodel.Duration `yaml:"creation_grace_period,omitempty"`
+	MaxLineSize               string             `yaml:"max_line_size,omitempty"`
+	MaxLineSizeTruncate       bool               `yaml:"max_line_size_truncate,omitempty"`
+	MaxStreamsPerUser         int                `yaml:"max_streams_per_user,omitempty"`
+	MaxGlobalStreamsPerUser   int                `yaml:"max_global_streams_per_user,omitempty"`
+	MaxChunksPerQuery         int                `yaml:"max_chunks_per_query,omitempty"`
+	MaxQuerySeries            int                `yaml:"max_query_series,omitempty"`
+	MaxQueryLookback          prommodel.Duration `yaml:"max_query_lookback,omitempty"`
+	MaxQueryLength            prommodel.Duration `yaml:"max_query_length,omitempty"`
+	MaxQueryParallelism       int                `yaml:"max_query_parallelism,omitempty"`
+	TSDBMaxQueryParallelism   int                `yaml:"tsdb_max_query_parallelism,omitempty"`
+	MaxEntriesLimitPerQuery   int                `yaml:"max_entries_limit_per_query,omitempty"`
+	MaxCacheFreshnessPerQuery prommodel.Duration `yaml:"max_cache_freshness_per_query,omitempty"`
+	SplitQueriesByInterval    prommodel.Duration `yaml:"split_queries_by_interval,omitempty"`
+	QueryTimeout              prommodel.Duration `yaml:"query_timeout,omitempty"`
+	PerStreamRateLimit        string             `yaml:"per_stream_rate_limit,omitempty"`
+	PerStreamRateLimitBurst   string             `yaml:"per_stream_rate_limit_burst,omitempty"`
+	RetentionPeriod           prommodel.Duration `yaml:"retention_period,omitempty"`
+	PerTenantOverrideConfig   string             `yaml:"per_tenant_override_config,omitempty"`
+}
+
+// AnalyticsConfig configures the usage statistics reporting to grafana.com.
+type AnalyticsConfig struct {
+	ReportingEnabled bool `yaml:"reporting_enabled"`
+}
@@ -0,0 +1,375 @@
+package otelcol
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Taken from https://opentelemetry.io/docs/collector/configuration/ and the contrib components of v0.88.0.
+// Only the components used for traces pipelines are typed.
+
+// Component types, used as the first part of a component ID.
+const (
+	OTLPType          = "otlp"
+	OTLPHTTPType      = "otlphttp"
+	JaegerType        = "jaeger"
+	BatchType         = "batch"
+	MemoryLimiterType = "memory_limiter"
+	RoutingType       = "routing"
+	DebugType         = "debug"
+	HealthCheckType   = "health_check"
+)
+
+// ComponentID returns the ID of a component with the given type and name, as referenced in pipelines.
+// An empty name refers to the default instance of the component type.
+func ComponentID(componentType, name string) string {
+	if name == "" {
+		return componentType
+	}
+
+	return fmt.Sprintf("%s/%s", componentType, name)
+}
+
+// Config is the root configuration of the OpenTelemetry Collector.
+type Config struct {
+	Receivers  Receivers     `yaml:"receivers"`
+	Processors Processors    `yaml:"processors,omitempty"`
+	Exporters  Exporters     `yaml:"exporters"`
+	Extensions Extensions    `yaml:"extensions,omitempty"`
+	Service    ServiceConfig `yaml:"service"`
+}
+
+// String returns a string representation of the Config as YAML.
+// We use "gopkg.in/yaml.v2" instead of "github.com/ghodss/yaml" for correct formatting of this config.
+func (c Config) String() string {
+	ret, err := c.StringE()
+	if err != nil {
+		panic(err.Error())
+	}
+	return ret
+}
+
+// StringE is like String but returns an error instead of panicking when the Config cannot be marshalled.
+func (c Config) StringE() (string, error) {
+	ret, err := yaml.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling Config to yaml: %w", err)
+	}
+	return string(ret), nil
+}
+
+// Validate checks that every component referenced by the service is defined.
+func (c Config) Validate() error {
+	receivers := c.Receivers.ids()
+	processors := c.Processors.ids()
+	exporters := c.Exporters.ids()
+	extensions := c.Extensions.ids()
+
+	for _, ext := range c.Service.Extensions {
+		if _, ok := extensions[ext]; !ok {
+			return fmt.Errorf("service references undefined extension %q", ext)
+		}
+	}
+
+	pipelines := make([]string, 0, len(c.Service.Pipelines))
+	for name := range c.Service.Pipelines {
+		pipelines = append(pipelines, name)
+	}
+	sort.Strings(pipelines)
+
+	for _, name := range pipelines {
+		pipeline := c.Service.Pipelines[name]
+		if len(pipeline.Receivers) == 0 || len(pipeline.Exporters) == 0 {
+			return fmt.Errorf("pipeline %q must have at least one receiver and one exporter", name)
+		}
+
+		if err := checkRefs(name, "receiver", pipeline.Receivers, receivers); err != nil {
+			return err
+		}
+
+		if err := checkRefs(name, "processor", pipeline.Processors, processors); err != nil {
+			return err
+		}
+
+		if err := checkRefs(name, "exporter", pipeline.Exporters, exporters); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkRefs(pipeline, kind string, refs []string, defined map[string]struct{}) error {
+	for _, ref := range refs {
+		if _, ok := defined[ref]; !ok {
+			return fmt.Errorf("pipeline %q references undefined %s %q", pipeline, kind, ref)
+		}
+	}
+
+	return nil
+}
+
+// flatten merges the named instances of a component type into the given map, keyed by component ID.
+func flatten[T any](ret map[string]interface{}, componentType string, instances map[string]T) {
+	for name, cfg := range instances {
+		ret[ComponentID(componentType, name)] = cfg
+	}
+}
+
+func keys(m map[string]interface{}) map[string]struct{} {
+	ret := make(map[string]struct{}, len(m))
+	for k := range m {
+		ret[k] = struct{}{}
+	}
+	return ret
+}
+
+// Receivers holds the receivers of the collector, indexed by instance name.
+type Receivers struct {
+	OTLP map[string]OTLPReceiver
+}
+
+func (r Receivers) toMap() map[string]interface{} {
+	ret := map[string]interface{}{}
+	flatten(ret, OTLPType, r.OTLP)
+	return ret
+}
+
+func (r Receivers) ids() map[string]struct{} {
+	return keys(r.toMap())
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (r Receivers) MarshalYAML() (interface{}, error) {
+	return r.toMap(), nil
+}
+
+// OTLPReceiver receives data using the OpenTelemetry protocol.
+type OTLPReceiver struct {
+	Protocols OTLPProtocols `yaml:"protocols"`
+}
+
+// OTLPProtocols enables the gRPC and/or HTTP transports of the OTLP receiver.
+type OTLPProtocols struct {
+	GRPC *ServerConfig `yaml:"grpc,omitempty"`
+	HTTP *ServerConfig `yaml:"http,omitempty"`
+}
+
+// ServerConfig configures a receiver endpoint.
+type ServerConfig struct {
+	Endpoint        string     `yaml:"endpoint,omitempty"`
+	TLS             *TLSConfig `yaml:"tls,omitempty"`
+	IncludeMetadata bool       `yaml:"include_metadata,omitempty"`
+}
+
+// TLSConfig configures the TLS settings of a client or server.
+type TLSConfig struct {
+	Insecure           bool   `yaml:"insecure,omitempty"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty"`
+	CAFile             string `yaml:"ca_file,omitempty"`
+	CertFile           string `yaml:"cert_file,omitempty"`
+	KeyFile            string `yaml:"key_file,omitempty"`
+	ServerName         string `yaml:"server_name_override,omitempty"`
+}
+
+// Processors holds the processors of the collector, indexed by instance name.
+type Processors struct {
+	Batch         map[string]BatchProcessor
+	MemoryLimiter map[string]MemoryLimiterProcessor
+	Routing       map[string]RoutingProcessor
+}
+
+func (p Processors) toMap() map[string]interface{} {
+	ret := map[string]interface{}{}
+	flatten(ret, BatchType, p.Batch)
+	flatten(ret, MemoryLimiterType, p.MemoryLimiter)
+	flatten(ret, RoutingType, p.Routing)
+	return ret
+}
+
+func (p Processors) ids() map[string]struct{} {
+	return keys(p.toMap())
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (p Processors) MarshalYAML() (interface{}, error) {
+	return p.toMap(), nil
+}
+
+// BatchProcessor batches data before sending it to the exporters.
+type BatchProcessor struct {
+	Timeout          time.Duration `yaml:"timeout,omitempty"`
+	SendBatchSize    int           `yaml:"send_batch_size,omitempty"`
+	SendBatchMaxSize int           `yaml:"send_batch_max_size,omitempty"`
+}
+
+// MarshalYAML implements the yaml.Marshaler interface to render durations as strings.
+func (b BatchProcessor) MarshalYAML() (interface{}, error) {
+	type plain struct {
+		Timeout          string `yaml:"timeout,omitempty"`
+		SendBatchSize    int    `yaml:"send_batch_size,omitempty"`
+		SendBatchMaxSize int    `yaml:"send_batch_max_size,omitempty"`
+	}
+
+	return plain{
+		Timeout:          durationString(b.Timeout),
+		SendBatchSize:    b.SendBatchSize,
+		SendBatchMaxSize: b.SendBatchMaxSize,
+	}, nil
+}
+
+// MemoryLimiterProcessor prevents out of memory situations on the collector.
+type MemoryLimiterProcessor struct {
+	CheckInterval        time.Duration `yaml:"check_interval"`
+	LimitPercentage      int           `yaml:"limit_percentage,omitempty"`
+	SpikeLimitPercentage int           `yaml:"spike_limit_percentage,omitempty"`
+	LimitMiB             int           `yaml:"limit_mib,omitempty"`
+	SpikeLimitMiB        int           `yaml:"spike_limit_mib,omitempty"`
+}
+
+// MarshalYAML implements the yaml.Marshaler interface to render durations as strings.
+func (m MemoryLimiterProcessor) MarshalYAML() (interface{}, error) {
+	type plain struct {
+		CheckInterval        string `yaml:"check_interval"`
+		LimitPercentage      int    `yaml:"limit_percentage,omitempty"`
+		SpikeLimitPercentage int    `yaml:"spike_limit_percentage,omitempty"`
+		LimitMiB             int    `yaml:"limit_mib,omitempty"`
+		SpikeLimitMiB        int    `yaml:"spike_limit_mib,omitempty"`
+	}
+
+	return plain{
+		CheckInterval:        durationString(m.CheckInterval),
+		LimitPercentage:      m.LimitPercentage,
+		SpikeLimitPercentage: m.SpikeLimitPercentage,
+		LimitMiB:             m.LimitMiB,
+		SpikeLimitMiB:        m.SpikeLimitMiB,
+	}, nil
+}
+
+// RoutingProcessor routes data to specific exporters depending on an attribute, e.g. the tenant header.
+type RoutingProcessor struct {
+	FromAttribute    string         `yaml:"from_attribute"`
+	AttributeSource  string         `yaml:"attribute_source,omitempty"`
+	DefaultExporters []string       `yaml:"default_exporters,omitempty"`
+	Table            []RoutingEntry `yaml:"table"`
+}
+
+// RoutingEntry maps an attribute value to a list of exporters.
+type RoutingEntry struct {
+	Value     string   `yaml:"value"`
+	Exporters []string `yaml:"exporters"`
+}
+
+// Exporters holds the exporters of the collector, indexed by instance name.
+type Exporters struct {
+	OTLP     map[string]OTLPExporter
+	OTLPHTTP map[string]OTLPHTTPExporter
+	Debug    map[string]DebugExporter
+}
+
+func (e Exporters) toMap() map[string]interface{} {
+	ret := map[string]interface{}{}
+	flatten(ret, OTLPType, e.OTLP)
+	flatten(ret, OTLPHTTPType, e.OTLPHTTP)
+	flatten(ret, DebugType, e.Debug)
+	return ret
+}
+
+func (e Exporters) ids() map[string]struct{} {
+	return keys(e.toMap())
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (e Exporters) MarshalYAML() (interface{}, error) {
+	return e.toMap(), nil
+}
+
+// OTLPExporter exports data using the OpenTelemetry protocol over gRPC.
+type OTLPExporter struct {
+	Endpoint    string            `yaml:"endpoint"`
+	TLS         *TLSConfig        `yaml:"tls,omitempty"`
+	Headers     map[string]string `yaml:"headers,omitempty"`
+	Compression string            `yaml:"compression,omitempty"`
+}
+
+// OTLPHTTPExporter exports data using the OpenTelemetry protocol over HTTP.
+type OTLPHTTPExporter struct {
+	Endpoint    string            `yaml:"endpoint"`
+	TLS         *TLSConfig        `yaml:"tls,omitempty"`
+	Headers     map[string]string `yaml:"headers,omitempty"`
+	Compression string            `yaml:"compression,omitempty"`
+}
+
+// DebugExporter writes data to the console, for troubleshooting.
+type DebugExporter struct {
+	Verbosity string `yaml:"verbosity,omitempty"`
+}
+
+// Extensions holds the extensions of the collector, indexed by instance name.
+type Extensions struct {
+	HealthCheck map[string]HealthCheckExtension
+}
+
+func (e Extensions) toMap() map[string]interface{} {
+	ret := map[string]interface{}{}
+	flatten(ret, HealthCheckType, e.HealthCheck)
+	return ret
+}
+
+func (e Extensions) ids() map[string]struct{} {
+	return keys(e.toMap())
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (e Extensions) MarshalYAML() (interface{}, error) {
+	return e.toMap(), nil
+}
+
+// HealthCheckExtension exposes an HTTP endpoint used for liveness and readiness probes.
+type HealthCheckExtension struct {
+	Endpoint string `yaml:"endpoint,omitempty"`
+	Path     string `yaml:"path,omitempty"`
+}
+
+// ServiceConfig enables the extensions and pipelines of the collector.
+type ServiceConfig struct {
+	Extensions []string            `yaml:"extensions,omitempty"`
+	Pipelines  map[string]Pipeline `yaml:"pipelines"`
+	Telemetry  *TelemetryConfig    `yaml:"telemetry,omitempty"`
+}
+
+// Pipeline chains receivers, processors and exporters for a data type (e.g. "traces").
+type Pipeline struct {
+	Receivers  []string `yaml:"receivers"`
+	Processors []string `yaml:"processors,omitempty"`
+	Exporters  []string `yaml:"exporters"`
+}
+
+// TelemetryConfig configures the collector's own telemetry.
+type TelemetryConfig struct {
+	Logs    *TelemetryLogsConfig    `yaml:"logs,omitempty"`
+	Metrics *TelemetryMetricsConfig `yaml:"metrics,omitempty"`
+}
+
+// TelemetryLogsConfig configures the collector's logs.
+type TelemetryLogsConfig struct {
+	Level    string `yaml:"level,omitempty"`
+	Encoding string `yaml:"encoding,omitempty"`
+}
+
+// TelemetryMetricsConfig configures the collector's metrics endpoint.
+type TelemetryMetricsConfig struct {
+	Level   string `yaml:"level,omitempty"`
+	Address string `yaml:"address,omitempty"`
+}
+
+func durationString(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+
+	return d.String()
+}
@@ -58,11 +58,20 @@ type IndexCacheConfig struct {
 // String returns a string representation of the IndexCacheConfig as YAML.
 // We use "gopkg.in/yaml.v2" instead of "github.com/ghodss/yaml" for correct formatting of this config.
 func (c IndexCacheConfig) String() string {
+	ret, err := c.StringE()
+	if err != nil {
+		panic(err.Error())
+	}
+	return ret
+}
+
+// StringE is like String but returns an error instead of panicking when the IndexCacheConfig cannot be marshalled.
+func (c IndexCacheConfig) StringE() (string, error) {
 	ret, err := yaml.Marshal(c)
 	if err != nil {
-		panic(fmt.Sprintf("error mashalling IndexCacheConfig to yaml: %v", err))
+		return "", fmt.Errorf("error marshalling IndexCacheConfig to yaml: %w", err)
 	}
-	return string(ret)
+	return string(ret), nil
 }
 
 // Taken from https://github.com/thanos-io/thanos/blob/release-0.32/pkg/store/cache/caching_bucket_factory.go#L37
@@ -96,11 +105,20 @@ type BucketCacheConfig struct {
 // String returns a string representation of the BucketCacheConfig as YAML.
 // We use "gopkg.in/yaml.v2" instead of "github.com/ghodss/yaml" for correct formatting of this config.
 func (c BucketCacheConfig) String() string {
+	ret, err := c.StringE()
+	if err != nil {
+		panic(err.Error())
+	}
+	return ret
+}
+
+// StringE is like String but returns an error instead of panicking when the BucketCacheConfig cannot be marshalled.
+func (c BucketCacheConfig) StringE() (string, error) {
 	ret, err := yaml.Marshal(c)
 	if err != nil {
-		panic(fmt.Sprintf("error mashalling BucketCacheConfig to yaml: %v", err))
+		return "", fmt.Errorf("error marshalling BucketCacheConfig to yaml: %w", err)
 	}
-	return string(ret)
+	return string(ret), nil
 }
 
 type ResponseCacheConfig struct {
@@ -111,9 +129,18 @@ type ResponseCacheConfig struct {
 // String returns a string representation of the ResponseCacheConfig as YAML.
 // We use "gopkg.in/yaml.v2" instead of "github.com/ghodss/yaml" for correct formatting of this config.
 func (c ResponseCacheConfig) String() string {
+	ret, err := c.StringE()
+	if err != nil {
+		panic(err.Error())
+	}
+	return ret
+}
+
+// StringE is like String but returns an error instead of panicking when the ResponseCacheConfig cannot be marshalled.
+func (c ResponseCacheConfig) StringE() (string, error) {
 	ret, err := yaml.Marshal(c)
 	if err != nil {
-		panic(fmt.Sprintf("error mashalling ResponseCacheConfig to yaml: %v", err))
+		return "", fmt.Errorf("error marshalling ResponseCacheConfig to yaml: %w", err)
 	}
-	return string(ret)
+	return string(ret), nil
 }
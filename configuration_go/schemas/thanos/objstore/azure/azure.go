@@ -0,0 +1,63 @@
+package azure
+
+import (
+	"errors"
+
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore/exthttp"
+	prommodel "github.com/prometheus/common/model"
+)
+
+// Taken from https://github.com/thanos-io/objstore/blob/63a603e651eddaaa2747ee81fabb891d2abda926/providers/azure/azure.go
+type Config struct {
+	StorageAccountName      string             `yaml:"storage_account,omitempty"`
+	StorageAccountKey       string             `yaml:"storage_account_key,omitempty"`
+	StorageConnectionString string             `yaml:"storage_connection_string,omitempty"`
+	ContainerName           string             `yaml:"container"`
+	Endpoint                string             `yaml:"endpoint,omitempty"`
+	UserAssignedID          string             `yaml:"user_assigned_id,omitempty"`
+	MaxRetries              int                `yaml:"max_retries,omitempty"`
+	ReaderConfig            ReaderConfig       `yaml:"reader_config,omitempty"`
+	PipelineConfig          PipelineConfig     `yaml:"pipeline_config,omitempty"`
+	HTTPConfig              exthttp.HTTPConfig `yaml:"http_config,omitempty"`
+
+	// Deprecated: Is automatically set by the Azure SDK.
+	MSIResource string `yaml:"msi_resource,omitempty"`
+}
+
+type ReaderConfig struct {
+	MaxRetryRequests int `yaml:"max_retry_requests,omitempty"`
+}
+
+type PipelineConfig struct {
+	MaxTries      int32              `yaml:"max_tries,omitempty"`
+	TryTimeout    prommodel.Duration `yaml:"try_timeout,omitempty"`
+	RetryDelay    prommodel.Duration `yaml:"retry_delay,omitempty"`
+	MaxRetryDelay prommodel.Duration `yaml:"max_retry_delay,omitempty"`
+}
+
+// Provider implements objstore.ProviderConfig.
+func (c Config) Provider() objstore.ObjProvider {
+	return objstore.AZURE
+}
+
+// Validate implements objstore.ProviderConfig.
+func (c Config) Validate() error {
+	if c.ContainerName == "" {
+		return errors.New("missing container")
+	}
+
+	if c.StorageConnectionString == "" && c.StorageAccountName == "" {
+		return errors.New("storage_account or storage_connection_string must be set")
+	}
+
+	if c.UserAssignedID != "" && c.StorageAccountKey != "" {
+		return errors.New("user_assigned_id cannot be set with storage_account_key")
+	}
+
+	if c.StorageConnectionString != "" && (c.UserAssignedID != "" || c.StorageAccountKey != "") {
+		return errors.New("storage_connection_string cannot be set with user_assigned_id or storage_account_key")
+	}
+
+	return nil
+}
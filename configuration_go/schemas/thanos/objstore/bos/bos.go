@@ -0,0 +1,29 @@
+package bos
+
+import (
+	"errors"
+
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore"
+)
+
+// Taken from https://github.com/thanos-io/objstore/blob/63a603e651eddaaa2747ee81fabb891d2abda926/providers/bos/bos.go
+type Config struct {
+	Bucket    string `yaml:"bucket"`
+	Endpoint  string `yaml:"endpoint"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+}
+
+// Provider implements objstore.ProviderConfig.
+func (c Config) Provider() objstore.ObjProvider {
+	return objstore.BOS
+}
+
+// Validate implements objstore.ProviderConfig.
+func (c Config) Validate() error {
+	if c.Bucket == "" || c.Endpoint == "" || c.AccessKey == "" || c.SecretKey == "" {
+		return errors.New("bucket, endpoint, access_key and secret_key must be set")
+	}
+
+	return nil
+}
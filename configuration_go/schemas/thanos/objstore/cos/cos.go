@@ -0,0 +1,38 @@
+package cos
+
+import (
+	"errors"
+
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore/exthttp"
+)
+
+// Taken from https://github.com/thanos-io/objstore/blob/63a603e651eddaaa2747ee81fabb891d2abda926/providers/cos/cos.go
+type Config struct {
+	Bucket     string             `yaml:"bucket"`
+	Region     string             `yaml:"region,omitempty"`
+	AppId      string             `yaml:"app_id,omitempty"`
+	Endpoint   string             `yaml:"endpoint,omitempty"`
+	SecretKey  string             `yaml:"secret_key,omitempty"`
+	SecretId   string             `yaml:"secret_id,omitempty"`
+	HTTPConfig exthttp.HTTPConfig `yaml:"http_config,omitempty"`
+}
+
+// Provider implements objstore.ProviderConfig.
+func (c Config) Provider() objstore.ObjProvider {
+	return objstore.COS
+}
+
+// Validate implements objstore.ProviderConfig.
+// The endpoint takes precedence over the bucket, app_id and region triplet.
+func (c Config) Validate() error {
+	if c.Endpoint == "" && (c.Bucket == "" || c.AppId == "" || c.Region == "") {
+		return errors.New("bucket, app_id and region must be set when endpoint is empty")
+	}
+
+	if (c.SecretId == "") != (c.SecretKey == "") {
+		return errors.New("secret_id and secret_key must be set together")
+	}
+
+	return nil
+}
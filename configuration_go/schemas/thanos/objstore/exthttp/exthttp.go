@@ -0,0 +1,35 @@
+package exthttp
+
+import prommodel "github.com/prometheus/common/model"
+
+// Taken from https://github.com/thanos-io/objstore/blob/63a603e651eddaaa2747ee81fabb891d2abda926/exthttp/transport.go
+
+// HTTPConfig stores the http.Transport configuration shared by the object storage clients.
+type HTTPConfig struct {
+	IdleConnTimeout       prommodel.Duration `yaml:"idle_conn_timeout,omitempty"`
+	ResponseHeaderTimeout prommodel.Duration `yaml:"response_header_timeout,omitempty"`
+	InsecureSkipVerify    bool               `yaml:"insecure_skip_verify,omitempty"`
+
+	TLSHandshakeTimeout   prommodel.Duration `yaml:"tls_handshake_timeout,omitempty"`
+	ExpectContinueTimeout prommodel.Duration `yaml:"expect_continue_timeout,omitempty"`
+	MaxIdleConns          int                `yaml:"max_idle_conns,omitempty"`
+	MaxIdleConnsPerHost   int                `yaml:"max_idle_conns_per_host,omitempty"`
+	MaxConnsPerHost       int                `yaml:"max_conns_per_host,omitempty"`
+
+	TLSConfig          TLSConfig `yaml:"tls_config,omitempty"`
+	DisableCompression bool      `yaml:"disable_compression,omitempty"`
+}
+
+// TLSConfig configures the options for TLS connections.
+type TLSConfig struct {
+	// The CA cert to use for the targets.
+	CAFile string `yaml:"ca_file,omitempty"`
+	// The client cert file for the targets.
+	CertFile string `yaml:"cert_file,omitempty"`
+	// The client key file for the targets.
+	KeyFile string `yaml:"key_file,omitempty"`
+	// Used to verify the hostname for the targets.
+	ServerName string `yaml:"server_name,omitempty"`
+	// Disable target certificate validation.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify,omitempty"`
+}
@@ -0,0 +1,27 @@
+package filesystem
+
+import (
+	"errors"
+
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore"
+)
+
+// Taken from https://github.com/thanos-io/objstore/blob/63a603e651eddaaa2747ee81fabb891d2abda926/providers/filesystem/filesystem.go
+// The filesystem provider is meant for testing only.
+type Config struct {
+	Directory string `yaml:"directory"`
+}
+
+// Provider implements objstore.ProviderConfig.
+func (c Config) Provider() objstore.ObjProvider {
+	return objstore.FILESYSTEM
+}
+
+// Validate implements objstore.ProviderConfig.
+func (c Config) Validate() error {
+	if c.Directory == "" {
+		return errors.New("missing directory")
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package gcs
+
+import (
+	"errors"
+
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore/exthttp"
+)
+
+// Taken from https://github.com/thanos-io/objstore/blob/63a603e651eddaaa2747ee81fabb891d2abda926/providers/gcs/gcs.go
+type Config struct {
+	Bucket         string `yaml:"bucket"`
+	ServiceAccount string `yaml:"service_account,omitempty"`
+	UseGRPC        bool   `yaml:"use_grpc,omitempty"`
+	// GRPCConnPoolSize controls the size of the gRPC connection pool and should only be used
+	// when direct path is not enabled.
+	GRPCConnPoolSize int                `yaml:"grpc_conn_pool_size,omitempty"`
+	HTTPConfig       exthttp.HTTPConfig `yaml:"http_config,omitempty"`
+	// ChunkSizeBytes controls the maximum number of bytes of the object that the Writer will attempt to send to the server in a single request.
+	ChunkSizeBytes int `yaml:"chunk_size_bytes,omitempty"`
+}
+
+// Provider implements objstore.ProviderConfig.
+func (c Config) Provider() objstore.ObjProvider {
+	return objstore.GCS
+}
+
+// Validate implements objstore.ProviderConfig.
+func (c Config) Validate() error {
+	if c.Bucket == "" {
+		return errors.New("missing bucket")
+	}
+
+	if !c.UseGRPC && c.GRPCConnPoolSize != 0 {
+		return errors.New("grpc_conn_pool_size can only be set when use_grpc is enabled")
+	}
+
+	return nil
+}
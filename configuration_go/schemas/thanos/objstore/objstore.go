@@ -1,6 +1,11 @@
 package objstore
 
-import "gopkg.in/yaml.v2"
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
 
 // Taken from github.com/thanos-io/objstore/client/factory.go eb06103887ab787f47d08e8a2f100264087319d5
 
@@ -13,11 +18,56 @@ type BucketConfig struct {
 // String returns a string representation of the BucketConfig as YAML.
 // We use "gopkg.in/yaml.v2" instead of "github.com/ghodss/yaml" for correct formatting of this config.
 func (c BucketConfig) String() string {
+	ret, err := c.StringE()
+	if err != nil {
+		panic(err.Error())
+	}
+	return ret
+}
+
+// StringE is like String but returns an error instead of panicking when the BucketConfig cannot be marshalled.
+func (c BucketConfig) StringE() (string, error) {
 	ret, err := yaml.Marshal(c)
 	if err != nil {
-		panic(err)
+		return "", fmt.Errorf("error marshalling BucketConfig to yaml: %w", err)
 	}
-	return string(ret)
+	return string(ret), nil
+}
+
+// ProviderConfig is implemented by the typed configurations of the providers, e.g. s3.Config.
+type ProviderConfig interface {
+	// Provider returns the provider the configuration is meant for.
+	Provider() ObjProvider
+	// Validate returns an error if required fields are missing or incompatible.
+	Validate() error
+}
+
+// Validate checks that the Type is a known provider and that the Config matches it.
+// Typed configs (value or pointer), e.g. s3.Config, are checked against the Type and validated.
+// Untyped configs (e.g. maps) are accepted as is, as their content cannot be checked.
+func (c BucketConfig) Validate() error {
+	if !c.Type.IsValid() {
+		return fmt.Errorf("unsupported objstore provider type %q", c.Type)
+	}
+
+	if c.Config == nil {
+		return errors.New("objstore config is empty")
+	}
+
+	cfg, ok := c.Config.(ProviderConfig)
+	if !ok {
+		return nil
+	}
+
+	if cfg.Provider() != c.Type {
+		return fmt.Errorf("objstore config of type %s does not match bucket type %s", cfg.Provider(), c.Type)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid %s objstore config: %w", c.Type, err)
+	}
+
+	return nil
 }
 
 type ObjProvider string
@@ -34,3 +84,12 @@ const (
 	OCI        ObjProvider = "OCI"
 	OBS        ObjProvider = "OBS"
 )
+
+// IsValid returns true if the provider is supported by Thanos.
+func (p ObjProvider) IsValid() bool {
+	switch p {
+	case FILESYSTEM, GCS, S3, AZURE, SWIFT, COS, ALIYUNOSS, BOS, OCI, OBS:
+		return true
+	}
+	return false
+}
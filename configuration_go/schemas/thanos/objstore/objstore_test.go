@@ -0,0 +1,147 @@
+package objstore_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore/azure"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore/bos"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore/cos"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore/filesystem"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore/gcs"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore/obs"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore/oci"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore/oss"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore/s3"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore/swift"
+)
+
+func TestBucketConfigValidate(t *testing.T) {
+	testCases := map[string]struct {
+		config      objstore.BucketConfig
+		expectedErr string
+	}{
+		"unknown type": {
+			config:      objstore.BucketConfig{Type: "FTP", Config: filesystem.Config{Directory: "/data"}},
+			expectedErr: `unsupported objstore provider type "FTP"`,
+		},
+		"empty config": {
+			config:      objstore.BucketConfig{Type: objstore.S3},
+			expectedErr: "objstore config is empty",
+		},
+		"untyped config": {
+			config: objstore.BucketConfig{Type: objstore.S3, Config: map[string]interface{}{"bucket": "b"}},
+		},
+		"untyped config with unknown type": {
+			config:      objstore.BucketConfig{Type: "FTP", Config: map[string]interface{}{"bucket": "b"}},
+			expectedErr: `unsupported objstore provider type "FTP"`,
+		},
+		"mismatched type": {
+			config:      objstore.BucketConfig{Type: objstore.GCS, Config: s3.Config{Bucket: "b", Endpoint: "e"}},
+			expectedErr: "objstore config of type S3 does not match bucket type GCS",
+		},
+		"pointer config": {
+			config: objstore.BucketConfig{Type: objstore.S3, Config: &s3.Config{Bucket: "b", Endpoint: "e"}},
+		},
+
+		"azure": {
+			config: objstore.BucketConfig{Type: objstore.AZURE, Config: azure.Config{ContainerName: "c", StorageAccountName: "a"}},
+		},
+		"azure without account": {
+			config:      objstore.BucketConfig{Type: objstore.AZURE, Config: azure.Config{ContainerName: "c"}},
+			expectedErr: "storage_account or storage_connection_string must be set",
+		},
+		"azure with user assigned id and key": {
+			config:      objstore.BucketConfig{Type: objstore.AZURE, Config: azure.Config{ContainerName: "c", StorageAccountName: "a", UserAssignedID: "id", StorageAccountKey: "k"}},
+			expectedErr: "user_assigned_id cannot be set with storage_account_key",
+		},
+		"bos": {
+			config: objstore.BucketConfig{Type: objstore.BOS, Config: bos.Config{Bucket: "b", Endpoint: "e", AccessKey: "a", SecretKey: "s"}},
+		},
+		"bos without keys": {
+			config:      objstore.BucketConfig{Type: objstore.BOS, Config: bos.Config{Bucket: "b", Endpoint: "e"}},
+			expectedErr: "bucket, endpoint, access_key and secret_key must be set",
+		},
+		"cos": {
+			config: objstore.BucketConfig{Type: objstore.COS, Config: cos.Config{Bucket: "b", AppId: "a", Region: "r"}},
+		},
+		"cos with secret id only": {
+			config:      objstore.BucketConfig{Type: objstore.COS, Config: cos.Config{Bucket: "b", AppId: "a", Region: "r", SecretId: "id"}},
+			expectedErr: "secret_id and secret_key must be set together",
+		},
+		"filesystem": {
+			config: objstore.BucketConfig{Type: objstore.FILESYSTEM, Config: filesystem.Config{Directory: "/data"}},
+		},
+		"filesystem without directory": {
+			config:      objstore.BucketConfig{Type: objstore.FILESYSTEM, Config: filesystem.Config{}},
+			expectedErr: "missing directory",
+		},
+		"gcs": {
+			config: objstore.BucketConfig{Type: objstore.GCS, Config: gcs.Config{Bucket: "b"}},
+		},
+		"gcs pool size without grpc": {
+			config:      objstore.BucketConfig{Type: objstore.GCS, Config: gcs.Config{Bucket: "b", GRPCConnPoolSize: 2}},
+			expectedErr: "grpc_conn_pool_size can only be set when use_grpc is enabled",
+		},
+		"obs": {
+			config: objstore.BucketConfig{Type: objstore.OBS, Config: obs.Config{Bucket: "b", Endpoint: "e"}},
+		},
+		"obs without endpoint": {
+			config:      objstore.BucketConfig{Type: objstore.OBS, Config: obs.Config{Bucket: "b"}},
+			expectedErr: "missing endpoint",
+		},
+		"oci": {
+			config: objstore.BucketConfig{Type: objstore.OCI, Config: oci.Config{Bucket: "b", AuthProvider: oci.AuthProviderOKEWorkloadIdentity, Region: "r"}},
+		},
+		"oci raw without credentials": {
+			config:      objstore.BucketConfig{Type: objstore.OCI, Config: oci.Config{Bucket: "b", AuthProvider: oci.AuthProviderRaw}},
+			expectedErr: "must be set with the raw provider",
+		},
+		"oci unknown provider": {
+			config:      objstore.BucketConfig{Type: objstore.OCI, Config: oci.Config{Bucket: "b", AuthProvider: "other"}},
+			expectedErr: `unsupported provider "other"`,
+		},
+		"oss": {
+			config: objstore.BucketConfig{Type: objstore.ALIYUNOSS, Config: oss.Config{Endpoint: "e", Bucket: "b", AccessKeyID: "a", AccessKeySecret: "s"}},
+		},
+		"oss without keys": {
+			config:      objstore.BucketConfig{Type: objstore.ALIYUNOSS, Config: oss.Config{Endpoint: "e", Bucket: "b"}},
+			expectedErr: "endpoint, bucket, access_key_id and access_key_secret must be set",
+		},
+		"s3": {
+			config: objstore.BucketConfig{Type: objstore.S3, Config: s3.Config{Bucket: "b", Endpoint: "e", AccessKey: "a", SecretKey: "s"}},
+		},
+		"s3 without bucket": {
+			config:      objstore.BucketConfig{Type: objstore.S3, Config: s3.Config{Endpoint: "e"}},
+			expectedErr: "missing bucket",
+		},
+		"s3 unknown lookup type": {
+			config:      objstore.BucketConfig{Type: objstore.S3, Config: s3.Config{Bucket: "b", Endpoint: "e", BucketLookupType: "dns"}},
+			expectedErr: `unsupported bucket_lookup_type "dns"`,
+		},
+		"swift": {
+			config: objstore.BucketConfig{Type: objstore.SWIFT, Config: swift.Config{AuthUrl: "https://keystone", ContainerName: "c"}},
+		},
+		"swift secret without credential": {
+			config:      objstore.BucketConfig{Type: objstore.SWIFT, Config: swift.Config{AuthUrl: "https://keystone", ContainerName: "c", ApplicationCredentialSecret: "s"}},
+			expectedErr: "application_credential_secret requires application_credential_id or application_credential_name",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Fatalf("expected error containing %q, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
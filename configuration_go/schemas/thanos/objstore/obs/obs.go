@@ -0,0 +1,39 @@
+package obs
+
+import (
+	"errors"
+
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore/exthttp"
+)
+
+// Taken from https://github.com/thanos-io/objstore/blob/63a603e651eddaaa2747ee81fabb891d2abda926/providers/obs/obs.go
+type Config struct {
+	Bucket     string             `yaml:"bucket"`
+	Endpoint   string             `yaml:"endpoint"`
+	AccessKey  string             `yaml:"access_key,omitempty"`
+	SecretKey  string             `yaml:"secret_key,omitempty"`
+	HTTPConfig exthttp.HTTPConfig `yaml:"http_config,omitempty"`
+}
+
+// Provider implements objstore.ProviderConfig.
+func (c Config) Provider() objstore.ObjProvider {
+	return objstore.OBS
+}
+
+// Validate implements objstore.ProviderConfig.
+func (c Config) Validate() error {
+	if c.Bucket == "" {
+		return errors.New("missing bucket")
+	}
+
+	if c.Endpoint == "" {
+		return errors.New("missing endpoint")
+	}
+
+	if (c.AccessKey == "") != (c.SecretKey == "") {
+		return errors.New("access_key and secret_key must be set together")
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package oci
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore"
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore/exthttp"
+	prommodel "github.com/prometheus/common/model"
+)
+
+// AuthProvider is the authentication method used to access the OCI object storage.
+type AuthProvider string
+
+const (
+	AuthProviderDefault             AuthProvider = "default"
+	AuthProviderRaw                 AuthProvider = "raw"
+	AuthProviderInstancePrincipal   AuthProvider = "instance-principal"
+	AuthProviderOKEWorkloadIdentity AuthProvider = "oke-workload-identity"
+)
+
+// Taken from https://github.com/thanos-io/objstore/blob/63a603e651eddaaa2747ee81fabb891d2abda926/providers/oci/oci.go
+type Config struct {
+	AuthProvider         AuthProvider       `yaml:"provider,omitempty"`
+	Bucket               string             `yaml:"bucket"`
+	Compartment          string             `yaml:"compartment_ocid,omitempty"`
+	Tenancy              string             `yaml:"tenancy_ocid,omitempty"`
+	User                 string             `yaml:"user_ocid,omitempty"`
+	Region               string             `yaml:"region,omitempty"`
+	Fingerprint          string             `yaml:"fingerprint,omitempty"`
+	PrivateKey           string             `yaml:"privatekey,omitempty"`
+	Passphrase           string             `yaml:"passphrase,omitempty"`
+	PartSize             int64              `yaml:"part_size,omitempty"`
+	MaxRequestRetries    int                `yaml:"max_request_retries,omitempty"`
+	RequestRetryInterval prommodel.Duration `yaml:"request_retry_interval,omitempty"`
+	HTTPConfig           exthttp.HTTPConfig `yaml:"http_config,omitempty"`
+}
+
+// Provider implements objstore.ProviderConfig.
+func (c Config) Provider() objstore.ObjProvider {
+	return objstore.OCI
+}
+
+// Validate implements objstore.ProviderConfig.
+func (c Config) Validate() error {
+	if c.Bucket == "" {
+		return errors.New("missing bucket")
+	}
+
+	switch c.AuthProvider {
+	case "", AuthProviderDefault, AuthProviderInstancePrincipal:
+	case AuthProviderRaw:
+		if c.Tenancy == "" || c.User == "" || c.Region == "" || c.Fingerprint == "" || c.PrivateKey == "" {
+			return errors.New("tenancy_ocid, user_ocid, region, fingerprint and privatekey must be set with the raw provider")
+		}
+	case AuthProviderOKEWorkloadIdentity:
+		if c.Region == "" {
+			return errors.New("region must be set with the oke-workload-identity provider")
+		}
+	default:
+		return fmt.Errorf("unsupported provider %q", c.AuthProvider)
+	}
+
+	return nil
+}
@@ -1,6 +1,12 @@
 package s3
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore"
+)
 
 // Taken from https://github.com/thanos-io/objstore/blob/63a603e651eddaaa2747ee81fabb891d2abda926/providers/s3/s3.go#L124
 type Config struct {
@@ -16,7 +22,7 @@ type Config struct {
 	PutUserMetadata    map[string]string `yaml:"put_user_metadata,omitempty"`
 	HTTPConfig         HTTPConfig        `yaml:"http_config,omitempty"`
 	TraceConfig        TraceConfig       `yaml:"trace,omitempty"`
-	ListObjectsVersion string            `yaml:"list_objects_versio,omitempty"`
+	ListObjectsVersion string            `yaml:"list_objects_version,omitempty"`
 	BucketLookupType   BucketLookupType  `yaml:"bucket_lookup_type,omitempty"`
 	// PartSize used for multipart upload. Only used if uploaded object size is known and larger than configured PartSize.
 	// NOTE we need to make sure this number does not produce more parts than 10 000.
@@ -75,3 +81,37 @@ const (
 	BucketLookupTypeVirtualHosted BucketLookupType = "virtual-hosted"
 	BucketLookupTypePath          BucketLookupType = "path"
 )
+
+// Provider implements objstore.ProviderConfig.
+func (c Config) Provider() objstore.ObjProvider {
+	return objstore.S3
+}
+
+// Validate implements objstore.ProviderConfig.
+func (c Config) Validate() error {
+	if c.Bucket == "" {
+		return errors.New("missing bucket")
+	}
+
+	if c.Endpoint == "" {
+		return errors.New("missing endpoint")
+	}
+
+	if (c.AccessKey == "") != (c.SecretKey == "") {
+		return errors.New("access_key and secret_key must be set together")
+	}
+
+	switch c.ListObjectsVersion {
+	case "", "v1", "v2":
+	default:
+		return fmt.Errorf("unsupported list_objects_version %q", c.ListObjectsVersion)
+	}
+
+	switch c.BucketLookupType {
+	case "", BucketLookupTypeAuto, BucketLookupTypeVirtualHosted, BucketLookupTypePath:
+	default:
+		return fmt.Errorf("unsupported bucket_lookup_type %q", c.BucketLookupType)
+	}
+
+	return nil
+}
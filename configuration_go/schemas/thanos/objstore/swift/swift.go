@@ -0,0 +1,58 @@
+package swift
+
+import (
+	"errors"
+
+	"github.com/observatorium/observatorium/configuration_go/schemas/thanos/objstore"
+	prommodel "github.com/prometheus/common/model"
+)
+
+// Taken from https://github.com/thanos-io/objstore/blob/63a603e651eddaaa2747ee81fabb891d2abda926/providers/swift/swift.go
+type Config struct {
+	AuthVersion                 int                `yaml:"auth_version,omitempty"`
+	AuthUrl                     string             `yaml:"auth_url"`
+	Username                    string             `yaml:"username,omitempty"`
+	UserDomainName              string             `yaml:"user_domain_name,omitempty"`
+	UserDomainID                string             `yaml:"user_domain_id,omitempty"`
+	UserId                      string             `yaml:"user_id,omitempty"`
+	Password                    string             `yaml:"password,omitempty"`
+	DomainId                    string             `yaml:"domain_id,omitempty"`
+	DomainName                  string             `yaml:"domain_name,omitempty"`
+	ApplicationCredentialID     string             `yaml:"application_credential_id,omitempty"`
+	ApplicationCredentialName   string             `yaml:"application_credential_name,omitempty"`
+	ApplicationCredentialSecret string             `yaml:"application_credential_secret,omitempty"`
+	ProjectID                   string             `yaml:"project_id,omitempty"`
+	ProjectName                 string             `yaml:"project_name,omitempty"`
+	ProjectDomainID             string             `yaml:"project_domain_id,omitempty"`
+	ProjectDomainName           string             `yaml:"project_domain_name,omitempty"`
+	RegionName                  string             `yaml:"region_name,omitempty"`
+	ContainerName               string             `yaml:"container_name"`
+	ChunkSize                   int64              `yaml:"large_object_chunk_size,omitempty"`
+	SegmentContainerName        string             `yaml:"large_object_segments_container_name,omitempty"`
+	Retries                     int                `yaml:"retries,omitempty"`
+	ConnectTimeout              prommodel.Duration `yaml:"connect_timeout,omitempty"`
+	Timeout                     prommodel.Duration `yaml:"timeout,omitempty"`
+	UseDynamicLargeObjects      bool               `yaml:"use_dynamic_large_objects,omitempty"`
+}
+
+// Provider implements objstore.ProviderConfig.
+func (c Config) Provider() objstore.ObjProvider {
+	return objstore.SWIFT
+}
+
+// Validate implements objstore.ProviderConfig.
+func (c Config) Validate() error {
+	if c.AuthUrl == "" {
+		return errors.New("missing auth_url")
+	}
+
+	if c.ContainerName == "" {
+		return errors.New("missing container_name")
+	}
+
+	if c.ApplicationCredentialSecret != "" && c.ApplicationCredentialID == "" && c.ApplicationCredentialName == "" {
+		return errors.New("application_credential_secret requires application_credential_id or application_credential_name")
+	}
+
+	return nil
+}
@@ -1,6 +1,10 @@
 package reqlogging
 
-import "gopkg.in/yaml.v2"
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
 
 // Taken from https://github.com/thanos-io/thanos/blob/release-0.32/pkg/logging/yaml_parser.go
 
@@ -23,11 +27,20 @@ type RequestConfig struct {
 // String returns a string representation of the RequestConfig as YAML.
 // We use "gopkg.in/yaml.v2" instead of "github.com/ghodss/yaml" for correct formatting of this config.
 func (c RequestConfig) String() string {
+	ret, err := c.StringE()
+	if err != nil {
+		panic(err.Error())
+	}
+	return ret
+}
+
+// StringE is like String but returns an error instead of panicking when the RequestConfig cannot be marshalled.
+func (c RequestConfig) StringE() (string, error) {
 	ret, err := yaml.Marshal(c)
 	if err != nil {
-		panic(err)
+		return "", fmt.Errorf("error marshalling RequestConfig to yaml: %w", err)
 	}
-	return string(ret)
+	return string(ret), nil
 }
 
 type HTTPProtocolConfigs struct {
@@ -1,6 +1,12 @@
 package time
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	prommodel "github.com/prometheus/common/model"
+)
 
 // Taken from https://github.com/thanos-io/thanos/blob/release-0.32/pkg/model/timeduration.go#L17
 type TimeOrDurationValue struct {
@@ -22,3 +28,32 @@ func (tdv *TimeOrDurationValue) String() string {
 
 	return "nil"
 }
+
+// UnmarshalText parses times in RFC 3339 or as rendered by String, and durations in Go or Prometheus
+// format, optionally negative, e.g. "-2w" as accepted by Thanos.
+func (tdv *TimeOrDurationValue) UnmarshalText(text []byte) error {
+	s := string(text)
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05.999999999 -0700 MST"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			tdv.Time, tdv.Dur = &t, nil
+			return nil
+		}
+	}
+
+	abs, negative := strings.CutPrefix(s, "-")
+	d, err := time.ParseDuration(abs)
+	if err != nil {
+		pd, perr := prommodel.ParseDuration(abs)
+		if perr != nil {
+			return fmt.Errorf("%q is neither a time nor a duration", s)
+		}
+		d = time.Duration(pd)
+	}
+
+	if negative {
+		d = -d
+	}
+	tdv.Time, tdv.Dur = nil, &d
+
+	return nil
+}
@@ -1,6 +1,10 @@
 package client
 
-import "gopkg.in/yaml.v2"
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
 
 // Taken from github.com/thanos-io/thanos/pkg/tracing/client/client.go v0.32.2
 
@@ -23,9 +27,18 @@ type TracingConfig struct {
 // String returns a string representation of the TracingConfig as YAML.
 // We use "gopkg.in/yaml.v2" instead of "github.com/ghodss/yaml" for correct formatting of this config.
 func (c TracingConfig) String() string {
+	ret, err := c.StringE()
+	if err != nil {
+		panic(err.Error())
+	}
+	return ret
+}
+
+// StringE is like String but returns an error instead of panicking when the TracingConfig cannot be marshalled.
+func (c TracingConfig) StringE() (string, error) {
 	ret, err := yaml.Marshal(c)
 	if err != nil {
-		panic(err)
+		return "", fmt.Errorf("error marshalling TracingConfig to yaml: %w", err)
 	}
-	return string(ret)
+	return string(ret), nil
 }
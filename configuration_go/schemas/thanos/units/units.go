@@ -2,6 +2,7 @@ package units
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -29,6 +30,67 @@ func (b Bytes) String() string {
 	return ToString(int64(b), 1024, "iB", "B")
 }
 
+// UnmarshalText parses sizes such as rendered by String, see ParseBytes.
+func (b *Bytes) UnmarshalText(text []byte) error {
+	ret, err := ParseBytes(string(text))
+	if err != nil {
+		return err
+	}
+
+	*b = ret
+	return nil
+}
+
+var bytesUnits = map[string]Bytes{
+	"B": 1, "": 1,
+	"KiB": KiB, "KB": KiB,
+	"MiB": MiB, "MB": MiB,
+	"GiB": GiB, "GB": GiB,
+	"TiB": TiB, "TB": TiB,
+	"PiB": PiB, "PB": PiB,
+	"EiB": EiB, "EB": EiB,
+}
+
+// ParseBytes parses sizes made of integers followed by units, e.g. "1GiB512MiB" as rendered by String.
+// Units are base-2, also when written without the "i", as done by Thanos. A number without unit is in bytes.
+func ParseBytes(s string) (Bytes, error) {
+	if s == "" {
+		return 0, fmt.Errorf("empty size")
+	}
+
+	var ret Bytes
+	for rest := s; rest != ""; {
+		i := strings.IndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' })
+		if i == 0 {
+			return 0, fmt.Errorf("invalid size %q: expected a number", s)
+		}
+		if i < 0 {
+			i = len(rest)
+		}
+
+		n, err := strconv.ParseInt(rest[:i], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid size %q: %w", s, err)
+		}
+		rest = rest[i:]
+
+		j := strings.IndexFunc(rest, func(r rune) bool { return r >= '0' && r <= '9' })
+		if j < 0 {
+			j = len(rest)
+		}
+
+		unit, ok := bytesUnits[rest[:j]]
+		if !ok {
+			return 0, fmt.Errorf("invalid size %q: unknown unit %q", s, rest[:j])
+		}
+		rest = rest[j:]
+
+		ret += Bytes(n) * unit
+	}
+
+	return ret, nil
+}
+
 var (
 	siUnits = []string{"", "K", "M", "G", "T", "P", "E"}
 )